@@ -6,6 +6,8 @@
 package native
 
 import (
+	"sort"
+
 	"go.dedis.ch/dela/core/execution"
 	"go.dedis.ch/dela/core/store"
 	"golang.org/x/xerrors"
@@ -16,6 +18,10 @@ const (
 	ContractArg = "go.dedis.ch/dela.ContractArg"
 )
 
+// ErrStepLimit is the error returned when a contract exceeds the maximum number
+// of steps of an execution.
+var ErrStepLimit = xerrors.New("step limit exceeded")
+
 // Contract is the interface to implement to register a smart contract that will
 // be executed natively.
 type Contract interface {
@@ -28,14 +34,41 @@ type Contract interface {
 // - implements execution.Service
 type Service struct {
 	contracts map[string]Contract
+	stepLimit uint64
+}
+
+// ServiceOption is the type of option to set some fields of the service.
+type ServiceOption func(*Service)
+
+// WithStepLimit is an option to set the maximum number of steps of the
+// execution of a contract, where every access to the snapshot and every call to
+// Step is a step. A contract that exceeds it fails the transaction, and none of
+// its updates is applied. The limit is part of the validation rules, so it must
+// be the same on every participant. A wall-clock timeout is not used as it
+// would accept a transaction on one participant and refuse it on another.
+//
+// The steps are counted and not the time, therefore a computation that neither
+// accesses the snapshot nor calls Step is not limited. A contract with loops
+// that don't depend on the store must call Step at each iteration. The
+// execution is not limited by default.
+func WithStepLimit(limit uint64) ServiceOption {
+	return func(ns *Service) {
+		ns.stepLimit = limit
+	}
 }
 
 // NewExecution returns a new native execution. The given service will be
 // executed for every incoming transaction.
-func NewExecution() *Service {
-	return &Service{
+func NewExecution(opts ...ServiceOption) *Service {
+	ns := &Service{
 		contracts: map[string]Contract{},
 	}
+
+	for _, opt := range opts {
+		opt(ns)
+	}
+
+	return ns
 }
 
 // Set stores the contract using the name as the key. A transaction can trigger
@@ -44,6 +77,18 @@ func (ns *Service) Set(name string, contract Contract) {
 	ns.contracts[name] = contract
 }
 
+// List returns the names of the registered contracts in alphabetical order.
+func (ns *Service) List() []string {
+	names := make([]string, 0, len(ns.contracts))
+	for name := range ns.contracts {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
 // Execute implements execution.Service. It uses the executor to process the
 // incoming transaction and return the result.
 func (ns *Service) Execute(snap store.Snapshot, step execution.Step) (execution.Result, error) {
@@ -58,7 +103,7 @@ func (ns *Service) Execute(snap store.Snapshot, step execution.Step) (execution.
 		Accepted: true,
 	}
 
-	err := contract.Execute(snap, step)
+	err := ns.run(contract, snap, step)
 	if err != nil {
 		res.Accepted = false
 		res.Message = err.Error()
@@ -66,3 +111,156 @@ func (ns *Service) Execute(snap store.Snapshot, step execution.Step) (execution.
 
 	return res, nil
 }
+
+// Step counts a step of the execution of a contract on the snapshot it was
+// given, and returns ErrStepLimit when the limit of the service is exceeded. It
+// lets a contract bound a computation that does not access the snapshot. It
+// does nothing when the execution is not limited.
+func Step(snap store.Snapshot) error {
+	metered, ok := snap.(*meteredSnapshot)
+	if !ok {
+		return nil
+	}
+
+	return metered.step()
+}
+
+// run executes the contract. When a step limit is set, the contract works on a
+// metered snapshot that stages the updates, and they are applied only if the
+// execution succeeds within the limit.
+func (ns *Service) run(contract Contract, snap store.Snapshot, step execution.Step) error {
+	if ns.stepLimit == 0 {
+		return contract.Execute(snap, step)
+	}
+
+	metered := newMeteredSnapshot(snap, ns.stepLimit)
+
+	err := contract.Execute(metered, step)
+	if err != nil {
+		return err
+	}
+
+	// A contract could ignore the error of an access past the limit, so the
+	// budget is checked again before any update is applied.
+	if metered.exceeded() {
+		return xerrors.Errorf("execution failed: %w", ErrStepLimit)
+	}
+
+	err = metered.apply()
+	if err != nil {
+		return xerrors.Errorf("failed to apply updates: %v", err)
+	}
+
+	return nil
+}
+
+// stagedValue is an update of a key that is waiting to be applied.
+type stagedValue struct {
+	value   []byte
+	deleted bool
+}
+
+// meteredSnapshot is a snapshot that counts every access as a step, and that
+// stages the updates until they are applied.
+//
+// - implements store.Snapshot
+type meteredSnapshot struct {
+	snap   store.Snapshot
+	limit  uint64
+	steps  uint64
+	staged map[string]stagedValue
+}
+
+func newMeteredSnapshot(snap store.Snapshot, limit uint64) *meteredSnapshot {
+	return &meteredSnapshot{
+		snap:   snap,
+		limit:  limit,
+		staged: make(map[string]stagedValue),
+	}
+}
+
+// Get implements store.Readable. It returns the staged value of the key if any,
+// otherwise the value of the snapshot.
+func (s *meteredSnapshot) Get(key []byte) ([]byte, error) {
+	err := s.step()
+	if err != nil {
+		return nil, err
+	}
+
+	staged, found := s.staged[string(key)]
+	if found {
+		if staged.deleted {
+			return nil, nil
+		}
+
+		return staged.value, nil
+	}
+
+	return s.snap.Get(key)
+}
+
+// Set implements store.Writable. It stages the value of the key.
+func (s *meteredSnapshot) Set(key, value []byte) error {
+	err := s.step()
+	if err != nil {
+		return err
+	}
+
+	s.staged[string(key)] = stagedValue{value: value}
+
+	return nil
+}
+
+// Delete implements store.Writable. It stages the deletion of the key.
+func (s *meteredSnapshot) Delete(key []byte) error {
+	err := s.step()
+	if err != nil {
+		return err
+	}
+
+	s.staged[string(key)] = stagedValue{deleted: true}
+
+	return nil
+}
+
+func (s *meteredSnapshot) step() error {
+	s.steps++
+
+	if s.exceeded() {
+		return ErrStepLimit
+	}
+
+	return nil
+}
+
+func (s *meteredSnapshot) exceeded() bool {
+	return s.steps > s.limit
+}
+
+// apply writes the staged updates to the snapshot, in the order of the keys so
+// that every participant performs the same sequence.
+func (s *meteredSnapshot) apply() error {
+	keys := make([]string, 0, len(s.staged))
+	for key := range s.staged {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var err error
+
+		staged := s.staged[key]
+		if staged.deleted {
+			err = s.snap.Delete([]byte(key))
+		} else {
+			err = s.snap.Set([]byte(key), staged.value)
+		}
+
+		if err != nil {
+			return xerrors.Errorf("key %#x: %v", key, err)
+		}
+	}
+
+	return nil
+}
@@ -32,6 +32,150 @@ func TestService_Execute(t *testing.T) {
 	require.EqualError(t, err, "unknown contract 'none'")
 }
 
+func TestService_StepLimit_Execute(t *testing.T) {
+	srvc := NewExecution(WithStepLimit(3))
+	srvc.Set("fit", writeExec{steps: 3})
+	srvc.Set("over", writeExec{steps: 4})
+	srvc.Set("ignore", writeExec{steps: 4, ignore: true})
+	srvc.Set("bad", writeExec{steps: 1, err: fake.GetError()})
+
+	snap := fake.NewSnapshot()
+
+	step := execution.Step{}
+	step.Current = fakeTx{contract: "fit"}
+
+	res, err := srvc.Execute(snap, step)
+	require.NoError(t, err)
+	require.Equal(t, execution.Result{Accepted: true}, res)
+
+	value, err := snap.Get([]byte{2})
+	require.NoError(t, err)
+	require.Equal(t, []byte("fit"), value)
+
+	step.Current = fakeTx{contract: "over"}
+	res, err = srvc.Execute(snap, step)
+	require.NoError(t, err)
+	require.Equal(t, execution.Result{Message: ErrStepLimit.Error()}, res)
+
+	step.Current = fakeTx{contract: "ignore"}
+	res, err = srvc.Execute(snap, step)
+	require.NoError(t, err)
+	require.Equal(t, execution.Result{
+		Message: "execution failed: " + ErrStepLimit.Error(),
+	}, res)
+
+	step.Current = fakeTx{contract: "bad"}
+	res, err = srvc.Execute(snap, step)
+	require.NoError(t, err)
+	require.Equal(t, execution.Result{Message: fake.GetError().Error()}, res)
+
+	// Only the updates of the successful execution are applied.
+	for i := byte(0); i < 4; i++ {
+		value, err = snap.Get([]byte{i})
+		require.NoError(t, err)
+
+		if i < 3 {
+			require.Equal(t, []byte("fit"), value)
+		} else {
+			require.Nil(t, value)
+		}
+	}
+
+	srvc.Set("fail", writeExec{steps: 1})
+	step.Current = fakeTx{contract: "fail"}
+	res, err = srvc.Execute(fake.NewBadSnapshot(), step)
+	require.NoError(t, err)
+	require.False(t, res.Accepted)
+	require.Equal(t, "failed to apply updates: key 0x00: "+fake.GetError().Error(), res.Message)
+}
+
+func TestService_SlowContract_Execute(t *testing.T) {
+	srvc := NewExecution(WithStepLimit(100))
+	srvc.Set("fast", loopExec{iterations: 10})
+	srvc.Set("slow", loopExec{})
+
+	step := execution.Step{}
+	step.Current = fakeTx{contract: "fast"}
+
+	res, err := srvc.Execute(fake.NewSnapshot(), step)
+	require.NoError(t, err)
+	require.Equal(t, execution.Result{Accepted: true}, res)
+
+	// The contract loops forever without accessing the snapshot, and it is
+	// stopped by the steps it counts.
+	step.Current = fakeTx{contract: "slow"}
+
+	res, err = srvc.Execute(fake.NewSnapshot(), step)
+	require.NoError(t, err)
+	require.Equal(t, execution.Result{Message: ErrStepLimit.Error()}, res)
+}
+
+func TestStep(t *testing.T) {
+	require.NoError(t, Step(fake.NewSnapshot()))
+
+	metered := newMeteredSnapshot(fake.NewSnapshot(), 1)
+	require.NoError(t, Step(metered))
+	require.ErrorIs(t, Step(metered), ErrStepLimit)
+}
+
+func TestMeteredSnapshot(t *testing.T) {
+	snap := fake.NewSnapshot()
+	require.NoError(t, snap.Set([]byte("A"), []byte("a")))
+	require.NoError(t, snap.Set([]byte("B"), []byte("b")))
+
+	metered := newMeteredSnapshot(snap, 5)
+
+	require.NoError(t, metered.Set([]byte("A"), []byte("aa")))
+	require.NoError(t, metered.Delete([]byte("B")))
+
+	value, err := metered.Get([]byte("A"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("aa"), value)
+
+	value, err = metered.Get([]byte("B"))
+	require.NoError(t, err)
+	require.Nil(t, value)
+
+	// The snapshot is untouched until the updates are applied.
+	value, err = snap.Get([]byte("B"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("b"), value)
+
+	require.NoError(t, metered.apply())
+
+	value, err = snap.Get([]byte("A"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("aa"), value)
+
+	value, err = snap.Get([]byte("B"))
+	require.NoError(t, err)
+	require.Nil(t, value)
+
+	_, err = metered.Get([]byte("C"))
+	require.NoError(t, err)
+
+	_, err = metered.Get([]byte("C"))
+	require.ErrorIs(t, err, ErrStepLimit)
+
+	err = metered.Set([]byte("C"), nil)
+	require.ErrorIs(t, err, ErrStepLimit)
+
+	err = metered.Delete([]byte("C"))
+	require.ErrorIs(t, err, ErrStepLimit)
+
+	require.True(t, metered.exceeded())
+}
+
+func TestService_List(t *testing.T) {
+	srvc := NewExecution()
+	require.Empty(t, srvc.List())
+
+	srvc.Set("def", fakeExec{})
+	srvc.Set("abc", fakeExec{})
+
+	require.Equal(t, []string{"abc", "def"}, srvc.List())
+}
+
 // -----------------------------------------------------------------------------
 // Utility functions
 
@@ -43,6 +187,41 @@ func (e fakeExec) Execute(store.Snapshot, execution.Step) error {
 	return e.err
 }
 
+// writeExec is a contract that writes to a number of keys.
+type writeExec struct {
+	steps  byte
+	ignore bool
+	err    error
+}
+
+func (e writeExec) Execute(snap store.Snapshot, step execution.Step) error {
+	for i := byte(0); i < e.steps; i++ {
+		err := snap.Set([]byte{i}, step.Current.GetArg(ContractArg))
+		if err != nil && !e.ignore {
+			return err
+		}
+	}
+
+	return e.err
+}
+
+// loopExec is a contract that computes for a number of iterations, or forever
+// when it is zero, without accessing the snapshot.
+type loopExec struct {
+	iterations int
+}
+
+func (e loopExec) Execute(snap store.Snapshot, step execution.Step) error {
+	for i := 0; e.iterations == 0 || i < e.iterations; i++ {
+		err := Step(snap)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 type fakeTx struct {
 	txn.Transaction
 	contract string
@@ -54,3 +54,23 @@ type Factory interface {
 
 	AuthorityOf(serde.Context, []byte) (Authority, error)
 }
+
+// AddressNormalizer maps the addresses that denote the same endpoint to a
+// single representation, so that a participant can't appear twice in a roster
+// under two different addresses.
+type AddressNormalizer interface {
+	// Normalize returns the canonical form of the address.
+	Normalize(addr mino.Address) mino.Address
+}
+
+// AddressCodec is the text codec used to serialize the addresses of a roster.
+// It allows the addresses to be serialized in the format expected by an
+// external system.
+type AddressCodec interface {
+	// Encode returns the text representation of the address.
+	Encode(addr mino.Address) ([]byte, error)
+
+	// Decode returns the address of the text representation. The address
+	// factory of the roster is provided to instantiate the address.
+	Decode(fac mino.AddressFactory, data []byte) (mino.Address, error)
+}
@@ -142,10 +142,12 @@ func (f rosterFormat) Encode(ctx serde.Context, msg serde.Message) ([]byte, erro
 
 	players := make([]Player, roster.Len())
 
+	codec := roster.GetAddressCodec()
+
 	addrIter := roster.AddressIterator()
 	pkIter := roster.PublicKeyIterator()
 	for i := 0; addrIter.HasNext() && pkIter.HasNext(); i++ {
-		addr, err := addrIter.GetNext().MarshalText()
+		addr, err := codec.Encode(addrIter.GetNext())
 		if err != nil {
 			return nil, xerrors.Errorf("couldn't marshal address: %v", err)
 		}
@@ -194,11 +196,23 @@ func (f rosterFormat) Decode(ctx serde.Context, data []byte) (serde.Message, err
 		return nil, xerrors.Errorf("invalid address factory of type '%T'", factory)
 	}
 
+	codec := authority.CodecOf(addrFac)
+
+	var decoder authority.AddressCodec = authority.TextCodec{}
+	if codec != nil {
+		decoder = codec
+	}
+
 	addrs := make([]mino.Address, len(m))
 	pubkeys := make([]crypto.PublicKey, len(m))
 
 	for i, player := range m {
-		addrs[i] = addrFac.FromText(player.Address)
+		addr, err := decoder.Decode(addrFac, player.Address)
+		if err != nil {
+			return nil, xerrors.Errorf("couldn't decode address: %v", err)
+		}
+
+		addrs[i] = addr
 
 		pubkey, err := pkFac.PublicKeyOf(ctx, player.PublicKey)
 		if err != nil {
@@ -208,5 +222,5 @@ func (f rosterFormat) Decode(ctx serde.Context, data []byte) (serde.Message, err
 		pubkeys[i] = pubkey
 	}
 
-	return authority.New(addrs, pubkeys), nil
+	return authority.New(addrs, pubkeys, authority.WithCodec(codec)), nil
 }
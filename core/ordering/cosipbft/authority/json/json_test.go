@@ -1,6 +1,7 @@
 package json
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -9,6 +10,7 @@ import (
 	"go.dedis.ch/dela/internal/testing/fake"
 	"go.dedis.ch/dela/mino"
 	"go.dedis.ch/dela/serde"
+	"golang.org/x/xerrors"
 )
 
 func TestChangeSetFormat_Encode(t *testing.T) {
@@ -129,3 +131,73 @@ func TestRosterFormat_Decode(t *testing.T) {
 	_, err = format.Decode(badCtx, []byte(`[{}]`))
 	require.EqualError(t, err, "invalid public key factory of type '<nil>'")
 }
+
+func TestRosterFormat_AddressCodec(t *testing.T) {
+	codec := prefixCodec{prefix: []byte("ext:")}
+
+	ctx := fake.NewContextWithFormat(serde.FormatJSON)
+	fac := authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{},
+		authority.WithAddressCodec(codec))
+
+	ro := authority.New(
+		[]mino.Address{fake.NewAddress(0), fake.NewAddress(1)},
+		[]crypto.PublicKey{fake.PublicKey{}, fake.PublicKey{}},
+		authority.WithCodec(codec),
+	)
+
+	data, err := ro.Serialize(ctx)
+	require.NoError(t, err)
+	require.Equal(t,
+		`[{"Address":"ZXh0OgAAAAA=","PublicKey":{}},{"Address":"ZXh0OgEAAAA=","PublicKey":{}}]`,
+		string(data))
+
+	ro2, err := fac.AuthorityOf(ctx, data)
+	require.NoError(t, err)
+	require.Equal(t, 2, ro2.Len())
+	require.Equal(t, codec, ro2.(authority.Roster).GetAddressCodec())
+
+	iter := ro2.AddressIterator()
+	require.True(t, iter.GetNext().Equal(fake.NewAddress(0)))
+	require.True(t, iter.GetNext().Equal(fake.NewAddress(1)))
+
+	// The roster is serialized back in the same format.
+	data2, err := ro2.Serialize(ctx)
+	require.NoError(t, err)
+	require.Equal(t, data, data2)
+
+	// The default codec doesn't understand the custom format.
+	ro3, err := authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{}).
+		AuthorityOf(ctx, data)
+	require.NoError(t, err)
+	require.False(t, ro3.AddressIterator().GetNext().Equal(fake.NewAddress(0)))
+
+	_, err = fac.AuthorityOf(ctx, []byte(`[{"Address":"AAAAAA==","PublicKey":{}}]`))
+	require.EqualError(t, err,
+		"couldn't decode roster: couldn't decode address: missing prefix")
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+// prefixCodec is an address codec that prepends a prefix to the text of the
+// addresses.
+type prefixCodec struct {
+	prefix []byte
+}
+
+func (c prefixCodec) Encode(addr mino.Address) ([]byte, error) {
+	data, err := addr.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append([]byte{}, c.prefix...), data...), nil
+}
+
+func (c prefixCodec) Decode(fac mino.AddressFactory, data []byte) (mino.Address, error) {
+	if !bytes.HasPrefix(data, c.prefix) {
+		return nil, xerrors.New("missing prefix")
+	}
+
+	return fac.FromText(data[len(c.prefix):]), nil
+}
@@ -0,0 +1,117 @@
+// This file contains the implementation of a loader of a roster from a file,
+// and of the canonical encoding of a roster in the same format.
+//
+
+package authority
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"sort"
+
+	"go.dedis.ch/dela/crypto"
+	"go.dedis.ch/dela/mino"
+	"golang.org/x/xerrors"
+)
+
+// memberJSON is the JSON representation of a member in a roster file. The
+// address is in its text form, and the public key is encoded in base64.
+type memberJSON struct {
+	Address   string `json:"address"`
+	PublicKey string `json:"pubkey"`
+}
+
+// LoadRoster parses a JSON array of members from the reader and returns the
+// roster, in the same order, using the factories to decode the addresses and
+// the public keys.
+//
+// The expected format is:
+//
+//	[{"address": "127.0.0.1:2000", "pubkey": "<base64>"}, ...]
+func LoadRoster(r io.Reader, addrFac mino.AddressFactory, pubFac crypto.PublicKeyFactory) (Roster, error) {
+	var members []memberJSON
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(&members)
+	if err != nil {
+		return Roster{}, xerrors.Errorf("couldn't decode roster: %v", err)
+	}
+
+	if len(members) == 0 {
+		return Roster{}, xerrors.New("roster is empty")
+	}
+
+	addrs := make([]mino.Address, len(members))
+	pubkeys := make([]crypto.PublicKey, len(members))
+
+	for i, member := range members {
+		if member.Address == "" {
+			return Roster{}, xerrors.Errorf("member %d: missing address", i)
+		}
+
+		addr := addrFac.FromText([]byte(member.Address))
+		if addr == nil {
+			return Roster{}, xerrors.Errorf("member %d: invalid address '%s'", i, member.Address)
+		}
+
+		for _, other := range addrs[:i] {
+			if other.Equal(addr) {
+				return Roster{}, xerrors.Errorf("member %d: duplicate address '%s'", i, member.Address)
+			}
+		}
+
+		data, err := base64.StdEncoding.DecodeString(member.PublicKey)
+		if err != nil {
+			return Roster{}, xerrors.Errorf("member %d: invalid base64 public key: %v", i, err)
+		}
+
+		pubkey, err := pubFac.FromBytes(data)
+		if err != nil {
+			return Roster{}, xerrors.Errorf("member %d: invalid public key: %v", i, err)
+		}
+
+		addrs[i] = addr
+		pubkeys[i] = pubkey
+	}
+
+	return New(addrs, pubkeys), nil
+}
+
+// CanonicalJSON returns the JSON representation of the roster in the format of
+// LoadRoster, with the members sorted by address and without insignificant
+// whitespace. Two rosters with the same members produce the same bytes so that
+// the output can be signed.
+func (r Roster) CanonicalJSON() ([]byte, error) {
+	members := make([]memberJSON, len(r.addrs))
+
+	for i, addr := range r.addrs {
+		text, err := addr.MarshalText()
+		if err != nil {
+			return nil, xerrors.Errorf("couldn't marshal address: %v", err)
+		}
+
+		pubkey, err := r.pubkeys[i].MarshalBinary()
+		if err != nil {
+			return nil, xerrors.Errorf("couldn't marshal public key: %v", err)
+		}
+
+		members[i] = memberJSON{
+			Address:   string(text),
+			PublicKey: base64.StdEncoding.EncodeToString(pubkey),
+		}
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Address < members[j].Address
+	})
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't encode roster: %v", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,166 @@
+package authority
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/crypto"
+	"go.dedis.ch/dela/crypto/bls"
+	"go.dedis.ch/dela/internal/testing/fake"
+	"go.dedis.ch/dela/mino"
+)
+
+func TestLoadRoster(t *testing.T) {
+	signers := []crypto.Signer{bls.NewSigner(), bls.NewSigner(), bls.NewSigner()}
+
+	entries := make([]string, len(signers))
+	for i, signer := range signers {
+		entries[i] = fmt.Sprintf(`{"address": "node%d", "pubkey": "%s"}`, i, encodeKey(t, signer))
+	}
+
+	file := "[" + strings.Join(entries, ",") + "]"
+
+	roster, err := LoadRoster(strings.NewReader(file), textAddressFactory{}, bls.NewPublicKeyFactory())
+	require.NoError(t, err)
+	require.Equal(t, 3, roster.Len())
+
+	for i, signer := range signers {
+		addr := textAddressFactory{}.FromText([]byte(fmt.Sprintf("node%d", i)))
+
+		pubkey, index := roster.GetPublicKey(addr)
+		require.Equal(t, i, index)
+		require.True(t, pubkey.Equal(signer.GetPublicKey()))
+	}
+}
+
+func TestLoadRoster_Malformed(t *testing.T) {
+	key := encodeKey(t, bls.NewSigner())
+	addrFac := textAddressFactory{}
+	pubFac := bls.NewPublicKeyFactory()
+
+	load := func(file string) error {
+		_, err := LoadRoster(strings.NewReader(file), addrFac, pubFac)
+		return err
+	}
+
+	err := load(`{}`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "couldn't decode roster: ")
+
+	err = load(`[{"address": "node0", "pubkey": "` + key + `", "port": 2000}]`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "couldn't decode roster: ")
+
+	err = load(`[]`)
+	require.EqualError(t, err, "roster is empty")
+
+	err = load(`[{"address": "node0", "pubkey": "` + key + `"}, {"pubkey": "` + key + `"}]`)
+	require.EqualError(t, err, "member 1: missing address")
+
+	err = load(`[{"address": "node0", "pubkey": "` + key + `"}, {"address": "node0", "pubkey": "` + key + `"}]`)
+	require.EqualError(t, err, "member 1: duplicate address 'node0'")
+
+	err = load(`[{"address": "node0", "pubkey": "not base64"}]`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "member 0: invalid base64 public key: ")
+
+	err = load(`[{"address": "node0", "pubkey": "AAAA"}]`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "member 0: invalid public key: ")
+
+	_, err = LoadRoster(strings.NewReader(`[{"address": "node0", "pubkey": "AAAA"}]`),
+		fake.AddressFactory{}, fake.NewBadPublicKeyFactory())
+	require.EqualError(t, err, fake.Err("member 0: invalid public key"))
+
+	_, err = LoadRoster(strings.NewReader(`[{"address": "node0", "pubkey": "AAAA"}]`),
+		nilAddressFactory{}, pubFac)
+	require.EqualError(t, err, "member 0: invalid address 'node0'")
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+func TestRoster_CanonicalJSON(t *testing.T) {
+	signers := []crypto.Signer{bls.NewSigner(), bls.NewSigner(), bls.NewSigner()}
+
+	addrs := []mino.Address{textAddress("node2"), textAddress("node0"), textAddress("node1")}
+	pubkeys := make([]crypto.PublicKey, len(signers))
+	for i, signer := range signers {
+		pubkeys[i] = signer.GetPublicKey()
+	}
+
+	roster := New(addrs, pubkeys)
+
+	// The same members in a different order.
+	other := New(
+		[]mino.Address{addrs[1], addrs[2], addrs[0]},
+		[]crypto.PublicKey{pubkeys[1], pubkeys[2], pubkeys[0]},
+	)
+
+	data, err := roster.CanonicalJSON()
+	require.NoError(t, err)
+
+	otherData, err := other.CanonicalJSON()
+	require.NoError(t, err)
+	require.Equal(t, data, otherData)
+
+	expected := fmt.Sprintf(`[{"address":"node0","pubkey":"%s"},`+
+		`{"address":"node1","pubkey":"%s"},{"address":"node2","pubkey":"%s"}]`,
+		encodeKey(t, signers[1]), encodeKey(t, signers[2]), encodeKey(t, signers[0]))
+	require.Equal(t, expected, string(data))
+
+	// The canonical output can be loaded back.
+	loaded, err := LoadRoster(bytes.NewReader(data), textAddressFactory{}, bls.NewPublicKeyFactory())
+	require.NoError(t, err)
+
+	loadedData, err := loaded.CanonicalJSON()
+	require.NoError(t, err)
+	require.Equal(t, data, loadedData)
+
+	_, err = New([]mino.Address{fake.NewBadAddress()}, pubkeys[:1]).CanonicalJSON()
+	require.EqualError(t, err, fake.Err("couldn't marshal address"))
+
+	_, err = New(addrs[:1], []crypto.PublicKey{fake.NewBadPublicKey()}).CanonicalJSON()
+	require.EqualError(t, err, fake.Err("couldn't marshal public key"))
+}
+
+func encodeKey(t *testing.T, signer crypto.Signer) string {
+	data, err := signer.GetPublicKey().MarshalBinary()
+	require.NoError(t, err)
+
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+type textAddress string
+
+func (a textAddress) Equal(other mino.Address) bool {
+	return a == other
+}
+
+func (a textAddress) MarshalText() ([]byte, error) {
+	return []byte(a), nil
+}
+
+func (a textAddress) String() string {
+	return string(a)
+}
+
+type textAddressFactory struct {
+	mino.AddressFactory
+}
+
+func (textAddressFactory) FromText(text []byte) mino.Address {
+	return textAddress(text)
+}
+
+type nilAddressFactory struct {
+	fake.AddressFactory
+}
+
+func (nilAddressFactory) FromText([]byte) mino.Address {
+	return nil
+}
@@ -6,9 +6,13 @@
 package authority
 
 import (
+	"bytes"
 	"io"
+	"net"
+	"sort"
 
 	"go.dedis.ch/dela"
+	"go.dedis.ch/dela/cosi"
 	"go.dedis.ch/dela/crypto"
 	"go.dedis.ch/dela/mino"
 	"go.dedis.ch/dela/serde"
@@ -16,6 +20,9 @@ import (
 	"golang.org/x/xerrors"
 )
 
+// missingPort is the error of the net package for an address without a port.
+const missingPort = "missing port in address"
+
 var rosterFormats = registry.NewSimpleRegistry()
 
 // RegisterRosterFormat registers the engine for the provided format.
@@ -79,18 +86,45 @@ func (i *publicKeyIterator) GetNext() crypto.PublicKey {
 type Roster struct {
 	addrs   []mino.Address
 	pubkeys []crypto.PublicKey
+	codec   AddressCodec
+}
+
+// RosterOption is the type of option to create a roster.
+type RosterOption func(*Roster)
+
+// WithCodec is an option to set the codec used to serialize the addresses of
+// the roster.
+func WithCodec(codec AddressCodec) RosterOption {
+	return func(r *Roster) {
+		r.codec = codec
+	}
+}
+
+// WithNormalizer is an option to normalize the addresses of the roster. The
+// participants whose addresses normalize to the same one are collapsed into the
+// first of them.
+func WithNormalizer(n AddressNormalizer) RosterOption {
+	return func(r *Roster) {
+		*r = r.normalize(n)
+	}
 }
 
 // New creates a new roster from the list of addresses and public keys.
-func New(addrs []mino.Address, pubkeys []crypto.PublicKey) Roster {
-	return Roster{
+func New(addrs []mino.Address, pubkeys []crypto.PublicKey, opts ...RosterOption) Roster {
+	r := Roster{
 		addrs:   addrs,
 		pubkeys: pubkeys,
 	}
+
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	return r
 }
 
 // FromAuthority returns a viewchange roster from a collective authority.
-func FromAuthority(authority crypto.CollectiveAuthority) Roster {
+func FromAuthority(authority crypto.CollectiveAuthority, opts ...RosterOption) Roster {
 	addrs := make([]mino.Address, authority.Len())
 	pubkeys := make([]crypto.PublicKey, authority.Len())
 
@@ -101,7 +135,65 @@ func FromAuthority(authority crypto.CollectiveAuthority) Roster {
 		pubkeys[i] = pubkeyIter.GetNext()
 	}
 
-	return New(addrs, pubkeys)
+	return New(addrs, pubkeys, opts...)
+}
+
+// normalize returns a roster with the normalized addresses, where the duplicate
+// participants are removed.
+func (r Roster) normalize(n AddressNormalizer) Roster {
+	if n == nil {
+		return r
+	}
+
+	addrs := make([]mino.Address, 0, len(r.addrs))
+	pubkeys := make([]crypto.PublicKey, 0, len(r.pubkeys))
+
+	for i, addr := range r.addrs {
+		addr = n.Normalize(addr)
+
+		duplicate := false
+		for _, other := range addrs {
+			if other.Equal(addr) {
+				duplicate = true
+				break
+			}
+		}
+
+		if duplicate {
+			dela.Logger.Warn().Str("addr", addr.String()).Msg("duplicate participant in roster")
+			continue
+		}
+
+		addrs = append(addrs, addr)
+		pubkeys = append(pubkeys, r.pubkeys[i])
+	}
+
+	r.addrs = addrs
+	r.pubkeys = pubkeys
+
+	return r
+}
+
+// checkNormalized returns an error if an address of the roster is not in its
+// normalized form, or if two participants share the same address.
+func (r Roster) checkNormalized(n AddressNormalizer) error {
+	if n == nil {
+		return nil
+	}
+
+	for i, addr := range r.addrs {
+		if !n.Normalize(addr).Equal(addr) {
+			return xerrors.Errorf("address '%v' is not normalized", addr)
+		}
+
+		for _, other := range r.addrs[:i] {
+			if other.Equal(addr) {
+				return xerrors.Errorf("duplicate participant '%v'", addr)
+			}
+		}
+	}
+
+	return nil
 }
 
 // Fingerprint implements serde.Fingerprinter. It marshals the roster and writes
@@ -139,6 +231,7 @@ func (r Roster) Take(updaters ...mino.FilterUpdater) mino.Players {
 	newRoster := Roster{
 		addrs:   make([]mino.Address, len(filter.Indices)),
 		pubkeys: make([]crypto.PublicKey, len(filter.Indices)),
+		codec:   r.codec,
 	}
 
 	for i, k := range filter.Indices {
@@ -150,8 +243,11 @@ func (r Roster) Take(updaters ...mino.FilterUpdater) mino.Players {
 }
 
 // Apply implements authority.Authority. It returns a new authority after
-// applying the change set. The removals must be sorted by descending order and
-// unique or the behaviour will be undefined.
+// applying the change set, without modifying the current one. The removals are
+// applied before the additions, so that the indices refer to the current
+// roster. A change set that removes an index out of range or twice, or that
+// adds a participant already in the roster, is invalid and the current roster
+// is returned unchanged.
 func (r Roster) Apply(in ChangeSet) Authority {
 	changeset, ok := in.(*RosterChangeSet)
 	if !ok {
@@ -159,31 +255,97 @@ func (r Roster) Apply(in ChangeSet) Authority {
 		return r
 	}
 
-	addrs := make([]mino.Address, r.Len())
-	pubkeys := make([]crypto.PublicKey, r.Len())
+	next, err := r.apply(changeset)
+	if err != nil {
+		dela.Logger.Warn().Err(err).Msg("Invalid change set. Ignoring.")
+		return r
+	}
 
-	for i, addr := range r.addrs {
-		addrs[i] = addr
-		pubkeys[i] = r.pubkeys[i]
+	return next
+}
+
+// ValidateChange returns an error if the change set is invalid, if the
+// resulting roster is empty, or if it tolerates less faulty participants than
+// the current one. The roster is left untouched.
+func (r Roster) ValidateChange(in ChangeSet) error {
+	changeset, ok := in.(*RosterChangeSet)
+	if !ok {
+		return xerrors.Errorf("unsupported change set '%T'", in)
+	}
+
+	next, err := r.apply(changeset)
+	if err != nil {
+		return err
+	}
+
+	if next.Len() == 0 {
+		return xerrors.New("roster is empty")
+	}
+
+	current := faultTolerance(r.Len())
+	tolerated := faultTolerance(next.Len())
+
+	if tolerated < current {
+		return xerrors.Errorf("fault tolerance drops from %d to %d with %d participants",
+			current, tolerated, next.Len())
 	}
 
-	for _, i := range changeset.remove {
-		if int(i) < len(addrs) {
-			addrs = append(addrs[:i], addrs[i+1:]...)
-			pubkeys = append(pubkeys[:i], pubkeys[i+1:]...)
+	return nil
+}
+
+// apply returns the roster after the change set is applied, or an error if the
+// change set is invalid.
+func (r Roster) apply(changeset *RosterChangeSet) (Roster, error) {
+	removed := make(map[uint]struct{}, len(changeset.remove))
+
+	for _, index := range changeset.remove {
+		if int(index) >= r.Len() {
+			return r, xerrors.Errorf("removal of %d is out of range", index)
+		}
+
+		_, found := removed[index]
+		if found {
+			return r, xerrors.Errorf("duplicate removal of %d", index)
+		}
+
+		removed[index] = struct{}{}
+	}
+
+	size := r.Len() - len(removed) + len(changeset.addrs)
+
+	next := Roster{
+		addrs:   make([]mino.Address, 0, size),
+		pubkeys: make([]crypto.PublicKey, 0, size),
+		codec:   r.codec,
+	}
+
+	for i, addr := range r.addrs {
+		_, found := removed[uint(i)]
+		if !found {
+			next.addrs = append(next.addrs, addr)
+			next.pubkeys = append(next.pubkeys, r.pubkeys[i])
 		}
 	}
 
-	roster := Roster{
-		addrs:   append(addrs, changeset.addrs...),
-		pubkeys: append(pubkeys, changeset.pubkeys...),
+	next.addrs = append(next.addrs, changeset.addrs...)
+	next.pubkeys = append(next.pubkeys, changeset.pubkeys...)
+
+	for i, addr := range next.addrs {
+		for _, other := range next.addrs[:i] {
+			if other.Equal(addr) {
+				return r, xerrors.Errorf("duplicate participant %v", addr)
+			}
+		}
 	}
 
-	return roster
+	return next, nil
 }
 
 // Diff implements authority.Authority. It returns the change set that must be
-// applied to the current authority to get the given one.
+// applied to the current authority to get the given one. A participant is the
+// same only if both its address and its public key are, so that a key rotation
+// is reported as a removal followed by an addition. The removals are sorted by
+// descending order.
 func (r Roster) Diff(o Authority) ChangeSet {
 	changeset := NewChangeSet()
 
@@ -196,7 +358,7 @@ func (r Roster) Diff(o Authority) ChangeSet {
 	k := 0
 	for i < len(r.addrs) || k < len(other.addrs) {
 		if i < len(r.addrs) && k < len(other.addrs) {
-			if r.addrs[i].Equal(other.addrs[k]) {
+			if r.sameMember(i, other, k) {
 				i++
 				k++
 			} else {
@@ -213,9 +375,33 @@ func (r Roster) Diff(o Authority) ChangeSet {
 		}
 	}
 
+	sort.Slice(changeset.remove, func(a, b int) bool {
+		return changeset.remove[a] > changeset.remove[b]
+	})
+
 	return changeset
 }
 
+// sameMember returns true if the participant at index i has the same address
+// and the same public key as the participant at index k of the other roster.
+func (r Roster) sameMember(i int, other Roster, k int) bool {
+	if !r.addrs[i].Equal(other.addrs[k]) {
+		return false
+	}
+
+	key, err := r.pubkeys[i].MarshalBinary()
+	if err != nil {
+		return false
+	}
+
+	otherKey, err := other.pubkeys[k].MarshalBinary()
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(key, otherKey)
+}
+
 // Len implements mino.Players. It returns the length of the authority.
 func (r Roster) Len() int {
 	return len(r.addrs)
@@ -225,13 +411,29 @@ func (r Roster) Len() int {
 // of the address if it exists, nil otherwise. The second return is the index of
 // the public key in the authority.
 func (r Roster) GetPublicKey(target mino.Address) (crypto.PublicKey, int) {
+	index := r.IndexOf(target)
+	if index < 0 {
+		return nil, -1
+	}
+
+	return r.pubkeys[index], index
+}
+
+// IndexOf returns the index of the first member of the roster with the address,
+// or -1 if the address is not in the roster.
+func (r Roster) IndexOf(target mino.Address) int {
 	for i, addr := range r.addrs {
 		if addr.Equal(target) {
-			return r.pubkeys[i], i
+			return i
 		}
 	}
 
-	return nil, -1
+	return -1
+}
+
+// Contains returns true if the address is a member of the roster.
+func (r Roster) Contains(target mino.Address) bool {
+	return r.IndexOf(target) >= 0
 }
 
 // AddressIterator implements mino.Players. It returns an iterator of the
@@ -246,6 +448,16 @@ func (r Roster) PublicKeyIterator() crypto.PublicKeyIterator {
 	return &publicKeyIterator{iterator: &iterator{roster: &r}}
 }
 
+// GetAddressCodec returns the codec used to serialize the addresses of the
+// roster. It defaults to the text codec.
+func (r Roster) GetAddressCodec() AddressCodec {
+	if r.codec == nil {
+		return TextCodec{}
+	}
+
+	return r.codec
+}
+
 // Serialize implements serde.Message. It returns the serialized data for this
 // roster.
 func (r Roster) Serialize(ctx serde.Context) ([]byte, error) {
@@ -265,14 +477,44 @@ func (r Roster) Serialize(ctx serde.Context) ([]byte, error) {
 type rosterFac struct {
 	addrFactory   mino.AddressFactory
 	pubkeyFactory crypto.PublicKeyFactory
+	codec         AddressCodec
+	normalizer    AddressNormalizer
+}
+
+// FactoryOption is the type of option to create a roster factory.
+type FactoryOption func(*rosterFac)
+
+// WithAddressCodec is an option to set the codec used to deserialize the
+// addresses of the rosters. The rosters created by the factory keep the codec
+// so that they are serialized back in the same format.
+func WithAddressCodec(codec AddressCodec) FactoryOption {
+	return func(f *rosterFac) {
+		f.codec = codec
+	}
+}
+
+// WithAddressNormalizer is an option to set the normalizer of the addresses of
+// the rosters. A roster is decoded as is, so that its digest is the same on
+// every participant, but it is refused if an address is not normalized or if
+// two participants share the same endpoint.
+func WithAddressNormalizer(n AddressNormalizer) FactoryOption {
+	return func(f *rosterFac) {
+		f.normalizer = n
+	}
 }
 
 // NewFactory creates a new instance of the authority factory.
-func NewFactory(af mino.AddressFactory, pf crypto.PublicKeyFactory) Factory {
-	return rosterFac{
+func NewFactory(af mino.AddressFactory, pf crypto.PublicKeyFactory, opts ...FactoryOption) Factory {
+	fac := rosterFac{
 		addrFactory:   af,
 		pubkeyFactory: pf,
 	}
+
+	for _, opt := range opts {
+		opt(&fac)
+	}
+
+	return fac
 }
 
 // Deserialize implements serde.Factory.  It returns the roster from the data if
@@ -287,7 +529,15 @@ func (f rosterFac) AuthorityOf(ctx serde.Context, data []byte) (Authority, error
 	format := rosterFormats.Get(ctx.GetFormat())
 
 	ctx = serde.WithFactory(ctx, PubKeyFac{}, f.pubkeyFactory)
-	ctx = serde.WithFactory(ctx, AddrKeyFac{}, f.addrFactory)
+
+	if f.codec != nil {
+		ctx = serde.WithFactory(ctx, AddrKeyFac{}, codecFactory{
+			AddressFactory: f.addrFactory,
+			codec:          f.codec,
+		})
+	} else {
+		ctx = serde.WithFactory(ctx, AddrKeyFac{}, f.addrFactory)
+	}
 
 	msg, err := format.Decode(ctx, data)
 	if err != nil {
@@ -299,5 +549,118 @@ func (f rosterFac) AuthorityOf(ctx serde.Context, data []byte) (Authority, error
 		return nil, xerrors.Errorf("invalid message of type '%T'", msg)
 	}
 
+	err = roster.checkNormalized(f.normalizer)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid roster: %v", err)
+	}
+
 	return roster, nil
 }
+
+// TextCodec is the default address codec. It uses the text marshaling of the
+// addresses.
+//
+// - implements authority.AddressCodec
+type TextCodec struct{}
+
+// Encode implements authority.AddressCodec. It returns the text marshaling of
+// the address.
+func (TextCodec) Encode(addr mino.Address) ([]byte, error) {
+	return addr.MarshalText()
+}
+
+// Decode implements authority.AddressCodec. It returns the address of the text
+// using the factory.
+func (TextCodec) Decode(fac mino.AddressFactory, data []byte) (mino.Address, error) {
+	return fac.FromText(data), nil
+}
+
+// PortNormalizer is an address normalizer for the addresses in the host:port
+// text form. The default port is added to the addresses without one so that an
+// address with and without it are the same, and that the normalized address can
+// still be dialed. Only the end of the text is changed, which means that a
+// prefix before the host, like the one of the minogrpc addresses, is kept.
+//
+// - implements authority.AddressNormalizer
+type PortNormalizer struct {
+	fac  mino.AddressFactory
+	port string
+}
+
+// NewPortNormalizer creates a new normalizer that adds the default port to the
+// addresses without one. The factory is used to instantiate the normalized addresses.
+func NewPortNormalizer(fac mino.AddressFactory, defaultPort string) PortNormalizer {
+	return PortNormalizer{
+		fac:  fac,
+		port: defaultPort,
+	}
+}
+
+// Normalize implements authority.AddressNormalizer. It returns the address
+// with the default port if it has none, otherwise the address as is.
+func (n PortNormalizer) Normalize(addr mino.Address) mino.Address {
+	text, err := addr.MarshalText()
+	if err != nil || len(text) == 0 {
+		return addr
+	}
+
+	_, _, err = net.SplitHostPort(string(text))
+
+	var addrErr *net.AddrError
+	if !xerrors.As(err, &addrErr) || addrErr.Err != missingPort {
+		return addr
+	}
+
+	return n.fac.FromText(append(text, ":"+n.port...))
+}
+
+// codecFactory is an address factory bundled with the codec of the roster
+// factory so that the format engines can look it up from the context.
+//
+// - implements mino.AddressFactory
+type codecFactory struct {
+	mino.AddressFactory
+
+	codec AddressCodec
+}
+
+// CodecOf returns the address codec associated with the address factory, or
+// nil if the default text codec should be used.
+func CodecOf(fac mino.AddressFactory) AddressCodec {
+	cf, ok := fac.(codecFactory)
+	if !ok {
+		return nil
+	}
+
+	return cf.codec
+}
+
+// QuorumThreshold returns the number of participants of a roster of the given
+// size that must agree so that the decision holds despite the faulty ones, which
+// is 2f+1 when n = 3f+1. It is the Byzantine threshold of the collective
+// signing so that a quorum is always enough to produce a signature.
+func QuorumThreshold(n int) int {
+	return cosi.ByzantineThreshold(n)
+}
+
+// WeightedQuorumThreshold returns the total weight that the participants must
+// gather so that the decision holds despite faulty participants holding up to a
+// third of the total weight.
+func WeightedQuorumThreshold(weights []uint64) uint64 {
+	total := uint64(0)
+	for _, w := range weights {
+		total += w
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	return total - (total-1)/3
+}
+
+// faultTolerance returns the maximum number of faulty participants that a
+// roster of the given size tolerates, such that n >= 3f+1.
+func faultTolerance(n int) int {
+	return n - QuorumThreshold(n)
+}
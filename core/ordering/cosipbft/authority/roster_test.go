@@ -2,9 +2,11 @@ package authority
 
 import (
 	"bytes"
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/crypto"
 	"go.dedis.ch/dela/crypto/bls"
 	"go.dedis.ch/dela/internal/testing/fake"
 	"go.dedis.ch/dela/mino"
@@ -129,12 +131,31 @@ func TestRoster_Take(t *testing.T) {
 	require.Equal(t, 2, roster2.Len())
 }
 
+func TestRoster_Take_Sorted(t *testing.T) {
+	roster := FromAuthority(fake.NewAuthority(4, fake.NewSigner))
+
+	selection := mino.ListFilter([]int{3, 0, 2})
+
+	// The subset follows the order of the filter by default.
+	inFilterOrder := roster.Take(selection).(Roster)
+	require.Equal(t, []mino.Address{
+		roster.addrs[3], roster.addrs[0], roster.addrs[2],
+	}, inFilterOrder.addrs)
+
+	inCanonicalOrder := roster.Take(selection, mino.SortFilter()).(Roster)
+	require.Equal(t, []mino.Address{
+		roster.addrs[0], roster.addrs[2], roster.addrs[3],
+	}, inCanonicalOrder.addrs)
+	require.Equal(t, []crypto.PublicKey{
+		roster.pubkeys[0], roster.pubkeys[2], roster.pubkeys[3],
+	}, inCanonicalOrder.pubkeys)
+}
+
 func TestRoster_Apply(t *testing.T) {
 	roster := FromAuthority(fake.NewAuthority(3, fake.NewSigner))
 	require.Equal(t, roster, roster.Apply(nil))
 
 	cset := NewChangeSet()
-	cset.Remove(3)
 	cset.Remove(2)
 	cset.Remove(0)
 
@@ -148,6 +169,145 @@ func TestRoster_Apply(t *testing.T) {
 	require.Equal(t, roster.Len()-1, roster3.Len())
 }
 
+func TestRoster_Apply_Membership(t *testing.T) {
+	roster := FromAuthority(fake.NewAuthority(3, fake.NewSigner))
+
+	addrs := func(ro Authority) []mino.Address {
+		return ro.(Roster).addrs
+	}
+
+	// Empty change set.
+	require.Equal(t, roster, roster.Apply(NewChangeSet()))
+
+	// Add only.
+	cset := NewChangeSet()
+	cset.Add(fake.NewAddress(5), fake.PublicKey{})
+
+	next := roster.Apply(cset)
+	require.Equal(t, []mino.Address{
+		roster.addrs[0], roster.addrs[1], roster.addrs[2], fake.NewAddress(5),
+	}, addrs(next))
+	require.Equal(t, fake.PublicKey{}, next.(Roster).pubkeys[3])
+
+	// Remove only.
+	cset = NewChangeSet()
+	cset.Remove(2)
+	cset.Remove(0)
+
+	next = roster.Apply(cset)
+	require.Equal(t, []mino.Address{roster.addrs[1]}, addrs(next))
+	require.Equal(t, []crypto.PublicKey{roster.pubkeys[1]}, next.(Roster).pubkeys)
+
+	// The removals are applied before the additions.
+	cset = NewChangeSet()
+	cset.Remove(1)
+	cset.Add(fake.NewAddress(5), fake.PublicKey{})
+
+	next = roster.Apply(cset)
+	require.Equal(t, []mino.Address{
+		roster.addrs[0], roster.addrs[2], fake.NewAddress(5),
+	}, addrs(next))
+
+	// The original roster is left untouched.
+	require.Equal(t, 3, roster.Len())
+	require.Equal(t, fake.NewAddress(1), roster.addrs[1])
+
+	// An invalid change set leaves the roster unchanged.
+	cset = NewChangeSet()
+	cset.Add(roster.addrs[0], fake.PublicKey{})
+
+	require.Equal(t, roster, roster.Apply(cset))
+
+	cset = NewChangeSet()
+	cset.Remove(3)
+	cset.Add(fake.NewAddress(5), fake.PublicKey{})
+
+	require.Equal(t, roster, roster.Apply(cset))
+
+	cset = NewChangeSet()
+	cset.Remove(1)
+	cset.Remove(1)
+
+	require.Equal(t, roster, roster.Apply(cset))
+}
+
+func TestQuorumThreshold(t *testing.T) {
+	require.Equal(t, 0, QuorumThreshold(0))
+	require.Equal(t, 1, QuorumThreshold(1))
+	require.Equal(t, 3, QuorumThreshold(4))
+	require.Equal(t, 5, QuorumThreshold(7))
+	require.Equal(t, 7, QuorumThreshold(10))
+
+	// The quorum of a roster that is not 3f+1 still overlaps on an honest
+	// participant.
+	require.Equal(t, 4, QuorumThreshold(5))
+}
+
+func TestWeightedQuorumThreshold(t *testing.T) {
+	require.Equal(t, uint64(0), WeightedQuorumThreshold(nil))
+	require.Equal(t, uint64(3), WeightedQuorumThreshold([]uint64{1, 1, 1, 1}))
+	require.Equal(t, uint64(7), WeightedQuorumThreshold([]uint64{5, 2, 2, 1}))
+	require.Equal(t, uint64(1), WeightedQuorumThreshold([]uint64{1}))
+}
+
+func TestRoster_ValidateChange(t *testing.T) {
+	roster := FromAuthority(fake.NewAuthority(4, fake.NewSigner))
+
+	// A roster of 4 tolerates 1 fault, like a roster of 6.
+	cset := NewChangeSet()
+	cset.Remove(3)
+	cset.Add(fake.NewAddress(10), fake.PublicKey{})
+	cset.Add(fake.NewAddress(11), fake.PublicKey{})
+	cset.Add(fake.NewAddress(12), fake.PublicKey{})
+
+	err := roster.ValidateChange(cset)
+	require.NoError(t, err)
+	require.Equal(t, 4, roster.Len())
+
+	// A roster of 3 doesn't tolerate any fault.
+	cset = NewChangeSet()
+	cset.Remove(0)
+
+	err = roster.ValidateChange(cset)
+	require.EqualError(t, err, "fault tolerance drops from 1 to 0 with 3 participants")
+	require.Equal(t, 4, roster.Len())
+
+	err = roster.ValidateChange(NewChangeSet())
+	require.NoError(t, err)
+}
+
+func TestRoster_InvalidChange_ValidateChange(t *testing.T) {
+	roster := FromAuthority(fake.NewAuthority(1, fake.NewSigner))
+
+	err := roster.ValidateChange(nil)
+	require.EqualError(t, err, "unsupported change set '<nil>'")
+
+	cset := NewChangeSet()
+	cset.Remove(1)
+
+	err = roster.ValidateChange(cset)
+	require.EqualError(t, err, "removal of 1 is out of range")
+
+	cset = NewChangeSet()
+	cset.Remove(0)
+	cset.Remove(0)
+
+	err = roster.ValidateChange(cset)
+	require.EqualError(t, err, "duplicate removal of 0")
+
+	cset = NewChangeSet()
+	cset.Remove(0)
+
+	err = roster.ValidateChange(cset)
+	require.EqualError(t, err, "roster is empty")
+
+	cset = NewChangeSet()
+	cset.Add(fake.NewAddress(0), fake.PublicKey{})
+
+	err = roster.ValidateChange(cset)
+	require.EqualError(t, err, "duplicate participant fake.Address[0]")
+}
+
 func TestRoster_Diff(t *testing.T) {
 	roster1 := FromAuthority(fake.NewAuthority(3, fake.NewSigner))
 
@@ -163,7 +323,7 @@ func TestRoster_Diff(t *testing.T) {
 	roster4 := FromAuthority(fake.NewAuthority(3, fake.NewSigner))
 	roster4.addrs[1] = fake.NewAddress(5)
 	diff = roster1.Diff(roster4).(*RosterChangeSet)
-	require.Equal(t, []uint{1, 2}, diff.remove)
+	require.Equal(t, []uint{2, 1}, diff.remove)
 	require.Len(t, diff.addrs, 2)
 	require.Len(t, diff.pubkeys, 2)
 
@@ -171,6 +331,72 @@ func TestRoster_Diff(t *testing.T) {
 	require.Equal(t, NewChangeSet(), diff)
 }
 
+func TestRoster_Diff_KeyRotation(t *testing.T) {
+	signers := []crypto.Signer{bls.NewSigner(), bls.NewSigner(), bls.NewSigner()}
+
+	roster := New(
+		[]mino.Address{fake.NewAddress(0), fake.NewAddress(1)},
+		[]crypto.PublicKey{signers[0].GetPublicKey(), signers[1].GetPublicKey()})
+
+	rotated := New(
+		[]mino.Address{fake.NewAddress(0), fake.NewAddress(1)},
+		[]crypto.PublicKey{signers[0].GetPublicKey(), signers[2].GetPublicKey()})
+
+	diff := roster.Diff(rotated).(*RosterChangeSet)
+	require.Equal(t, []uint{1}, diff.remove)
+	require.Equal(t, []mino.Address{fake.NewAddress(1)}, diff.addrs)
+	require.Equal(t, []crypto.PublicKey{signers[2].GetPublicKey()}, diff.pubkeys)
+
+	require.Equal(t, NewChangeSet(), roster.Diff(roster))
+
+	roster.pubkeys[0] = fake.NewBadPublicKey()
+	diff = roster.Diff(rotated).(*RosterChangeSet)
+	require.Equal(t, []uint{1, 0}, diff.remove)
+}
+
+func TestRoster_Diff_RoundTrip(t *testing.T) {
+	addrs := make([]mino.Address, 6)
+	pubkeys := make([]crypto.PublicKey, 2)
+
+	for i := range addrs {
+		addrs[i] = fake.NewAddress(i)
+	}
+
+	for i := range pubkeys {
+		pubkeys[i] = bls.NewSigner().GetPublicKey()
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+
+	// A random roster has unique addresses in a random order, and each of
+	// them has one of the keys.
+	randomRoster := func() Roster {
+		perm := rnd.Perm(len(addrs))[:rnd.Intn(len(addrs)+1)]
+
+		ro := Roster{}
+		for _, index := range perm {
+			ro.addrs = append(ro.addrs, addrs[index])
+			ro.pubkeys = append(ro.pubkeys, pubkeys[rnd.Intn(len(pubkeys))])
+		}
+
+		return ro
+	}
+
+	for n := 0; n < 500; n++ {
+		from := randomRoster()
+		to := randomRoster()
+
+		next := from.Apply(from.Diff(to)).(Roster)
+
+		require.Len(t, next.addrs, len(to.addrs))
+
+		for i := range to.addrs {
+			require.True(t, next.addrs[i].Equal(to.addrs[i]))
+			require.True(t, next.pubkeys[i].Equal(to.pubkeys[i]))
+		}
+	}
+}
+
 func TestRoster_Len(t *testing.T) {
 	roster := FromAuthority(fake.NewAuthority(3, fake.NewSigner))
 	require.Equal(t, 3, roster.Len())
@@ -194,6 +420,34 @@ func TestRoster_GetPublicKey(t *testing.T) {
 	require.Nil(t, pubkey)
 }
 
+func TestRoster_IndexOf(t *testing.T) {
+	roster := FromAuthority(fake.NewAuthority(3, fake.NewSigner))
+
+	require.Equal(t, 0, roster.IndexOf(fake.NewAddress(0)))
+	require.Equal(t, 2, roster.IndexOf(fake.NewAddress(2)))
+	require.Equal(t, -1, roster.IndexOf(fake.NewAddress(999)))
+
+	// The first member with the address wins.
+	dup := New(
+		[]mino.Address{fake.NewAddress(0), fake.NewAddress(1), fake.NewAddress(1)},
+		[]crypto.PublicKey{fake.PublicKey{}, fake.PublicKey{}, fake.PublicKey{}},
+	)
+	require.Equal(t, 1, dup.IndexOf(fake.NewAddress(1)))
+
+	_, index := dup.GetPublicKey(fake.NewAddress(1))
+	require.Equal(t, 1, index)
+
+	require.Equal(t, -1, New(nil, nil).IndexOf(fake.NewAddress(0)))
+}
+
+func TestRoster_Contains(t *testing.T) {
+	roster := FromAuthority(fake.NewAuthority(3, fake.NewSigner))
+
+	require.True(t, roster.Contains(fake.NewAddress(1)))
+	require.False(t, roster.Contains(fake.NewAddress(999)))
+	require.False(t, New(nil, nil).Contains(fake.NewAddress(0)))
+}
+
 func TestRoster_AddressIterator(t *testing.T) {
 	authority := fake.NewAuthority(3, fake.NewSigner)
 	roster := FromAuthority(authority)
@@ -214,6 +468,19 @@ func TestRoster_PublicKeyIterator(t *testing.T) {
 	}
 }
 
+func TestRoster_GetAddressCodec(t *testing.T) {
+	roster := New(nil, nil)
+	require.Equal(t, TextCodec{}, roster.GetAddressCodec())
+
+	roster = New(nil, nil, WithCodec(fakeCodec{}))
+	require.Equal(t, fakeCodec{}, roster.GetAddressCodec())
+
+	roster = FromAuthority(fake.NewAuthority(3, fake.NewSigner))
+	roster.codec = fakeCodec{}
+	require.Equal(t, fakeCodec{}, roster.Take(mino.IndexFilter(0)).(Roster).codec)
+	require.Equal(t, fakeCodec{}, roster.Apply(NewChangeSet()).(Roster).codec)
+}
+
 func TestRoster_Serialize(t *testing.T) {
 	roster := Roster{}
 
@@ -238,3 +505,147 @@ func TestFactory_Deserialize(t *testing.T) {
 	_, err = factory.Deserialize(fake.NewContextWithFormat(serde.Format("BAD_TYPE")), nil)
 	require.EqualError(t, err, "invalid message of type 'fake.Message'")
 }
+
+func TestFactory_Deserialize_Normalizer(t *testing.T) {
+	addrFac := textAddressFactory{}
+
+	roster := New(
+		[]mino.Address{addrFac.FromText([]byte("host:0")), addrFac.FromText([]byte("other:0"))},
+		[]crypto.PublicKey{fake.PublicKey{}, fake.PublicKey{}},
+	)
+
+	RegisterRosterFormat(serde.Format("NORMALIZED"), fake.Format{Msg: roster})
+
+	duplicate := New(
+		[]mino.Address{addrFac.FromText([]byte("host:0")), addrFac.FromText([]byte("host:0"))},
+		[]crypto.PublicKey{fake.PublicKey{}, fake.NewBadPublicKey()},
+	)
+
+	RegisterRosterFormat(serde.Format("DUPLICATE"), fake.Format{Msg: duplicate})
+
+	missing := New(
+		[]mino.Address{addrFac.FromText([]byte("host:0")), addrFac.FromText([]byte("host"))},
+		[]crypto.PublicKey{fake.PublicKey{}, fake.NewBadPublicKey()},
+	)
+
+	RegisterRosterFormat(serde.Format("MISSING"), fake.Format{Msg: missing})
+
+	factory := NewFactory(addrFac, fake.PublicKeyFactory{})
+
+	// Without a normalizer, the roster is accepted as is.
+	msg, err := factory.Deserialize(fake.NewContextWithFormat(serde.Format("MISSING")), nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, msg.(Roster).Len())
+
+	factory = NewFactory(addrFac, fake.PublicKeyFactory{},
+		WithAddressNormalizer(NewPortNormalizer(addrFac, "0")))
+
+	// The roster is never changed by the decoding so that its digest is the
+	// same on every participant.
+	msg, err = factory.Deserialize(fake.NewContextWithFormat(serde.Format("NORMALIZED")), nil)
+	require.NoError(t, err)
+	require.Equal(t, roster, msg)
+
+	_, err = factory.Deserialize(fake.NewContextWithFormat(serde.Format("MISSING")), nil)
+	require.EqualError(t, err, "invalid roster: address 'host' is not normalized")
+
+	_, err = factory.Deserialize(fake.NewContextWithFormat(serde.Format("DUPLICATE")), nil)
+	require.EqualError(t, err, "invalid roster: duplicate participant 'host:0'")
+}
+
+func TestRoster_WithNormalizer(t *testing.T) {
+	addrFac := textAddressFactory{}
+
+	addrs := []mino.Address{
+		addrFac.FromText([]byte("host:0")),
+		addrFac.FromText([]byte("other:2000")),
+		addrFac.FromText([]byte("host")),
+	}
+
+	pubkeys := []crypto.PublicKey{fake.PublicKey{}, fake.PublicKey{}, fake.NewBadPublicKey()}
+
+	roster := New(addrs, pubkeys)
+	require.Equal(t, 3, roster.Len())
+
+	roster = New(addrs, pubkeys, WithNormalizer(NewPortNormalizer(addrFac, "0")))
+	require.Equal(t, 2, roster.Len())
+
+	// The first participant is kept.
+	require.True(t, roster.addrs[0].Equal(addrFac.FromText([]byte("host:0"))))
+	require.Equal(t, fake.PublicKey{}, roster.pubkeys[0])
+	require.True(t, roster.addrs[1].Equal(addrs[1]))
+
+	roster = New(addrs, pubkeys, WithNormalizer(nil))
+	require.Equal(t, 3, roster.Len())
+
+	roster = FromAuthority(New(addrs, pubkeys), WithNormalizer(NewPortNormalizer(addrFac, "0")))
+	require.Equal(t, 2, roster.Len())
+}
+
+func TestPortNormalizer_Normalize(t *testing.T) {
+	addrFac := textAddressFactory{}
+
+	n := NewPortNormalizer(addrFac, "0")
+
+	host := addrFac.FromText([]byte("host"))
+	hostPort := addrFac.FromText([]byte("host:0"))
+
+	require.True(t, n.Normalize(host).Equal(hostPort))
+	require.True(t, n.Normalize(hostPort).Equal(hostPort))
+	require.True(t, n.Normalize(hostPort).Equal(n.Normalize(host)))
+
+	other := addrFac.FromText([]byte("host:2000"))
+	require.True(t, n.Normalize(other).Equal(other))
+
+	// The prefix of the text form of a minogrpc address is kept, and the
+	// normalized address has a port.
+	n = NewPortNormalizer(addrFac, "2000")
+
+	require.True(t, n.Normalize(addrFac.FromText([]byte("F127.0.0.1"))).
+		Equal(addrFac.FromText([]byte("F127.0.0.1:2000"))))
+
+	grpc := addrFac.FromText([]byte("F127.0.0.1:2000"))
+	require.True(t, n.Normalize(grpc).Equal(grpc))
+
+	ipv6 := addrFac.FromText([]byte("::1"))
+	require.True(t, n.Normalize(ipv6).Equal(ipv6))
+
+	require.Equal(t, fake.NewBadAddress(), n.Normalize(fake.NewBadAddress()))
+}
+
+func TestTextCodec_Encode(t *testing.T) {
+	codec := TextCodec{}
+
+	data, err := codec.Encode(fake.NewAddress(1))
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 0, 0, 0}, data)
+
+	_, err = codec.Encode(fake.NewBadAddress())
+	require.EqualError(t, err, fake.GetError().Error())
+}
+
+func TestTextCodec_Decode(t *testing.T) {
+	codec := TextCodec{}
+
+	addr, err := codec.Decode(fake.AddressFactory{}, []byte{1, 0, 0, 0})
+	require.NoError(t, err)
+	require.Equal(t, fake.NewAddress(1), addr)
+}
+
+func TestCodecOf(t *testing.T) {
+	require.Nil(t, CodecOf(fake.AddressFactory{}))
+
+	fac := codecFactory{
+		AddressFactory: fake.AddressFactory{},
+		codec:          fakeCodec{},
+	}
+
+	require.Equal(t, fakeCodec{}, CodecOf(fac))
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+type fakeCodec struct {
+	AddressCodec
+}
@@ -27,6 +27,10 @@ import (
 // ErrNoBlock is the error message returned when the block is unknown.
 var ErrNoBlock = errors.New("no block")
 
+// ErrCompacted is the error message returned when the block has been dropped by
+// a compaction.
+var ErrCompacted = errors.New("block compacted")
+
 // TreeCache is a cache to store a tree that needs to be accessed in different
 // places.
 type TreeCache interface {
@@ -92,3 +96,12 @@ type BlockStore interface {
 	// operations on the database.
 	WithTx(store.Transaction) BlockStore
 }
+
+// Repairer is an optional interface of a block store that can overwrite a
+// block already stored, so that a damaged one can be replaced without touching
+// the others.
+type Repairer interface {
+	// Replace must overwrite the block link at the index of its block, which
+	// must be lower than the length of the store.
+	Replace(types.BlockLink) error
+}
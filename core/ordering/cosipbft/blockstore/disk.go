@@ -146,6 +146,50 @@ func (s *InDisk) Store(link types.BlockLink) error {
 	})
 }
 
+// Replace implements blockstore.Repairer. It overwrites the block link at the
+// index of its block, which must already be part of the store.
+func (s *InDisk) Replace(link types.BlockLink) error {
+	index := link.GetBlock().GetIndex()
+
+	s.Lock()
+	length := s.length
+	s.Unlock()
+
+	if index >= length {
+		return xerrors.Errorf("index %d out of range (length %d)", index, length)
+	}
+
+	data, err := link.Serialize(s.context)
+	if err != nil {
+		return xerrors.Errorf("failed to serialize: %v", err)
+	}
+
+	return s.doUpdate(func(tx kv.WritableTx) error {
+		bucket, err := tx.GetBucketOrCreate(s.bucket)
+		if err != nil {
+			return xerrors.Errorf("bucket failed: %v", err)
+		}
+
+		err = bucket.Set(s.makeKey(index), data)
+		if err != nil {
+			return xerrors.Errorf("while writing: %v", err)
+		}
+
+		tx.OnCommit(func() {
+			s.Lock()
+
+			s.indices[link.GetBlock().GetHash()] = index
+			if index == s.length-1 {
+				s.last = link
+			}
+
+			s.Unlock()
+		})
+
+		return nil
+	})
+}
+
 // Get implements blockstore.BlockStore. It loads the block with the given
 // identifier if it exists, otherwise it returns an error.
 func (s *InDisk) Get(id types.Digest) (types.BlockLink, error) {
@@ -124,6 +124,44 @@ func TestInDisk_GetByIndex(t *testing.T) {
 	require.EqualError(t, err, fake.Err("malformed block"))
 }
 
+func TestInDisk_Replace(t *testing.T) {
+	db, clean := makeDB(t)
+	defer clean()
+
+	store := NewDiskStore(db, makeBlockFac())
+
+	first := makeLink(t, types.Digest{}, types.WithIndex(0))
+
+	err := store.Store(first)
+	require.NoError(t, err)
+
+	err = store.Store(makeLink(t, first.GetTo(), types.WithIndex(1)))
+	require.NoError(t, err)
+
+	err = db.Update(func(tx kv.WritableTx) error {
+		return tx.GetBucket(store.bucket).Set(store.makeKey(0), []byte("corrupted"))
+	})
+	require.NoError(t, err)
+
+	_, err = store.GetByIndex(0)
+	require.Error(t, err)
+
+	err = store.Replace(first)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), store.length)
+
+	link, err := store.GetByIndex(0)
+	require.NoError(t, err)
+	require.Equal(t, first.GetTo(), link.GetTo())
+
+	err = store.Replace(makeLink(t, types.Digest{}, types.WithIndex(2)))
+	require.EqualError(t, err, "index 2 out of range (length 2)")
+
+	store.db = badDB{bucket: badBucket{}}
+	err = store.Replace(first)
+	require.EqualError(t, err, fake.Err("while writing"))
+}
+
 func TestInDisk_GetChain(t *testing.T) {
 	db, clean := makeDB(t)
 	defer clean()
@@ -81,7 +81,7 @@ func (s *InMemory) Get(id types.Digest) (types.BlockLink, error) {
 
 	for _, link := range s.blocks {
 		if link.GetTo() == id {
-			return link, nil
+			return checkCompacted(link)
 		}
 	}
 
@@ -98,7 +98,7 @@ func (s *InMemory) GetByIndex(index uint64) (types.BlockLink, error) {
 		return nil, xerrors.Errorf("block not found: %w", ErrNoBlock)
 	}
 
-	return s.blocks[index], nil
+	return checkCompacted(s.blocks[index])
 }
 
 // GetChain implements blockstore.BlockStore. It returns the chain to the latest
@@ -121,6 +121,67 @@ func (s *InMemory) GetChain() (types.Chain, error) {
 	return types.NewChain(s.blocks[num], prevs), nil
 }
 
+// Compact merges the runs of consecutive empty blocks below the floor into
+// checkpoints. The blocks of a run are dropped but their forward links are
+// kept, so that the chain still verifies across the run. It returns the
+// checkpoints of the runs that include newly compacted blocks.
+//
+// A compacted block cannot be read anymore, which means that the store cannot
+// help a participant to catch up on the compacted range. The floor must
+// therefore be the lowest index that a participant may still request, and the
+// blocks from the floor, as well as the latest block, are never compacted.
+func (s *InMemory) Compact(floor uint64) []Checkpoint {
+	s.Lock()
+	defer s.Unlock()
+
+	limit := len(s.blocks) - 1
+	if floor < uint64(limit) {
+		limit = int(floor)
+	}
+
+	checkpoints := []Checkpoint{}
+
+	start := 0
+	for start < limit {
+		if !isEmpty(s.blocks[start]) {
+			start++
+			continue
+		}
+
+		end := start
+		for end+1 < limit && isEmpty(s.blocks[end+1]) {
+			end++
+		}
+
+		if end > start && s.compactRun(start, end) {
+			checkpoints = append(checkpoints, Checkpoint{
+				Start: uint64(start),
+				End:   uint64(end),
+			})
+		}
+
+		start = end + 1
+	}
+
+	return checkpoints
+}
+
+// compactRun replaces the blocks of the run by their forward links. It returns
+// true if at least one block has been compacted.
+func (s *InMemory) compactRun(start, end int) bool {
+	compacted := false
+
+	for i := start; i <= end; i++ {
+		_, ok := s.blocks[i].(compactedLink)
+		if !ok {
+			s.blocks[i] = compactedLink{Link: s.blocks[i].Reduce()}
+			compacted = true
+		}
+	}
+
+	return compacted
+}
+
 // Last implements blockstore.BlockStore. It returns the latest block of the
 // store.
 func (s *InMemory) Last() (types.BlockLink, error) {
@@ -169,6 +230,53 @@ func (s *InMemory) WithTx(txn store.Transaction) BlockStore {
 	return store
 }
 
+// Checkpoint is the summary of a run of consecutive empty blocks that have been
+// merged by a compaction.
+type Checkpoint struct {
+	// Start is the index of the first block of the run.
+	Start uint64
+
+	// End is the index of the last block of the run.
+	End uint64
+}
+
+// compactedLink is the entry of a block dropped by a compaction. It only keeps
+// the forward link.
+//
+// - implements types.BlockLink
+type compactedLink struct {
+	types.Link
+}
+
+// GetBlock implements types.BlockLink. It returns an empty block as the block
+// has been dropped.
+func (link compactedLink) GetBlock() types.Block {
+	return types.Block{}
+}
+
+// Reduce implements types.BlockLink. It returns the forward link.
+func (link compactedLink) Reduce() types.Link {
+	return link.Link
+}
+
+func isEmpty(link types.BlockLink) bool {
+	_, ok := link.(compactedLink)
+	if ok {
+		return true
+	}
+
+	return len(link.GetBlock().GetTransactions()) == 0
+}
+
+func checkCompacted(link types.BlockLink) (types.BlockLink, error) {
+	_, ok := link.(compactedLink)
+	if ok {
+		return nil, xerrors.Errorf("block not available: %w", ErrCompacted)
+	}
+
+	return link, nil
+}
+
 // Observer is an observer that can be added to store watcher. It will announce
 // the blocks in order and without blocking the watcher even if the listener is
 // not actively emptying the queue.
@@ -183,6 +291,7 @@ type observer struct {
 	closed  bool
 	working sync.WaitGroup
 	ch      chan types.BlockLink
+	done    chan struct{}
 }
 
 func newObserver(ctx context.Context, watcher core.Observable) *observer {
@@ -194,6 +303,7 @@ func newObserver(ctx context.Context, watcher core.Observable) *observer {
 	obs := &observer{
 		logger: dela.Logger,
 		ch:     ch,
+		done:   make(chan struct{}),
 	}
 
 	watcher.Add(obs)
@@ -268,8 +378,13 @@ func (obs *observer) pushAndWait() {
 
 		obs.Unlock()
 
-		// Wait for the channel to be available to writings.
-		obs.ch <- msg
+		// Wait for the channel to be available to writings, or for the
+		// observer to be closed.
+		select {
+		case obs.ch <- msg:
+		case <-obs.done:
+			return
+		}
 	}
 }
 
@@ -280,6 +395,13 @@ func (obs *observer) close() {
 	obs.running = false
 	obs.buffer = nil
 
+	close(obs.done)
+
+	obs.Unlock()
+
+	// The channel is closed only when no routine is pushing anymore.
+	obs.working.Wait()
+
 	// Drain message in transit to close the channel properly.
 	select {
 	case <-obs.ch:
@@ -287,8 +409,4 @@ func (obs *observer) close() {
 	}
 
 	close(obs.ch)
-
-	obs.Unlock()
-
-	obs.working.Wait()
 }
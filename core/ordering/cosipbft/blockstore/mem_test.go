@@ -2,13 +2,19 @@ package blockstore
 
 import (
 	"context"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
 	"go.dedis.ch/dela/core/ordering/cosipbft/types"
 	"go.dedis.ch/dela/core/store"
+	"go.dedis.ch/dela/core/txn/signed"
 	"go.dedis.ch/dela/core/validation/simple"
+	"go.dedis.ch/dela/crypto"
+	"go.dedis.ch/dela/crypto/bls"
 	"go.dedis.ch/dela/internal/testing/fake"
+	"go.dedis.ch/dela/mino"
 )
 
 func TestInMemory_Len(t *testing.T) {
@@ -89,6 +95,94 @@ func TestInMemory_GetChain(t *testing.T) {
 	require.EqualError(t, err, "store is empty")
 }
 
+func TestInMemory_Compact(t *testing.T) {
+	signer := bls.NewSigner()
+
+	roster := authority.New([]mino.Address{fake.NewAddress(0)}, []crypto.PublicKey{signer.GetPublicKey()})
+
+	genesis, err := types.NewGenesis(roster)
+	require.NoError(t, err)
+
+	store := NewInMemory()
+
+	prev := genesis.GetHash()
+	for i := 0; i < 5; i++ {
+		link := makeSignedLink(t, signer, prev, simple.NewResult(nil), types.WithIndex(uint64(i)))
+		require.NoError(t, store.Store(link))
+
+		prev = link.GetTo()
+	}
+
+	tx, err := signed.NewTransaction(0, fake.PublicKey{})
+	require.NoError(t, err)
+
+	result := simple.NewResult([]simple.TransactionResult{simple.NewTransactionResult(tx, true, "")})
+
+	last := makeSignedLink(t, signer, prev, result, types.WithIndex(5))
+	require.NoError(t, store.Store(last))
+
+	empty, err := store.GetByIndex(2)
+	require.NoError(t, err)
+
+	// The blocks from the floor are kept for the participants catching up.
+	checkpoints := store.Compact(2)
+	require.Equal(t, []Checkpoint{{Start: 0, End: 1}}, checkpoints)
+
+	_, err = store.GetByIndex(2)
+	require.NoError(t, err)
+
+	checkpoints = store.Compact(6)
+	require.Equal(t, []Checkpoint{{Start: 0, End: 4}}, checkpoints)
+	require.Equal(t, uint64(6), store.Len())
+
+	chain, err := store.GetChain()
+	require.NoError(t, err)
+	require.Len(t, chain.GetLinks(), 6)
+	require.NoError(t, chain.Verify(genesis, genesis.GetHash(), signer.GetVerifierFactory()))
+
+	_, err = store.GetByIndex(2)
+	require.ErrorIs(t, err, ErrCompacted)
+
+	_, err = store.Get(empty.GetTo())
+	require.ErrorIs(t, err, ErrCompacted)
+
+	link, err := store.GetByIndex(5)
+	require.NoError(t, err)
+	require.Equal(t, last, link)
+
+	// Nothing left to compact.
+	require.Empty(t, store.Compact(6))
+
+	// The store keeps growing after a compaction, and the latest block is never
+	// compacted even when it is empty.
+	prev = last.GetTo()
+	for i := 6; i < 9; i++ {
+		link := makeSignedLink(t, signer, prev, simple.NewResult(nil), types.WithIndex(uint64(i)))
+		require.NoError(t, store.Store(link))
+
+		prev = link.GetTo()
+	}
+
+	checkpoints = store.Compact(math.MaxUint64)
+	require.Equal(t, []Checkpoint{{Start: 6, End: 7}}, checkpoints)
+
+	_, err = store.GetByIndex(8)
+	require.NoError(t, err)
+
+	chain, err = store.GetChain()
+	require.NoError(t, err)
+	require.NoError(t, chain.Verify(genesis, genesis.GetHash(), signer.GetVerifierFactory()))
+
+	// A single empty block is not a run.
+	store = NewInMemory()
+	store.blocks = []types.BlockLink{
+		makeLink(t, types.Digest{}),
+		makeLink(t, types.Digest{}),
+	}
+
+	require.Empty(t, store.Compact(math.MaxUint64))
+}
+
 func TestInMemory_Last(t *testing.T) {
 	store := NewInMemory()
 
@@ -139,7 +233,8 @@ func TestInMemory_WithTx(t *testing.T) {
 
 func TestObserver_NotifyCallback(t *testing.T) {
 	obs := &observer{
-		ch: make(chan types.BlockLink, 1),
+		ch:   make(chan types.BlockLink, 1),
+		done: make(chan struct{}),
 	}
 
 	link := makeLink(t, types.Digest{})
@@ -174,6 +269,7 @@ func TestObserver_Flooding_NotifyCallback(t *testing.T) {
 	obs := &observer{
 		logger: logger,
 		ch:     make(chan types.BlockLink),
+		done:   make(chan struct{}),
 	}
 
 	link := makeLink(t, types.Digest{})
@@ -188,6 +284,7 @@ func TestObserver_Flooding_NotifyCallback(t *testing.T) {
 func TestObserver_WhileEmpty_Close(t *testing.T) {
 	obs := &observer{
 		ch:      make(chan types.BlockLink),
+		done:    make(chan struct{}),
 		running: true,
 	}
 
@@ -195,6 +292,24 @@ func TestObserver_WhileEmpty_Close(t *testing.T) {
 	require.True(t, obs.closed)
 }
 
+func TestObserver_WhilePushing_Close(t *testing.T) {
+	obs := &observer{
+		ch:   make(chan types.BlockLink, 1),
+		done: make(chan struct{}),
+	}
+
+	link := makeLink(t, types.Digest{})
+
+	// The second event is pushed by a routine that waits for the channel to
+	// be drained.
+	obs.NotifyCallback(link)
+	obs.NotifyCallback(link)
+
+	obs.close()
+	require.True(t, obs.closed)
+	require.Empty(t, obs.ch)
+}
+
 // -----------------------------------------------------------------------------
 // Utility functions
 
@@ -217,3 +332,27 @@ type fakeTx struct {
 func (tx *fakeTx) OnCommit(fn func()) {
 	tx.fn = fn
 }
+
+func makeSignedLink(t *testing.T, signer crypto.Signer, from types.Digest,
+	res simple.Result, opts ...types.BlockOption) types.BlockLink {
+
+	to, err := types.NewBlock(res, opts...)
+	require.NoError(t, err)
+
+	unsigned, err := types.NewForwardLink(from, to.GetHash())
+	require.NoError(t, err)
+
+	prepare, err := signer.Sign(unsigned.GetHash().Bytes())
+	require.NoError(t, err)
+
+	data, err := prepare.MarshalBinary()
+	require.NoError(t, err)
+
+	commit, err := signer.Sign(data)
+	require.NoError(t, err)
+
+	link, err := types.NewBlockLink(from, to, types.WithSignatures(prepare, commit))
+	require.NoError(t, err)
+
+	return link
+}
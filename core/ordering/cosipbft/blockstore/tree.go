@@ -11,11 +11,13 @@ import (
 	"go.dedis.ch/dela/core/store/hashtree"
 )
 
-// TreeCache is a storage for a tree. It supports asynchronous calls.
+// TreeCache is a storage for a tree. It supports asynchronous calls. The reads
+// share the lock so that they don't block each other, whereas a set is
+// exclusive.
 //
 // - implements blockstore.TreeCache
 type treeCache struct {
-	sync.Mutex
+	sync.RWMutex
 	tree hashtree.Tree
 }
 
@@ -29,8 +31,8 @@ func NewTreeCache(tree hashtree.Tree) TreeCache {
 // Get implements blockstore.TreeCache. It returns the current value of the
 // cache.
 func (c *treeCache) Get() hashtree.Tree {
-	c.Lock()
-	defer c.Unlock()
+	c.RLock()
+	defer c.RUnlock()
 
 	return c.tree
 }
@@ -39,9 +41,9 @@ func (c *treeCache) Get() hashtree.Tree {
 // the cache alongside a function to unlock the cache. It allows one to delay
 // a set while fetching associated data. The function returned must be called.
 func (c *treeCache) GetWithLock() (hashtree.Tree, func()) {
-	c.Lock()
+	c.RLock()
 
-	return c.tree, c.unlock
+	return c.tree, c.RUnlock
 }
 
 // Set implements blockstore.TreeCache. It stores the new tree as the cache
@@ -28,6 +28,25 @@ func TestTreeCache_GetWithLock(t *testing.T) {
 	unlock()
 }
 
+func TestTreeCache_SharedRead(t *testing.T) {
+	cache := NewTreeCache(fakeTree{})
+
+	_, unlock := cache.GetWithLock()
+	defer unlock()
+
+	ch := make(chan struct{})
+	go func() {
+		cache.Get()
+		close(ch)
+	}()
+
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("get should not be locked by a read")
+	}
+}
+
 func TestTreeCache_Set(t *testing.T) {
 	cache := NewTreeCache(fakeTree{})
 
@@ -10,9 +10,15 @@ package blocksync
 import (
 	"context"
 
+	otypes "go.dedis.ch/dela/core/ordering/cosipbft/types"
 	"go.dedis.ch/dela/mino"
+	"golang.org/x/xerrors"
 )
 
+// ErrSnapshotRequired is the error returned when a node is too far behind the
+// chain to catch up block by block.
+var ErrSnapshotRequired = xerrors.New("gap too large, snapshot required")
+
 // Config is the configuration to change the behaviour of the synchronization.
 type Config struct {
 	// MinSoft is the number of participants that have soft-synchronized,
@@ -35,3 +41,12 @@ type Synchronizer interface {
 	// announce the current state of the chain.
 	Sync(ctx context.Context, players mino.Players, cfg Config) error
 }
+
+// Fetcher is an optional interface of a synchronizer that can request a single
+// block from the participants, for instance to repair a damaged store.
+type Fetcher interface {
+	// Fetch requests the block link at the index to the participants, and
+	// returns the links they replied with. It is left to the caller to decide
+	// which one to trust.
+	Fetch(ctx context.Context, players mino.Players, index uint64) ([]otypes.BlockLink, error)
+}
@@ -0,0 +1,60 @@
+// This file contains the implementation of the buffer of the blocks that
+// arrive before their predecessors during a catch up.
+//
+
+package blocksync
+
+import (
+	otypes "go.dedis.ch/dela/core/ordering/cosipbft/types"
+	"golang.org/x/xerrors"
+)
+
+// DefaultBufferSize is the default maximum number of blocks that are held
+// while waiting for their predecessors.
+const DefaultBufferSize = 64
+
+// linkBuffer is a bounded buffer of the links that can't be applied yet
+// because the block of a lower index is missing. The links are released in the
+// order of the indices once the gap is filled.
+type linkBuffer struct {
+	size  int
+	links map[uint64]otypes.BlockLink
+}
+
+func newLinkBuffer(size int) *linkBuffer {
+	return &linkBuffer{
+		size:  size,
+		links: make(map[uint64]otypes.BlockLink),
+	}
+}
+
+// Len returns the number of links in the buffer.
+func (b *linkBuffer) Len() int {
+	return len(b.links)
+}
+
+// Push adds the link to the buffer. A link for an index that is already
+// buffered replaces the previous one. It returns an error if the buffer is
+// full.
+func (b *linkBuffer) Push(link otypes.BlockLink) error {
+	index := link.GetBlock().GetIndex()
+
+	_, found := b.links[index]
+	if !found && len(b.links) >= b.size {
+		return xerrors.Errorf("buffer is full (%d)", b.size)
+	}
+
+	b.links[index] = link
+
+	return nil
+}
+
+// Pop removes and returns the link of the given index, if any.
+func (b *linkBuffer) Pop(index uint64) (otypes.BlockLink, bool) {
+	link, found := b.links[index]
+	if found {
+		delete(b.links, index)
+	}
+
+	return link, found
+}
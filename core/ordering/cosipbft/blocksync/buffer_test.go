@@ -0,0 +1,48 @@
+package blocksync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/ordering/cosipbft/blockstore"
+)
+
+func TestLinkBuffer_PushPop(t *testing.T) {
+	blocks := blockstore.NewInMemory()
+	storeBlocks(t, blocks, 3)
+
+	buffer := newLinkBuffer(2)
+
+	for i := uint64(1); i < blocks.Len(); i++ {
+		link, err := blocks.GetByIndex(i)
+		require.NoError(t, err)
+
+		err = buffer.Push(link)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, 2, buffer.Len())
+
+	// A link for an index already buffered replaces the previous one even
+	// when the buffer is full.
+	link, err := blocks.GetByIndex(2)
+	require.NoError(t, err)
+	require.NoError(t, buffer.Push(link))
+
+	link, err = blocks.GetByIndex(0)
+	require.NoError(t, err)
+
+	err = buffer.Push(link)
+	require.EqualError(t, err, "buffer is full (2)")
+
+	_, found := buffer.Pop(0)
+	require.False(t, found)
+
+	link, found = buffer.Pop(1)
+	require.True(t, found)
+	require.Equal(t, uint64(1), link.GetBlock().GetIndex())
+	require.Equal(t, 1, buffer.Len())
+
+	_, found = buffer.Pop(1)
+	require.False(t, found)
+}
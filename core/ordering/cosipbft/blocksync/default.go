@@ -19,6 +19,7 @@ import (
 	"go.dedis.ch/dela/crypto"
 	"go.dedis.ch/dela/internal/tracing"
 	"go.dedis.ch/dela/mino"
+	"go.dedis.ch/dela/serde"
 	"golang.org/x/xerrors"
 )
 
@@ -53,6 +54,16 @@ type SyncParam struct {
 	LinkFactory     otypes.LinkFactory
 	ChainFactory    otypes.ChainFactory
 	VerifierFactory crypto.VerifierFactory
+
+	// BufferSize is the maximum number of blocks held during a catch up while
+	// waiting for their predecessors. DefaultBufferSize is used when it is
+	// not set.
+	BufferSize int
+
+	// MaxGap is the maximum number of blocks that a node catches up with. A
+	// node falling further behind doesn't catch up as it should rather import
+	// a snapshot. The default zero value allows any gap.
+	MaxGap uint64
 }
 
 // NewSynchronizer creates a new block synchronizer.
@@ -61,6 +72,11 @@ func NewSynchronizer(param SyncParam) Synchronizer {
 
 	logger := dela.Logger.With().Str("addr", param.Mino.GetAddress().String()).Logger()
 
+	bufferSize := param.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+
 	h := &handler{
 		latest:      &latest,
 		catchUpLock: new(sync.Mutex),
@@ -69,6 +85,8 @@ func NewSynchronizer(param SyncParam) Synchronizer {
 		blocks:      param.Blocks,
 		pbftsm:      param.PBFT,
 		verifierFac: param.VerifierFactory,
+		bufferSize:  bufferSize,
+		maxGap:      param.MaxGap,
 	}
 
 	fac := types.NewMessageFactory(param.LinkFactory, param.ChainFactory)
@@ -178,6 +196,38 @@ func (s defaultSync) Sync(ctx context.Context, players mino.Players, cfg Config)
 	return nil
 }
 
+// Fetch implements blocksync.Fetcher. It sends a request for the block link at
+// the index to the participants and returns the replies received before the
+// context is done.
+func (s defaultSync) Fetch(ctx context.Context, players mino.Players,
+	index uint64) ([]otypes.BlockLink, error) {
+
+	ctx = context.WithValue(ctx, tracing.ProtocolKey, protocolName)
+
+	resps, err := s.rpc.Call(ctx, types.NewSyncRequest(index), players)
+	if err != nil {
+		return nil, xerrors.Errorf("rpc failed: %v", err)
+	}
+
+	links := []otypes.BlockLink{}
+
+	for resp := range resps {
+		msg, err := resp.GetMessageOrError()
+		if err != nil {
+			s.logger.Debug().Err(err).Stringer("from", resp.GetFrom()).
+				Msg("fetch failed")
+			continue
+		}
+
+		reply, ok := msg.(types.SyncReply)
+		if ok {
+			links = append(links, reply.GetLink())
+		}
+	}
+
+	return links, nil
+}
+
 func (s defaultSync) syncNode(from uint64, sender mino.Sender, to mino.Address) {
 	for i := from; i < s.blocks.Len(); i++ {
 		link, err := s.blocks.GetByIndex(i)
@@ -213,6 +263,8 @@ type handler struct {
 	genesis     blockstore.GenesisStore
 	pbftsm      pbft.StateMachine
 	verifierFac crypto.VerifierFactory
+	bufferSize  int
+	maxGap      uint64
 }
 
 // Stream implements mino.Handler. It waits for an announcement message and then
@@ -267,11 +319,26 @@ func (h *handler) Stream(out mino.Sender, in mino.Receiver) error {
 		*h.latest = m.GetLatestIndex()
 	}
 
+	// Another synchronization might have stored the blocks while waiting for
+	// the lock.
+	if m.GetLatestIndex() < h.blocks.Len() {
+		return h.ack(out, orch)
+	}
+
+	// The latest index is still learnt so that the proposals are refused, but
+	// the blocks are not requested one by one as a snapshot is needed.
+	gap := m.GetLatestIndex() - h.blocks.Len()
+	if h.maxGap > 0 && gap > h.maxGap {
+		return xerrors.Errorf("behind by %d blocks: %w", gap, ErrSnapshotRequired)
+	}
+
 	err = <-out.Send(types.NewSyncRequest(h.blocks.Len()), orch)
 	if err != nil {
 		return xerrors.Errorf("sending request failed: %v", err)
 	}
 
+	buffer := newLinkBuffer(h.bufferSize)
+
 	for h.blocks.Len() <= m.GetLatestIndex() {
 		_, msg, err := in.Recv(ctx)
 		if err != nil {
@@ -280,13 +347,9 @@ func (h *handler) Stream(out mino.Sender, in mino.Receiver) error {
 
 		reply, ok := msg.(types.SyncReply)
 		if ok {
-			h.logger.Debug().
-				Uint64("index", reply.GetLink().GetBlock().GetIndex()).
-				Msg("catch up block")
-
-			err = h.pbftsm.CatchUp(reply.GetLink())
+			err = h.catchUp(reply.GetLink(), buffer)
 			if err != nil {
-				return xerrors.Errorf("pbft catch up failed: %v", err)
+				return err
 			}
 		}
 	}
@@ -296,6 +359,60 @@ func (h *handler) Stream(out mino.Sender, in mino.Receiver) error {
 	return h.ack(out, orch)
 }
 
+// Process implements mino.Handler. It replies to a request for a single block
+// link with the one stored at the index.
+func (h *handler) Process(req mino.Request) (serde.Message, error) {
+	msg, ok := req.Message.(types.SyncRequest)
+	if !ok {
+		return nil, xerrors.Errorf("unsupported message '%T'", req.Message)
+	}
+
+	link, err := h.blocks.GetByIndex(msg.GetFrom())
+	if err != nil {
+		return nil, xerrors.Errorf("block %d: %v", msg.GetFrom(), err)
+	}
+
+	return types.NewSyncReply(link), nil
+}
+
+// catchUp applies the link, and then the buffered links that follow it. A link
+// that arrives before its predecessor is buffered until the gap is filled.
+func (h *handler) catchUp(link otypes.BlockLink, buffer *linkBuffer) error {
+	index := link.GetBlock().GetIndex()
+
+	if index > h.blocks.Len() {
+		h.logger.Debug().
+			Uint64("index", index).
+			Uint64("expected", h.blocks.Len()).
+			Msg("buffer block")
+
+		err := buffer.Push(link)
+		if err != nil {
+			return xerrors.Errorf("couldn't buffer block %d: %v", index, err)
+		}
+
+		return nil
+	}
+
+	for {
+		h.logger.Debug().
+			Uint64("index", link.GetBlock().GetIndex()).
+			Msg("catch up block")
+
+		err := h.pbftsm.CatchUp(link)
+		if err != nil {
+			return xerrors.Errorf("pbft catch up failed: %v", err)
+		}
+
+		next, found := buffer.Pop(h.blocks.Len())
+		if !found {
+			return nil
+		}
+
+		link = next
+	}
+}
+
 func (h *handler) waitAnnounce(ctx context.Context,
 	in mino.Receiver) (*types.SyncMessage, mino.Address, error) {
 
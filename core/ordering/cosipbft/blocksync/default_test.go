@@ -20,6 +20,7 @@ import (
 	"go.dedis.ch/dela/internal/testing/fake"
 	"go.dedis.ch/dela/mino"
 	"go.dedis.ch/dela/mino/minoch"
+	"golang.org/x/xerrors"
 )
 
 func TestDefaultSync_Basic(t *testing.T) {
@@ -163,6 +164,49 @@ func TestDefaultSync_SyncNode(t *testing.T) {
 	check(t)
 }
 
+func TestDefaultSync_Fetch(t *testing.T) {
+	syncs, genesis, roster := makeNodes(t, 4)
+
+	storeBlocks(t, syncs[1].blocks, 3, genesis.GetHash().Bytes()...)
+	storeBlocks(t, syncs[2].blocks, 3, genesis.GetHash().Bytes()...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The last participant doesn't have the block, so only two of them reply.
+	links, err := syncs[0].Fetch(ctx, roster.Take(mino.RangeFilter(1, 4)), 2)
+	require.NoError(t, err)
+	require.Len(t, links, 2)
+
+	expected, err := syncs[1].blocks.GetByIndex(2)
+	require.NoError(t, err)
+
+	for _, link := range links {
+		require.Equal(t, expected.GetTo(), link.GetTo())
+	}
+
+	syncs[0].rpc = fake.NewBadRPC()
+	_, err = syncs[0].Fetch(ctx, roster, 2)
+	require.EqualError(t, err, fake.Err("rpc failed"))
+}
+
+func TestHandler_Process(t *testing.T) {
+	blocks := blockstore.NewInMemory()
+	storeBlocks(t, blocks, 2)
+
+	h := &handler{blocks: blocks}
+
+	msg, err := h.Process(mino.Request{Message: types.NewSyncRequest(1)})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), msg.(types.SyncReply).GetLink().GetBlock().GetIndex())
+
+	_, err = h.Process(mino.Request{Message: types.NewSyncRequest(5)})
+	require.EqualError(t, err, "block 5: block not found: no block")
+
+	_, err = h.Process(mino.Request{Message: fake.Message{}})
+	require.EqualError(t, err, "unsupported message 'fake.Message'")
+}
+
 func TestHandler_Stream(t *testing.T) {
 	latest := uint64(0)
 	blocks := blockstore.NewInMemory()
@@ -174,6 +218,7 @@ func TestHandler_Stream(t *testing.T) {
 		genesis:     blockstore.NewGenesisStore(),
 		blocks:      blockstore.NewInMemory(),
 		verifierFac: fake.VerifierFactory{},
+		bufferSize:  DefaultBufferSize,
 	}
 	handler.genesis.Set(otypes.Genesis{})
 	handler.pbftsm = testSM{blocks: handler.blocks}
@@ -248,6 +293,108 @@ func TestHandler_Stream(t *testing.T) {
 	require.EqualError(t, err, fake.Err("sending ack failed"))
 }
 
+func TestHandler_MaxGap_Stream(t *testing.T) {
+	latest := uint64(0)
+
+	handler := &handler{
+		latest:      &latest,
+		catchUpLock: new(sync.Mutex),
+		genesis:     blockstore.NewGenesisStore(),
+		blocks:      blockstore.NewInMemory(),
+		verifierFac: fake.VerifierFactory{},
+		bufferSize:  DefaultBufferSize,
+		maxGap:      2,
+	}
+	handler.genesis.Set(otypes.Genesis{})
+	handler.pbftsm = testSM{blocks: handler.blocks}
+
+	recv := fake.NewReceiver(
+		fake.NewRecvMsg(fake.NewAddress(0), types.NewSyncMessage(makeChain(t, 6))),
+	)
+
+	// The bad sender proves that no block is requested.
+	err := handler.Stream(fake.NewBadSender(), recv)
+	require.EqualError(t, err, "behind by 6 blocks: gap too large, snapshot required")
+	require.True(t, xerrors.Is(err, ErrSnapshotRequired))
+	require.Equal(t, uint64(6), latest)
+	require.Equal(t, uint64(0), handler.blocks.Len())
+}
+
+func TestHandler_CaughtUpWhileWaiting_Stream(t *testing.T) {
+	latest := uint64(0)
+	blocks := blockstore.NewInMemory()
+	storeBlocks(t, blocks, 3)
+
+	handler := &handler{
+		latest:      &latest,
+		catchUpLock: new(sync.Mutex),
+		genesis:     blockstore.NewGenesisStore(),
+		blocks:      &catchingUpBlockStore{BlockStore: blocks},
+		verifierFac: fake.VerifierFactory{},
+		bufferSize:  DefaultBufferSize,
+		maxGap:      2,
+	}
+	handler.genesis.Set(otypes.Genesis{})
+
+	recv := fake.NewReceiver(
+		fake.NewRecvMsg(fake.NewAddress(0), types.NewSyncMessage(makeChain(t, 2))),
+	)
+
+	// The blocks are stored by another synchronization after the first check
+	// of the handler, so that the store is ahead of the announcement.
+	err := handler.Stream(fake.Sender{}, recv)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), latest)
+}
+
+func TestHandler_Stream_OutOfOrder(t *testing.T) {
+	latest := uint64(0)
+	blocks := blockstore.NewInMemory()
+	storeBlocks(t, blocks, 5)
+
+	handler := &handler{
+		latest:      &latest,
+		catchUpLock: new(sync.Mutex),
+		genesis:     blockstore.NewGenesisStore(),
+		blocks:      blockstore.NewInMemory(),
+		verifierFac: fake.VerifierFactory{},
+		bufferSize:  DefaultBufferSize,
+	}
+	handler.genesis.Set(otypes.Genesis{})
+	handler.pbftsm = testSM{blocks: handler.blocks}
+
+	msgs := []fake.ReceiverMessage{
+		fake.NewRecvMsg(fake.NewAddress(0), types.NewSyncMessage(makeChain(t, blocks.Len()-1))),
+	}
+	for _, index := range []uint64{3, 1, 4, 0, 2} {
+		link, err := blocks.GetByIndex(index)
+		require.NoError(t, err)
+
+		msgs = append(msgs, fake.NewRecvMsg(fake.NewAddress(0), types.NewSyncReply(link)))
+	}
+
+	err := handler.Stream(fake.Sender{}, fake.NewReceiver(msgs...))
+	require.NoError(t, err)
+	require.Equal(t, blocks.Len(), handler.blocks.Len())
+
+	for i := uint64(0); i < blocks.Len(); i++ {
+		expected, err := blocks.GetByIndex(i)
+		require.NoError(t, err)
+
+		link, err := handler.blocks.GetByIndex(i)
+		require.NoError(t, err)
+		require.Equal(t, expected.GetTo(), link.GetTo())
+	}
+
+	handler.blocks = blockstore.NewInMemory()
+	handler.pbftsm = testSM{blocks: handler.blocks}
+	handler.bufferSize = 1
+
+	err = handler.Stream(fake.Sender{}, fake.NewReceiver(msgs...))
+	require.EqualError(t, err, "couldn't buffer block 1: buffer is full (1)")
+	require.Equal(t, uint64(0), handler.blocks.Len())
+}
+
 // -----------------------------------------------------------------------------
 // Utility functions
 
@@ -332,6 +479,23 @@ func (sm testSM) CatchUp(link otypes.BlockLink) error {
 	return nil
 }
 
+// catchingUpBlockStore is a block store that is empty when its length is read
+// for the first time.
+type catchingUpBlockStore struct {
+	blockstore.BlockStore
+
+	read bool
+}
+
+func (s *catchingUpBlockStore) Len() uint64 {
+	if !s.read {
+		s.read = true
+		return 0
+	}
+
+	return s.BlockStore.Len()
+}
+
 type badBlockStore struct {
 	blockstore.BlockStore
 
@@ -119,7 +119,7 @@ func (m miniController) OnStart(flags cli.Flags, inj node.Injector) error {
 	}
 
 	cosi := threshold.NewThreshold(onet.WithSegment("cosi"), signer)
-	cosi.SetThreshold(threshold.ByzantineThreshold)
+	cosi.SetThreshold(authority.QuorumThreshold)
 
 	exec := native.NewExecution()
 	access := darc.NewService(json.NewContext())
@@ -167,18 +167,7 @@ func (m miniController) OnStart(flags cli.Flags, inj node.Injector) error {
 		return xerrors.Errorf("failed to load genesis: %v", err)
 	}
 
-	blockFac := types.NewBlockFactory(vs.GetFactory())
-	csFac := authority.NewChangeSetFactory(onet.GetAddressFactory(), cosi.GetPublicKeyFactory())
-	linkFac := types.NewLinkFactory(blockFac, cosi.GetSignatureFactory(), csFac)
-
-	blocks := blockstore.NewDiskStore(db, linkFac)
-
-	err = blocks.Load()
-	if err != nil {
-		return xerrors.Errorf("failed to load blocks: %v", err)
-	}
-
-	srvc, err := cosipbft.NewService(param, cosipbft.WithGenesisStore(genstore), cosipbft.WithBlockStore(blocks))
+	srvc, err := cosipbft.NewService(param, cosipbft.WithGenesisStore(genstore), cosipbft.WithDiskBlockStore())
 	if err != nil {
 		return xerrors.Errorf("service: %v", err)
 	}
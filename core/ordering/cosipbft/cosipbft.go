@@ -32,9 +32,12 @@ package cosipbft
 import (
 	"context"
 	"fmt"
+	"io"
 	"math"
+	"sync"
 	"time"
 
+	"github.com/rs/zerolog"
 	"go.dedis.ch/dela"
 	"go.dedis.ch/dela/core/access"
 	"go.dedis.ch/dela/core/execution/native"
@@ -44,17 +47,19 @@ import (
 	"go.dedis.ch/dela/core/ordering/cosipbft/blocksync"
 	"go.dedis.ch/dela/core/ordering/cosipbft/contracts/viewchange"
 	"go.dedis.ch/dela/core/ordering/cosipbft/pbft"
+	"go.dedis.ch/dela/core/ordering/cosipbft/txindex"
 	"go.dedis.ch/dela/core/ordering/cosipbft/types"
 	"go.dedis.ch/dela/core/store"
 	"go.dedis.ch/dela/core/store/hashtree"
 	"go.dedis.ch/dela/core/store/kv"
 	"go.dedis.ch/dela/core/txn"
 	"go.dedis.ch/dela/core/txn/pool"
+	"go.dedis.ch/dela/core/txn/signed"
 	"go.dedis.ch/dela/core/validation"
 	"go.dedis.ch/dela/cosi"
-	"go.dedis.ch/dela/cosi/threshold"
 	"go.dedis.ch/dela/crypto"
 	"go.dedis.ch/dela/mino"
+	"go.dedis.ch/dela/serde"
 	"golang.org/x/xerrors"
 )
 
@@ -80,6 +85,31 @@ const (
 	// RoundMaxWait is the maximum amount for the backoff.
 	RoundMaxWait = 5 * time.Minute
 
+	// DefaultFinalizeAttempts is the maximum number of times the finalization
+	// of a block is tried when it fails because of a transient error.
+	DefaultFinalizeAttempts = 3
+
+	// DefaultFinalizeBackoff is the initial waiting time between two attempts
+	// to finalize a block. It doubles after each attempt.
+	DefaultFinalizeBackoff = 50 * time.Millisecond
+
+	// DefaultCatchUpTimeout is the maximum time a node waits to catch up with
+	// the latest block before processing a proposal.
+	DefaultCatchUpTimeout = 10 * time.Second
+
+	// DefaultStorageAckTimeout is the maximum time the leader waits for a
+	// quorum of acknowledgements of a stored block.
+	DefaultStorageAckTimeout = 5 * time.Second
+
+	// DefaultStorageAckBackoff is the initial waiting time before the leader
+	// requests again the acknowledgements of the missing participants. It
+	// doubles after each request.
+	DefaultStorageAckBackoff = 50 * time.Millisecond
+
+	// DumpValueMaxSize is the maximum number of bytes of a value written by a
+	// dump of the tree.
+	DumpValueMaxSize = 64
+
 	rpcName = "cosipbft"
 )
 
@@ -101,8 +131,13 @@ type Service struct {
 	me          mino.Address
 	rpc         mino.RPC
 	actor       cosi.Actor
+	signer      crypto.Signer
 	val         validation.Service
 	verifierFac crypto.VerifierFactory
+	blockFac    types.BlockFactory
+	linkFac     types.LinkFactory
+	chainFac    types.ChainFactory
+	db          kv.DB
 
 	timeoutRound             time.Duration
 	timeoutRoundAfterFailure time.Duration
@@ -112,17 +147,116 @@ type Service struct {
 	closing     chan struct{}
 	closed      chan struct{}
 	failedRound bool
+	embedRoster bool
+	merkleRoot  bool
+
+	// blockInterval is the minimum time between two blocks proposed by the
+	// leader, and emptyBlocks allows the leader to propose a block without
+	// transactions.
+	blockInterval time.Duration
+	emptyBlocks   bool
+	lastProposal  time.Time
+
+	// maxBlockSize is the maximum estimated size in bytes of a block proposed
+	// by the leader, or zero when the size is not limited.
+	maxBlockSize int
+
+	// commitTimeout is the maximum time the leader waits for a quorum of
+	// commits, or zero when only the round timeout applies.
+	commitTimeout time.Duration
+
+	// storageAckTimeout is the maximum time the leader waits for a quorum of
+	// acknowledgements of a stored block, and storageAckBackoff the initial
+	// waiting time between two requests to the missing participants.
+	storageAckTimeout time.Duration
+	storageAckBackoff time.Duration
+
+	// extraData is stamped in the blocks proposed by the leader.
+	extraData []byte
+
+	// genesisAttempts is the maximum number of times the genesis block is sent
+	// to the participants that didn't acknowledge it, with a backoff starting
+	// at genesisBackoff between two attempts.
+	genesisAttempts int
+	genesisBackoff  time.Duration
+
+	// normalizer normalizes the addresses of the roster of the genesis block
+	// created by the setup.
+	normalizer authority.AddressNormalizer
+
+	// roundLock prevents the terminal block to be proposed alongside a block of
+	// the current round.
+	roundLock sync.Mutex
+
+	// cancelLeaderRound cancels the context of the leader round in progress,
+	// or it is nil when the node is not running one.
+	cancelLock        sync.Mutex
+	cancelLeaderRound context.CancelFunc
 }
 
 type serviceTemplate struct {
-	hashFac crypto.HashFactory
-	blocks  blockstore.BlockStore
-	genesis blockstore.GenesisStore
+	logger            zerolog.Logger
+	hashFac           crypto.HashFactory
+	blocks            blockstore.BlockStore
+	diskBlocks        bool
+	genesis           blockstore.GenesisStore
+	filters           []pool.Filter
+	embedRoster       bool
+	merkleRoot        bool
+	blockInterval     time.Duration
+	emptyBlocks       bool
+	genesisLoader     GenesisLoader
+	genesisState      GenesisStateInitializer
+	localGenesis      *types.Genesis
+	interceptor       MessageInterceptor
+	commitEncoding    types.SignatureEncoding
+	version           types.ProtocolVersion
+	indexTxs          bool
+	maxCatchUpGap     uint64
+	catchUpTimeout    time.Duration
+	storageAck        bool
+	storageAckTimeout time.Duration
+	storageAckBackoff time.Duration
+	election          pbft.LeaderElection
+	verifyWorkers     int
+	maxBlockSize      int
+	maxTxSize         int
+	maxTxArgs         int
+	archival          bool
+	commitTimeout     time.Duration
+	faults            *FaultInjector
+	extraData         []byte
+	normalizer        authority.AddressNormalizer
+
+	finalizeAttempts int
+	finalizeBackoff  time.Duration
+
+	genesisAttempts int
+	genesisBackoff  time.Duration
 }
 
 // ServiceOption is the type of option to set some fields of the service.
 type ServiceOption func(*serviceTemplate)
 
+// WithLogger is an option to set the logger of the service. The address of the
+// node is bound to every line so that the output of several nodes can be told
+// apart. The default is the package logger.
+func WithLogger(logger zerolog.Logger) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.logger = logger
+	}
+}
+
+// WithLocalGenesis is an option to set the genesis block from the local
+// configuration, for instance read with ReadGenesisFile. It takes precedence
+// over the genesis propagated by the other participants, which is refused if
+// it is different.
+func WithLocalGenesis(genesis types.Genesis) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.localGenesis = &genesis
+	}
+}
+
 // WithGenesisStore is an option to set the genesis store.
 func WithGenesisStore(store blockstore.GenesisStore) ServiceOption {
 	return func(tmpl *serviceTemplate) {
@@ -130,10 +264,25 @@ func WithGenesisStore(store blockstore.GenesisStore) ServiceOption {
 	}
 }
 
-// WithBlockStore is an option to set the block store.
+// WithBlockStore is an option to set the block store. The processor only
+// relies on the blockstore.BlockStore interface, so any backend can be used.
+// The default is the in-memory store, which is lost when the node stops and is
+// thus suited for tests.
 func WithBlockStore(store blockstore.BlockStore) ServiceOption {
 	return func(tmpl *serviceTemplate) {
 		tmpl.blocks = store
+		tmpl.diskBlocks = false
+	}
+}
+
+// WithDiskBlockStore is an option to store the blocks in the database of the
+// service, which is the backend to use in production. The blocks are written in
+// the same transaction as the tree, and they are loaded when the service is
+// created so that a node restarting from the same database resumes from its
+// latest block.
+func WithDiskBlockStore() ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.diskBlocks = true
 	}
 }
 
@@ -144,6 +293,270 @@ func WithHashFactory(fac crypto.HashFactory) ServiceOption {
 	}
 }
 
+// WithPoolFilter is an option to add filters that a transaction must go
+// through before being admitted in the pool, in addition to the ones of the
+// service.
+func WithPoolFilter(filters ...pool.Filter) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.filters = append(tmpl.filters, filters...)
+	}
+}
+
+// WithRosterDigest is an option to embed the digest of the roster in each block
+// proposed by the service, so that a client can learn the membership at a
+// given height without replaying the roster changes. It is disabled by default
+// as it increases the size of the blocks.
+func WithRosterDigest() ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.embedRoster = true
+	}
+}
+
+// WithPayloadRoot is an option to record the Merkle root of the transaction
+// results in each block proposed by the service, so that a client can verify
+// the inclusion of a transaction without the whole block. It changes the digest
+// of the blocks, so it is disabled by default.
+func WithPayloadRoot() ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.merkleRoot = true
+	}
+}
+
+// WithBlockInterval is an option to set the minimum time between two blocks
+// proposed by the leader. Blocks are proposed as soon as possible by default.
+func WithBlockInterval(interval time.Duration) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.blockInterval = interval
+	}
+}
+
+// WithEmptyBlocks is an option to let the leader propose a block even when the
+// pool has no transaction, for instance to produce a heartbeat of the chain at
+// the pace of the block interval. Empty blocks are suppressed by default so
+// that the chain only grows with transactions.
+func WithEmptyBlocks() ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.emptyBlocks = true
+	}
+}
+
+// WithGenesisLoader is an option to restrict the rosters accepted in a genesis
+// block to the ones whose digest is supplied by the loader. Any roster is
+// accepted by default.
+func WithGenesisLoader(loader GenesisLoader) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.genesisLoader = loader
+	}
+}
+
+// WithGenesisState is an option to seed the tree of the genesis block with the
+// initial state of the application, which is then reflected in the root of the
+// genesis block. The tree only holds the roster by default.
+func WithGenesisState(init GenesisStateInitializer) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.genesisState = init
+	}
+}
+
+// WithMessageInterceptor is an option to set the interceptor that transforms
+// the messages of the service before they are sent, and after they are
+// received. The messages are left untouched by default.
+func WithMessageInterceptor(interceptor MessageInterceptor) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.interceptor = interceptor
+	}
+}
+
+// WithCommitEncoding is an option to set the encoding of the prepare signature
+// that is signed during the commit phase, for instance to interoperate with a
+// verifier expecting a specific format. Every participant must use the same
+// encoding. The binary format of the signature is used by default, while
+// types.FormatEncoding uses the format of the signatures stored in the blocks.
+func WithCommitEncoding(encoding types.SignatureEncoding) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.commitEncoding = encoding
+	}
+}
+
+// WithProtocolVersion is an option to set the version of the signing protocol.
+// From the version 1, the signatures are bound to the genesis block of the
+// chain so that they can't be replayed on another chain sharing the same keys.
+// Every participant must use the same version.
+func WithProtocolVersion(version types.ProtocolVersion) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.version = version
+	}
+}
+
+// WithTransactionIndex is an option to maintain an index of the transactions
+// by identity in the tree, so that the blocks containing the transactions of
+// an identity can be found without scanning the chain. Every participant must
+// enable it as the index is part of the state.
+func WithTransactionIndex() ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.indexTxs = true
+	}
+}
+
+// WithLeaderElection is an option to set the rule that elects the leader of the
+// next view after a view change. Every participant must use the same rule. By
+// default, the leader rotates over the roster in a round-robin.
+func WithLeaderElection(le pbft.LeaderElection) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.election = le
+	}
+}
+
+// WithVerifyWorkers is an option to set the maximum number of links of a chain
+// whose signatures are verified in parallel, for instance when a participant
+// catches up. The verification is serial by default.
+func WithVerifyWorkers(num int) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.verifyWorkers = num
+	}
+}
+
+// WithArchivalMode is an option to run an archival node that follows the chain
+// through the synchronizations of the leaders, but never proposes nor signs a
+// block. The messages of the rounds are rejected.
+func WithArchivalMode() ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.archival = true
+	}
+}
+
+// WithMaxBlockSize is an option to set the maximum size in bytes of a block
+// proposed by the leader. The transactions are added to the block until the
+// estimated size would exceed the limit, and the remaining ones are kept in the
+// pool for the next rounds. The default zero value doesn't limit the size.
+func WithMaxBlockSize(size int) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.maxBlockSize = size
+	}
+}
+
+// WithMaxTransactionSize is an option to set the maximum size in bytes of the
+// payload of a signed transaction admitted in the pool. The default is
+// signed.DefaultMaxSize, and a size of zero or less disables the limit.
+func WithMaxTransactionSize(size int) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.maxTxSize = size
+	}
+}
+
+// WithMaxTransactionArgs is an option to set the maximum number of arguments of
+// a signed transaction admitted in the pool. The default is
+// signed.DefaultMaxArgs, and a number of zero or less disables the limit.
+func WithMaxTransactionArgs(num int) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.maxTxArgs = num
+	}
+}
+
+// WithCommitTimeout is an option to set the maximum time the leader waits for a
+// quorum of the participants to commit a proposal. The leader then gives up on
+// the proposal and starts a view change instead of waiting for the end of the
+// round. The default zero value only relies on the round timeout.
+func WithCommitTimeout(timeout time.Duration) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.commitTimeout = timeout
+	}
+}
+
+// WithMaxCatchUpGap is an option to set the maximum number of blocks that a
+// node catches up with before accepting a proposal. A node falling further
+// behind refuses the proposal and stops the synchronization of the blocks, as
+// it should rather import a snapshot. The default zero value allows any gap.
+func WithMaxCatchUpGap(gap uint64) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.maxCatchUpGap = gap
+	}
+}
+
+// WithStorageAck is an option to make the participants acknowledge that they
+// have stored a committed block, and the leader wait for the acknowledgements
+// of a quorum before it starts the next round. It must be set on every
+// participant as the others do not acknowledge by default.
+func WithStorageAck() ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.storageAck = true
+	}
+}
+
+// WithStorageAckRetry is an option to set the maximum time the leader waits for
+// a quorum of acknowledgements of a stored block, and the initial backoff
+// between two requests to the participants that have not acknowledged it yet.
+// The defaults are DefaultStorageAckTimeout and DefaultStorageAckBackoff.
+func WithStorageAckRetry(timeout, backoff time.Duration) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.storageAckTimeout = timeout
+		tmpl.storageAckBackoff = backoff
+	}
+}
+
+// WithCatchUpTimeout is an option to set the maximum time a node waits to
+// catch up with the latest block before processing a proposal. The proposal is
+// rejected when the deadline elapses, and a zero value waits without limit.
+// The default is DefaultCatchUpTimeout.
+func WithCatchUpTimeout(timeout time.Duration) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.catchUpTimeout = timeout
+	}
+}
+
+// WithFinalizeRetry is an option to set the maximum number of attempts to
+// finalize a block when the failure is transient, and the initial backoff
+// between two attempts.
+func WithFinalizeRetry(attempts int, backoff time.Duration) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.finalizeAttempts = attempts
+		tmpl.finalizeBackoff = backoff
+	}
+}
+
+// WithExtraData is an option to stamp the blocks proposed by the leader with
+// opaque data defined by the application, like a version or the digest of a
+// configuration. The data must not exceed types.MaxExtraDataSize.
+func WithExtraData(data []byte) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.extraData = data
+	}
+}
+
+// WithAddressNormalizer is an option to normalize the addresses of the roster
+// of the genesis block before it is created, and to refuse the rosters read by
+// the service whose addresses are not normalized. It must be the same on every
+// participant and set from the genesis block. The roster contract should use a
+// factory with the same normalizer so that a roster change is checked before it
+// is stored.
+func WithAddressNormalizer(n authority.AddressNormalizer) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.normalizer = n
+	}
+}
+
+// WithFaultInjector is an option to make the operations of the service fail on
+// demand through the injector. It is meant for testing the resilience of the
+// chain and must not be used in production.
+func WithFaultInjector(injector *FaultInjector) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.faults = injector
+	}
+}
+
+// WithGenesisRetry is an option to set the maximum number of attempts to send
+// the genesis block to the participants of the setup, and the initial backoff
+// between two attempts. Each attempt only targets the participants that failed
+// to acknowledge the previous one, so that a participant that is unreachable
+// at first still receives the genesis block. The genesis block is sent only
+// once by default.
+func WithGenesisRetry(attempts int, backoff time.Duration) ServiceOption {
+	return func(tmpl *serviceTemplate) {
+		tmpl.genesisAttempts = attempts
+		tmpl.genesisBackoff = backoff
+	}
+}
+
 // ServiceParam is the different components to provide to the service. All the
 // fields are mandatory and it will panic if any is nil.
 type ServiceParam struct {
@@ -159,24 +572,72 @@ type ServiceParam struct {
 // NewService starts a new ordering service.
 func NewService(param ServiceParam, opts ...ServiceOption) (*Service, error) {
 	tmpl := serviceTemplate{
+		logger:  dela.Logger,
 		hashFac: crypto.NewSha256Factory(),
 		genesis: blockstore.NewGenesisStore(),
 		blocks:  blockstore.NewInMemory(),
+
+		interceptor: IdentityInterceptor{},
+
+		catchUpTimeout:   DefaultCatchUpTimeout,
+		finalizeAttempts: DefaultFinalizeAttempts,
+		finalizeBackoff:  DefaultFinalizeBackoff,
+
+		storageAckTimeout: DefaultStorageAckTimeout,
+		storageAckBackoff: DefaultStorageAckBackoff,
+
+		maxTxSize: signed.DefaultMaxSize,
+		maxTxArgs: signed.DefaultMaxArgs,
 	}
 
 	for _, opt := range opts {
 		opt(&tmpl)
 	}
 
+	if len(tmpl.extraData) > types.MaxExtraDataSize {
+		return nil, xerrors.Errorf("extra data of %d bytes exceeds %d",
+			len(tmpl.extraData), types.MaxExtraDataSize)
+	}
+
+	blockFac := types.NewBlockFactory(param.Validation.GetFactory())
+	csFac := authority.NewChangeSetFactory(param.Mino.GetAddressFactory(), param.Cosi.GetPublicKeyFactory())
+	linkFac := types.NewLinkFactory(blockFac, param.Cosi.GetSignatureFactory(), csFac)
+
+	if tmpl.diskBlocks {
+		blocks := blockstore.NewDiskStore(param.DB, linkFac)
+
+		err := blocks.Load()
+		if err != nil {
+			return nil, xerrors.Errorf("failed to load blocks: %v", err)
+		}
+
+		tmpl.blocks = blocks
+	}
+
 	proc := newProcessor()
 	proc.hashFactory = tmpl.hashFac
 	proc.blocks = tmpl.blocks
 	proc.genesis = tmpl.genesis
 	proc.pool = param.Pool
-	proc.rosterFac = authority.NewFactory(param.Mino.GetAddressFactory(), param.Cosi.GetPublicKeyFactory())
+	proc.rosterFac = authority.NewFactory(param.Mino.GetAddressFactory(), param.Cosi.GetPublicKeyFactory(),
+		authority.WithAddressNormalizer(tmpl.normalizer))
 	proc.tree = blockstore.NewTreeCache(param.Tree)
 	proc.access = param.Access
-	proc.logger = dela.Logger.With().Str("addr", param.Mino.GetAddress().String()).Logger()
+	proc.finalizeAttempts = tmpl.finalizeAttempts
+	proc.finalizeBackoff = tmpl.finalizeBackoff
+	proc.genesisLoader = tmpl.genesisLoader
+	proc.genesisState = tmpl.genesisState
+	proc.localGenesis = tmpl.localGenesis
+	proc.commitEncoding = tmpl.commitEncoding
+	proc.version = tmpl.version
+	proc.indexTxs = tmpl.indexTxs
+	proc.verifyWorkers = tmpl.verifyWorkers
+	proc.maxCatchUpGap = tmpl.maxCatchUpGap
+	proc.catchUpTimeout = tmpl.catchUpTimeout
+	proc.storageAck = tmpl.storageAck
+	proc.archival = tmpl.archival
+	proc.faults = tmpl.faults
+	proc.logger = tmpl.logger.With().Str("addr", param.Mino.GetAddress().String()).Logger()
 
 	pcparam := pbft.StateMachineParam{
 		Logger:          proc.logger,
@@ -188,14 +649,16 @@ func NewService(param ServiceParam, opts ...ServiceOption) (*Service, error) {
 		Tree:            proc.tree,
 		AuthorityReader: proc.readRoster,
 		DB:              param.DB,
+		CommitEncoding:  tmpl.commitEncoding,
+		ProtocolVersion: tmpl.version,
+
+		IndexTransactions: tmpl.indexTxs,
+		LeaderElection:    tmpl.election,
 	}
 
 	proc.pbftsm = pbft.NewStateMachine(pcparam)
 
-	blockFac := types.NewBlockFactory(param.Validation.GetFactory())
-	csFac := authority.NewChangeSetFactory(param.Mino.GetAddressFactory(), param.Cosi.GetPublicKeyFactory())
-	linkFac := types.NewLinkFactory(blockFac, param.Cosi.GetSignatureFactory(), csFac)
-	chainFac := types.NewChainFactory(linkFac)
+	chainFac := types.NewChainFactory(linkFac, proc.chainOptions()...)
 
 	syncparam := blocksync.SyncParam{
 		Mino:            param.Mino,
@@ -205,6 +668,7 @@ func NewService(param ServiceParam, opts ...ServiceOption) (*Service, error) {
 		LinkFactory:     linkFac,
 		ChainFactory:    chainFac,
 		VerifierFactory: param.Cosi.GetVerifierFactory(),
+		MaxGap:          tmpl.maxCatchUpGap,
 	}
 
 	bs := blocksync.NewSynchronizer(syncparam)
@@ -217,11 +681,11 @@ func NewService(param ServiceParam, opts ...ServiceOption) (*Service, error) {
 		param.Mino.GetAddressFactory(),
 		param.Cosi.GetSignatureFactory(),
 		csFac,
-	)
+	).WithIndexCheck(proc.checkIndex)
 
 	proc.MessageFactory = fac
 
-	actor, err := param.Cosi.Listen(proc)
+	actor, err := param.Cosi.Listen(interceptedReactor{Reactor: proc, MessageInterceptor: tmpl.interceptor})
 	if err != nil {
 		return nil, xerrors.Errorf("creating cosi failed: %v", err)
 	}
@@ -229,22 +693,50 @@ func NewService(param ServiceParam, opts ...ServiceOption) (*Service, error) {
 	s := &Service{
 		processor:                proc,
 		me:                       param.Mino.GetAddress(),
-		rpc:                      mino.MustCreateRPC(param.Mino, rpcName, proc, fac),
+		rpc:                      createRPC(param.Mino, proc, fac, tmpl.interceptor),
 		actor:                    actor,
+		signer:                   param.Cosi.GetSigner(),
 		val:                      param.Validation,
 		verifierFac:              param.Cosi.GetVerifierFactory(),
+		blockFac:                 blockFac,
+		linkFac:                  linkFac,
+		chainFac:                 chainFac,
+		db:                       param.DB,
 		timeoutRound:             DefaultRoundTimeout,
 		timeoutRoundAfterFailure: DefaultFailedRoundTimeout,
 		transactionTimeout:       DefaultTransactionTimeout,
 		events:                   make(chan ordering.Event, 1),
 		closing:                  make(chan struct{}),
 		closed:                   make(chan struct{}),
+		embedRoster:              tmpl.embedRoster,
+		merkleRoot:               tmpl.merkleRoot,
+		blockInterval:            tmpl.blockInterval,
+		emptyBlocks:              tmpl.emptyBlocks,
+		maxBlockSize:             tmpl.maxBlockSize,
+		commitTimeout:            tmpl.commitTimeout,
+		storageAckTimeout:        tmpl.storageAckTimeout,
+		storageAckBackoff:        tmpl.storageAckBackoff,
+		extraData:                tmpl.extraData,
+		normalizer:               tmpl.normalizer,
+		genesisAttempts:          tmpl.genesisAttempts,
+		genesisBackoff:           tmpl.genesisBackoff,
 	}
 
 	// Pool will filter the transaction that are already accepted by this
 	// service.
 	param.Pool.AddFilter(poolFilter{tree: proc.tree, srvc: param.Validation})
 
+	// Oversized payloads, transactions with too many arguments and conditions
+	// on keys that the tree can't hold are refused at the admission so that
+	// they never reach a block.
+	param.Pool.AddFilter(signed.NewSizeFilter(tmpl.maxTxSize))
+	param.Pool.AddFilter(signed.NewArgsFilter(tmpl.maxTxArgs))
+	param.Pool.AddFilter(signed.NewConditionFilter(signed.DefaultMaxConditionKey))
+
+	for _, filter := range tmpl.filters {
+		param.Pool.AddFilter(filter)
+	}
+
 	go s.main()
 
 	go s.watchBlocks()
@@ -260,7 +752,10 @@ func NewService(param ServiceParam, opts ...ServiceOption) (*Service, error) {
 
 // Setup creates a genesis block and sends it to the collective authority.
 func (s *Service) Setup(ctx context.Context, ca crypto.CollectiveAuthority) error {
-	err := s.storeGenesis(authority.FromAuthority(ca), nil)
+	s.genesisLock.Lock()
+	err := s.storeGenesis(authority.FromAuthority(ca, authority.WithNormalizer(s.normalizer)), nil)
+	s.genesisLock.Unlock()
+
 	if err != nil {
 		return xerrors.Errorf("creating genesis: %v", err)
 	}
@@ -270,16 +765,9 @@ func (s *Service) Setup(ctx context.Context, ca crypto.CollectiveAuthority) erro
 		return xerrors.Errorf("failed to read genesis: %v", err)
 	}
 
-	resps, err := s.rpc.Call(ctx, types.NewGenesisMessage(genesis), ca)
+	err = s.propagateGenesis(ctx, genesis, ca)
 	if err != nil {
-		return xerrors.Errorf("sending genesis: %v", err)
-	}
-
-	for resp := range resps {
-		_, err := resp.GetMessageOrError()
-		if err != nil {
-			return xerrors.Errorf("one request failed: %v", err)
-		}
+		return err
 	}
 
 	s.logger.Info().
@@ -290,6 +778,60 @@ func (s *Service) Setup(ctx context.Context, ca crypto.CollectiveAuthority) erro
 	return nil
 }
 
+// propagateGenesis sends the genesis block to the participants, and sends it
+// again to the ones that failed to acknowledge it until the attempts are
+// exhausted.
+func (s *Service) propagateGenesis(ctx context.Context, genesis types.Genesis, players mino.Players) error {
+	backoff := s.genesisBackoff
+
+	attempts := s.genesisAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	msg := types.NewGenesisMessage(genesis)
+
+	var lastErr error
+
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			s.logger.Warn().Err(lastErr).Int("attempt", i).Int("missing", players.Len()).
+				Msg("retrying genesis propagation")
+
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return lastErr
+			}
+
+			backoff *= 2
+		}
+
+		resps, err := s.rpc.Call(ctx, msg, players)
+		if err != nil {
+			return xerrors.Errorf("sending genesis: %v", err)
+		}
+
+		failed := []mino.Address{}
+
+		for resp := range resps {
+			_, err := resp.GetMessageOrError()
+			if err != nil {
+				lastErr = xerrors.Errorf("one request failed: %v", err)
+				failed = append(failed, resp.GetFrom())
+			}
+		}
+
+		if len(failed) == 0 {
+			return nil
+		}
+
+		players = mino.NewAddresses(failed...)
+	}
+
+	return lastErr
+}
+
 // GetProof implements ordering.Service. It returns the proof of absence or
 // inclusion for the latest block. The proof integrity is not verified as this
 // is assumed the node is acting correctly so the data is anyway consistent. The
@@ -311,9 +853,85 @@ func (s *Service) GetProof(key []byte) (ordering.Proof, error) {
 		return nil, xerrors.Errorf("reading chain: %v", err)
 	}
 
+	chain = types.ConfigureChain(chain, s.chainOptions()...)
+
 	return newProof(path, chain), nil
 }
 
+// TransactionsOf returns the indices of the blocks that contain a transaction
+// of the identity, in ascending order. The transaction index must be enabled.
+func (s *Service) TransactionsOf(identity access.Identity) ([]uint64, error) {
+	if !s.indexTxs {
+		return nil, xerrors.New("transaction index is disabled")
+	}
+
+	tree, unlock := s.tree.GetWithLock()
+	defer unlock()
+
+	indices, err := txindex.Read(tree, identity)
+	if err != nil {
+		return nil, xerrors.Errorf("reading index: %v", err)
+	}
+
+	return indices, nil
+}
+
+// HasTransaction returns true and the index of the block that contains the
+// transaction if it has been committed, otherwise false. The transaction index
+// must be enabled.
+func (s *Service) HasTransaction(id []byte) (bool, uint64, error) {
+	if !s.indexTxs {
+		return false, 0, xerrors.New("transaction index is disabled")
+	}
+
+	tree, unlock := s.tree.GetWithLock()
+	defer unlock()
+
+	found, index, err := txindex.Lookup(tree, id)
+	if err != nil {
+		return false, 0, xerrors.Errorf("reading index: %v", err)
+	}
+
+	return found, index, nil
+}
+
+// DumpTree writes the root and the key/value pairs of the current tree to the
+// writer, in hexadecimal, for offline inspection. Values longer than
+// DumpValueMaxSize are truncated.
+func (s *Service) DumpTree(w io.Writer) error {
+	tree, unlock := s.tree.GetWithLock()
+	defer unlock()
+
+	iterable, ok := tree.(hashtree.IterableTree)
+	if !ok {
+		return xerrors.Errorf("tree '%T' is not iterable", tree)
+	}
+
+	_, err := fmt.Fprintf(w, "root=%x\n", tree.GetRoot())
+	if err != nil {
+		return xerrors.Errorf("writing root: %v", err)
+	}
+
+	err = iterable.ForEach(func(key, value []byte) error {
+		summary := value
+		suffix := ""
+
+		if len(summary) > DumpValueMaxSize {
+			summary = summary[:DumpValueMaxSize]
+			suffix = "..."
+		}
+
+		_, err := fmt.Fprintf(w, "key=%x value=%x%s (%d bytes)\n", key, summary, suffix, len(value))
+		return err
+	})
+
+	if err != nil {
+		return xerrors.Errorf("dumping tree: %v", err)
+	}
+
+	return nil
+}
+
 // GetStore implements ordering.Service. It returns the current tree as a
 // read-only storage.
 func (s *Service) GetStore() store.Readable {
@@ -325,33 +943,218 @@ func (s *Service) GetRoster() (authority.Authority, error) {
 	return s.getCurrentRoster()
 }
 
-// Watch implements ordering.Service. It returns a channel that will be
-// populated with new incoming blocks and some information about them. The
-// channel must be listened at all time and the context must be closed when
-// done.
-func (s *Service) Watch(ctx context.Context) <-chan ordering.Event {
-	obs := observer{ch: make(chan ordering.Event, 1)}
+// GenesisRoster returns the roster of the genesis block, which is the
+// membership of the chain at its creation whatever the changes applied since.
+func (s *Service) GenesisRoster() (authority.Authority, error) {
+	genesis, err := s.genesis.Get()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read genesis: %v", err)
+	}
 
-	s.watcher.Add(obs)
+	return genesis.GetRoster(), nil
+}
 
-	go func() {
-		<-ctx.Done()
-		s.watcher.Remove(obs)
-		close(obs.ch)
-	}()
+// Abort cancels the round in progress for the given candidate and announces it
+// to the participants so that they can discard it too. It must be called by the
+// leader of the round, whose signing of the candidate is interrupted.
+func (s *Service) Abort(ctx context.Context, id types.Digest) error {
+	leader, err := s.pbftsm.GetLeader()
+	if err != nil {
+		return xerrors.Errorf("reading leader: %v", err)
+	}
 
-	return obs.ch
-}
+	if !s.me.Equal(leader) {
+		return xerrors.Errorf("'%v' is not the leader", s.me)
+	}
 
-// Close implements ordering.Service. It gracefully closes the service. It will
-// announce the closing request and wait for the current to end before
-// returning.
-func (s *Service) Close() error {
-	close(s.closing)
-	<-s.closed
+	err = s.AbortRound(id)
+	if err != nil {
+		return xerrors.Errorf("aborting round: %v", err)
+	}
 
-	return nil
-}
+	s.cancelLock.Lock()
+	if s.cancelLeaderRound != nil {
+		s.cancelLeaderRound()
+	}
+	s.cancelLock.Unlock()
+
+	roster, err := s.getCurrentRoster()
+	if err != nil {
+		return xerrors.Errorf("read roster failed: %v", err)
+	}
+
+	resps, err := s.rpc.Call(ctx, types.NewAbortMessage(id), roster)
+	if err != nil {
+		return xerrors.Errorf("rpc failed: %v", err)
+	}
+
+	for resp := range resps {
+		_, err := resp.GetMessageOrError()
+		if err != nil {
+			s.logger.Warn().Err(err).Msg("abort propagation failed")
+		}
+	}
+
+	return nil
+}
+
+// Repair walks the chain from the latest block down to the first one to find
+// the blocks that are missing or damaged in the store, and replaces them with
+// the ones fetched from the participants. A block is trusted when its digest
+// matches the link of its successor, or the latest block known by the service
+// for the last one, so that the intact blocks are left untouched.
+func (s *Service) Repair(ctx context.Context) error {
+	repairer, ok := s.blocks.(blockstore.Repairer)
+	if !ok {
+		return xerrors.Errorf("block store '%T' can't be repaired", s.blocks)
+	}
+
+	fetcher, ok := s.sync.(blocksync.Fetcher)
+	if !ok {
+		return xerrors.Errorf("synchronizer '%T' can't fetch blocks", s.sync)
+	}
+
+	last, err := s.blocks.Last()
+	if xerrors.Is(err, blockstore.ErrNoBlock) {
+		// Nothing to repair in an empty store.
+		return nil
+	}
+	if err != nil {
+		return xerrors.Errorf("failed to read last block: %v", err)
+	}
+
+	roster, err := s.getCurrentRoster()
+	if err != nil {
+		return xerrors.Errorf("read roster failed: %v", err)
+	}
+
+	expected := last.GetTo()
+
+	for i := s.blocks.Len(); i > 0; i-- {
+		index := i - 1
+
+		link, err := s.blocks.GetByIndex(index)
+		if err != nil || link.GetTo() != expected {
+			s.logger.Info().Uint64("index", index).Msg("repairing block")
+
+			link, err = s.fetchBlock(ctx, fetcher, roster, index, expected)
+			if err != nil {
+				return xerrors.Errorf("block %d: %v", index, err)
+			}
+
+			err = repairer.Replace(link)
+			if err != nil {
+				return xerrors.Errorf("failed to replace block %d: %v", index, err)
+			}
+		}
+
+		expected = link.GetFrom()
+	}
+
+	return nil
+}
+
+func (s *Service) fetchBlock(ctx context.Context, fetcher blocksync.Fetcher,
+	roster authority.Authority, index uint64, expected types.Digest) (types.BlockLink, error) {
+
+	links, err := fetcher.Fetch(ctx, roster, index)
+	if err != nil {
+		return nil, xerrors.Errorf("fetch failed: %v", err)
+	}
+
+	for _, link := range links {
+		if link.GetBlock().GetIndex() == index && link.GetTo() == expected {
+			return link, nil
+		}
+	}
+
+	return nil, xerrors.Errorf("no valid block among %d replies", len(links))
+}
+
+// Watch implements ordering.Service. It returns a channel that will be
+// populated with new incoming blocks and some information about them. The
+// channel must be listened at all time and the context must be closed when
+// done. A block is final once committed by a quorum, which means the chain
+// never forks and the events are never reverted by a reorganization.
+func (s *Service) Watch(ctx context.Context) <-chan ordering.Event {
+	obs := observer{ch: make(chan ordering.Event, 1)}
+
+	s.watcher.Add(obs)
+
+	go func() {
+		<-ctx.Done()
+		s.watcher.Remove(obs)
+		close(obs.ch)
+	}()
+
+	return obs.ch
+}
+
+// WatchViews returns a channel that will be populated with the view changes
+// processed by the service. The channel must be listened at all time and the
+// context must be closed when done.
+func (s *Service) WatchViews(ctx context.Context) <-chan ViewChangeEvent {
+	obs := viewObserver{ch: make(chan ViewChangeEvent, 1)}
+
+	s.watcher.Add(obs)
+
+	go func() {
+		<-ctx.Done()
+		s.watcher.Remove(obs)
+		close(obs.ch)
+	}()
+
+	return obs.ch
+}
+
+// WatchCommitTimeouts returns a channel that will be populated with the
+// proposals of the service that have not been committed in time. The channel
+// must be listened at all time and the context must be closed when done.
+func (s *Service) WatchCommitTimeouts(ctx context.Context) <-chan CommitTimeoutEvent {
+	obs := commitTimeoutObserver{ch: make(chan CommitTimeoutEvent, 1)}
+
+	s.watcher.Add(obs)
+
+	go func() {
+		<-ctx.Done()
+		s.watcher.Remove(obs)
+		close(obs.ch)
+	}()
+
+	return obs.ch
+}
+
+// Close implements ordering.Service. It gracefully closes the service. It will
+// announce the closing request and wait for the current to end before
+// returning.
+func (s *Service) Close() error {
+	close(s.closing)
+	<-s.closed
+
+	return nil
+}
+
+// Flush forces the state of the chain to the durable storage, for instance
+// before a planned shutdown when the database doesn't sync each update. The
+// state of a round in progress is not committed as it is not final yet.
+func (s *Service) Flush() error {
+	// The lock makes sure the leader doesn't prepare a block meanwhile.
+	s.roundLock.Lock()
+	defer s.roundLock.Unlock()
+
+	syncer, ok := s.db.(kv.Syncer)
+	if !ok {
+		// The database doesn't defer the synchronization of the updates.
+		return nil
+	}
+
+	err := syncer.Sync()
+	if err != nil {
+		return xerrors.Errorf("failed to sync: %v", err)
+	}
+
+	return nil
+}
 
 func (s *Service) watchBlocks() {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -461,6 +1264,15 @@ func (s *Service) main() error {
 			err := s.doRound(ctx)
 			cancel()
 
+			if xerrors.Is(err, pbft.ErrSealed) {
+				// A sealed chain will not produce any new block, so the node
+				// stops running rounds but keeps serving the history.
+				s.logger.Info().Msg("chain is sealed")
+
+				<-s.closing
+				return nil
+			}
+
 			if err != nil {
 				if calculateBackoff(backoff+1) < RoundMaxWait {
 					backoff++
@@ -475,6 +1287,19 @@ func (s *Service) main() error {
 }
 
 func (s *Service) doRound(ctx context.Context) error {
+	sealed, err := pbft.IsSealed(s.blocks)
+	if err != nil {
+		return xerrors.Errorf("sealed: %v", err)
+	}
+
+	if sealed {
+		return pbft.ErrSealed
+	}
+
+	if s.archival {
+		return s.doArchivalRound(ctx)
+	}
+
 	roster, err := s.getCurrentRoster()
 	if err != nil {
 		return xerrors.Errorf("reading roster: %v", err)
@@ -500,14 +1325,33 @@ func (s *Service) doRound(ctx context.Context) error {
 }
 
 func (s *Service) doLeaderRound(ctx context.Context, roster authority.Authority, timeout time.Duration) error {
+	// The minimum interval is awaited before the round timeout starts so that
+	// a long interval doesn't fail the round.
+	err := s.waitBlockInterval(ctx)
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	// The round can be cancelled by an abort of the candidate, which stops the
+	// collective signing in progress.
+	s.cancelLock.Lock()
+	s.cancelLeaderRound = cancel
+	s.cancelLock.Unlock()
+
+	defer func() {
+		s.cancelLock.Lock()
+		s.cancelLeaderRound = nil
+		s.cancelLock.Unlock()
+	}()
+
 	s.logger.Debug().Uint64("index", s.blocks.Len()).Msg("round has started")
 
 	// Send a synchronization to the roster so that they can learn about the
 	// latest block of the chain.
-	err := s.sync.Sync(ctx, roster, blocksync.Config{MinHard: threshold.ByzantineThreshold(roster.Len())})
+	err = s.sync.Sync(ctx, roster, blocksync.Config{MinHard: authority.QuorumThreshold(roster.Len())})
 	if err != nil {
 		return xerrors.Errorf("sync failed: %v", err)
 	}
@@ -516,7 +1360,7 @@ func (s *Service) doLeaderRound(ctx context.Context, roster authority.Authority,
 
 	err = s.doPBFT(ctx)
 	if err != nil {
-		return xerrors.Errorf("pbft failed: %v", err)
+		return xerrors.Errorf("pbft failed: %w", err)
 	}
 
 	// The leader can be a new leader coming from a view change, so it resets
@@ -526,6 +1370,82 @@ func (s *Service) doLeaderRound(ctx context.Context, roster authority.Authority,
 	return nil
 }
 
+// doArchivalRound pulls the missing blocks from the roster and then waits for
+// the next block, or the round timeout. An archival node doesn't take part in
+// the round, nor in the view changes, and it might not be part of the roster
+// that the leader synchronizes, so it fetches the blocks by itself.
+func (s *Service) doArchivalRound(ctx context.Context) error {
+	fetcher, ok := s.sync.(blocksync.Fetcher)
+	if !ok {
+		return xerrors.Errorf("synchronizer '%T' can't fetch blocks", s.sync)
+	}
+
+	roster, err := s.getCurrentRoster()
+	if err != nil {
+		return xerrors.Errorf("reading roster: %v", err)
+	}
+
+	pullCtx, cancel := context.WithTimeout(ctx, s.timeoutRound)
+	defer cancel()
+
+	err = s.pullBlocks(pullCtx, fetcher, roster)
+	if err != nil {
+		return xerrors.Errorf("pull failed: %v", err)
+	}
+
+	select {
+	case <-s.events:
+	case <-time.After(s.timeoutRound):
+	case <-ctx.Done():
+	}
+
+	return nil
+}
+
+// pullBlocks fetches the blocks that follow the latest one of the store, one
+// index at a time, until no participant replies with the next block. The
+// blocks are verified by the state machine before they are stored.
+func (s *Service) pullBlocks(ctx context.Context, fetcher blocksync.Fetcher,
+	roster authority.Authority) error {
+
+	for ctx.Err() == nil {
+		from, err := s.getLatestID()
+		if err != nil {
+			return xerrors.Errorf("reading latest id: %v", err)
+		}
+
+		index := s.blocks.Len()
+
+		links, err := fetcher.Fetch(ctx, roster, index)
+		if err != nil {
+			return xerrors.Errorf("fetch failed: %v", err)
+		}
+
+		var next types.BlockLink
+
+		for _, link := range links {
+			if link.GetBlock().GetIndex() == index && link.GetFrom() == from {
+				next = link
+				break
+			}
+		}
+
+		if next == nil {
+			// The node is up-to-date with the participants.
+			return nil
+		}
+
+		s.logger.Debug().Uint64("index", index).Msg("pulled block")
+
+		err = s.pbftsm.CatchUp(next)
+		if err != nil {
+			return xerrors.Errorf("block %d: %v", index, err)
+		}
+	}
+
+	return nil
+}
+
 func (s *Service) doFollowerRound(ctx context.Context, roster authority.Authority) error {
 	// A follower has to wait for the new block, or the round timeout, to proceed.
 	select {
@@ -538,26 +1458,12 @@ func (s *Service) doFollowerRound(ctx context.Context, roster authority.Authorit
 
 		s.pool.ResetStats() // avoid infinite view change
 
-		view, err := s.pbftsm.Expire(s.me) // start the viewChange
-		if err != nil {
-			return xerrors.Errorf("pbft expire failed: %v", err)
-		}
-
-		viewMsg := types.NewViewMessage(view.GetID(), view.GetLeader(), view.GetSignature())
-
 		ctx, cancel := context.WithTimeout(ctx, s.timeoutRound)
 		defer cancel()
 
-		resps, err := s.rpc.Call(ctx, viewMsg, roster)
+		viewMsg, err := s.startViewChange(ctx, roster)
 		if err != nil {
-			return xerrors.Errorf("rpc failed to send views: %v", err)
-		}
-
-		for resp := range resps {
-			_, err = resp.GetMessageOrError()
-			if err != nil {
-				s.logger.Warn().Err(err).Str("to", resp.GetFrom().String()).Msg("view propagation failure")
-			}
+			return err
 		}
 
 		statesCh := s.pbftsm.Watch(ctx)
@@ -589,6 +1495,137 @@ func (s *Service) doFollowerRound(ctx context.Context, roster authority.Authorit
 	}
 }
 
+// startViewChange expires the current leader and requests the views of the
+// participants. When more than the threshold of them have expired the round
+// too, their views are aggregated into a certificate that moves every
+// participant to the new leader. Otherwise, the node waits for the certificate
+// of the last participant to expire the round.
+func (s *Service) startViewChange(ctx context.Context, roster authority.Authority) (types.ViewMessage, error) {
+	view, err := s.pbftsm.Expire(s.me) // start the viewChange
+	if err != nil {
+		return types.ViewMessage{}, xerrors.Errorf("pbft expire failed: %v", err)
+	}
+
+	viewMsg := types.NewViewMessage(view.GetID(), view.GetLeader(), view.GetSignature())
+
+	resps, err := s.rpc.Call(ctx, viewMsg, roster)
+	if err != nil {
+		return viewMsg, xerrors.Errorf("rpc failed to send views: %v", err)
+	}
+
+	votes := map[mino.Address]pbft.View{s.me: view}
+
+	reached := 0
+	for resp := range resps {
+		msg, err := readReply(resp)
+		if err != nil {
+			s.logger.Warn().Err(err).Str("to", resp.GetFrom().String()).Msg("view propagation failure")
+			continue
+		}
+
+		reached++
+
+		vote, ok := s.readVote(resp.GetFrom(), msg, view, roster)
+		if ok {
+			votes[resp.GetFrom()] = vote
+		}
+	}
+
+	// Without a quorum of participants, the chain can't move forward, so the
+	// node only serves reads until it reaches them again.
+	s.setReachable(reached)
+	s.SetReadOnly(reached < authority.QuorumThreshold(roster.Len()))
+
+	certified, err := s.certifyView(ctx, view, votes, roster)
+	if err != nil {
+		return viewMsg, xerrors.Errorf("couldn't certify view: %v", err)
+	}
+
+	return certified, nil
+}
+
+// readVote returns the view of the reply if it is a valid vote of the
+// participant for the same view.
+func (s *Service) readVote(from mino.Address, msg serde.Message, view pbft.View,
+	roster authority.Authority) (pbft.View, bool) {
+
+	reply, ok := msg.(types.ViewMessage)
+	if !ok {
+		// The round has not expired for the participant.
+		return pbft.View{}, false
+	}
+
+	param := pbft.ViewParam{
+		From:   from,
+		ID:     reply.GetID(),
+		Leader: reply.GetLeader(),
+	}
+
+	vote := pbft.NewView(param, reply.GetSignature())
+
+	pubkey, _ := roster.GetPublicKey(from)
+	if pubkey == nil || vote.GetID() != view.GetID() || vote.GetLeader() != view.GetLeader() {
+		s.logger.Warn().Str("from", from.String()).Msg("mismatch vote")
+		return pbft.View{}, false
+	}
+
+	err := vote.Verify(pubkey)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("from", from.String()).Msg("invalid vote")
+		return pbft.View{}, false
+	}
+
+	return vote, true
+}
+
+// certifyView aggregates the votes into a certificate of the view when there
+// are enough of them, and sends it to the participants so that they move to the
+// new leader. It returns the view message that has been sent, which has no
+// certificate if the votes can't be certified, like when there are not enough
+// of them.
+func (s *Service) certifyView(ctx context.Context, view pbft.View,
+	votes map[mino.Address]pbft.View, roster authority.Authority) (types.ViewMessage, error) {
+
+	viewMsg := types.NewViewMessage(view.GetID(), view.GetLeader(), view.GetSignature())
+
+	signer, ok := s.signer.(crypto.AggregateSigner)
+	if !ok {
+		return viewMsg, xerrors.Errorf("signer '%T' can't aggregate", s.signer)
+	}
+
+	views := make([]pbft.View, 0, len(votes))
+	for _, vote := range votes {
+		views = append(views, vote)
+	}
+
+	cert, err := pbft.NewViewCertificate(views, roster, signer)
+	if err != nil {
+		// The node can still move to the new leader with the certificate of
+		// another participant.
+		s.logger.Debug().Err(err).Int("votes", len(views)).Msg("waiting for a view certificate")
+		return viewMsg, nil
+	}
+
+	viewMsg = types.NewViewMessage(view.GetID(), view.GetLeader(), view.GetSignature(),
+		types.WithViewCertificate(cert))
+
+	// The certificate is also sent to the node itself so that it moves to the
+	// new leader like any other participant.
+	resps, err := s.rpc.Call(ctx, viewMsg, roster)
+	if err != nil {
+		return viewMsg, xerrors.Errorf("rpc failed to send certificate: %v", err)
+	}
+
+	for resp := range resps {
+		_, err = resp.GetMessageOrError()
+		if err != nil {
+			s.logger.Warn().Err(err).Str("to", resp.GetFrom().String()).Msg("certificate propagation failure")
+		}
+	}
+
+	return viewMsg, nil
+}
+
 func (s *Service) roundHasFailed() bool {
 	stats := s.pool.Stats()
 
@@ -615,8 +1652,17 @@ func (s *Service) doPBFT(ctx context.Context) error {
 		// have accepted, but somehow the finalization failed.
 		id, block = s.pbftsm.GetCommit()
 	} else {
-		txs := s.pool.Gather(ctx, pool.Config{Min: 1})
-		if len(txs) == 0 {
+		cfg := pool.Config{Min: 1}
+		if s.emptyBlocks {
+			cfg.Min = 0
+		}
+
+		txs, err := s.fitBlockSize(s.pool.Gather(ctx, cfg))
+		if err != nil {
+			return xerrors.Errorf("failed to estimate block size: %v", err)
+		}
+
+		if len(txs) == 0 && !s.emptyBlocks {
 			s.logger.Debug().Msg("no transaction in pool")
 
 			return nil
@@ -631,34 +1677,226 @@ func (s *Service) doPBFT(ctx context.Context) error {
 			return ctx.Err()
 		}
 
-		data, root, err := s.prepareData(txs)
+		// The chain might be sealed while gathering the transactions.
+		s.roundLock.Lock()
+		defer s.roundLock.Unlock()
+
+		data, stageTree, err := s.prepareData(txs)
 		if err != nil {
 			return xerrors.Errorf("failed to prepare data: %v", err)
 		}
 
-		block, err = types.NewBlock(
-			data,
-			types.WithTreeRoot(root),
-			types.WithIndex(uint64(s.blocks.Len())),
-			types.WithHashFactory(s.hashFactory))
-
+		block, err = s.makeBlock(data, stageTree)
 		if err != nil {
-			return xerrors.Errorf("creating block failed: %v", err)
+			return err
 		}
 
 		id, err = s.pbftsm.Prepare(s.me, block)
 		if err != nil {
-			return xerrors.Errorf("pbft prepare failed: %v", err)
+			return xerrors.Errorf("pbft prepare failed: %w", err)
+		}
+	}
+
+	err := s.propose(ctx, id, block)
+	if err != nil {
+		return err
+	}
+
+	s.lastProposal = time.Now()
+
+	return nil
+}
+
+// fitBlockSize returns the transactions that fit in a block of the maximum
+// size, in the order of the gathering so that the nonces of an identity stay
+// contiguous. It stops at the first transaction that would exceed the limit,
+// except for a transaction that doesn't fit even in an empty block, which is
+// removed from the pool so that it doesn't block the ones queued behind it.
+func (s *Service) fitBlockSize(txs []txn.Transaction) ([]txn.Transaction, error) {
+	if s.maxBlockSize <= 0 {
+		return txs, nil
+	}
+
+	estimator := types.NewSizeEstimator(s.context)
+	empty := estimator.AddExtraData(s.extraData)
+
+	fitting := make([]txn.Transaction, 0, len(txs))
+
+	for i, tx := range txs {
+		size, err := estimator.Measure(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		if empty+size > s.maxBlockSize {
+			s.logger.Warn().
+				Hex("tx", tx.GetID()).
+				Int("size", size).
+				Msg("transaction is too large for a block")
+
+			err = s.pool.Remove(tx)
+			if err != nil {
+				return nil, xerrors.Errorf("removing transaction: %v", err)
+			}
+
+			continue
+		}
+
+		if estimator.Size()+size > s.maxBlockSize {
+			s.logger.Debug().
+				Int("num", len(fitting)).
+				Int("left", len(txs)-i).
+				Int("size", estimator.Size()).
+				Msg("block size limit reached")
+
+			break
+		}
+
+		estimator.Add(size)
+		fitting = append(fitting, tx)
+	}
+
+	return fitting, nil
+}
+
+// waitBlockInterval waits until the minimum interval since the last block
+// proposed by the leader is over, or the context is done.
+func (s *Service) waitBlockInterval(ctx context.Context) error {
+	wait := s.blockInterval - time.Since(s.lastProposal)
+	if wait <= 0 {
+		return nil
+	}
+
+	select {
+	case <-time.After(wait):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Seal seals the chain by committing a terminal block. The chain does not
+// accept new blocks afterwards, but the history is still available. It must be
+// called on the leader of the round.
+func (s *Service) Seal() error {
+	leader, err := s.pbftsm.GetLeader()
+	if err != nil {
+		return xerrors.Errorf("reading leader: %v", err)
+	}
+
+	if !s.me.Equal(leader) {
+		return xerrors.Errorf("'%v' is not the leader", s.me)
+	}
+
+	s.roundLock.Lock()
+	defer s.roundLock.Unlock()
+
+	sealed, err := pbft.IsSealed(s.blocks)
+	if err != nil {
+		return xerrors.Errorf("sealed: %v", err)
+	}
+
+	if sealed {
+		return pbft.ErrSealed
+	}
+
+	data, stageTree, err := s.prepareData(nil)
+	if err != nil {
+		return xerrors.Errorf("failed to prepare data: %v", err)
+	}
+
+	block, err := s.makeBlock(data, stageTree, types.WithTerminal())
+	if err != nil {
+		return err
+	}
+
+	id, err := s.pbftsm.Prepare(s.me, block)
+	if err != nil {
+		return xerrors.Errorf("pbft prepare failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeoutRound)
+	defer cancel()
+
+	err = s.propose(ctx, id, block)
+	if err != nil {
+		return xerrors.Errorf("terminal block: %v", err)
+	}
+
+	s.logger.Info().Uint64("index", block.GetIndex()).Msg("chain sealed")
+
+	return nil
+}
+
+// makeBlock creates the next block of the chain for the data and the tree
+// produced by the data.
+func (s *Service) makeBlock(data validation.Result, stageTree hashtree.StagingTree,
+	opts ...types.BlockOption) (types.Block, error) {
+
+	root := types.Digest{}
+	copy(root[:], stageTree.GetRoot())
+
+	opts = append(opts,
+		types.WithTreeRoot(root),
+		types.WithIndex(uint64(s.blocks.Len())),
+		types.WithHashFactory(s.hashFactory),
+	)
+
+	if len(s.extraData) > 0 {
+		opts = append(opts, types.WithExtraData(s.extraData))
+	}
+
+	if s.merkleRoot {
+		opts = append(opts, types.WithPayloadRoot())
+	}
+
+	if s.embedRoster {
+		roster, err := s.readRoster(stageTree)
+		if err != nil {
+			return types.Block{}, xerrors.Errorf("read next roster failed: %v", err)
+		}
+
+		digest, err := types.RosterDigest(roster, s.hashFactory)
+		if err != nil {
+			return types.Block{}, xerrors.Errorf("roster digest failed: %v", err)
 		}
+
+		opts = append(opts, types.WithRosterDigest(digest))
 	}
 
+	block, err := types.NewBlock(data, opts...)
+	if err != nil {
+		return block, xerrors.Errorf("creating block failed: %v", err)
+	}
+
+	return block, nil
+}
+
+// propose runs the phases of PBFT for the block that the leader has prepared.
+func (s *Service) propose(ctx context.Context, id types.Digest, block types.Block) error {
 	roster, err := s.getCurrentRoster()
 	if err != nil {
 		return xerrors.Errorf("read roster failed: %v", err)
 	}
 
+	// The proposal is signed so that the participants can verify who the
+	// proposer is.
+	digest := block.GetHash()
+
+	proposerSig, err := s.signer.Sign(digest[:])
+	if err != nil {
+		return xerrors.Errorf("signing proposal failed: %v", err)
+	}
+
+	previous, err := s.getLatestID()
+	if err != nil {
+		return xerrors.Errorf("read latest digest failed: %v", err)
+	}
+
 	// 1. Prepare phase
-	req := types.NewBlockMessage(block, s.prepareViews())
+	req := types.NewBlockMessage(block, s.prepareViews(),
+		types.WithProposerSignature(proposerSig),
+		types.WithPrevious(previous))
 
 	sig, err := s.actor.Sign(ctx, req, roster)
 	if err != nil {
@@ -670,8 +1908,19 @@ func (s *Service) doPBFT(ctx context.Context) error {
 	// 2. Commit phase
 	commit := types.NewCommit(id, sig)
 
-	sig, err = s.actor.Sign(ctx, commit, roster)
+	commitCtx := ctx
+	if s.commitTimeout > 0 {
+		var cancel context.CancelFunc
+		commitCtx, cancel = context.WithTimeout(ctx, s.commitTimeout)
+		defer cancel()
+	}
+
+	sig, err = s.actor.Sign(commitCtx, commit, roster)
 	if err != nil {
+		if commitCtx.Err() != nil && ctx.Err() == nil {
+			return s.expireCommit(ctx, roster, id, block)
+		}
+
 		return xerrors.Errorf("commit signature failed: %v", err)
 	}
 
@@ -685,10 +1934,16 @@ func (s *Service) doPBFT(ctx context.Context) error {
 		return xerrors.Errorf("propagation failed: %v", err)
 	}
 
-	for resp := range resps {
-		_, err = resp.GetMessageOrError()
+	acks := map[mino.Address]struct{}{}
+
+	reached := s.collectStoredAcks(resps, block, acks)
+
+	s.setReachable(reached)
+
+	if s.storageAck {
+		err = s.awaitStorageAcks(ctx, done, block, roster, acks)
 		if err != nil {
-			s.logger.Warn().Err(err).Msg("propagation failed")
+			return err
 		}
 	}
 
@@ -701,6 +1956,122 @@ func (s *Service) doPBFT(ctx context.Context) error {
 	return nil
 }
 
+// collectStoredAcks reads the replies to the propagation of a block and adds
+// the participants that acknowledged the block to the set. It returns the
+// number of participants that replied.
+func (s *Service) collectStoredAcks(resps <-chan mino.Response, block types.Block,
+	acks map[mino.Address]struct{}) int {
+
+	reached := 0
+
+	for resp := range resps {
+		msg, err := readReply(resp)
+		if err != nil {
+			s.logger.Warn().Err(err).Msg("propagation failed")
+			continue
+		}
+
+		reached++
+
+		stored, ok := msg.(types.StoredMessage)
+		if ok && stored.GetIndex() == block.GetIndex() && stored.GetRoot() == block.GetTreeRoot() {
+			acks[resp.GetFrom()] = struct{}{}
+		}
+	}
+
+	return reached
+}
+
+// awaitStorageAcks sends the block again to the participants that have not
+// acknowledged it, until a quorum has or the deadline elapses, so that the next
+// round doesn't start before the block is stored by a quorum.
+func (s *Service) awaitStorageAcks(ctx context.Context, done types.DoneMessage,
+	block types.Block, roster authority.Authority, acks map[mino.Address]struct{}) error {
+
+	threshold := authority.QuorumThreshold(roster.Len())
+
+	backoff := s.storageAckBackoff
+	if backoff <= 0 {
+		backoff = DefaultStorageAckBackoff
+	}
+
+	var timeout <-chan time.Time
+	if s.storageAckTimeout > 0 {
+		timer := time.NewTimer(s.storageAckTimeout)
+		defer timer.Stop()
+
+		timeout = timer.C
+	}
+
+	for len(acks) < threshold {
+		missing := []mino.Address{}
+
+		iter := roster.AddressIterator()
+		for iter.HasNext() {
+			addr := iter.GetNext()
+
+			_, found := acks[addr]
+			if !found {
+				missing = append(missing, addr)
+			}
+		}
+
+		s.logger.Debug().
+			Uint64("index", block.GetIndex()).
+			Int("missing", len(missing)).
+			Msg("waiting for storage acknowledgements")
+
+		select {
+		case <-time.After(backoff):
+		case <-timeout:
+			return xerrors.Errorf("block %d stored by %d participants out of %d",
+				block.GetIndex(), len(acks), roster.Len())
+		case <-ctx.Done():
+			return xerrors.Errorf("block %d stored by %d participants out of %d: %v",
+				block.GetIndex(), len(acks), roster.Len(), ctx.Err())
+		}
+
+		backoff *= 2
+
+		resps, err := s.rpc.Call(ctx, done, mino.NewAddresses(missing...))
+		if err != nil {
+			return xerrors.Errorf("requesting acknowledgements: %v", err)
+		}
+
+		s.collectStoredAcks(resps, block, acks)
+	}
+
+	return nil
+}
+
+// expireCommit gives up on a proposal that the participants have not committed
+// in time, and starts a view change so that another leader can take over.
+func (s *Service) expireCommit(ctx context.Context, roster authority.Authority,
+	id types.Digest, block types.Block) error {
+
+	s.logger.Warn().
+		Uint64("index", block.GetIndex()).
+		Dur("timeout", s.commitTimeout).
+		Msg("commit timed out")
+
+	s.watcher.Notify(CommitTimeoutEvent{
+		ID:    id,
+		Index: block.GetIndex(),
+	})
+
+	s.failedRound = true
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeoutRound)
+	defer cancel()
+
+	_, err := s.startViewChange(ctx, roster)
+	if err != nil {
+		return xerrors.Errorf("view change failed: %v", err)
+	}
+
+	return xerrors.Errorf("commit timed out after %v", s.commitTimeout)
+}
+
 func (s *Service) prepareViews() map[mino.Address]types.ViewMessage {
 	views := s.pbftsm.GetViews()
 	msgs := make(map[mino.Address]types.ViewMessage)
@@ -712,8 +2083,8 @@ func (s *Service) prepareViews() map[mino.Address]types.ViewMessage {
 	return msgs
 }
 
-func (s *Service) prepareData(txs []txn.Transaction) (data validation.Result, id types.Digest, err error) {
-	var stageTree hashtree.StagingTree
+func (s *Service) prepareData(txs []txn.Transaction) (data validation.Result,
+	stageTree hashtree.StagingTree, err error) {
 
 	stageTree, err = s.tree.Get().Stage(func(snap store.Snapshot) error {
 		data, err = s.val.Validate(snap, txs)
@@ -721,6 +2092,13 @@ func (s *Service) prepareData(txs []txn.Transaction) (data validation.Result, id
 			return xerrors.Errorf("validation failed: %v", err)
 		}
 
+		if s.indexTxs {
+			err = txindex.Update(snap, s.blocks.Len(), txs)
+			if err != nil {
+				return xerrors.Errorf("couldn't index transactions: %v", err)
+			}
+		}
+
 		return nil
 	})
 
@@ -729,8 +2107,6 @@ func (s *Service) prepareData(txs []txn.Transaction) (data validation.Result, id
 		return
 	}
 
-	copy(id[:], stageTree.GetRoot())
-
 	return
 }
 
@@ -762,12 +2138,58 @@ func (s *Service) wakeUp(ctx context.Context, ro authority.Authority) error {
 	return nil
 }
 
+// ViewChangeEvent is the event notified when the participants agree on a new
+// leader after a view change.
+type ViewChangeEvent struct {
+	// Leader is the address of the new leader.
+	Leader mino.Address
+
+	// View is the view number, which is the index of the new leader in the
+	// roster.
+	View uint16
+}
+
+// CommitTimeoutEvent is the event notified when the leader gives up on a
+// proposal as a quorum of the participants didn't commit it in time.
+type CommitTimeoutEvent struct {
+	// ID is the digest of the proposal.
+	ID types.Digest
+
+	// Index is the index of the proposed block.
+	Index uint64
+}
+
 type observer struct {
 	ch chan ordering.Event
 }
 
 func (obs observer) NotifyCallback(event interface{}) {
-	obs.ch <- event.(ordering.Event)
+	evt, ok := event.(ordering.Event)
+	if ok {
+		obs.ch <- evt
+	}
+}
+
+type viewObserver struct {
+	ch chan ViewChangeEvent
+}
+
+func (obs viewObserver) NotifyCallback(event interface{}) {
+	evt, ok := event.(ViewChangeEvent)
+	if ok {
+		obs.ch <- evt
+	}
+}
+
+type commitTimeoutObserver struct {
+	ch chan CommitTimeoutEvent
+}
+
+func (obs commitTimeoutObserver) NotifyCallback(event interface{}) {
+	evt, ok := event.(CommitTimeoutEvent)
+	if ok {
+		obs.ch <- evt
+	}
 }
 
 func calculateBackoff(backoff float64) time.Duration {
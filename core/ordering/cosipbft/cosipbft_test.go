@@ -1,14 +1,19 @@
 package cosipbft
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"runtime/debug"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
 	"go.dedis.ch/dela/core/access"
 	"go.dedis.ch/dela/core/access/darc"
@@ -41,6 +46,7 @@ import (
 	"go.dedis.ch/dela/mino/minoch"
 	"go.dedis.ch/dela/serde"
 	"go.dedis.ch/dela/serde/json"
+	"golang.org/x/xerrors"
 )
 
 func TestService_Scenario_Basic(t *testing.T) {
@@ -94,6 +100,624 @@ func TestService_Scenario_Basic(t *testing.T) {
 	checkProof(t, proof.(Proof), nodes[0].service)
 }
 
+func TestService_Scenario_Archival(t *testing.T) {
+	nodes, ro, clean := makeAuthority(t, 4)
+	defer clean()
+
+	// The fourth node only archives the chain, which the three others can
+	// build on their own.
+	archive := nodes[3].service
+	archive.archival = true
+
+	signer := nodes[0].signer
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := nodes[0].service.Setup(ctx, ro)
+	require.NoError(t, err)
+
+	events := nodes[1].service.Watch(ctx)
+	archived := archive.Watch(ctx)
+
+	for i := 0; i < 3; i++ {
+		err = nodes[1].pool.Add(makeTx(t, uint64(i), signer))
+		require.NoError(t, err)
+
+		evt := waitEvent(t, events, 20*DefaultRoundTimeout)
+		require.Equal(t, uint64(i), evt.Index)
+	}
+
+	// The archival node learns the blocks through the synchronizations that
+	// the leader sends before each proposal, or the ones it pulls from the
+	// roster.
+	for i := 0; i < 2; i++ {
+		evt := waitEvent(t, archived, 20*DefaultRoundTimeout)
+		require.Equal(t, uint64(i), evt.Index)
+	}
+
+	_, err = archive.Invoke(nodes[0].onet.GetAddress(), types.NewBlockMessage(types.Block{}, nil))
+	require.True(t, errors.Is(err, ErrArchival))
+}
+
+func TestService_Scenario_Seal(t *testing.T) {
+	nodes, ro, clean := makeAuthority(t, 3)
+	defer clean()
+
+	signer := nodes[0].signer
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := nodes[0].service.Setup(ctx, ro)
+	require.NoError(t, err)
+
+	events := nodes[1].service.Watch(ctx)
+
+	err = nodes[0].pool.Add(makeTx(t, 0, signer))
+	require.NoError(t, err)
+
+	evt := waitEvent(t, events, 2*DefaultRoundTimeout)
+	require.Equal(t, uint64(0), evt.Index)
+
+	err = nodes[1].service.Seal()
+	require.EqualError(t, err,
+		fmt.Sprintf("'%v' is not the leader", nodes[1].onet.GetAddress()))
+
+	err = nodes[0].service.Seal()
+	require.NoError(t, err)
+
+	evt = waitEvent(t, events, 20*DefaultRoundTimeout)
+	require.Equal(t, uint64(1), evt.Index)
+	require.Empty(t, evt.Transactions)
+
+	err = nodes[0].service.Seal()
+	require.EqualError(t, err, "chain sealed")
+
+	// New transactions are not included anymore.
+	err = nodes[0].pool.Add(makeTx(t, 1, signer))
+	require.NoError(t, err)
+
+	select {
+	case <-events:
+		t.Fatal("unexpected block after the seal")
+	case <-time.After(DefaultRoundTimeout / 4):
+	}
+
+	for _, node := range nodes {
+		require.Equal(t, uint64(2), node.service.blocks.Len())
+
+		last, err := node.service.blocks.Last()
+		require.NoError(t, err)
+		require.True(t, last.GetBlock().IsTerminal())
+	}
+
+	// A proposal after the terminal block is rejected.
+	root := types.Digest{}
+	copy(root[:], nodes[1].service.tree.Get().GetRoot())
+
+	block, err := types.NewBlock(simple.NewResult(nil), types.WithIndex(2),
+		types.WithTreeRoot(root))
+	require.NoError(t, err)
+
+	_, err = nodes[1].service.pbftsm.Prepare(nodes[0].onet.GetAddress(), block)
+	require.EqualError(t, err, "chain sealed")
+
+	// The history is still available.
+	link, err := nodes[2].service.blocks.GetByIndex(0)
+	require.NoError(t, err)
+	require.Len(t, link.GetBlock().GetTransactions(), 1)
+
+	proof, err := nodes[2].service.GetProof(keyRoster[:])
+	require.NoError(t, err)
+	checkProof(t, proof.(Proof), nodes[2].service)
+}
+
+func TestService_Scenario_RosterDigest(t *testing.T) {
+	nodes, ro, clean := makeAuthority(t, 4, WithRosterDigest())
+	defer clean()
+
+	signer := nodes[0].signer
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	initial := ro.Take(mino.RangeFilter(0, 3)).(crypto.CollectiveAuthority)
+
+	err := nodes[0].service.Setup(ctx, initial)
+	require.NoError(t, err)
+
+	events := nodes[1].service.Watch(ctx)
+
+	err = nodes[0].pool.Add(makeTx(t, 0, signer))
+	require.NoError(t, err)
+
+	evt := waitEvent(t, events, 2*DefaultRoundTimeout)
+	require.Equal(t, uint64(0), evt.Index)
+
+	checkRosterDigest(t, nodes[1].service)
+
+	err = nodes[0].pool.Add(makeRosterTx(t, 1, ro, signer))
+	require.NoError(t, err)
+
+	evt = waitEvent(t, events, 20*DefaultRoundTimeout)
+	require.Equal(t, uint64(1), evt.Index)
+
+	checkRosterDigest(t, nodes[1].service)
+
+	roster, err := nodes[1].service.GetRoster()
+	require.NoError(t, err)
+	require.Equal(t, 4, roster.Len())
+}
+
+func TestService_Scenario_PayloadRoot(t *testing.T) {
+	nodes, ro, clean := makeAuthority(t, 3, WithPayloadRoot())
+	defer clean()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := nodes[0].service.Setup(ctx, ro)
+	require.NoError(t, err)
+
+	events := nodes[1].service.Watch(ctx)
+
+	err = nodes[0].pool.Add(makeTx(t, 0, nodes[0].signer))
+	require.NoError(t, err)
+
+	evt := waitEvent(t, events, 2*DefaultRoundTimeout)
+	require.Equal(t, uint64(0), evt.Index)
+
+	link, err := nodes[1].service.blocks.GetByIndex(0)
+	require.NoError(t, err)
+
+	block := link.GetBlock()
+	require.NotEqual(t, types.Digest{}, block.GetPayloadRoot())
+
+	proof, err := types.NewPayloadProof(block.GetData().(types.MerklePayload), 0, crypto.NewSha256Factory())
+	require.NoError(t, err)
+	require.NoError(t, proof.Verify(block.GetPayloadRoot(), crypto.NewSha256Factory()))
+}
+
+func TestService_Scenario_GenesisRoster(t *testing.T) {
+	nodes, ro, clean := makeAuthority(t, 4)
+	defer clean()
+
+	signer := nodes[0].signer
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	initial := ro.Take(mino.RangeFilter(0, 3)).(crypto.CollectiveAuthority)
+
+	err := nodes[0].service.Setup(ctx, initial)
+	require.NoError(t, err)
+
+	events := nodes[1].service.Watch(ctx)
+
+	err = nodes[0].pool.Add(makeRosterTx(t, 0, ro, signer))
+	require.NoError(t, err)
+
+	evt := waitEvent(t, events, 2*DefaultRoundTimeout)
+	require.Equal(t, uint64(0), evt.Index)
+
+	roster, err := nodes[1].service.GetRoster()
+	require.NoError(t, err)
+	require.Equal(t, 4, roster.Len())
+
+	for _, node := range nodes[:3] {
+		genesisRoster, err := node.service.GenesisRoster()
+		require.NoError(t, err)
+		require.Equal(t, 3, genesisRoster.Len())
+
+		iter := genesisRoster.AddressIterator()
+		for i := 0; iter.HasNext(); i++ {
+			require.True(t, iter.GetNext().Equal(nodes[i].service.me))
+		}
+	}
+}
+
+func TestService_Scenario_GenesisState(t *testing.T) {
+	roots := make([]types.Digest, 2)
+
+	for i, opts := range [][]ServiceOption{nil, {WithGenesisState(seedState{})}} {
+		nodes, ro, clean := makeAuthority(t, 3, opts...)
+
+		err := nodes[0].service.Setup(context.Background(), ro)
+		require.NoError(t, err)
+
+		genesis, err := nodes[0].service.genesis.Get()
+		require.NoError(t, err)
+
+		roots[i] = genesis.GetRoot()
+
+		// The other participants have stored the same genesis, which means they
+		// computed the same root.
+		for _, node := range nodes[1:] {
+			other, err := node.service.genesis.Get()
+			require.NoError(t, err)
+			require.Equal(t, genesis.GetHash(), other.GetHash())
+		}
+
+		value, err := nodes[2].service.GetStore().Get([]byte("seed"))
+		require.NoError(t, err)
+
+		if i == 0 {
+			require.Nil(t, value)
+		} else {
+			require.Equal(t, []byte("initial"), value)
+		}
+
+		clean()
+	}
+
+	require.NotEqual(t, roots[0], roots[1])
+
+	// The initial state can't overwrite the roster.
+	nodes, ro, clean := makeAuthority(t, 1, WithGenesisState(seedState{key: keyRoster[:]}))
+	defer clean()
+
+	err := nodes[0].service.Setup(context.Background(), ro)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is already set")
+}
+
+func TestService_Scenario_StorageAck(t *testing.T) {
+	nodes, ro, clean := makeAuthority(t, 4, WithStorageAck())
+	defer clean()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := nodes[0].service.Setup(ctx, ro)
+	require.NoError(t, err)
+
+	events := nodes[0].service.Watch(ctx)
+
+	for i := 0; i < 2; i++ {
+		err = nodes[0].pool.Add(makeTx(t, uint64(i), nodes[0].signer))
+		require.NoError(t, err)
+
+		evt := waitEvent(t, events, 20*DefaultRoundTimeout)
+		require.Equal(t, uint64(i), evt.Index)
+	}
+}
+
+func TestService_Scenario_BlockStore(t *testing.T) {
+	run := func(opts ...ServiceOption) []int {
+		nodes, ro, clean := makeAuthority(t, 4, opts...)
+		defer clean()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		err := nodes[0].service.Setup(ctx, ro)
+		require.NoError(t, err)
+
+		events := nodes[0].service.Watch(ctx)
+
+		for i := 0; i < 3; i++ {
+			err = nodes[0].pool.Add(makeTx(t, uint64(i), nodes[0].signer))
+			require.NoError(t, err)
+
+			evt := waitEvent(t, events, 20*DefaultRoundTimeout)
+			require.Equal(t, uint64(i), evt.Index)
+		}
+
+		blocks := nodes[0].service.blocks
+		require.Equal(t, uint64(3), blocks.Len())
+
+		txs := make([]int, blocks.Len())
+		for i := range txs {
+			link, err := blocks.GetByIndex(uint64(i))
+			require.NoError(t, err)
+			require.Equal(t, uint64(i), link.GetBlock().GetIndex())
+
+			txs[i] = len(link.GetBlock().GetData().GetTransactionResults())
+		}
+
+		chain, err := blocks.GetChain()
+		require.NoError(t, err)
+		require.Len(t, chain.GetLinks(), 3)
+
+		return txs
+	}
+
+	require.Equal(t, run(), run(WithDiskBlockStore()))
+}
+
+func TestService_Scenario_MessageInterceptor(t *testing.T) {
+	nodes, ro, clean := makeAuthority(t, 3, WithMessageInterceptor(xorInterceptor{key: 0xaa}))
+	defer clean()
+
+	signer := nodes[0].signer
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := nodes[0].service.Setup(ctx, ro)
+	require.NoError(t, err)
+
+	events := nodes[2].service.Watch(ctx)
+
+	err = nodes[0].pool.Add(makeTx(t, 0, signer))
+	require.NoError(t, err)
+
+	evt := waitEvent(t, events, 2*DefaultRoundTimeout)
+	require.Equal(t, uint64(0), evt.Index)
+
+	err = nodes[1].pool.Add(makeTx(t, 1, signer))
+	require.NoError(t, err)
+
+	evt = waitEvent(t, events, 20*DefaultRoundTimeout)
+	require.Equal(t, uint64(1), evt.Index)
+}
+
+func TestService_Scenario_CommitEncoding(t *testing.T) {
+	nodes, ro, clean := makeAuthority(t, 3, WithCommitEncoding(hexEncoding))
+	defer clean()
+
+	signer := nodes[0].signer
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := nodes[0].service.Setup(ctx, ro)
+	require.NoError(t, err)
+
+	events := nodes[2].service.Watch(ctx)
+
+	err = nodes[0].pool.Add(makeTx(t, 0, signer))
+	require.NoError(t, err)
+
+	evt := waitEvent(t, events, 2*DefaultRoundTimeout)
+	require.Equal(t, uint64(0), evt.Index)
+
+	proof, err := nodes[2].service.GetProof(keyRoster[:])
+	require.NoError(t, err)
+
+	checkProof(t, proof.(Proof), nodes[2].service)
+}
+
+func TestService_Scenario_ProtocolVersion(t *testing.T) {
+	nodes, ro, clean := makeAuthority(t, 3, WithProtocolVersion(types.ProtocolV1))
+	defer clean()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := nodes[0].service.Setup(ctx, ro)
+	require.NoError(t, err)
+
+	events := nodes[2].service.Watch(ctx)
+
+	err = nodes[0].pool.Add(makeTx(t, 0, nodes[0].signer))
+	require.NoError(t, err)
+
+	evt := waitEvent(t, events, 2*DefaultRoundTimeout)
+	require.Equal(t, uint64(0), evt.Index)
+
+	proof, err := nodes[2].service.GetProof(keyRoster[:])
+	require.NoError(t, err)
+
+	checkProof(t, proof.(Proof), nodes[2].service)
+
+	// The signatures are bound to the chain, so they don't verify with the
+	// initial protocol.
+	genesis, err := nodes[2].service.genesis.Get()
+	require.NoError(t, err)
+
+	chain := types.ConfigureChain(proof.(Proof).chain, types.WithProtocolVersion(types.ProtocolV0))
+
+	err = chain.Verify(genesis, genesis.GetHash(), nodes[2].service.verifierFac)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid prepare signature: ")
+}
+
+func TestService_Scenario_Submit(t *testing.T) {
+	nodes, ro, clean := makeAuthority(t, 3)
+	defer clean()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := nodes[0].service.Setup(ctx, ro)
+	require.NoError(t, err)
+
+	conf, err := nodes[1].service.Submit(makeTx(t, 0, nodes[1].signer))
+	require.NoError(t, err)
+
+	waitCtx, cancelWait := context.WithTimeout(ctx, 2*DefaultRoundTimeout)
+	defer cancelWait()
+
+	index, res, err := conf.Wait(waitCtx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), index)
+
+	accepted, _ := res.GetStatus()
+	require.True(t, accepted)
+}
+
+func TestService_Scenario_EmptyBlockSuppression(t *testing.T) {
+	nodes, ro, clean := makeAuthority(t, 3)
+	defer clean()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := nodes[0].service.Setup(ctx, ro)
+	require.NoError(t, err)
+
+	events := nodes[0].service.Watch(ctx)
+
+	select {
+	case evt := <-events:
+		t.Fatalf("unexpected empty block at index %d", evt.Index)
+	case <-time.After(2 * DefaultRoundTimeout):
+	}
+
+	for _, node := range nodes {
+		require.Equal(t, uint64(0), node.service.blocks.Len())
+	}
+}
+
+func TestService_Scenario_EmptyBlocks(t *testing.T) {
+	interval := 200 * time.Millisecond
+
+	nodes, ro, clean := makeAuthority(t, 3, WithEmptyBlocks(), WithBlockInterval(interval))
+	defer clean()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := nodes[0].service.Setup(ctx, ro)
+	require.NoError(t, err)
+
+	events := nodes[0].service.Watch(ctx)
+
+	evt := waitEvent(t, events, 2*DefaultRoundTimeout)
+	require.Equal(t, uint64(0), evt.Index)
+	require.Len(t, evt.Transactions, 0)
+
+	start := time.Now()
+
+	evt = waitEvent(t, events, 20*DefaultRoundTimeout)
+	require.Equal(t, uint64(1), evt.Index)
+	require.Len(t, evt.Transactions, 0)
+	require.GreaterOrEqual(t, time.Since(start), interval/2)
+}
+
+func TestService_Scenario_ExtraData(t *testing.T) {
+	nodes, ro, clean := makeAuthority(t, 3, WithExtraData([]byte("v1.2.0")))
+	defer clean()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := nodes[0].service.Setup(ctx, ro)
+	require.NoError(t, err)
+
+	events := watchAll(ctx, nodes)
+
+	err = nodes[0].pool.Add(makeTx(t, 0, nodes[0].signer))
+	require.NoError(t, err)
+
+	// Each node is awaited as the block is asserted on all of them.
+	waitAll(t, events, 0, 2*DefaultRoundTimeout)
+
+	for _, node := range nodes {
+		link, err := node.service.blocks.Last()
+		require.NoError(t, err)
+		require.Equal(t, []byte("v1.2.0"), link.GetBlock().GetExtraData())
+	}
+}
+
+func TestService_Scenario_TransactionIndex(t *testing.T) {
+	nodes, ro, clean := makeAuthority(t, 3, WithTransactionIndex())
+	defer clean()
+
+	signer := nodes[0].signer
+	other := bls.NewSigner()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := nodes[0].service.Setup(ctx, ro)
+	require.NoError(t, err)
+
+	// Each node is awaited as the index is asserted on all of them.
+	events := watchAll(ctx, nodes)
+
+	err = nodes[0].pool.Add(makeTx(t, 0, signer))
+	require.NoError(t, err)
+
+	waitAll(t, events, 0, 2*DefaultRoundTimeout)
+
+	tx := makeTx(t, 0, other)
+
+	err = nodes[0].pool.Add(tx)
+	require.NoError(t, err)
+
+	waitAll(t, events, 1, 2*DefaultRoundTimeout)
+
+	err = nodes[1].pool.Add(makeTx(t, 1, signer))
+	require.NoError(t, err)
+
+	waitAll(t, events, 2, 2*DefaultRoundTimeout)
+
+	for _, node := range nodes {
+		indices, err := node.service.TransactionsOf(signer.GetPublicKey())
+		require.NoError(t, err)
+		require.Equal(t, []uint64{0, 2}, indices)
+
+		indices, err = node.service.TransactionsOf(other.GetPublicKey())
+		require.NoError(t, err)
+		require.Equal(t, []uint64{1}, indices)
+
+		indices, err = node.service.TransactionsOf(bls.NewSigner().GetPublicKey())
+		require.NoError(t, err)
+		require.Empty(t, indices)
+
+		found, index, err := node.service.HasTransaction(tx.GetID())
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, uint64(1), index)
+
+		found, _, err = node.service.HasTransaction([]byte("unknown"))
+		require.NoError(t, err)
+		require.False(t, found)
+	}
+}
+
+func TestService_Scenario_ReadOnly(t *testing.T) {
+	nodes, ro, clean := makeAuthority(t, 4)
+	defer clean()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := nodes[0].service.Setup(ctx, ro)
+	require.NoError(t, err)
+
+	events := nodes[0].service.Watch(ctx)
+
+	err = nodes[0].pool.Add(makeTx(t, 0, nodes[0].signer))
+	require.NoError(t, err)
+
+	evt := waitEvent(t, events, 2*DefaultRoundTimeout)
+	require.Equal(t, uint64(0), evt.Index)
+
+	// Without enough participants accepting the proposals, the chain can't
+	// move forward.
+	for _, node := range nodes[1:] {
+		node.service.SetReadOnly(true)
+	}
+
+	err = nodes[0].pool.Add(makeTx(t, 1, nodes[0].signer))
+	require.NoError(t, err)
+
+	select {
+	case <-events:
+		t.Fatal("a block has been committed in read-only mode")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	// The read-only nodes keep serving the chain.
+	for _, node := range nodes[1:] {
+		require.Equal(t, uint64(1), node.service.blocks.Len())
+
+		_, err := node.service.GetProof(keyRoster[:])
+		require.NoError(t, err)
+	}
+
+	for _, node := range nodes[1:] {
+		node.service.SetReadOnly(false)
+	}
+
+	evt = waitEvent(t, events, DefaultTransactionTimeout)
+	require.Equal(t, uint64(1), evt.Index)
+}
+
 func TestService_Scenario_ViewChange(t *testing.T) {
 	nodes, ro, clean := makeAuthority(t, 4)
 	defer clean()
@@ -109,6 +733,7 @@ func TestService_Scenario_ViewChange(t *testing.T) {
 	require.NoError(t, err)
 
 	events := nodes[2].service.Watch(ctx)
+	views := nodes[2].service.WatchViews(ctx)
 
 	// Other nodes will detect a transaction but no block incoming => timeout
 	err = nodes[1].pool.Add(makeTx(t, 0, nodes[1].signer))
@@ -116,6 +741,14 @@ func TestService_Scenario_ViewChange(t *testing.T) {
 
 	evt := waitEvent(t, events, DefaultTransactionTimeout+2*time.Second)
 	require.Equal(t, uint64(0), evt.Index)
+
+	select {
+	case view := <-views:
+		require.Equal(t, uint16(1), view.View)
+		require.True(t, view.Leader.Equal(nodes[1].onet.GetAddress()))
+	default:
+		t.Fatal("missing view change event")
+	}
 }
 
 func TestService_Scenario_ViewChangeRequest(t *testing.T) {
@@ -226,6 +859,51 @@ func TestService_Scenario_FinalizeFailure(t *testing.T) {
 	require.Equal(t, uint64(0), evt.Index)
 }
 
+func TestService_Scenario_FaultFinalize(t *testing.T) {
+	faults := NewFaultInjector()
+	faults.Fail(FaultFinalize, 2, pbft.NewTransientError(fake.GetError()))
+
+	nodes, ro, clean := makeAuthority(t, 3,
+		WithFaultInjector(faults), WithFinalizeRetry(3, time.Millisecond))
+	defer clean()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := nodes[0].service.Setup(ctx, ro)
+	require.NoError(t, err)
+
+	events := nodes[2].service.Watch(ctx)
+
+	err = nodes[0].pool.Add(makeTx(t, 0, nodes[0].signer))
+	require.NoError(t, err)
+
+	// The finalization is retried after the transient failures.
+	evt := waitEvent(t, events, 2*DefaultRoundTimeout)
+	require.Equal(t, uint64(0), evt.Index)
+	require.NoError(t, faults.inject(FaultFinalize))
+}
+
+func TestService_Scenario_FaultGenesisRoot(t *testing.T) {
+	faults := NewFaultInjector()
+
+	// Only the node creating the chain diverges as the fault is consumed.
+	faults.Fail(FaultGenesisRoot, 1, fake.GetError())
+
+	nodes, ro, clean := makeAuthority(t, 3, WithFaultInjector(faults))
+	defer clean()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := nodes[0].service.Setup(ctx, ro)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "mismatch tree root")
+
+	require.False(t, nodes[1].service.genesis.Exists())
+	require.False(t, nodes[2].service.genesis.Exists())
+}
+
 func TestService_New(t *testing.T) {
 	param := ServiceParam{
 		Mino:       fake.Mino{},
@@ -250,11 +928,163 @@ func TestService_New(t *testing.T) {
 
 	<-srvc.closed
 
+	_, err = NewService(param, WithExtraData(make([]byte, types.MaxExtraDataSize+1)))
+	require.EqualError(t, err, "extra data of 257 bytes exceeds 256")
+
+	param.DB = fake.NewBadViewDB()
+	_, err = NewService(param, WithDiskBlockStore())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to load blocks: ")
+
 	param.Cosi = badCosi{}
 	_, err = NewService(param)
 	require.EqualError(t, err, fake.Err("creating cosi failed"))
 }
 
+func TestService_WithLogger_New(t *testing.T) {
+	param := ServiceParam{
+		Mino:       fake.Mino{},
+		Cosi:       flatcosi.NewFlat(fake.Mino{}, fake.NewAggregateSigner()),
+		Tree:       fakeTree{},
+		Validation: simple.NewService(nil, nil),
+		Pool:       mem.NewPool(),
+	}
+
+	buffer := new(bytes.Buffer)
+
+	srvc, err := NewService(param, WithLogger(zerolog.New(buffer)))
+	require.NoError(t, err)
+
+	srvc.Close()
+
+	srvc.SetReadOnly(true)
+	require.Contains(t, buffer.String(), `"message":"read-only mode"`)
+	require.Contains(t, buffer.String(), fmt.Sprintf(`"addr":"%v"`, fake.Mino{}.GetAddress()))
+}
+
+func TestService_WithPoolFilter_New(t *testing.T) {
+	txpool := mem.NewPool()
+
+	param := ServiceParam{
+		Mino:       fake.Mino{},
+		Cosi:       flatcosi.NewFlat(fake.Mino{}, fake.NewAggregateSigner()),
+		Tree:       fakeTree{},
+		Validation: simple.NewService(nil, nil),
+		Pool:       txpool,
+	}
+
+	srvc, err := NewService(param, WithPoolFilter(signed.NewSignatureFilter()))
+	require.NoError(t, err)
+
+	defer srvc.Close()
+
+	signer := bls.NewSigner()
+
+	tx, err := signed.NewTransaction(0, signer.GetPublicKey())
+	require.NoError(t, err)
+
+	err = txpool.Add(tx)
+	require.EqualError(t, err, "store failed: invalid transaction: missing signature")
+
+	require.NoError(t, tx.Sign(signer))
+
+	err = txpool.Add(tx)
+	require.NoError(t, err)
+	require.Equal(t, 1, txpool.Stats().TxCount)
+}
+
+func TestService_WithMaxTransactionSize_New(t *testing.T) {
+	txpool := mem.NewPool()
+
+	param := ServiceParam{
+		Mino:       fake.Mino{},
+		Cosi:       flatcosi.NewFlat(fake.Mino{}, fake.NewAggregateSigner()),
+		Tree:       fakeTree{},
+		Validation: simple.NewService(nil, nil),
+		Pool:       txpool,
+	}
+
+	srvc, err := NewService(param)
+	require.NoError(t, err)
+
+	defer srvc.Close()
+
+	signer := bls.NewSigner()
+
+	tx, err := signed.NewTransaction(0, signer.GetPublicKey(),
+		signed.WithArg("A", make([]byte, signed.DefaultMaxSize)))
+	require.NoError(t, err)
+
+	err = txpool.Add(tx)
+	require.EqualError(t, err,
+		"store failed: invalid transaction: payload of 1048577 bytes exceeds the limit of 1048576 bytes")
+
+	txpool = mem.NewPool()
+	param.Pool = txpool
+
+	other, err := NewService(param, WithMaxTransactionSize(0))
+	require.NoError(t, err)
+
+	defer other.Close()
+
+	err = txpool.Add(tx)
+	require.NoError(t, err)
+}
+
+func TestService_WithMaxTransactionArgs_New(t *testing.T) {
+	txpool := mem.NewPool()
+
+	param := ServiceParam{
+		Mino:       fake.Mino{},
+		Cosi:       flatcosi.NewFlat(fake.Mino{}, fake.NewAggregateSigner()),
+		Tree:       fakeTree{},
+		Validation: simple.NewService(nil, nil),
+		Pool:       txpool,
+	}
+
+	srvc, err := NewService(param, WithMaxTransactionArgs(1))
+	require.NoError(t, err)
+
+	defer srvc.Close()
+
+	signer := bls.NewSigner()
+
+	tx, err := signed.NewTransaction(0, signer.GetPublicKey(),
+		signed.WithArg("A", nil), signed.WithArg("B", nil))
+	require.NoError(t, err)
+
+	err = txpool.Add(tx)
+	require.EqualError(t, err,
+		"store failed: invalid transaction: transaction with 2 arguments exceeds the limit of 1")
+}
+
+func TestService_ConditionFilter_New(t *testing.T) {
+	txpool := mem.NewPool()
+
+	param := ServiceParam{
+		Mino:       fake.Mino{},
+		Cosi:       flatcosi.NewFlat(fake.Mino{}, fake.NewAggregateSigner()),
+		Tree:       fakeTree{},
+		Validation: simple.NewService(nil, nil),
+		Pool:       txpool,
+	}
+
+	srvc, err := NewService(param)
+	require.NoError(t, err)
+
+	defer srvc.Close()
+
+	signer := bls.NewSigner()
+
+	tx, err := signed.NewTransaction(0, signer.GetPublicKey(),
+		signed.WithCondition(make([]byte, 33), nil))
+	require.NoError(t, err)
+
+	err = txpool.Add(tx)
+	require.EqualError(t, err,
+		"store failed: invalid transaction: condition key of 33 bytes exceeds the limit of 32 bytes")
+}
+
 func TestService_Setup(t *testing.T) {
 	rpc := fake.NewRPC()
 
@@ -279,6 +1109,22 @@ func TestService_Setup(t *testing.T) {
 	genesis, err := srvc.genesis.Get()
 	require.NoError(t, err)
 	require.Equal(t, 3, genesis.GetRoster().Len())
+
+	// The roster is normalized before the genesis block is created.
+	srvc = &Service{processor: newProcessor()}
+	srvc.rpc = rpc
+	srvc.hashFactory = crypto.NewSha256Factory()
+	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.genesis = blockstore.NewGenesisStore()
+	srvc.access = fakeAccess{}
+	srvc.normalizer = fakeNormalizer{}
+
+	err = srvc.Setup(ctx, a)
+	require.NoError(t, err)
+
+	genesis, err = srvc.genesis.Get()
+	require.NoError(t, err)
+	require.Equal(t, 1, genesis.GetRoster().Len())
 }
 
 func TestService_AlreadySet_Setup(t *testing.T) {
@@ -349,6 +1195,7 @@ func TestService_RequestFailure_Setup(t *testing.T) {
 
 	rpc := fake.NewRPC()
 	rpc.SendResponseWithError(fake.NewAddress(1), fake.GetError())
+	rpc.Done()
 	srvc.rpc = rpc
 
 	a := fake.NewAuthority(3, fake.NewSigner)
@@ -360,6 +1207,47 @@ func TestService_RequestFailure_Setup(t *testing.T) {
 	require.EqualError(t, err, fake.Err("one request failed"))
 }
 
+func TestService_GenesisRetry_Setup(t *testing.T) {
+	a := fake.NewAuthority(3, fake.NewSigner)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	setup := func(attempts, failures int) (*flakyRPC, error) {
+		srvc := &Service{
+			processor:       newProcessor(),
+			genesisAttempts: attempts,
+			genesisBackoff:  time.Millisecond,
+		}
+
+		srvc.tree = blockstore.NewTreeCache(fakeTree{})
+		srvc.access = fakeAccess{}
+		srvc.genesis = blockstore.NewGenesisStore()
+
+		// The second participant is unreachable for the first calls.
+		rpc := &flakyRPC{failures: map[string]int{a.GetAddress(1).String(): failures}}
+		srvc.rpc = rpc
+
+		return rpc, srvc.Setup(ctx, a)
+	}
+
+	rpc, err := setup(3, 1)
+	require.NoError(t, err)
+	require.Len(t, rpc.received, 2)
+	require.Len(t, rpc.received[0], 3)
+	require.Equal(t, []mino.Address{a.GetAddress(1)}, rpc.received[1])
+
+	// The participant stays unreachable longer than the attempts.
+	rpc, err = setup(3, 3)
+	require.EqualError(t, err, fake.Err("one request failed"))
+	require.Len(t, rpc.received, 3)
+
+	// The genesis block is sent once by default.
+	rpc, err = setup(0, 1)
+	require.EqualError(t, err, fake.Err("one request failed"))
+	require.Len(t, rpc.received, 1)
+}
+
 func TestService_Main(t *testing.T) {
 	srvc := &Service{processor: newProcessor()}
 	srvc.rosterFac = authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
@@ -372,6 +1260,7 @@ func TestService_Main(t *testing.T) {
 	require.NoError(t, err)
 
 	srvc.tree = blockstore.NewTreeCache(fakeTree{err: fake.GetError()})
+	srvc.genesis = makeGenesisStore(t)
 	srvc.closing = make(chan struct{})
 	srvc.started = make(chan struct{})
 	srvc.closed = make(chan struct{})
@@ -393,6 +1282,7 @@ func TestService_Main(t *testing.T) {
 
 	srvc.logger = logger
 	srvc.pool = mem.NewPool()
+	srvc.blocks = blockstore.NewInMemory()
 	srvc.pbftsm = fakeSM{errLeader: fake.GetError()}
 	srvc.closed = make(chan struct{})
 	err = srvc.main()
@@ -407,6 +1297,7 @@ func TestService_DoRound(t *testing.T) {
 		processor:                newProcessor(),
 		me:                       fake.NewAddress(1),
 		rpc:                      rpc,
+		signer:                   fake.NewSigner(),
 		timeoutRound:             time.Millisecond,
 		timeoutRoundAfterFailure: time.Millisecond,
 		closing:                  make(chan struct{}),
@@ -415,6 +1306,7 @@ func TestService_DoRound(t *testing.T) {
 	srvc.sync = fakeSync{}
 	srvc.pool = mem.NewPool()
 	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.genesis = makeGenesisStore(t)
 	srvc.rosterFac = authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
 	srvc.pbftsm = fakeSM{
 		state: pbft.ViewChangeState,
@@ -443,6 +1335,94 @@ func TestService_DoRound(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestService_ReadOnly_DoRound(t *testing.T) {
+	rpc := fake.NewRPC()
+	ch := make(chan pbft.State, 2)
+
+	srvc := &Service{
+		processor:                newProcessor(),
+		me:                       fake.NewAddress(1),
+		rpc:                      rpc,
+		signer:                   fake.NewSigner(),
+		timeoutRound:             time.Millisecond,
+		timeoutRoundAfterFailure: time.Millisecond,
+		closing:                  make(chan struct{}),
+	}
+	srvc.blocks = blockstore.NewInMemory()
+	srvc.sync = fakeSync{}
+	srvc.pool = mem.NewPool()
+	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.genesis = makeGenesisStore(t)
+	srvc.rosterFac = fakeRosterFac{}
+	srvc.pbftsm = fakeSM{
+		state: pbft.ViewChangeState,
+		ch:    ch,
+	}
+	srvc.failedRound = true
+
+	srvc.pool.Add(makeTx(t, 0, fake.NewSigner()))
+
+	// Only two of the three participants are reachable.
+	rpc.SendResponse(fake.NewAddress(0), nil)
+	rpc.SendResponse(fake.NewAddress(1), nil)
+	rpc.SendResponseWithError(fake.NewAddress(2), fake.GetError())
+	rpc.Done()
+
+	ch <- pbft.InitialState
+
+	ctx := context.Background()
+
+	err := srvc.doRound(ctx)
+	require.NoError(t, err)
+	require.True(t, srvc.IsReadOnly())
+
+	status, err := srvc.Health()
+	require.NoError(t, err)
+	require.Equal(t, 2, status.Reachable)
+	require.False(t, status.HasQuorum())
+
+	rpc = fake.NewRPC()
+	rpc.SendResponse(fake.NewAddress(0), nil)
+	rpc.SendResponse(fake.NewAddress(1), nil)
+	rpc.SendResponse(fake.NewAddress(2), nil)
+	rpc.Done()
+	srvc.rpc = rpc
+
+	ch <- pbft.InitialState
+
+	err = srvc.doRound(ctx)
+	require.NoError(t, err)
+	require.False(t, srvc.IsReadOnly())
+
+	status, err = srvc.Health()
+	require.NoError(t, err)
+	require.Equal(t, 3, status.Reachable)
+	require.True(t, status.HasQuorum())
+}
+
+func TestService_WaitBlockInterval(t *testing.T) {
+	srvc := &Service{}
+
+	err := srvc.waitBlockInterval(context.Background())
+	require.NoError(t, err)
+
+	srvc.blockInterval = 50 * time.Millisecond
+	srvc.lastProposal = time.Now()
+
+	start := time.Now()
+	err = srvc.waitBlockInterval(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+	srvc.blockInterval = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = srvc.waitBlockInterval(ctx)
+	require.Equal(t, context.Canceled, err)
+}
+
 func TestService_ViewchangeFailed_DoRound(t *testing.T) {
 	pbftsm := fakeSM{
 		state: pbft.ViewChangeState,
@@ -458,6 +1438,7 @@ func TestService_ViewchangeFailed_DoRound(t *testing.T) {
 		processor:                newProcessor(),
 		me:                       fake.NewAddress(1),
 		rpc:                      rpc,
+		signer:                   fake.NewSigner(),
 		timeoutRound:             time.Millisecond,
 		timeoutRoundAfterFailure: time.Millisecond,
 	}
@@ -465,6 +1446,7 @@ func TestService_ViewchangeFailed_DoRound(t *testing.T) {
 	srvc.blocks = blockstore.NewInMemory()
 	srvc.pool = mem.NewPool()
 	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.genesis = makeGenesisStore(t)
 	srvc.rosterFac = authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
 	srvc.pbftsm = pbftsm
 
@@ -477,6 +1459,76 @@ func TestService_ViewchangeFailed_DoRound(t *testing.T) {
 	require.EqualError(t, err, "view change failed")
 }
 
+func TestService_StartViewChange(t *testing.T) {
+	ca := fake.NewAuthority(4, bls.Generate)
+	roster := authority.FromAuthority(ca)
+
+	views := make([]pbft.View, ca.Len())
+	iter := ca.AddressIterator()
+
+	for i := range views {
+		param := pbft.ViewParam{From: iter.GetNext(), ID: types.Digest{1}, Leader: 1}
+
+		view, err := pbft.NewViewAndSign(param, ca.GetSigner(i))
+		require.NoError(t, err)
+
+		views[i] = view
+	}
+
+	makeMsg := func(view pbft.View) types.ViewMessage {
+		return types.NewViewMessage(view.GetID(), view.GetLeader(), view.GetSignature())
+	}
+
+	rpc := fake.NewRPC()
+
+	srvc := &Service{
+		processor: newProcessor(),
+		me:        views[0].GetFrom(),
+		rpc:       rpc,
+		signer:    ca.GetSigner(0),
+	}
+	srvc.pbftsm = expireSM{view: views[0]}
+
+	// The last participant has not expired the round, but the votes of the
+	// others are enough to certify the view.
+	rpc.SendResponse(views[1].GetFrom(), makeMsg(views[1]))
+	rpc.SendResponse(views[2].GetFrom(), interceptedMessage{Message: makeMsg(views[2])})
+	rpc.SendResponse(views[3].GetFrom(), nil)
+	rpc.Done()
+
+	ctx := context.Background()
+
+	msg, err := srvc.startViewChange(ctx, roster)
+	require.NoError(t, err)
+	require.NotNil(t, msg.GetCertificate())
+	require.Len(t, msg.GetCertificate().GetSigners(), 3)
+
+	fac := ca.GetSigner(0).(crypto.AggregateSigner).GetVerifierFactory()
+	require.NoError(t, views[0].VerifyCertificate(*msg.GetCertificate(), roster, fac))
+
+	// The certificate is sent to the participants.
+	require.Equal(t, 2, rpc.Calls.Len())
+	require.Equal(t, msg, rpc.Calls.Get(1, 1))
+
+	// Invalid votes are ignored, so the node waits for the certificate of
+	// another participant.
+	rpc = fake.NewRPC()
+	rpc.SendResponse(views[1].GetFrom(), makeMsg(views[1]))
+	rpc.SendResponse(views[2].GetFrom(), types.NewViewMessage(types.Digest{1}, 2, views[2].GetSignature()))
+	rpc.SendResponse(views[3].GetFrom(), types.NewViewMessage(types.Digest{1}, 1, views[2].GetSignature()))
+	rpc.Done()
+	srvc.rpc = rpc
+
+	msg, err = srvc.startViewChange(ctx, roster)
+	require.NoError(t, err)
+	require.Nil(t, msg.GetCertificate())
+	require.Equal(t, 1, rpc.Calls.Len())
+
+	srvc.signer = nil
+	_, err = srvc.startViewChange(ctx, roster)
+	require.EqualError(t, err, "couldn't certify view: signer '<nil>' can't aggregate")
+}
+
 func TestService_FailPBFTExpire_DoRound(t *testing.T) {
 	rpc := fake.NewRPC()
 	rpc.Done()
@@ -485,6 +1537,7 @@ func TestService_FailPBFTExpire_DoRound(t *testing.T) {
 		processor:                newProcessor(),
 		me:                       fake.NewAddress(1),
 		rpc:                      rpc,
+		signer:                   fake.NewSigner(),
 		timeoutRound:             time.Millisecond,
 		timeoutRoundAfterFailure: time.Millisecond,
 	}
@@ -492,6 +1545,7 @@ func TestService_FailPBFTExpire_DoRound(t *testing.T) {
 	srvc.blocks = blockstore.NewInMemory()
 	srvc.pool = mem.NewPool()
 	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.genesis = makeGenesisStore(t)
 	srvc.rosterFac = authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
 	srvc.pbftsm = fakeSM{
 		err:   fake.GetError(),
@@ -505,6 +1559,14 @@ func TestService_FailPBFTExpire_DoRound(t *testing.T) {
 
 	err := srvc.doRound(ctx)
 	require.EqualError(t, err, fake.Err("pbft expire failed"))
+
+	srvc.pbftsm = fakeSM{
+		errLeader: fake.GetError(),
+		state:     pbft.InitialState,
+	}
+
+	err = srvc.doRound(ctx)
+	require.EqualError(t, err, fake.Err("reading leader"))
 }
 
 func TestService_FailSendViews_DoRound(t *testing.T) {
@@ -519,6 +1581,7 @@ func TestService_FailSendViews_DoRound(t *testing.T) {
 	srvc.blocks = blockstore.NewInMemory()
 	srvc.pool = mem.NewPool()
 	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.genesis = makeGenesisStore(t)
 	srvc.rosterFac = authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
 	srvc.pbftsm = fakeSM{}
 
@@ -540,6 +1603,8 @@ func TestService_FailReadRoster_DoRound(t *testing.T) {
 	}
 
 	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.genesis = makeGenesisStore(t)
+	srvc.blocks = blockstore.NewInMemory()
 	srvc.rosterFac = badRosterFac{}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -558,6 +1623,8 @@ func TestService_FailReadLeader_DoRound(t *testing.T) {
 	}
 
 	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.genesis = makeGenesisStore(t)
+	srvc.blocks = blockstore.NewInMemory()
 	srvc.rosterFac = authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
 	srvc.pbftsm = fakeSM{errLeader: fake.GetError()}
 
@@ -578,6 +1645,7 @@ func TestService_FailSync_DoRound(t *testing.T) {
 
 	srvc.blocks = blockstore.NewInMemory()
 	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.genesis = makeGenesisStore(t)
 	srvc.rosterFac = authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
 	srvc.pbftsm = fakeSM{}
 	srvc.sync = fakeSync{err: fake.GetError()}
@@ -601,6 +1669,7 @@ func TestService_FailPBFT_DoRound(t *testing.T) {
 	srvc.blocks = blockstore.NewInMemory()
 	srvc.pool = mem.NewPool()
 	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.genesis = makeGenesisStore(t)
 	srvc.rosterFac = authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
 	srvc.pbftsm = fakeSM{}
 	srvc.sync = fakeSync{}
@@ -619,33 +1688,276 @@ func TestService_DoPBFT(t *testing.T) {
 	rpc := fake.NewRPC()
 
 	srvc := &Service{processor: newProcessor()}
-	srvc.tree = blockstore.NewTreeCache(fakeTree{})
-	srvc.val = fakeValidation{}
-	srvc.blocks = blockstore.NewInMemory()
-	srvc.genesis = blockstore.NewGenesisStore()
-	srvc.hashFactory = crypto.NewSha256Factory()
-	srvc.pbftsm = fakeSM{}
-	srvc.rosterFac = authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
-	srvc.actor = fakeCosiActor{}
+	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.val = fakeValidation{}
+	srvc.blocks = blockstore.NewInMemory()
+	srvc.genesis = blockstore.NewGenesisStore()
+	srvc.hashFactory = crypto.NewSha256Factory()
+	srvc.pbftsm = fakeSM{}
+	srvc.rosterFac = authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
+	srvc.actor = fakeCosiActor{}
+	srvc.signer = fake.NewSigner()
+	srvc.pool = mem.NewPool()
+	srvc.rpc = rpc
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rpc.SendResponseWithError(fake.NewAddress(5), fake.GetError())
+	rpc.Done()
+	srvc.genesis.Set(types.Genesis{})
+
+	// Context timed out and no transaction are in the pool.
+	err := srvc.doPBFT(ctx)
+	require.NoError(t, err)
+
+	// This time the gathering succeeds.
+	ctx = context.Background()
+	srvc.pool.Add(makeTx(t, 0, fake.NewSigner()))
+	err = srvc.doPBFT(ctx)
+	require.NoError(t, err)
+}
+
+func TestService_Propose_StorageAck(t *testing.T) {
+	srvc := &Service{processor: newProcessor()}
+	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.blocks = blockstore.NewInMemory()
+	srvc.genesis = makeGenesisStore(t)
+	srvc.pbftsm = fakeSM{}
+	srvc.rosterFac = fakeRosterFac{}
+	srvc.actor = fakeCosiActor{}
+	srvc.signer = fake.NewSigner()
+	srvc.storageAck = true
+	srvc.storageAckTimeout = 20 * time.Millisecond
+	srvc.storageAckBackoff = time.Millisecond
+
+	block, err := types.NewBlock(simple.NewResult(nil), types.WithIndex(0),
+		types.WithTreeRoot(types.Digest{1}))
+	require.NoError(t, err)
+
+	// One of the three participants has stored a different state, and the
+	// quorum is not reached before the deadline.
+	rpc := fake.NewRPC()
+	rpc.SendResponse(fake.NewAddress(0), types.NewStoredMessage(0, types.Digest{1}))
+	rpc.SendResponse(fake.NewAddress(1), types.NewStoredMessage(0, types.Digest{1}))
+	rpc.SendResponse(fake.NewAddress(2), types.NewStoredMessage(0, types.Digest{2}))
+	rpc.Done()
+
+	srvc.rpc = rpc
+
+	err = srvc.propose(context.Background(), types.Digest{}, block)
+	require.EqualError(t, err, "block 0 stored by 2 participants out of 3")
+
+	rpc = fake.NewRPC()
+	for i := 0; i < 3; i++ {
+		rpc.SendResponse(fake.NewAddress(i), types.NewStoredMessage(0, types.Digest{1}))
+	}
+	rpc.Done()
+
+	srvc.rpc = rpc
+
+	err = srvc.propose(context.Background(), types.Digest{}, block)
+	require.NoError(t, err)
+
+	// The acknowledgements are ignored when the option is disabled.
+	rpc = fake.NewRPC()
+	rpc.Done()
+
+	srvc.rpc = rpc
+	srvc.storageAck = false
+
+	err = srvc.propose(context.Background(), types.Digest{}, block)
+	require.NoError(t, err)
+}
+
+func TestService_Propose_LateStorageAck(t *testing.T) {
+	srvc := &Service{processor: newProcessor()}
+	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.blocks = blockstore.NewInMemory()
+	srvc.genesis = makeGenesisStore(t)
+	srvc.pbftsm = fakeSM{}
+	srvc.rosterFac = fakeRosterFac{}
+	srvc.actor = fakeCosiActor{}
+	srvc.signer = fake.NewSigner()
+	srvc.storageAck = true
+	srvc.storageAckTimeout = time.Minute
+	srvc.storageAckBackoff = time.Millisecond
+
+	block, err := types.NewBlock(simple.NewResult(nil), types.WithIndex(0),
+		types.WithTreeRoot(types.Digest{1}))
+	require.NoError(t, err)
+
+	// The third follower acknowledges the block only at the third request, and
+	// the leader waits for it as the quorum requires every participant.
+	rpc := &flakyRPC{
+		failures: map[string]int{fake.NewAddress(2).String(): 2},
+		reply:    types.NewStoredMessage(0, types.Digest{1}),
+	}
+
+	srvc.rpc = rpc
+
+	err = srvc.propose(context.Background(), types.Digest{}, block)
+	require.NoError(t, err)
+	require.Len(t, rpc.received, 4)
+	require.Len(t, rpc.received[0], 3)
+	require.Equal(t, []mino.Address{fake.NewAddress(2)}, rpc.received[1])
+	require.Equal(t, []mino.Address{fake.NewAddress(2)}, rpc.received[2])
+
+	// The next round is refused when the round is done before the quorum.
+	rpc.failures = map[string]int{fake.NewAddress(2).String(): 100}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err = srvc.propose(ctx, types.Digest{}, block)
+	require.EqualError(t, err, "block 0 stored by 2 participants out of 3: context deadline exceeded")
+}
+
+func TestService_Propose_Reachable(t *testing.T) {
+	srvc := &Service{processor: newProcessor()}
+	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.blocks = blockstore.NewInMemory()
+	srvc.genesis = makeGenesisStore(t)
+	srvc.pbftsm = fakeSM{}
+	srvc.rosterFac = fakeRosterFac{}
+	srvc.actor = fakeCosiActor{}
+	srvc.signer = fake.NewSigner()
+
+	block, err := types.NewBlock(simple.NewResult(nil))
+	require.NoError(t, err)
+
+	// The leader measures the participants that answered the propagation.
+	rpc := fake.NewRPC()
+	rpc.SendResponse(fake.NewAddress(0), nil)
+	rpc.SendResponse(fake.NewAddress(1), nil)
+	rpc.SendResponseWithError(fake.NewAddress(2), fake.GetError())
+	rpc.Done()
+
+	srvc.rpc = rpc
+
+	err = srvc.propose(context.Background(), types.Digest{}, block)
+	require.NoError(t, err)
+
+	status, err := srvc.Health()
+	require.NoError(t, err)
+	require.Equal(t, 2, status.Reachable)
+	require.False(t, status.HasQuorum())
+}
+
+func TestService_Flush(t *testing.T) {
+	db := newVolatileDB(t)
+
+	srvc := &Service{processor: newProcessor(), db: db}
+
+	setValue(t, db, "A")
+
+	err := srvc.Flush()
+	require.NoError(t, err)
+
+	setValue(t, db, "B")
+
+	// The value written after the flush is lost in the crash.
+	restarted := db.crash(t)
+	defer restarted.Close()
+
+	require.True(t, hasValue(t, restarted, "A"))
+	require.False(t, hasValue(t, restarted, "B"))
+
+	srvc.db = fake.NewInMemoryDB()
+	err = srvc.Flush()
+	require.NoError(t, err)
+
+	srvc.db = badSyncDB{DB: fake.NewInMemoryDB()}
+	err = srvc.Flush()
+	require.EqualError(t, err, fake.Err("failed to sync"))
+}
+
+func TestService_FitBlockSize(t *testing.T) {
+	srvc := &Service{processor: newProcessor()}
+	srvc.pool = mem.NewPool()
+
+	signer := bls.NewSigner()
+
+	txs := make([]txn.Transaction, 5)
+	for i := range txs {
+		txs[i] = makeTx(t, uint64(i), signer)
+	}
+
+	res, err := srvc.fitBlockSize(txs)
+	require.NoError(t, err)
+	require.Len(t, res, 5)
+
+	data, err := txs[0].Serialize(srvc.context)
+	require.NoError(t, err)
+
+	// The limit is one byte short of the size of a block with four
+	// transactions.
+	txSize := len(data) + types.TransactionSizeOverhead + types.ReasonSizeOverhead
+	srvc.maxBlockSize = types.BlockSizeOverhead + 4*txSize - 1
+
+	res, err = srvc.fitBlockSize(txs)
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+
+	// The estimation is an upper bound even with the longest reasons, made of
+	// characters that are escaped.
+	reason := strings.Repeat("<", validation.MaxReasonSize)
+
+	results := make([]simple.TransactionResult, len(res))
+	for i, tx := range res {
+		results[i] = simple.NewTransactionResult(tx, false, reason)
+	}
+
+	block, err := types.NewBlock(simple.NewResult(results), types.WithIndex(math.MaxUint64),
+		types.WithRosterDigest(types.Digest{}), types.WithTerminal())
+	require.NoError(t, err)
+
+	data, err = block.Serialize(srvc.context)
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(data), srvc.maxBlockSize)
+
+	srvc.maxBlockSize = types.BlockSizeOverhead
+	res, err = srvc.fitBlockSize(txs)
+	require.NoError(t, err)
+	require.Empty(t, res)
+
+	srvc.context = fake.NewBadContext()
+	_, err = srvc.fitBlockSize(txs)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to serialize: ")
+}
+
+func TestService_OversizedTx_FitBlockSize(t *testing.T) {
+	srvc := &Service{processor: newProcessor()}
 	srvc.pool = mem.NewPool()
-	srvc.rpc = rpc
 
-	ctx, cancel := context.WithCancel(context.Background())
-	cancel()
+	signer := bls.NewSigner()
 
-	rpc.SendResponseWithError(fake.NewAddress(5), fake.GetError())
-	rpc.Done()
-	srvc.genesis.Set(types.Genesis{})
+	large, err := signed.NewTransaction(0, signer.GetPublicKey(),
+		signed.WithArg("value", make([]byte, 1000)))
+	require.NoError(t, err)
+	require.NoError(t, large.Sign(signer))
 
-	// Context timed out and no transaction are in the pool.
-	err := srvc.doPBFT(ctx)
+	small := makeTx(t, 1, signer)
+
+	require.NoError(t, srvc.pool.Add(large))
+	require.NoError(t, srvc.pool.Add(small))
+
+	data, err := small.Serialize(srvc.context)
 	require.NoError(t, err)
 
-	// This time the gathering succeeds.
-	ctx = context.Background()
-	srvc.pool.Add(makeTx(t, 0, fake.NewSigner()))
-	err = srvc.doPBFT(ctx)
+	// Only the small transaction fits even in an empty block.
+	srvc.maxBlockSize = types.BlockSizeOverhead + len(data) +
+		types.TransactionSizeOverhead + types.ReasonSizeOverhead
+
+	res, err := srvc.fitBlockSize([]txn.Transaction{large, small})
 	require.NoError(t, err)
+	require.Equal(t, []txn.Transaction{small}, res)
+	require.Equal(t, 1, srvc.pool.Stats().TxCount)
+
+	srvc.pool = badPool{}
+	_, err = srvc.fitBlockSize([]txn.Transaction{large})
+	require.EqualError(t, err, fake.Err("removing transaction"))
 }
 
 func TestService_ContextCanceld_DoPBFT(t *testing.T) {
@@ -690,6 +2002,8 @@ func TestService_FailCreateBlock_DoPBFT(t *testing.T) {
 	srvc.pool = mem.NewPool()
 	srvc.hashFactory = fake.NewHashFactory(fake.NewBadHash())
 	srvc.blocks = blockstore.NewInMemory()
+	srvc.genesis = blockstore.NewGenesisStore()
+	srvc.genesis.Set(types.Genesis{})
 
 	srvc.pool.Add(makeTx(t, 0, fake.NewSigner()))
 
@@ -709,6 +2023,8 @@ func TestService_FailPrepare_DoPBFT(t *testing.T) {
 	srvc.pool = mem.NewPool()
 	srvc.hashFactory = crypto.NewSha256Factory()
 	srvc.blocks = blockstore.NewInMemory()
+	srvc.genesis = blockstore.NewGenesisStore()
+	srvc.genesis.Set(types.Genesis{})
 
 	srvc.pool.Add(makeTx(t, 0, fake.NewSigner()))
 
@@ -717,6 +2033,13 @@ func TestService_FailPrepare_DoPBFT(t *testing.T) {
 
 	err := srvc.doPBFT(ctx)
 	require.EqualError(t, err, fake.Err("pbft prepare failed"))
+
+	// A chain sealed in the meantime is reported to the main loop.
+	srvc.pbftsm = fakeSM{err: pbft.ErrSealed}
+	srvc.pool.Add(makeTx(t, 1, fake.NewSigner()))
+
+	err = srvc.doPBFT(ctx)
+	require.True(t, xerrors.Is(err, pbft.ErrSealed))
 }
 
 func TestService_FailReadRoster_DoPBFT(t *testing.T) {
@@ -727,6 +2050,8 @@ func TestService_FailReadRoster_DoPBFT(t *testing.T) {
 	srvc.pool = mem.NewPool()
 	srvc.hashFactory = crypto.NewSha256Factory()
 	srvc.blocks = blockstore.NewInMemory()
+	srvc.genesis = blockstore.NewGenesisStore()
+	srvc.genesis.Set(types.Genesis{})
 
 	srvc.pool.Add(makeTx(t, 0, fake.NewSigner()))
 
@@ -737,6 +2062,71 @@ func TestService_FailReadRoster_DoPBFT(t *testing.T) {
 	require.EqualError(t, err, fake.Err("read roster failed: read from tree"))
 }
 
+func TestService_FailSignProposal_DoPBFT(t *testing.T) {
+	srvc := &Service{processor: newProcessor()}
+	srvc.val = fakeValidation{}
+	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.pbftsm = fakeSM{}
+	srvc.pool = mem.NewPool()
+	srvc.hashFactory = crypto.NewSha256Factory()
+	srvc.blocks = blockstore.NewInMemory()
+	srvc.genesis = blockstore.NewGenesisStore()
+	srvc.genesis.Set(types.Genesis{})
+	srvc.signer = fake.NewBadSigner()
+	srvc.rosterFac = authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
+
+	srvc.pool.Add(makeTx(t, 0, fake.NewSigner()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := srvc.doPBFT(ctx)
+	require.EqualError(t, err, fake.Err("signing proposal failed"))
+}
+
+func TestService_FailSeal(t *testing.T) {
+	srvc := &Service{processor: newProcessor()}
+	srvc.me = fake.NewAddress(0)
+	srvc.val = fakeValidation{}
+	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.pbftsm = fakeSM{errLeader: fake.GetError()}
+	srvc.hashFactory = crypto.NewSha256Factory()
+	srvc.blocks = blockstore.NewInMemory()
+	srvc.genesis = blockstore.NewGenesisStore()
+	srvc.genesis.Set(types.Genesis{})
+	srvc.signer = fake.NewSigner()
+	srvc.rosterFac = authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
+
+	err := srvc.Seal()
+	require.EqualError(t, err, fake.Err("reading leader"))
+
+	srvc.me = fake.NewAddress(1)
+	srvc.pbftsm = fakeSM{}
+	err = srvc.Seal()
+	require.EqualError(t, err, "'fake.Address[1]' is not the leader")
+
+	srvc.me = fake.NewAddress(0)
+	srvc.blocks = badBlockStore{}
+	err = srvc.Seal()
+	require.EqualError(t, err, fake.Err("sealed: couldn't read last block"))
+
+	srvc.blocks = blockstore.NewInMemory()
+	srvc.val = fakeValidation{err: fake.GetError()}
+	err = srvc.Seal()
+	require.EqualError(t, err, fake.Err("failed to prepare data: "+
+		"staging tree failed: validation failed"))
+
+	srvc.val = fakeValidation{}
+	srvc.pbftsm = fakeSM{err: fake.GetError()}
+	err = srvc.Seal()
+	require.EqualError(t, err, fake.Err("pbft prepare failed"))
+
+	srvc.pbftsm = fakeSM{}
+	srvc.actor = fakeCosiActor{err: fake.GetError()}
+	err = srvc.Seal()
+	require.EqualError(t, err, fake.Err("terminal block: prepare signature failed"))
+}
+
 func TestService_FailPrepareSig_DoPBFT(t *testing.T) {
 	srvc := &Service{processor: newProcessor()}
 	srvc.val = fakeValidation{}
@@ -745,7 +2135,10 @@ func TestService_FailPrepareSig_DoPBFT(t *testing.T) {
 	srvc.pool = mem.NewPool()
 	srvc.hashFactory = crypto.NewSha256Factory()
 	srvc.blocks = blockstore.NewInMemory()
+	srvc.genesis = blockstore.NewGenesisStore()
+	srvc.genesis.Set(types.Genesis{})
 	srvc.actor = fakeCosiActor{err: fake.GetError()}
+	srvc.signer = fake.NewSigner()
 	srvc.rosterFac = authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
 
 	srvc.pool.Add(makeTx(t, 0, fake.NewSigner()))
@@ -765,10 +2158,13 @@ func TestService_FailCommitSign_DoPBFT(t *testing.T) {
 	srvc.pool = mem.NewPool()
 	srvc.hashFactory = crypto.NewSha256Factory()
 	srvc.blocks = blockstore.NewInMemory()
+	srvc.genesis = blockstore.NewGenesisStore()
+	srvc.genesis.Set(types.Genesis{})
 	srvc.actor = fakeCosiActor{
 		err:     fake.GetError(),
 		counter: fake.NewCounter(1),
 	}
+	srvc.signer = fake.NewSigner()
 	srvc.rosterFac = authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
 
 	srvc.pool.Add(makeTx(t, 0, fake.NewSigner()))
@@ -780,6 +2176,63 @@ func TestService_FailCommitSign_DoPBFT(t *testing.T) {
 	require.EqualError(t, err, fake.Err("commit signature failed"))
 }
 
+func TestService_CommitTimeout_DoPBFT(t *testing.T) {
+	rpc := fake.NewRPC()
+	rpc.SendResponse(fake.NewAddress(1), nil)
+	rpc.Done()
+
+	srvc := &Service{
+		processor:     newProcessor(),
+		me:            fake.NewAddress(0),
+		rpc:           rpc,
+		timeoutRound:  time.Second,
+		commitTimeout: 10 * time.Millisecond,
+	}
+	srvc.val = fakeValidation{}
+	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.pbftsm = fakeSM{}
+	srvc.pool = mem.NewPool()
+	srvc.hashFactory = crypto.NewSha256Factory()
+	srvc.blocks = blockstore.NewInMemory()
+	srvc.genesis = blockstore.NewGenesisStore()
+	srvc.genesis.Set(types.Genesis{})
+	srvc.actor = partialCommitActor{}
+	srvc.signer = fake.NewSigner()
+	srvc.rosterFac = authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
+
+	srvc.pool.Add(makeTx(t, 0, fake.NewSigner()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := srvc.WatchCommitTimeouts(ctx)
+
+	err := srvc.doPBFT(ctx)
+	require.EqualError(t, err, "commit timed out after 10ms")
+	require.True(t, srvc.failedRound)
+
+	evt := <-events
+	require.Equal(t, uint64(0), evt.Index)
+
+	// The leader has sent its view to the participants.
+	require.Equal(t, 1, rpc.Calls.Len())
+	require.IsType(t, types.ViewMessage{}, rpc.Calls.Get(0, 1))
+
+	srvc.rpc = fake.NewBadRPC()
+	err = srvc.doPBFT(ctx)
+	require.EqualError(t, err, fake.Err("view change failed: rpc failed to send views"))
+	<-events
+
+	// The round context ends before the commit timeout.
+	srvc.commitTimeout = time.Minute
+	ctx, cancel = context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	err = srvc.doPBFT(ctx)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "commit signature failed: only 0 commits")
+}
+
 func TestService_FailPropagation_DoPBFT(t *testing.T) {
 	srvc := &Service{processor: newProcessor()}
 	srvc.val = fakeValidation{}
@@ -788,7 +2241,10 @@ func TestService_FailPropagation_DoPBFT(t *testing.T) {
 	srvc.pool = mem.NewPool()
 	srvc.hashFactory = crypto.NewSha256Factory()
 	srvc.blocks = blockstore.NewInMemory()
+	srvc.genesis = blockstore.NewGenesisStore()
+	srvc.genesis.Set(types.Genesis{})
 	srvc.actor = fakeCosiActor{}
+	srvc.signer = fake.NewSigner()
 	srvc.rosterFac = authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
 	srvc.rpc = fake.NewBadRPC()
 
@@ -806,90 +2262,396 @@ func TestService_FailWakeUp_DoPBFT(t *testing.T) {
 	rpc.Done()
 
 	srvc := &Service{processor: newProcessor()}
-	srvc.val = fakeValidation{}
-	srvc.tree = blockstore.NewTreeCache(fakeTree{})
-	srvc.pbftsm = fakeSM{}
-	srvc.pool = mem.NewPool()
-	srvc.hashFactory = crypto.NewSha256Factory()
-	srvc.blocks = blockstore.NewInMemory()
-	srvc.actor = fakeCosiActor{}
-	srvc.rosterFac = authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
-	srvc.rpc = rpc
+	srvc.val = fakeValidation{}
+	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.pbftsm = fakeSM{}
+	srvc.pool = mem.NewPool()
+	srvc.hashFactory = crypto.NewSha256Factory()
+	srvc.blocks = blockstore.NewInMemory()
+	srvc.actor = fakeCosiActor{}
+	srvc.signer = fake.NewSigner()
+	srvc.rosterFac = authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
+	srvc.rpc = rpc
+	srvc.genesis = fakeGenesisStore{exists: true, errGet: fake.GetError()}
+
+	srvc.pool.Add(makeTx(t, 0, fake.NewSigner()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := srvc.doPBFT(ctx)
+	require.EqualError(t, err, fake.Err("read latest digest failed: read genesis"))
+
+	// The latest digest is read from the block store when it is not empty.
+	require.NoError(t, srvc.blocks.Store(makeBlock(t, types.Digest{})))
+
+	err = srvc.doPBFT(ctx)
+	require.EqualError(t, err, fake.Err("wake up failed: read genesis failed"))
+}
+
+func TestService_WakeUp(t *testing.T) {
+	rpc := fake.NewRPC()
+
+	srvc := &Service{processor: newProcessor()}
+	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.genesis = blockstore.NewGenesisStore()
+	srvc.genesis.Set(types.Genesis{})
+	srvc.rosterFac = authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
+	srvc.rpc = rpc
+
+	ctx := context.Background()
+
+	rpc.SendResponseWithError(fake.NewAddress(5), fake.GetError())
+	rpc.Done()
+	ro := authority.FromAuthority(fake.NewAuthority(3, fake.NewSigner))
+
+	err := srvc.wakeUp(ctx, ro)
+	require.NoError(t, err)
+
+	srvc.tree.Set(fakeTree{err: fake.GetError()})
+	err = srvc.wakeUp(ctx, ro)
+	require.EqualError(t, err, fake.Err("read roster failed: read from tree"))
+
+	srvc.tree.Set(fakeTree{})
+	srvc.rpc = fake.NewBadRPC()
+	err = srvc.wakeUp(ctx, ro)
+	require.EqualError(t, err, fake.Err("rpc failed"))
+}
+
+func TestService_GetProof(t *testing.T) {
+	srvc := &Service{processor: newProcessor()}
+	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.blocks = blockstore.NewInMemory()
+	srvc.blocks.Store(makeBlock(t, types.Digest{}))
+
+	proof, err := srvc.GetProof([]byte("A"))
+	require.NoError(t, err)
+	require.NotNil(t, proof)
+
+	srvc.tree.Set(fakeTree{err: fake.GetError()})
+	_, err = srvc.GetProof([]byte("A"))
+	require.EqualError(t, err, fake.Err("reading path"))
+
+	srvc.tree.Set(fakeTree{})
+	srvc.blocks = blockstore.NewInMemory()
+	_, err = srvc.GetProof([]byte("A"))
+	require.EqualError(t, err, "reading chain: store is empty")
+}
+
+func TestService_TransactionsOf(t *testing.T) {
+	srvc := &Service{processor: newProcessor()}
+	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+
+	_, err := srvc.TransactionsOf(fake.PublicKey{})
+	require.EqualError(t, err, "transaction index is disabled")
+
+	srvc.indexTxs = true
+	_, err = srvc.TransactionsOf(fake.PublicKey{})
+	require.EqualError(t, err, "reading index: malformed index of 2 bytes")
+
+	srvc.tree.Set(fakeTree{err: fake.GetError()})
+	_, err = srvc.TransactionsOf(fake.PublicKey{})
+	require.EqualError(t, err, fake.Err("reading index: couldn't read index"))
+}
+
+func TestService_HasTransaction(t *testing.T) {
+	srvc := &Service{processor: newProcessor()}
+	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+
+	_, _, err := srvc.HasTransaction([]byte{1})
+	require.EqualError(t, err, "transaction index is disabled")
+
+	srvc.indexTxs = true
+	_, _, err = srvc.HasTransaction([]byte{1})
+	require.EqualError(t, err, "reading index: malformed index of 2 bytes")
+
+	srvc.tree.Set(fakeTree{err: fake.GetError()})
+	_, _, err = srvc.HasTransaction([]byte{1})
+	require.EqualError(t, err, fake.Err("reading index: couldn't read index"))
+}
+
+func TestService_FailIndex_PrepareData(t *testing.T) {
+	srvc := &Service{processor: newProcessor()}
+	srvc.tree = blockstore.NewTreeCache(fakeTree{errStore: fake.GetError()})
+	srvc.blocks = blockstore.NewInMemory()
+	srvc.val = fakeValidation{}
+	srvc.indexTxs = true
+
+	_, _, err := srvc.prepareData([]txn.Transaction{makeTx(t, 0, fake.NewSigner())})
+	require.EqualError(t, err,
+		fake.Err("staging tree failed: couldn't index transactions: couldn't read index"))
+}
+
+func TestService_DumpTree(t *testing.T) {
+	dir, err := os.MkdirTemp(os.TempDir(), "cosipbft")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	db, err := kv.New(filepath.Join(dir, "test.db"))
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	roster := authority.FromAuthority(fake.NewAuthority(3, fake.NewSigner))
+
+	rosterData, err := roster.Serialize(json.NewContext())
+	require.NoError(t, err)
+
+	tree, err := binprefix.NewMerkleTree(db, binprefix.Nonce{}).Stage(func(snap store.Snapshot) error {
+		err := snap.Set(keyRoster[:], rosterData)
+		if err != nil {
+			return err
+		}
+
+		return snap.Set(keyAccess[:], []byte{0xaa})
+	})
+	require.NoError(t, err)
+	require.NoError(t, tree.Commit())
+
+	srvc := &Service{processor: newProcessor()}
+	srvc.tree = blockstore.NewTreeCache(tree)
+
+	out := new(bytes.Buffer)
+	err = srvc.DumpTree(out)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	require.Equal(t, fmt.Sprintf("root=%x", tree.GetRoot()), lines[0])
+	require.Contains(t, lines, fmt.Sprintf("key=%x value=%x... (%d bytes)",
+		[]byte{}, rosterData[:DumpValueMaxSize], len(rosterData)))
+	require.Contains(t, lines, fmt.Sprintf("key=%x value=aa (1 bytes)", keyAccess[:]))
+
+	err = srvc.DumpTree(fake.NewBadHash())
+	require.EqualError(t, err, fake.Err("writing root"))
+
+	err = srvc.DumpTree(fake.NewBadHashWithDelay(1))
+	require.EqualError(t, err, fake.Err("dumping tree: couldn't iterate"))
+
+	srvc.tree.Set(fakeTree{})
+	err = srvc.DumpTree(out)
+	require.EqualError(t, err, "tree 'cosipbft.fakeTree' is not iterable")
+}
+
+func TestService_GetStore(t *testing.T) {
+	srvc := &Service{processor: newProcessor()}
+	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+
+	require.IsType(t, fakeTree{}, srvc.GetStore())
+}
+
+func TestService_GetRoster(t *testing.T) {
+	srvc := &Service{processor: newProcessor()}
+	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.genesis = makeGenesisStore(t)
+	srvc.rosterFac = fakeRosterFac{}
+
+	roster, err := srvc.GetRoster()
+	require.NoError(t, err)
+	require.Equal(t, 3, roster.Len())
+}
+
+func TestService_GenesisRoster(t *testing.T) {
+	ro := authority.FromAuthority(fake.NewAuthority(3, fake.NewSigner))
+
+	genesis, err := types.NewGenesis(ro)
+	require.NoError(t, err)
+
+	srvc := &Service{processor: newProcessor()}
 	srvc.genesis = blockstore.NewGenesisStore()
+	require.NoError(t, srvc.genesis.Set(genesis))
 
-	srvc.pool.Add(makeTx(t, 0, fake.NewSigner()))
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	roster, err := srvc.GenesisRoster()
+	require.NoError(t, err)
+	require.Equal(t, 3, roster.Len())
 
-	err := srvc.doPBFT(ctx)
-	require.EqualError(t, err, "wake up failed: read genesis failed: missing genesis block")
+	srvc.genesis = blockstore.NewGenesisStore()
+	_, err = srvc.GenesisRoster()
+	require.EqualError(t, err, "failed to read genesis: missing genesis block")
 }
 
-func TestService_WakeUp(t *testing.T) {
+func TestService_Abort(t *testing.T) {
 	rpc := fake.NewRPC()
+	rpc.SendResponseWithError(fake.NewAddress(1), fake.GetError())
+	rpc.Done()
 
 	srvc := &Service{processor: newProcessor()}
+	srvc.me = fake.NewAddress(0)
+	srvc.pbftsm = fakeSM{}
 	srvc.tree = blockstore.NewTreeCache(fakeTree{})
-	srvc.genesis = blockstore.NewGenesisStore()
-	srvc.genesis.Set(types.Genesis{})
-	srvc.rosterFac = authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
+	srvc.genesis = makeGenesisStore(t)
+	srvc.rosterFac = fakeRosterFac{}
 	srvc.rpc = rpc
 
-	ctx := context.Background()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	rpc.SendResponseWithError(fake.NewAddress(5), fake.GetError())
-	rpc.Done()
-	ro := authority.FromAuthority(fake.NewAuthority(3, fake.NewSigner))
+	// The leader round in progress is cancelled by the abort.
+	roundCtx, roundCancel := context.WithCancel(ctx)
+	srvc.cancelLeaderRound = roundCancel
 
-	err := srvc.wakeUp(ctx, ro)
+	err := srvc.Abort(ctx, types.Digest{1})
 	require.NoError(t, err)
+	require.Error(t, roundCtx.Err())
+
+	srvc.rpc = fake.NewBadRPC()
+	err = srvc.Abort(ctx, types.Digest{1})
+	require.EqualError(t, err, fake.Err("rpc failed"))
 
 	srvc.tree.Set(fakeTree{err: fake.GetError()})
-	err = srvc.wakeUp(ctx, ro)
+	err = srvc.Abort(ctx, types.Digest{1})
 	require.EqualError(t, err, fake.Err("read roster failed: read from tree"))
 
-	srvc.tree.Set(fakeTree{})
-	srvc.rpc = fake.NewBadRPC()
-	err = srvc.wakeUp(ctx, ro)
-	require.EqualError(t, err, fake.Err("rpc failed"))
+	srvc.pbftsm = fakeSM{err: fake.GetError()}
+	err = srvc.Abort(ctx, types.Digest{1})
+	require.EqualError(t, err, fake.Err("aborting round: pbft abort failed"))
+
+	srvc.pbftsm = fakeSM{errLeader: fake.GetError()}
+	err = srvc.Abort(ctx, types.Digest{1})
+	require.EqualError(t, err, fake.Err("reading leader"))
+
+	srvc.me = fake.NewAddress(1)
+	srvc.pbftsm = fakeSM{}
+	err = srvc.Abort(ctx, types.Digest{1})
+	require.EqualError(t, err, "'fake.Address[1]' is not the leader")
 }
 
-func TestService_GetProof(t *testing.T) {
+func TestService_Repair(t *testing.T) {
+	blocks, db, links, clean := makeDiskStore(t, 3)
+	defer clean()
+
+	// Corrupt the block in the middle of the chain.
+	corruptBlock(t, db, 1)
+
+	_, err := blocks.GetByIndex(1)
+	require.Error(t, err)
+
+	fetcher := &fakeFetcher{
+		links: []types.BlockLink{makeBlock(t, types.Digest{}), links[1]},
+	}
+
 	srvc := &Service{processor: newProcessor()}
+	srvc.blocks = blocks
+	srvc.sync = fetcher
 	srvc.tree = blockstore.NewTreeCache(fakeTree{})
-	srvc.blocks = blockstore.NewInMemory()
-	srvc.blocks.Store(makeBlock(t, types.Digest{}))
+	srvc.genesis = makeGenesisStore(t)
+	srvc.rosterFac = fakeRosterFac{}
 
-	proof, err := srvc.GetProof([]byte("A"))
+	err = srvc.Repair(context.Background())
 	require.NoError(t, err)
-	require.NotNil(t, proof)
 
-	srvc.tree.Set(fakeTree{err: fake.GetError()})
-	_, err = srvc.GetProof([]byte("A"))
-	require.EqualError(t, err, fake.Err("reading path"))
+	// Only the damaged block has been fetched.
+	require.Equal(t, []uint64{1}, fetcher.indices)
 
-	srvc.tree.Set(fakeTree{})
-	srvc.blocks = blockstore.NewInMemory()
-	_, err = srvc.GetProof([]byte("A"))
-	require.EqualError(t, err, "reading chain: store is empty")
+	for i, expected := range links {
+		link, err := blocks.GetByIndex(uint64(i))
+		require.NoError(t, err)
+		require.Equal(t, expected.GetTo(), link.GetTo())
+	}
+
+	// A store in a good state doesn't need any block.
+	fetcher.indices = nil
+	err = srvc.Repair(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, fetcher.indices)
 }
 
-func TestService_GetStore(t *testing.T) {
+func TestService_Repair_Failures(t *testing.T) {
 	srvc := &Service{processor: newProcessor()}
+	srvc.blocks = blockstore.NewInMemory()
+
+	err := srvc.Repair(context.Background())
+	require.EqualError(t, err, "block store '*blockstore.InMemory' can't be repaired")
+
+	blocks, db, _, clean := makeDiskStore(t, 1)
+	defer clean()
+
+	srvc.blocks = blocks
+	srvc.sync = fakeSync{}
+	err = srvc.Repair(context.Background())
+	require.EqualError(t, err, "synchronizer 'cosipbft.fakeSync' can't fetch blocks")
+
+	corruptBlock(t, db, 0)
+
 	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.genesis = makeGenesisStore(t)
+	srvc.rosterFac = fakeRosterFac{}
+	srvc.sync = &fakeFetcher{err: fake.GetError()}
+	err = srvc.Repair(context.Background())
+	require.EqualError(t, err, fake.Err("block 0: fetch failed"))
 
-	require.IsType(t, fakeTree{}, srvc.GetStore())
+	srvc.sync = &fakeFetcher{}
+	err = srvc.Repair(context.Background())
+	require.EqualError(t, err, "block 0: no valid block among 0 replies")
+
+	srvc.blocks = blockstore.NewDiskStore(db, nil)
+	err = srvc.Repair(context.Background())
+	require.NoError(t, err)
 }
 
-func TestService_GetRoster(t *testing.T) {
+func TestService_DoArchivalRound(t *testing.T) {
+	genesis := makeGenesisStore(t)
+
+	first := makeIndexedBlock(t, types.Digest{}, 0)
+	second := makeIndexedBlock(t, first.GetTo(), 1)
+
+	fetcher := &fakeFetcher{
+		links: []types.BlockLink{second, makeIndexedBlock(t, types.Digest{1}, 0), first},
+	}
+
+	blocks := blockstore.NewInMemory()
+
 	srvc := &Service{processor: newProcessor()}
+	srvc.blocks = blocks
+	srvc.genesis = genesis
+	srvc.pbftsm = catchUpSM{blocks: blocks}
+	srvc.sync = fetcher
 	srvc.tree = blockstore.NewTreeCache(fakeTree{})
 	srvc.rosterFac = fakeRosterFac{}
+	srvc.timeoutRound = time.Millisecond
 
-	roster, err := srvc.GetRoster()
+	err := srvc.doArchivalRound(context.Background())
 	require.NoError(t, err)
-	require.Equal(t, 3, roster.Len())
+	require.Equal(t, []uint64{0, 1, 2}, fetcher.indices)
+	require.Equal(t, uint64(2), blocks.Len())
+
+	last, err := blocks.Last()
+	require.NoError(t, err)
+	require.Equal(t, second.GetTo(), last.GetTo())
+
+	// The next round only asks for the next block.
+	fetcher.indices = nil
+	err = srvc.doArchivalRound(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []uint64{2}, fetcher.indices)
+}
+
+func TestService_DoArchivalRound_Failures(t *testing.T) {
+	srvc := &Service{processor: newProcessor()}
+	srvc.sync = fakeSync{}
+
+	err := srvc.doArchivalRound(context.Background())
+	require.EqualError(t, err, "synchronizer 'cosipbft.fakeSync' can't fetch blocks")
+
+	srvc.sync = &fakeFetcher{}
+	srvc.genesis = blockstore.NewGenesisStore()
+	err = srvc.doArchivalRound(context.Background())
+	require.EqualError(t, err, "reading roster: not bootstrapped")
+
+	srvc.genesis = makeGenesisStore(t)
+	srvc.blocks = blockstore.NewInMemory()
+	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.rosterFac = fakeRosterFac{}
+	srvc.timeoutRound = time.Millisecond
+	srvc.sync = &fakeFetcher{err: fake.GetError()}
+	err = srvc.doArchivalRound(context.Background())
+	require.EqualError(t, err, fake.Err("pull failed: fetch failed"))
+
+	srvc.sync = &fakeFetcher{links: []types.BlockLink{makeIndexedBlock(t, types.Digest{}, 0)}}
+	srvc.pbftsm = catchUpSM{err: fake.GetError()}
+	err = srvc.doArchivalRound(context.Background())
+	require.EqualError(t, err, fake.Err("pull failed: block 0"))
 }
 
 func TestService_PoolFilter(t *testing.T) {
@@ -965,6 +2727,18 @@ func makeRosterTx(t *testing.T, nonce uint64, roster authority.Authority, signer
 	return tx
 }
 
+func checkRosterDigest(t *testing.T, s *Service) {
+	link, err := s.blocks.Last()
+	require.NoError(t, err)
+
+	roster, err := s.GetRoster()
+	require.NoError(t, err)
+
+	digest, err := types.RosterDigest(roster, s.hashFactory)
+	require.NoError(t, err)
+	require.Equal(t, digest, link.GetBlock().GetRosterDigest())
+}
+
 func waitEvent(t *testing.T, events <-chan ordering.Event, timeout time.Duration) ordering.Event {
 	select {
 	case <-time.After(timeout):
@@ -976,7 +2750,26 @@ func waitEvent(t *testing.T, events <-chan ordering.Event, timeout time.Duration
 	}
 }
 
-func makeAuthority(t *testing.T, n int) ([]testNode, authority.Authority, func()) {
+// watchAll returns the channels of the events of every node.
+func watchAll(ctx context.Context, nodes []testNode) []<-chan ordering.Event {
+	events := make([]<-chan ordering.Event, len(nodes))
+	for i, node := range nodes {
+		events[i] = node.service.Watch(ctx)
+	}
+
+	return events
+}
+
+// waitAll waits for the event of the block at the index on every channel, so
+// that the block is known to be stored by all the nodes.
+func waitAll(t *testing.T, events []<-chan ordering.Event, index uint64, timeout time.Duration) {
+	for _, ch := range events {
+		evt := waitEvent(t, ch, timeout)
+		require.Equal(t, index, evt.Index)
+	}
+}
+
+func makeAuthority(t *testing.T, n int, opts ...ServiceOption) ([]testNode, authority.Authority, func()) {
 	manager := minoch.NewManager()
 
 	addrs := make([]mino.Address, n)
@@ -1027,7 +2820,7 @@ func makeAuthority(t *testing.T, n int) ([]testNode, authority.Authority, func()
 			DB:         db,
 		}
 
-		srv, err := NewService(param)
+		srv, err := NewService(param, opts...)
 		require.NoError(t, err)
 
 		nodes[i] = testNode{
@@ -1053,6 +2846,124 @@ func makeAuthority(t *testing.T, n int) ([]testNode, authority.Authority, func()
 	return nodes, ro, clean
 }
 
+// flakyRPC is an RPC where each participant fails a number of calls before it
+// acknowledges the messages.
+type flakyRPC struct {
+	mino.RPC
+
+	failures map[string]int
+	received [][]mino.Address
+	reply    serde.Message
+}
+
+func (rpc *flakyRPC) Call(ctx context.Context,
+	msg serde.Message, players mino.Players) (<-chan mino.Response, error) {
+
+	addrs := []mino.Address{}
+	resps := make(chan mino.Response, players.Len())
+
+	iter := players.AddressIterator()
+	for iter.HasNext() {
+		addr := iter.GetNext()
+		addrs = append(addrs, addr)
+
+		if rpc.failures[addr.String()] > 0 {
+			rpc.failures[addr.String()]--
+			resps <- mino.NewResponseWithError(addr, fake.GetError())
+		} else {
+			resps <- mino.NewResponse(addr, rpc.reply)
+		}
+	}
+
+	rpc.received = append(rpc.received, addrs)
+
+	close(resps)
+
+	return resps, nil
+}
+
+// volatileDB is a database that doesn't sync the updates, and keeps a copy of
+// the file at the latest synchronization to simulate a crash.
+type volatileDB struct {
+	kv.DB
+
+	path    string
+	durable string
+}
+
+func newVolatileDB(t *testing.T) volatileDB {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "test.db")
+
+	db, err := kv.New(path, kv.WithNoSync())
+	require.NoError(t, err)
+
+	return volatileDB{
+		DB:      db,
+		path:    path,
+		durable: filepath.Join(dir, "durable.db"),
+	}
+}
+
+func (db volatileDB) Sync() error {
+	err := db.DB.(kv.Syncer).Sync()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(db.path)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(db.durable, data, 0600)
+}
+
+// crash closes the database and opens the state of the latest synchronization.
+func (db volatileDB) crash(t *testing.T) kv.DB {
+	require.NoError(t, db.DB.Close())
+
+	restarted, err := kv.New(db.durable)
+	require.NoError(t, err)
+
+	return restarted
+}
+
+func setValue(t *testing.T, db kv.DB, key string) {
+	err := db.Update(func(tx kv.WritableTx) error {
+		bucket, err := tx.GetBucketOrCreate([]byte("test"))
+		if err != nil {
+			return err
+		}
+
+		return bucket.Set([]byte(key), []byte{1})
+	})
+	require.NoError(t, err)
+}
+
+func hasValue(t *testing.T, db kv.DB, key string) bool {
+	found := false
+
+	err := db.View(func(tx kv.ReadableTx) error {
+		bucket := tx.GetBucket([]byte("test"))
+		found = bucket != nil && bucket.Get([]byte(key)) != nil
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	return found
+}
+
+type badSyncDB struct {
+	kv.DB
+}
+
+func (badSyncDB) Sync() error {
+	return fake.GetError()
+}
+
 type badRosterFac struct {
 	authority.Factory
 }
@@ -1082,6 +2993,10 @@ func (p badPool) SetPlayers(mino.Players) error {
 
 func (p badPool) AddFilter(pool.Filter) {}
 
+func (p badPool) Remove(txn.Transaction) error {
+	return fake.GetError()
+}
+
 type badCosi struct {
 	cosi.CollectiveSigning
 }
@@ -1138,11 +3053,47 @@ func (c fakeCosiActor) Sign(ctx context.Context, msg serde.Message,
 	return fake.Signature{}, nil
 }
 
+// partialCommitActor is a collective signing actor that reaches a quorum for
+// the prepare phase, but then only receives f commits and waits for the others.
+type partialCommitActor struct {
+	cosi.Actor
+}
+
+func (partialCommitActor) Sign(ctx context.Context, msg serde.Message,
+	ca crypto.CollectiveAuthority) (crypto.Signature, error) {
+
+	_, ok := msg.(types.CommitMessage)
+	if !ok {
+		return fake.Signature{}, nil
+	}
+
+	<-ctx.Done()
+
+	return nil, xerrors.Errorf("only %d commits: %v", (ca.Len()-1)/3, ctx.Err())
+}
+
+// expireSM is a state machine that expires the round with the view.
+type expireSM struct {
+	fakeSM
+
+	view pbft.View
+}
+
+func (sm expireSM) Expire(mino.Address) (pbft.View, error) {
+	return sm.view, nil
+}
+
 type fakeRosterFac struct {
 	authority.Factory
+
+	roster authority.Authority
 }
 
-func (fakeRosterFac) AuthorityOf(serde.Context, []byte) (authority.Authority, error) {
+func (f fakeRosterFac) AuthorityOf(serde.Context, []byte) (authority.Authority, error) {
+	if f.roster != nil {
+		return f.roster, nil
+	}
+
 	return authority.FromAuthority(fake.NewAuthority(3, fake.NewSigner)), nil
 }
 
@@ -1155,3 +3106,129 @@ type fakeAccess struct {
 func (srvc fakeAccess) Grant(store.Snapshot, access.Credential, ...access.Identity) error {
 	return srvc.err
 }
+
+type badBlockStore struct {
+	blockstore.BlockStore
+}
+
+func (badBlockStore) Len() uint64 {
+	return 1
+}
+
+func (badBlockStore) Last() (types.BlockLink, error) {
+	return nil, fake.GetError()
+}
+
+type fakeFetcher struct {
+	fakeSync
+
+	links   []types.BlockLink
+	indices []uint64
+	err     error
+}
+
+func (f *fakeFetcher) Fetch(ctx context.Context, players mino.Players,
+	index uint64) ([]types.BlockLink, error) {
+
+	f.indices = append(f.indices, index)
+
+	return f.links, f.err
+}
+
+// catchUpSM is a state machine that stores the blocks it catches up without
+// verifying them.
+type catchUpSM struct {
+	fakeSM
+
+	blocks blockstore.BlockStore
+	err    error
+}
+
+func (sm catchUpSM) CatchUp(link types.BlockLink) error {
+	if sm.err != nil {
+		return sm.err
+	}
+
+	return sm.blocks.Store(link)
+}
+
+func makeIndexedBlock(t *testing.T, from types.Digest, index uint64) types.BlockLink {
+	block, err := types.NewBlock(simple.NewResult(nil), types.WithIndex(index))
+	require.NoError(t, err)
+
+	link, err := types.NewBlockLink(from, block)
+	require.NoError(t, err)
+
+	return link
+}
+
+func makeDiskStore(t *testing.T, n int) (*blockstore.InDisk, kv.DB, []types.BlockLink, func()) {
+	dir, err := os.MkdirTemp(os.TempDir(), "cosipbft")
+	require.NoError(t, err)
+
+	db, err := kv.New(filepath.Join(dir, "test.db"))
+	require.NoError(t, err)
+
+	blockFac := types.NewBlockFactory(simple.NewResultFactory(signed.NewTransactionFactory()))
+	csFac := authority.NewChangeSetFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
+	linkFac := types.NewLinkFactory(blockFac, fake.SignatureFactory{}, csFac)
+
+	blocks := blockstore.NewDiskStore(db, linkFac)
+
+	links := make([]types.BlockLink, n)
+	from := types.Digest{}
+
+	for i := range links {
+		block, err := types.NewBlock(simple.NewResult(nil), types.WithIndex(uint64(i)))
+		require.NoError(t, err)
+
+		links[i], err = types.NewBlockLink(from, block,
+			types.WithSignatures(fake.Signature{}, fake.Signature{}))
+		require.NoError(t, err)
+
+		require.NoError(t, blocks.Store(links[i]))
+
+		from = links[i].GetTo()
+	}
+
+	clean := func() {
+		require.NoError(t, db.Close())
+		require.NoError(t, os.RemoveAll(dir))
+	}
+
+	return blocks, db, links, clean
+}
+
+func corruptBlock(t *testing.T, db kv.DB, index byte) {
+	err := db.Update(func(tx kv.WritableTx) error {
+		key := []byte{index, 0, 0, 0, 0, 0, 0, 0}
+
+		return tx.GetBucket([]byte("blocks")).Set(key, []byte("corrupted"))
+	})
+	require.NoError(t, err)
+}
+
+type seedState struct {
+	key []byte
+	err error
+}
+
+func (s seedState) Initialize(snap store.Snapshot, roster authority.Authority) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	key := s.key
+	if key == nil {
+		key = []byte("seed")
+	}
+
+	return snap.Set(key, []byte("initial"))
+}
+
+// fakeNormalizer maps every address to the same one.
+type fakeNormalizer struct{}
+
+func (fakeNormalizer) Normalize(mino.Address) mino.Address {
+	return fake.NewAddress(0)
+}
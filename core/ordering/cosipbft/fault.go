@@ -0,0 +1,90 @@
+// This file contains the implementation of the injection of faults in the
+// operations of the processor.
+
+package cosipbft
+
+import (
+	"sync"
+)
+
+// FaultPoint is the identifier of an operation of the processor that can be
+// made to fail on demand.
+type FaultPoint string
+
+const (
+	// FaultFinalize fails the finalization of a block by the state machine.
+	// The error must be transient for the finalization to be retried.
+	FaultFinalize FaultPoint = "finalize"
+
+	// FaultCommit fails the commit of a proposal by the state machine.
+	FaultCommit FaultPoint = "commit"
+
+	// FaultGenesisCommit fails the commit of the tree of the genesis block.
+	FaultGenesisCommit FaultPoint = "genesis-commit"
+
+	// FaultGenesisRoot alters the root of the tree of the genesis block, like a
+	// node whose state diverges from the other participants.
+	FaultGenesisRoot FaultPoint = "genesis-root"
+)
+
+// FaultInjector makes the operations of a processor fail on demand so that the
+// resilience of a deployment can be tested without replacing its components.
+// It is disabled unless the service is created with the option.
+type FaultInjector struct {
+	sync.Mutex
+
+	faults map[FaultPoint]*fault
+}
+
+type fault struct {
+	err   error
+	count int
+}
+
+// NewFaultInjector returns a new injector without any fault.
+func NewFaultInjector() *FaultInjector {
+	return &FaultInjector{
+		faults: make(map[FaultPoint]*fault),
+	}
+}
+
+// Fail makes the next calls of the operation fail with the error. A negative
+// count makes every call fail until the fault is cleared.
+func (i *FaultInjector) Fail(point FaultPoint, count int, err error) {
+	i.Lock()
+	i.faults[point] = &fault{err: err, count: count}
+	i.Unlock()
+}
+
+// Clear removes the fault of the operation.
+func (i *FaultInjector) Clear(point FaultPoint) {
+	i.Lock()
+	delete(i.faults, point)
+	i.Unlock()
+}
+
+// inject returns the error of the fault of the operation, or nil when the
+// operation must proceed. A nil injector never injects a fault.
+func (i *FaultInjector) inject(point FaultPoint) error {
+	if i == nil {
+		return nil
+	}
+
+	i.Lock()
+	defer i.Unlock()
+
+	f := i.faults[point]
+	if f == nil {
+		return nil
+	}
+
+	if f.count > 0 {
+		f.count--
+
+		if f.count == 0 {
+			delete(i.faults, point)
+		}
+	}
+
+	return f.err
+}
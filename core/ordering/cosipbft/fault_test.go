@@ -0,0 +1,41 @@
+package cosipbft
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/internal/testing/fake"
+)
+
+func TestFaultInjector_Fail(t *testing.T) {
+	injector := NewFaultInjector()
+
+	injector.Fail(FaultFinalize, 2, fake.GetError())
+	require.Len(t, injector.faults, 1)
+
+	require.Equal(t, fake.GetError(), injector.inject(FaultFinalize))
+	require.Equal(t, fake.GetError(), injector.inject(FaultFinalize))
+	require.NoError(t, injector.inject(FaultFinalize))
+	require.Empty(t, injector.faults)
+
+	require.NoError(t, injector.inject(FaultCommit))
+}
+
+func TestFaultInjector_Clear(t *testing.T) {
+	injector := NewFaultInjector()
+
+	injector.Fail(FaultCommit, -1, fake.GetError())
+
+	for i := 0; i < 5; i++ {
+		require.Equal(t, fake.GetError(), injector.inject(FaultCommit))
+	}
+
+	injector.Clear(FaultCommit)
+	require.NoError(t, injector.inject(FaultCommit))
+}
+
+func TestFaultInjector_Nil(t *testing.T) {
+	var injector *FaultInjector
+
+	require.NoError(t, injector.inject(FaultFinalize))
+}
@@ -0,0 +1,233 @@
+// This file contains the implementation of the loaders of the genesis
+// allow-list, and of the reconciliation of the sources of a genesis block.
+
+package cosipbft
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+
+	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
+	"go.dedis.ch/dela/core/ordering/cosipbft/types"
+	"go.dedis.ch/dela/core/store"
+	"go.dedis.ch/dela/serde/json"
+	"golang.org/x/xerrors"
+)
+
+// GenesisSource is the origin of a genesis block learnt by a node. The sources
+// are declared by order of precedence.
+type GenesisSource byte
+
+const (
+	// GenesisFromFile is a genesis read from the local configuration of the
+	// node. It takes precedence as it is provided by the operator.
+	GenesisFromFile GenesisSource = iota
+
+	// GenesisFromSnapshot is the genesis of a snapshot that has been imported.
+	GenesisFromSnapshot
+
+	// GenesisFromPropagate is a genesis received from another participant
+	// during the setup of the chain.
+	GenesisFromPropagate
+)
+
+// String implements fmt.Stringer. It returns the name of the source.
+func (s GenesisSource) String() string {
+	switch s {
+	case GenesisFromFile:
+		return "file"
+	case GenesisFromSnapshot:
+		return "snapshot"
+	case GenesisFromPropagate:
+		return "propagate"
+	default:
+		return "unknown"
+	}
+}
+
+// ReconcileGenesis returns the genesis of the source with the highest
+// precedence amongst the candidates, alongside the source. The sources must
+// agree on the genesis, otherwise an error is returned as the node would
+// follow a different chain depending on the source.
+func ReconcileGenesis(candidates map[GenesisSource]types.Genesis) (types.Genesis, GenesisSource, error) {
+	var genesis types.Genesis
+	var source GenesisSource
+
+	found := false
+
+	for _, s := range []GenesisSource{GenesisFromFile, GenesisFromSnapshot, GenesisFromPropagate} {
+		candidate, ok := candidates[s]
+		if !ok {
+			continue
+		}
+
+		if !found {
+			genesis, source, found = candidate, s, true
+			continue
+		}
+
+		if candidate.GetRoot() != genesis.GetRoot() {
+			return genesis, source, xerrors.Errorf("mismatch root of %v '%v' != '%v' of %v",
+				s, candidate.GetRoot(), genesis.GetRoot(), source)
+		}
+
+		if candidate.GetHash() != genesis.GetHash() {
+			return genesis, source, xerrors.Errorf("mismatch genesis of %v '%v' != '%v' of %v",
+				s, candidate.GetHash(), genesis.GetHash(), source)
+		}
+	}
+
+	if !found {
+		return genesis, source, xerrors.New("no genesis")
+	}
+
+	return genesis, source, nil
+}
+
+// ReadGenesisFile reads the genesis block from a JSON file, for instance to be
+// used as the local genesis of a node.
+func ReadGenesisFile(path string, fac types.GenesisFactory) (types.Genesis, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return types.Genesis{}, xerrors.Errorf("couldn't read file: %v", err)
+	}
+
+	msg, err := fac.Deserialize(json.NewContext(), data)
+	if err != nil {
+		return types.Genesis{}, xerrors.Errorf("couldn't decode genesis: %v", err)
+	}
+
+	genesis, ok := msg.(types.Genesis)
+	if !ok {
+		return types.Genesis{}, xerrors.Errorf("invalid genesis of type '%T'", msg)
+	}
+
+	return genesis, nil
+}
+
+// GenesisLoader is the interface to implement to supply the digests of the
+// rosters that are allowed in a genesis block. The loader is consulted every
+// time a genesis is stored so that the allow-list can change without a
+// rebuild.
+type GenesisLoader interface {
+	// Load returns the list of allowed roster digests.
+	Load() ([][]byte, error)
+}
+
+// GenesisStateInitializer is the interface to implement to seed the tree with
+// the initial state of the application when the genesis block is created. The
+// state is part of the root of the genesis block, so that every participant
+// must use the same initializer. The keys reserved for the roster and the
+// access rights can't be written.
+type GenesisStateInitializer interface {
+	// Initialize writes the initial state in the snapshot of the tree.
+	Initialize(snap store.Snapshot, roster authority.Authority) error
+}
+
+// StaticGenesisLoader is a loader that always returns the same allow-list.
+//
+// - implements cosipbft.GenesisLoader
+type StaticGenesisLoader struct {
+	digests [][]byte
+}
+
+// NewStaticGenesisLoader creates a loader that allows the given roster
+// digests.
+func NewStaticGenesisLoader(digests ...[]byte) StaticGenesisLoader {
+	return StaticGenesisLoader{
+		digests: digests,
+	}
+}
+
+// Load implements cosipbft.GenesisLoader. It returns the static digests.
+func (l StaticGenesisLoader) Load() ([][]byte, error) {
+	return l.digests, nil
+}
+
+// FileGenesisLoader is a loader that reads the allow-list from a file, where
+// each line is a roster digest in hexadecimal.
+//
+// - implements cosipbft.GenesisLoader
+type FileGenesisLoader struct {
+	path string
+}
+
+// NewFileGenesisLoader creates a loader that reads the allow-list from the
+// file at the given path.
+func NewFileGenesisLoader(path string) FileGenesisLoader {
+	return FileGenesisLoader{
+		path: path,
+	}
+}
+
+// Load implements cosipbft.GenesisLoader. It reads and parses the file.
+func (l FileGenesisLoader) Load() ([][]byte, error) {
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't read file: %v", err)
+	}
+
+	digests, err := parseDigests(data)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid file: %v", err)
+	}
+
+	return digests, nil
+}
+
+// TreeGenesisLoader is a loader that reads the allow-list from a key of a
+// store, using the same encoding as the file loader.
+//
+// - implements cosipbft.GenesisLoader
+type TreeGenesisLoader struct {
+	tree store.Readable
+	key  []byte
+}
+
+// NewTreeGenesisLoader creates a loader that reads the allow-list at the key of
+// the store.
+func NewTreeGenesisLoader(tree store.Readable, key []byte) TreeGenesisLoader {
+	return TreeGenesisLoader{
+		tree: tree,
+		key:  key,
+	}
+}
+
+// Load implements cosipbft.GenesisLoader. It reads and parses the value stored
+// at the key.
+func (l TreeGenesisLoader) Load() ([][]byte, error) {
+	data, err := l.tree.Get(l.key)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't read key: %v", err)
+	}
+
+	digests, err := parseDigests(data)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid value: %v", err)
+	}
+
+	return digests, nil
+}
+
+func parseDigests(data []byte) ([][]byte, error) {
+	digests := [][]byte{}
+
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		digest := make([]byte, hex.DecodedLen(len(line)))
+
+		_, err := hex.Decode(digest, line)
+		if err != nil {
+			return nil, xerrors.Errorf("malformed digest: %v", err)
+		}
+
+		digests = append(digests, digest)
+	}
+
+	return digests, nil
+}
@@ -0,0 +1,173 @@
+package cosipbft
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
+	"go.dedis.ch/dela/core/ordering/cosipbft/types"
+	"go.dedis.ch/dela/internal/testing/fake"
+	"go.dedis.ch/dela/serde/json"
+)
+
+func TestStaticGenesisLoader_Load(t *testing.T) {
+	loader := NewStaticGenesisLoader([]byte{1}, []byte{2})
+
+	digests, err := loader.Load()
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{1}, {2}}, digests)
+}
+
+func TestFileGenesisLoader_Load(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "allowlist")
+
+	loader := NewFileGenesisLoader(path)
+
+	_, err := loader.Load()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "couldn't read file: ")
+
+	require.NoError(t, os.WriteFile(path, []byte("0102\n\n  aabb \n"), 0600))
+
+	digests, err := loader.Load()
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{1, 2}, {0xaa, 0xbb}}, digests)
+
+	// The file is read again so that a change is taken into account.
+	require.NoError(t, os.WriteFile(path, []byte("03"), 0600))
+
+	digests, err = loader.Load()
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{3}}, digests)
+
+	require.NoError(t, os.WriteFile(path, []byte("zz"), 0600))
+
+	_, err = loader.Load()
+	require.EqualError(t, err,
+		"invalid file: malformed digest: encoding/hex: invalid byte: U+007A 'z'")
+}
+
+func TestTreeGenesisLoader_Load(t *testing.T) {
+	loader := NewTreeGenesisLoader(fakeReadable{value: []byte("0102")}, []byte("allowlist"))
+
+	digests, err := loader.Load()
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{1, 2}}, digests)
+
+	loader = NewTreeGenesisLoader(fakeReadable{}, []byte("allowlist"))
+
+	digests, err = loader.Load()
+	require.NoError(t, err)
+	require.Empty(t, digests)
+
+	loader = NewTreeGenesisLoader(fakeReadable{err: fake.GetError()}, []byte("allowlist"))
+
+	_, err = loader.Load()
+	require.EqualError(t, err, fake.Err("couldn't read key"))
+
+	loader = NewTreeGenesisLoader(fakeReadable{value: []byte("0")}, []byte("allowlist"))
+
+	_, err = loader.Load()
+	require.EqualError(t, err, "invalid value: malformed digest: encoding/hex: odd length hex string")
+}
+
+func TestGenesisSource_String(t *testing.T) {
+	require.Equal(t, "file", GenesisFromFile.String())
+	require.Equal(t, "snapshot", GenesisFromSnapshot.String())
+	require.Equal(t, "propagate", GenesisFromPropagate.String())
+	require.Equal(t, "unknown", GenesisSource(99).String())
+}
+
+func TestReconcileGenesis(t *testing.T) {
+	ro := authority.FromAuthority(fake.NewAuthority(3, fake.NewSigner))
+
+	genesis, err := types.NewGenesis(ro, types.WithGenesisRoot(types.Digest{1}))
+	require.NoError(t, err)
+
+	same, err := types.NewGenesis(ro, types.WithGenesisRoot(types.Digest{1}))
+	require.NoError(t, err)
+
+	otherRoot, err := types.NewGenesis(ro, types.WithGenesisRoot(types.Digest{2}))
+	require.NoError(t, err)
+
+	otherRoster, err := types.NewGenesis(authority.FromAuthority(fake.NewAuthority(2, fake.NewSigner)),
+		types.WithGenesisRoot(types.Digest{1}))
+	require.NoError(t, err)
+
+	res, source, err := ReconcileGenesis(map[GenesisSource]types.Genesis{
+		GenesisFromPropagate: same,
+		GenesisFromFile:      genesis,
+	})
+	require.NoError(t, err)
+	require.Equal(t, GenesisFromFile, source)
+	require.Equal(t, genesis.GetHash(), res.GetHash())
+
+	_, source, err = ReconcileGenesis(map[GenesisSource]types.Genesis{
+		GenesisFromPropagate: same,
+		GenesisFromSnapshot:  genesis,
+	})
+	require.NoError(t, err)
+	require.Equal(t, GenesisFromSnapshot, source)
+
+	_, _, err = ReconcileGenesis(map[GenesisSource]types.Genesis{
+		GenesisFromPropagate: otherRoot,
+		GenesisFromFile:      genesis,
+	})
+	require.EqualError(t, err, fmt.Sprintf("mismatch root of propagate '%v' != '%v' of file",
+		types.Digest{2}, types.Digest{1}))
+
+	_, _, err = ReconcileGenesis(map[GenesisSource]types.Genesis{
+		GenesisFromSnapshot: otherRoster,
+		GenesisFromFile:     genesis,
+	})
+	require.EqualError(t, err, fmt.Sprintf("mismatch genesis of snapshot '%v' != '%v' of file",
+		otherRoster.GetHash(), genesis.GetHash()))
+
+	_, _, err = ReconcileGenesis(nil)
+	require.EqualError(t, err, "no genesis")
+}
+
+func TestReadGenesisFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "genesis.json")
+
+	ro := authority.FromAuthority(fake.NewAuthority(3, fake.NewSigner))
+
+	genesis, err := types.NewGenesis(ro, types.WithGenesisRoot(types.Digest{1}))
+	require.NoError(t, err)
+
+	data, err := genesis.Serialize(json.NewContext())
+	require.NoError(t, err)
+
+	fac := types.NewGenesisFactory(authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{}))
+
+	_, err = ReadGenesisFile(path, fac)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "couldn't read file: ")
+
+	require.NoError(t, os.WriteFile(path, data, 0600))
+
+	res, err := ReadGenesisFile(path, fac)
+	require.NoError(t, err)
+	require.Equal(t, genesis.GetHash(), res.GetHash())
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0600))
+
+	_, err = ReadGenesisFile(path, fac)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "couldn't decode genesis: ")
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+type fakeReadable struct {
+	value []byte
+	err   error
+}
+
+func (r fakeReadable) Get(key []byte) ([]byte, error) {
+	return r.value, r.err
+}
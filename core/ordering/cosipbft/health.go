@@ -0,0 +1,55 @@
+// This file contains the implementation of the health status of the service.
+//
+
+package cosipbft
+
+import (
+	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
+	"golang.org/x/xerrors"
+)
+
+// UnknownReachable is the number of reachable participants reported when the
+// node has not contacted them since the latest block.
+const UnknownReachable = -1
+
+// HealthStatus is the status of the connectivity of the node to the
+// participants of the chain.
+type HealthStatus struct {
+	// Reachable is the number of participants that answered the latest
+	// propagation of a block led by the node, or of a view. It is
+	// UnknownReachable when the node has not contacted the participants since
+	// the latest block, which is the case of a follower.
+	Reachable int
+
+	// Threshold is the number of participants required to form a quorum.
+	Threshold int
+
+	// ReadOnly is true when the node refuses the proposals.
+	ReadOnly bool
+}
+
+// HasQuorum returns true if enough participants are known to be reachable to
+// form a quorum.
+func (s HealthStatus) HasQuorum() bool {
+	return s.Reachable != UnknownReachable && s.Reachable >= s.Threshold
+}
+
+// Health returns the current health status of the service, computed from the
+// current roster and the participants reached during the latest round or view
+// change.
+func (s *Service) Health() (HealthStatus, error) {
+	roster, err := s.getCurrentRoster()
+	if err != nil {
+		return HealthStatus{}, xerrors.Errorf("read roster failed: %v", err)
+	}
+
+	s.readOnlyLock.Lock()
+	status := HealthStatus{
+		Reachable: s.reachable,
+		Threshold: authority.QuorumThreshold(roster.Len()),
+		ReadOnly:  s.readOnly,
+	}
+	s.readOnlyLock.Unlock()
+
+	return status, nil
+}
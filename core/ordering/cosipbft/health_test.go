@@ -0,0 +1,41 @@
+package cosipbft
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/ordering/cosipbft/blockstore"
+)
+
+func TestHealthStatus_HasQuorum(t *testing.T) {
+	require.True(t, HealthStatus{Reachable: 3, Threshold: 3}.HasQuorum())
+	require.True(t, HealthStatus{Reachable: 0, Threshold: 0}.HasQuorum())
+	require.True(t, HealthStatus{Reachable: 4, Threshold: 3}.HasQuorum())
+	require.False(t, HealthStatus{Reachable: 2, Threshold: 3}.HasQuorum())
+	require.False(t, HealthStatus{Reachable: UnknownReachable, Threshold: 0}.HasQuorum())
+}
+
+func TestService_Health(t *testing.T) {
+	srvc := &Service{processor: newProcessor()}
+	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+	srvc.genesis = makeGenesisStore(t)
+	srvc.rosterFac = fakeRosterFac{}
+
+	// The participants have not been contacted yet.
+	status, err := srvc.Health()
+	require.NoError(t, err)
+	require.Equal(t, HealthStatus{Reachable: UnknownReachable, Threshold: 3}, status)
+	require.False(t, status.HasQuorum())
+
+	srvc.setReachable(2)
+	srvc.SetReadOnly(true)
+
+	status, err = srvc.Health()
+	require.NoError(t, err)
+	require.Equal(t, HealthStatus{Reachable: 2, Threshold: 3, ReadOnly: true}, status)
+	require.False(t, status.HasQuorum())
+
+	srvc.genesis = blockstore.NewGenesisStore()
+	_, err = srvc.Health()
+	require.EqualError(t, err, "read roster failed: not bootstrapped")
+}
@@ -0,0 +1,191 @@
+// This file contains the implementation of the interception of the messages
+// exchanged by the ordering service.
+
+package cosipbft
+
+import (
+	"context"
+
+	"go.dedis.ch/dela/cosi"
+	"go.dedis.ch/dela/mino"
+	"go.dedis.ch/dela/serde"
+	"golang.org/x/xerrors"
+)
+
+// MessageInterceptor is the interface to implement to transform the consensus
+// messages of the service after they are serialized and before they hit the
+// wire, for instance to sign or compress them. Incoming must revert the
+// transformation of Outgoing.
+type MessageInterceptor interface {
+	// Outgoing transforms the serialized message before it is sent.
+	Outgoing(data []byte) ([]byte, error)
+
+	// Incoming transforms the received data before it is deserialized.
+	Incoming(data []byte) ([]byte, error)
+}
+
+// IdentityInterceptor is the default interceptor that leaves the messages
+// untouched.
+//
+// - implements cosipbft.MessageInterceptor
+type IdentityInterceptor struct{}
+
+// Outgoing implements cosipbft.MessageInterceptor. It returns the data as is.
+func (IdentityInterceptor) Outgoing(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+// Incoming implements cosipbft.MessageInterceptor. It returns the data as is.
+func (IdentityInterceptor) Incoming(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+// interceptedMessage is a message that applies the interceptor after being
+// serialized.
+//
+// - implements serde.Message
+type interceptedMessage struct {
+	serde.Message
+
+	interceptor MessageInterceptor
+}
+
+// Serialize implements serde.Message. It serializes the message and transforms
+// the result with the interceptor.
+func (m interceptedMessage) Serialize(ctx serde.Context) ([]byte, error) {
+	data, err := m.Message.Serialize(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't serialize message: %v", err)
+	}
+
+	data, err = m.interceptor.Outgoing(data)
+	if err != nil {
+		return nil, xerrors.Errorf("outgoing interceptor failed: %v", err)
+	}
+
+	return data, nil
+}
+
+// interceptedFactory is a message factory that applies the interceptor before
+// deserializing the data.
+//
+// - implements serde.Factory
+type interceptedFactory struct {
+	serde.Factory
+
+	interceptor MessageInterceptor
+}
+
+// Deserialize implements serde.Factory. It transforms the data with the
+// interceptor and deserializes the result.
+func (f interceptedFactory) Deserialize(ctx serde.Context, data []byte) (serde.Message, error) {
+	data, err := f.interceptor.Incoming(data)
+	if err != nil {
+		return nil, xerrors.Errorf("incoming interceptor failed: %v", err)
+	}
+
+	return f.Factory.Deserialize(ctx, data)
+}
+
+// interceptedHandler is a handler that intercepts the replies.
+//
+// - implements mino.Handler
+type interceptedHandler struct {
+	mino.Handler
+
+	interceptor MessageInterceptor
+}
+
+// Process implements mino.Handler. It processes the request and intercepts the
+// reply, if any.
+func (h interceptedHandler) Process(req mino.Request) (serde.Message, error) {
+	resp, err := h.Handler.Process(req)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+
+	return interceptedMessage{Message: resp, interceptor: h.interceptor}, nil
+}
+
+// readReply returns the message or the error of the response. A reply that is
+// delivered without being serialized, like in a local network, still has the
+// wrapper of the interceptor, which is removed.
+func readReply(resp mino.Response) (serde.Message, error) {
+	msg, err := resp.GetMessageOrError()
+	if err != nil {
+		return nil, err
+	}
+
+	intercepted, ok := msg.(interceptedMessage)
+	if ok {
+		return intercepted.Message, nil
+	}
+
+	return msg, nil
+}
+
+// interceptedReactor is the reactor of the collective signing that intercepts
+// its messages, so that the signature requests and their replies are
+// transformed like the other messages of the service.
+//
+// - implements cosi.Reactor
+// - implements cosi.Interceptor
+type interceptedReactor struct {
+	cosi.Reactor
+	MessageInterceptor
+}
+
+// interceptedRPC is an RPC that intercepts the outgoing messages.
+//
+// - implements mino.RPC
+type interceptedRPC struct {
+	mino.RPC
+
+	interceptor MessageInterceptor
+}
+
+// Call implements mino.RPC. It intercepts the request and sends it.
+func (rpc interceptedRPC) Call(ctx context.Context, req serde.Message,
+	players mino.Players) (<-chan mino.Response, error) {
+
+	return rpc.RPC.Call(ctx, interceptedMessage{Message: req, interceptor: rpc.interceptor}, players)
+}
+
+// Stream implements mino.RPC. It opens a stream whose outgoing messages are
+// intercepted.
+func (rpc interceptedRPC) Stream(ctx context.Context,
+	players mino.Players) (mino.Sender, mino.Receiver, error) {
+
+	sender, rcvr, err := rpc.RPC.Stream(ctx, players)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return interceptedSender{Sender: sender, interceptor: rpc.interceptor}, rcvr, nil
+}
+
+// interceptedSender is a sender that intercepts the outgoing messages.
+//
+// - implements mino.Sender
+type interceptedSender struct {
+	mino.Sender
+
+	interceptor MessageInterceptor
+}
+
+// Send implements mino.Sender. It intercepts the message and sends it.
+func (s interceptedSender) Send(msg serde.Message, addrs ...mino.Address) <-chan error {
+	return s.Sender.Send(interceptedMessage{Message: msg, interceptor: s.interceptor}, addrs...)
+}
+
+// createRPC creates the RPC of the service so that the messages go through the
+// interceptor.
+func createRPC(m mino.Mino, h mino.Handler, f serde.Factory, i MessageInterceptor) mino.RPC {
+	h = interceptedHandler{Handler: h, interceptor: i}
+	f = interceptedFactory{Factory: f, interceptor: i}
+
+	return interceptedRPC{
+		RPC:         mino.MustCreateRPC(m, rpcName, h, f),
+		interceptor: i,
+	}
+}
@@ -0,0 +1,220 @@
+package cosipbft
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/ordering/cosipbft/types"
+	"go.dedis.ch/dela/cosi"
+	"go.dedis.ch/dela/internal/testing/fake"
+	"go.dedis.ch/dela/mino"
+	"go.dedis.ch/dela/serde"
+	"go.dedis.ch/dela/serde/json"
+)
+
+func TestIdentityInterceptor(t *testing.T) {
+	interceptor := IdentityInterceptor{}
+
+	data, err := interceptor.Outgoing([]byte("abc"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("abc"), data)
+
+	data, err = interceptor.Incoming([]byte("abc"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("abc"), data)
+}
+
+func TestInterceptedMessage_RoundTrip(t *testing.T) {
+	ctx := json.NewContext()
+	interceptor := xorInterceptor{key: 0x5a}
+
+	msg := interceptedMessage{
+		Message:     types.NewAbortMessage(types.Digest{1, 2, 3}),
+		interceptor: interceptor,
+	}
+
+	data, err := msg.Serialize(ctx)
+	require.NoError(t, err)
+
+	plain, err := types.NewAbortMessage(types.Digest{1, 2, 3}).Serialize(ctx)
+	require.NoError(t, err)
+	require.NotEqual(t, plain, data)
+
+	fac := interceptedFactory{
+		Factory:     types.MessageFactory{},
+		interceptor: interceptor,
+	}
+
+	res, err := fac.Deserialize(ctx, data)
+	require.NoError(t, err)
+	require.Equal(t, types.NewAbortMessage(types.Digest{1, 2, 3}), res)
+
+	// The data is not understood without the interceptor.
+	_, err = types.MessageFactory{}.Deserialize(ctx, data)
+	require.Error(t, err)
+}
+
+func TestInterceptedMessage_Serialize(t *testing.T) {
+	msg := interceptedMessage{
+		Message:     fake.Message{},
+		interceptor: xorInterceptor{},
+	}
+
+	_, err := msg.Serialize(fake.NewBadContext())
+	require.EqualError(t, err, fake.Err("couldn't serialize message"))
+
+	msg.interceptor = badInterceptor{}
+	_, err = msg.Serialize(fake.NewContext())
+	require.EqualError(t, err, fake.Err("outgoing interceptor failed"))
+}
+
+func TestInterceptedFactory_Deserialize(t *testing.T) {
+	fac := interceptedFactory{
+		Factory:     fake.MessageFactory{},
+		interceptor: badInterceptor{},
+	}
+
+	_, err := fac.Deserialize(fake.NewContext(), nil)
+	require.EqualError(t, err, fake.Err("incoming interceptor failed"))
+}
+
+func TestInterceptedHandler_Process(t *testing.T) {
+	h := interceptedHandler{
+		Handler:     fakeHandler{resp: fake.Message{}},
+		interceptor: xorInterceptor{},
+	}
+
+	resp, err := h.Process(mino.Request{})
+	require.NoError(t, err)
+	require.Equal(t, interceptedMessage{Message: fake.Message{}, interceptor: xorInterceptor{}}, resp)
+
+	h.Handler = fakeHandler{}
+	resp, err = h.Process(mino.Request{})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	h.Handler = fakeHandler{err: fake.GetError()}
+	_, err = h.Process(mino.Request{})
+	require.EqualError(t, err, fake.GetError().Error())
+}
+
+func TestReadReply(t *testing.T) {
+	resp := mino.NewResponse(fake.NewAddress(0), interceptedMessage{Message: fake.Message{}})
+
+	msg, err := readReply(resp)
+	require.NoError(t, err)
+	require.Equal(t, fake.Message{}, msg)
+
+	msg, err = readReply(mino.NewResponse(fake.NewAddress(0), fake.Message{}))
+	require.NoError(t, err)
+	require.Equal(t, fake.Message{}, msg)
+
+	_, err = readReply(mino.NewResponseWithError(fake.NewAddress(0), fake.GetError()))
+	require.EqualError(t, err, fake.GetError().Error())
+}
+
+func TestInterceptedRPC_Call(t *testing.T) {
+	rpc := fake.NewRPC()
+
+	irpc := interceptedRPC{
+		RPC:         rpc,
+		interceptor: xorInterceptor{},
+	}
+
+	_, err := irpc.Call(context.Background(), fake.Message{}, fake.NewAuthority(1, fake.NewSigner))
+	require.NoError(t, err)
+	require.Equal(t, 1, rpc.Calls.Len())
+	require.IsType(t, interceptedMessage{}, rpc.Calls.Get(0, 1))
+}
+
+func TestInterceptedRPC_Stream(t *testing.T) {
+	irpc := interceptedRPC{
+		RPC:         fake.NewStreamRPC(fake.NewReceiver(), fake.Sender{}),
+		interceptor: xorInterceptor{},
+	}
+
+	sender, _, err := irpc.Stream(context.Background(), fake.NewAuthority(1, fake.NewSigner))
+	require.NoError(t, err)
+
+	errs := sender.Send(fake.Message{})
+	require.NoError(t, <-errs)
+
+	irpc.RPC = fake.NewBadRPC()
+	_, _, err = irpc.Stream(context.Background(), fake.NewAuthority(1, fake.NewSigner))
+	require.EqualError(t, err, fake.GetError().Error())
+}
+
+func TestCreateRPC(t *testing.T) {
+	rpc := createRPC(fake.Mino{}, fakeHandler{}, fake.MessageFactory{}, xorInterceptor{})
+	require.IsType(t, interceptedRPC{}, rpc)
+}
+
+func TestInterceptedReactor(t *testing.T) {
+	ctx := json.NewContext()
+
+	reactor := interceptedReactor{
+		Reactor:            newProcessor(),
+		MessageInterceptor: xorInterceptor{key: 0x5a},
+	}
+
+	// The replies of the collective signing are transformed by the
+	// interceptor of the service.
+	resp := cosi.SignatureResponse{Signature: fake.Signature{}}
+
+	data, err := cosi.Intercept(reactor, resp).Serialize(ctx)
+	require.NoError(t, err)
+
+	plain, err := resp.Serialize(ctx)
+	require.NoError(t, err)
+	require.NotEqual(t, plain, data)
+
+	data, err = reactor.Incoming(data)
+	require.NoError(t, err)
+	require.Equal(t, plain, data)
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+type xorInterceptor struct {
+	key byte
+}
+
+func (i xorInterceptor) xor(data []byte) []byte {
+	out := make([]byte, len(data))
+	for j, b := range data {
+		out[j] = b ^ i.key
+	}
+
+	return out
+}
+
+func (i xorInterceptor) Outgoing(data []byte) ([]byte, error) {
+	return i.xor(data), nil
+}
+
+func (i xorInterceptor) Incoming(data []byte) ([]byte, error) {
+	return i.xor(data), nil
+}
+
+type badInterceptor struct{}
+
+func (badInterceptor) Outgoing([]byte) ([]byte, error) {
+	return nil, fake.GetError()
+}
+
+func (badInterceptor) Incoming([]byte) ([]byte, error) {
+	return nil, fake.GetError()
+}
+
+type fakeHandler struct {
+	mino.UnsupportedHandler
+
+	resp serde.Message
+	err  error
+}
+
+func (h fakeHandler) Process(mino.Request) (serde.Message, error) {
+	return h.resp, h.err
+}
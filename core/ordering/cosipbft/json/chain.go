@@ -2,6 +2,7 @@ package json
 
 import (
 	"encoding/json"
+	"io"
 
 	"go.dedis.ch/dela/core/ordering/cosipbft/types"
 	"go.dedis.ch/dela/serde"
@@ -58,6 +59,14 @@ func (fmt chainFormat) Decode(ctx serde.Context, data []byte) (serde.Message, er
 		return nil, xerrors.New("chain cannot be empty")
 	}
 
+	// The length is checked before any link is decoded so that an overly long
+	// chain is refused cheaply.
+	limiter, ok := ctx.GetFactory(types.ChainKey{}).(types.ChainLengthLimiter)
+	if ok && limiter.GetMaxLength() > 0 && len(m.Links) > limiter.GetMaxLength() {
+		return nil, xerrors.Errorf("chain of %d links exceeds %d",
+			len(m.Links), limiter.GetMaxLength())
+	}
+
 	fac := ctx.GetFactory(types.LinkKey{})
 
 	factory, ok := fac.(types.LinkFactory)
@@ -82,3 +91,41 @@ func (fmt chainFormat) Decode(ctx serde.Context, data []byte) (serde.Message, er
 
 	return types.NewChain(last, prevs), nil
 }
+
+// DecodeLinks reads a sequence of JSON block links from the reader and calls
+// the function for each of them in order, so that a long range of blocks can be
+// processed without holding them all in memory. The links can be separated by
+// whitespace. It stops at the first link that can't be read or decoded, or at
+// the first error returned by the function.
+func DecodeLinks(ctx serde.Context, r io.Reader, fn func(types.BlockLink) error) error {
+	fac := ctx.GetFactory(types.LinkKey{})
+
+	factory, ok := fac.(types.LinkFactory)
+	if !ok {
+		return xerrors.Errorf("invalid link factory '%T'", fac)
+	}
+
+	decoder := json.NewDecoder(r)
+
+	for i := 0; ; i++ {
+		var raw json.RawMessage
+
+		err := decoder.Decode(&raw)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return xerrors.Errorf("link %d: failed to read: %v", i, err)
+		}
+
+		link, err := factory.BlockLinkOf(ctx, raw)
+		if err != nil {
+			return xerrors.Errorf("link %d: couldn't deserialize block link: %v", i, err)
+		}
+
+		err = fn(link)
+		if err != nil {
+			return xerrors.Errorf("link %d: %w", i, err)
+		}
+	}
+}
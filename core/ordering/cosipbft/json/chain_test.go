@@ -1,6 +1,7 @@
 package json
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -44,6 +45,16 @@ func TestChainFormat_Decode(t *testing.T) {
 	_, err = format.Decode(ctx, []byte(`{}`))
 	require.EqualError(t, err, "chain cannot be empty")
 
+	// The length is checked before the links are decoded.
+	limitCtx := serde.WithFactory(ctx, types.ChainKey{}, fakeChainFac{maxLength: 2})
+	limitCtx = serde.WithFactory(limitCtx, types.LinkKey{}, fakeLinkFac{errLink: fake.GetError()})
+	_, err = format.Decode(limitCtx, []byte(`{"Links":[{}, {}, {}]}`))
+	require.EqualError(t, err, "chain of 3 links exceeds 2")
+
+	limitCtx = serde.WithFactory(ctx, types.ChainKey{}, fakeChainFac{maxLength: 3})
+	_, err = format.Decode(limitCtx, []byte(`{"Links":[{}, {}, {}]}`))
+	require.NoError(t, err)
+
 	badCtx := serde.WithFactory(ctx, types.LinkKey{}, fake.MessageFactory{})
 	_, err = format.Decode(badCtx, []byte(`{"Links":[{}]}`))
 	require.EqualError(t, err, "invalid link factory 'fake.MessageFactory'")
@@ -57,6 +68,55 @@ func TestChainFormat_Decode(t *testing.T) {
 	require.EqualError(t, err, fake.Err("couldn't deserialize block link"))
 }
 
+func TestDecodeLinks(t *testing.T) {
+	ctx := fake.NewContext()
+	ctx = serde.WithFactory(ctx, types.LinkKey{}, fakeLinkFac{})
+
+	count := 0
+	counter := func(types.BlockLink) error {
+		count++
+		return nil
+	}
+
+	err := DecodeLinks(ctx, strings.NewReader("{}{} \n\t{}\n"), counter)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+
+	count = 0
+	err = DecodeLinks(ctx, strings.NewReader(""), counter)
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+
+	// The links read before a truncated one are still processed.
+	err = DecodeLinks(ctx, strings.NewReader(`{} {"From":`), counter)
+	require.EqualError(t, err, "link 1: failed to read: unexpected EOF")
+	require.Equal(t, 1, count)
+
+	err = DecodeLinks(ctx, strings.NewReader(`{} ]`), counter)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "link 1: failed to read: ")
+
+	count = 0
+	err = DecodeLinks(ctx, strings.NewReader(`{} {} {}`), func(types.BlockLink) error {
+		count++
+		if count == 2 {
+			return fake.GetError()
+		}
+		return nil
+	})
+	require.EqualError(t, err, fake.Err("link 1"))
+	require.ErrorIs(t, err, fake.GetError())
+	require.Equal(t, 2, count)
+
+	badCtx := serde.WithFactory(ctx, types.LinkKey{}, fake.MessageFactory{})
+	err = DecodeLinks(badCtx, strings.NewReader(`{}`), counter)
+	require.EqualError(t, err, "invalid link factory 'fake.MessageFactory'")
+
+	badCtx = serde.WithFactory(ctx, types.LinkKey{}, fakeLinkFac{errBlockLink: fake.GetError()})
+	err = DecodeLinks(badCtx, strings.NewReader(`{}`), counter)
+	require.EqualError(t, err, fake.Err("link 0: couldn't deserialize block link"))
+}
+
 // -----------------------------------------------------------------------------
 // Utility functions
 
@@ -84,3 +144,13 @@ func (link fakeLinkFac) LinkOf(serde.Context, []byte) (types.Link, error) {
 func (link fakeLinkFac) BlockLinkOf(serde.Context, []byte) (types.BlockLink, error) {
 	return fakeLink{}, link.errBlockLink
 }
+
+type fakeChainFac struct {
+	types.ChainFactory
+
+	maxLength int
+}
+
+func (fac fakeChainFac) GetMaxLength() int {
+	return fac.maxLength
+}
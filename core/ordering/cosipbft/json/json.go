@@ -16,6 +16,7 @@ func init() {
 	types.RegisterGenesisFormat(serde.FormatJSON, genesisFormat{})
 	types.RegisterMessageFormat(serde.FormatJSON, msgFormat{})
 	types.RegisterBlockFormat(serde.FormatJSON, blockFormat{})
+	types.RegisterBlockHeaderFormat(serde.FormatJSON, headerFormat{})
 	types.RegisterLinkFormat(serde.FormatJSON, linkFormat{})
 	types.RegisterChainFormat(serde.FormatJSON, chainFormat{})
 }
@@ -28,9 +29,23 @@ type GenesisJSON struct {
 
 // BlockJSON is the JSON message for a block.
 type BlockJSON struct {
-	Index    uint64
-	TreeRoot []byte
-	Data     json.RawMessage
+	Index        uint64
+	TreeRoot     []byte
+	Data         json.RawMessage
+	RosterDigest []byte `json:",omitempty"`
+	PayloadRoot  []byte `json:",omitempty"`
+	Terminal     bool   `json:",omitempty"`
+	ExtraData    []byte `json:",omitempty"`
+}
+
+// BlockHeaderJSON is the JSON message for the header of a block.
+type BlockHeaderJSON struct {
+	Index        uint64
+	Digest       []byte
+	TreeRoot     []byte
+	RosterDigest []byte `json:",omitempty"`
+	PayloadRoot  []byte `json:",omitempty"`
+	Terminal     bool   `json:",omitempty"`
 }
 
 // LinkJSON is the JSON message for a link.
@@ -53,10 +68,15 @@ type GenesisMessageJSON struct {
 	Genesis json.RawMessage
 }
 
-// BlockMessageJSON is the JSON message to send a block.
+// BlockMessageJSON is the JSON message to send a block. The index and the
+// previous digest are duplicated from the block so that they can be read
+// without decoding it.
 type BlockMessageJSON struct {
-	Block json.RawMessage
-	Views map[string]ViewMessageJSON
+	Index     uint64
+	Previous  []byte `json:",omitempty"`
+	Block     json.RawMessage
+	Views     map[string]ViewMessageJSON
+	Signature json.RawMessage `json:",omitempty"`
 }
 
 // CommitMessageJSON is the JSON message to send a commit request.
@@ -73,11 +93,30 @@ type DoneMessageJSON struct {
 
 // ViewMessageJSON is the JSON message to send a view change request.
 type ViewMessageJSON struct {
-	Leader    uint16
-	ID        []byte
+	Leader      uint16
+	ID          []byte
+	Signature   json.RawMessage
+	Certificate *ViewCertificateJSON `json:",omitempty"`
+}
+
+// ViewCertificateJSON is the JSON message of the proof that a quorum agreed to
+// a view change.
+type ViewCertificateJSON struct {
+	Signers   []uint16
 	Signature json.RawMessage
 }
 
+// AbortMessageJSON is the JSON message to cancel a round.
+type AbortMessageJSON struct {
+	ID []byte
+}
+
+// StoredMessageJSON is the JSON message to acknowledge that a block is stored.
+type StoredMessageJSON struct {
+	Index uint64
+	Root  []byte
+}
+
 // MessageJSON is the JSON message that wraps the different kinds of messages.
 type MessageJSON struct {
 	Genesis *GenesisMessageJSON `json:",omitempty"`
@@ -85,6 +124,8 @@ type MessageJSON struct {
 	Commit  *CommitMessageJSON  `json:",omitempty"`
 	Done    *DoneMessageJSON    `json:",omitempty"`
 	View    *ViewMessageJSON    `json:",omitempty"`
+	Abort   *AbortMessageJSON   `json:",omitempty"`
+	Stored  *StoredMessageJSON  `json:",omitempty"`
 }
 
 // GenesisFormat is a format engine to serialize and deserialize the genesis
@@ -185,6 +226,17 @@ func (f blockFormat) Encode(ctx serde.Context, msg serde.Message) ([]byte, error
 		Data:     blockdata,
 	}
 
+	if block.GetRosterDigest() != (types.Digest{}) {
+		m.RosterDigest = block.GetRosterDigest().Bytes()
+	}
+
+	if block.GetPayloadRoot() != (types.Digest{}) {
+		m.PayloadRoot = block.GetPayloadRoot().Bytes()
+	}
+
+	m.Terminal = block.IsTerminal()
+	m.ExtraData = block.GetExtraData()
+
 	data, err := ctx.Marshal(m)
 	if err != nil {
 		return nil, xerrors.Errorf("failed to marshal: %v", err)
@@ -222,6 +274,25 @@ func (f blockFormat) Decode(ctx serde.Context, data []byte) (serde.Message, erro
 		types.WithIndex(m.Index),
 	}
 
+	if len(m.RosterDigest) > 0 {
+		rosterDigest := types.Digest{}
+		copy(rosterDigest[:], m.RosterDigest)
+
+		opts = append(opts, types.WithRosterDigest(rosterDigest))
+	}
+
+	if m.Terminal {
+		opts = append(opts, types.WithTerminal())
+	}
+
+	if len(m.ExtraData) > 0 {
+		opts = append(opts, types.WithExtraData(m.ExtraData))
+	}
+
+	if len(m.PayloadRoot) > 0 {
+		opts = append(opts, types.WithPayloadRoot())
+	}
+
 	if f.hashFac != nil {
 		opts = append(opts, types.WithHashFactory(f.hashFac))
 	}
@@ -231,9 +302,86 @@ func (f blockFormat) Decode(ctx serde.Context, data []byte) (serde.Message, erro
 		return nil, xerrors.Errorf("creating block: %v", err)
 	}
 
+	// The payload root is computed from the data, so the one recorded in the
+	// message must match.
+	if len(m.PayloadRoot) > 0 {
+		payloadRoot := types.Digest{}
+		copy(payloadRoot[:], m.PayloadRoot)
+
+		if payloadRoot != block.GetPayloadRoot() {
+			return nil, xerrors.Errorf("mismatch payload root '%v' != '%v'",
+				payloadRoot, block.GetPayloadRoot())
+		}
+	}
+
 	return block, nil
 }
 
+// HeaderFormat is the format engine to serialize and deserialize the headers of
+// the blocks.
+//
+// - implements serde.FormatEngine
+type headerFormat struct{}
+
+// Encode implements serde.FormatEngine. It returns the serialized data of the
+// block header if appropriate, otherwise it returns an error.
+func (f headerFormat) Encode(ctx serde.Context, msg serde.Message) ([]byte, error) {
+	header, ok := msg.(types.BlockHeader)
+	if !ok {
+		return nil, xerrors.Errorf("invalid block header '%T'", msg)
+	}
+
+	m := BlockHeaderJSON{
+		Index:    header.GetIndex(),
+		Digest:   header.GetHash().Bytes(),
+		TreeRoot: header.GetTreeRoot().Bytes(),
+		Terminal: header.IsTerminal(),
+	}
+
+	if header.GetRosterDigest() != (types.Digest{}) {
+		m.RosterDigest = header.GetRosterDigest().Bytes()
+	}
+
+	if header.GetPayloadRoot() != (types.Digest{}) {
+		m.PayloadRoot = header.GetPayloadRoot().Bytes()
+	}
+
+	data, err := ctx.Marshal(m)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to marshal: %v", err)
+	}
+
+	return data, nil
+}
+
+// Decode implements serde.FormatEngine. It populates the block header if
+// appropriate, otherwise it returns an error.
+func (f headerFormat) Decode(ctx serde.Context, data []byte) (serde.Message, error) {
+	m := BlockHeaderJSON{}
+	err := ctx.Unmarshal(data, &m)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to unmarshal: %v", err)
+	}
+
+	// The digest is checked so that a truncated one is not padded with zeros
+	// silently.
+	if len(m.Digest) != len(types.Digest{}) {
+		return nil, xerrors.Errorf("invalid digest of %d bytes", len(m.Digest))
+	}
+
+	param := types.BlockHeaderParam{
+		Index:    m.Index,
+		Terminal: m.Terminal,
+	}
+
+	copy(param.Digest[:], m.Digest)
+	copy(param.TreeRoot[:], m.TreeRoot)
+	copy(param.RosterDigest[:], m.RosterDigest)
+	copy(param.PayloadRoot[:], m.PayloadRoot)
+
+	return types.NewBlockHeader(param), nil
+}
+
 // MsgFormat is the format engine to serialize and deserialize the messages.
 //
 // - implements serde.FormatEngine
@@ -278,10 +426,22 @@ func (f msgFormat) Encode(ctx serde.Context, msg serde.Message) ([]byte, error)
 		}
 
 		bm := BlockMessageJSON{
+			Index: in.GetIndex(),
 			Block: block,
 			Views: views,
 		}
 
+		if in.GetPrevious() != (types.Digest{}) {
+			bm.Previous = in.GetPrevious().Bytes()
+		}
+
+		if in.GetSignature() != nil {
+			bm.Signature, err = in.GetSignature().Serialize(ctx)
+			if err != nil {
+				return nil, xerrors.Errorf("failed to serialize signature: %v", err)
+			}
+		}
+
 		m = MessageJSON{Block: &bm}
 	case types.CommitMessage:
 		sig, err := in.GetSignature().Serialize(ctx)
@@ -314,6 +474,19 @@ func (f msgFormat) Encode(ctx serde.Context, msg serde.Message) ([]byte, error)
 		}
 
 		m = MessageJSON{View: vm}
+	case types.AbortMessage:
+		am := AbortMessageJSON{
+			ID: in.GetID().Bytes(),
+		}
+
+		m = MessageJSON{Abort: &am}
+	case types.StoredMessage:
+		sm := StoredMessageJSON{
+			Index: in.GetIndex(),
+			Root:  in.GetRoot().Bytes(),
+		}
+
+		m = MessageJSON{Stored: &sm}
 	}
 
 	data, err := ctx.Marshal(m)
@@ -336,6 +509,19 @@ func encodeView(in types.ViewMessage, ctx serde.Context) (*ViewMessageJSON, erro
 		Signature: sig,
 	}
 
+	cert := in.GetCertificate()
+	if cert != nil {
+		certSig, err := cert.GetSignature().Serialize(ctx)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to serialize certificate: %v", err)
+		}
+
+		vm.Certificate = &ViewCertificateJSON{
+			Signers:   cert.GetSigners(),
+			Signature: certSig,
+		}
+	}
+
 	return vm, nil
 }
 
@@ -368,12 +554,22 @@ func (f msgFormat) Decode(ctx serde.Context, data []byte) (serde.Message, error)
 	}
 
 	if m.Block != nil {
-		// 1. Decode the block.
+		// 1. Check the index and decode the block.
 		factory := ctx.GetFactory(types.BlockKey{})
 		if factory == nil {
 			return nil, xerrors.New("missing block factory")
 		}
 
+		// The index is checked first so that an obviously wrong proposal is
+		// refused before paying the cost of decoding the block.
+		checker, ok := factory.(types.BlockIndexChecker)
+		if ok {
+			err := checker.CheckIndex(m.Block.Index)
+			if err != nil {
+				return nil, xerrors.Errorf("invalid index: %v", err)
+			}
+		}
+
 		msg, err := factory.Deserialize(ctx, m.Block.Block)
 		if err != nil {
 			return nil, xerrors.Errorf("failed to deserialize block: %v", err)
@@ -384,6 +580,11 @@ func (f msgFormat) Decode(ctx serde.Context, data []byte) (serde.Message, error)
 			return nil, xerrors.Errorf("invalid block '%T'", msg)
 		}
 
+		if block.GetIndex() != m.Block.Index {
+			return nil, xerrors.Errorf("mismatch index %d != %d",
+				m.Block.Index, block.GetIndex())
+		}
+
 		// 2. Decode the view messages if any.
 		factory = ctx.GetFactory(types.AddressKey{})
 
@@ -404,7 +605,27 @@ func (f msgFormat) Decode(ctx serde.Context, data []byte) (serde.Message, error)
 			views[addr] = view
 		}
 
-		return types.NewBlockMessage(block, views), nil
+		// 3. Decode the signature of the proposer and the previous digest if
+		// any.
+		var opts []types.BlockMessageOption
+
+		if len(m.Block.Previous) > 0 {
+			previous := types.Digest{}
+			copy(previous[:], m.Block.Previous)
+
+			opts = append(opts, types.WithPrevious(previous))
+		}
+
+		if len(m.Block.Signature) > 0 {
+			sig, err := decodeSignature(ctx, m.Block.Signature, types.SignatureKey{})
+			if err != nil {
+				return nil, xerrors.Errorf("proposer signature: %v", err)
+			}
+
+			opts = append(opts, types.WithProposerSignature(sig))
+		}
+
+		return types.NewBlockMessage(block, views, opts...), nil
 	}
 
 	if m.Commit != nil {
@@ -413,8 +634,10 @@ func (f msgFormat) Decode(ctx serde.Context, data []byte) (serde.Message, error)
 			return nil, xerrors.Errorf("commit failed: %v", err)
 		}
 
-		id := types.Digest{}
-		copy(id[:], m.Commit.ID)
+		id, err := types.NewRoundID(m.Commit.ID)
+		if err != nil {
+			return nil, xerrors.Errorf("commit failed: %v", err)
+		}
 
 		return types.NewCommit(id, sig), nil
 	}
@@ -425,8 +648,10 @@ func (f msgFormat) Decode(ctx serde.Context, data []byte) (serde.Message, error)
 			return nil, xerrors.Errorf("done failed: %v", err)
 		}
 
-		id := types.Digest{}
-		copy(id[:], m.Done.ID)
+		id, err := types.NewRoundID(m.Done.ID)
+		if err != nil {
+			return nil, xerrors.Errorf("done failed: %v", err)
+		}
 
 		return types.NewDone(id, sig), nil
 	}
@@ -435,6 +660,22 @@ func (f msgFormat) Decode(ctx serde.Context, data []byte) (serde.Message, error)
 		return decodeView(ctx, m.View)
 	}
 
+	if m.Abort != nil {
+		id, err := types.NewRoundID(m.Abort.ID)
+		if err != nil {
+			return nil, xerrors.Errorf("abort failed: %v", err)
+		}
+
+		return types.NewAbortMessage(id), nil
+	}
+
+	if m.Stored != nil {
+		root := types.Digest{}
+		copy(root[:], m.Stored.Root)
+
+		return types.NewStoredMessage(m.Stored.Index, root), nil
+	}
+
 	return nil, xerrors.New("message is empty")
 }
 
@@ -447,7 +688,20 @@ func decodeView(ctx serde.Context, view *ViewMessageJSON) (types.ViewMessage, er
 	id := types.Digest{}
 	copy(id[:], view.ID)
 
-	return types.NewViewMessage(id, view.Leader, sig), nil
+	var opts []types.ViewMessageOption
+
+	if view.Certificate != nil {
+		certSig, err := decodeSignature(ctx, view.Certificate.Signature, types.SignatureKey{})
+		if err != nil {
+			return types.ViewMessage{}, xerrors.Errorf("certificate: %v", err)
+		}
+
+		cert := types.NewViewCertificate(view.Certificate.Signers, certSig)
+
+		opts = append(opts, types.WithViewCertificate(cert))
+	}
+
+	return types.NewViewMessage(id, view.Leader, sig, opts...), nil
 }
 
 func decodeSignature(ctx serde.Context, data []byte, key interface{}) (crypto.Signature, error) {
@@ -1,6 +1,8 @@
 package json
 
 import (
+	"encoding/base64"
+	"fmt"
 	"io"
 	"testing"
 
@@ -84,6 +86,34 @@ func TestBlockFormat_Encode(t *testing.T) {
 	require.NoError(t, err)
 	require.Regexp(t, `{"Index":0,"TreeRoot":"[^"]+","Data":{}}`, string(data))
 
+	block, err = types.NewBlock(fakeResult{}, types.WithRosterDigest(types.Digest{1}))
+	require.NoError(t, err)
+
+	data, err = format.Encode(ctx, block)
+	require.NoError(t, err)
+	require.Regexp(t, `{"Index":0,"TreeRoot":"[^"]+","Data":{},"RosterDigest":"AQ[A]+="}`, string(data))
+
+	block, err = types.NewBlock(fakeResult{}, types.WithTerminal())
+	require.NoError(t, err)
+
+	data, err = format.Encode(ctx, block)
+	require.NoError(t, err)
+	require.Regexp(t, `{"Index":0,"TreeRoot":"[^"]+","Data":{},"Terminal":true}`, string(data))
+
+	block, err = types.NewBlock(fakeResult{}, types.WithExtraData([]byte{1}))
+	require.NoError(t, err)
+
+	data, err = format.Encode(ctx, block)
+	require.NoError(t, err)
+	require.Regexp(t, `{"Index":0,"TreeRoot":"[^"]+","Data":{},"ExtraData":"AQ=="}`, string(data))
+
+	block, err = types.NewBlock(fakeResult{leaves: [][]byte{{1}}}, types.WithPayloadRoot())
+	require.NoError(t, err)
+
+	data, err = format.Encode(ctx, block)
+	require.NoError(t, err)
+	require.Regexp(t, `{"Index":0,"TreeRoot":"[^"]+","Data":{},"PayloadRoot":"[^"]+"}`, string(data))
+
 	_, err = format.Encode(ctx, fake.Message{})
 	require.EqualError(t, err, "invalid block 'fake.Message'")
 
@@ -110,6 +140,49 @@ func TestBlockFormat_Decode(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, block, msg)
 
+	block, err = types.NewBlock(fakeResult{}, types.WithRosterDigest(types.Digest{1}))
+	require.NoError(t, err)
+
+	msg, err = format.Decode(ctx, []byte(`{"RosterDigest":"AQ=="}`))
+	require.NoError(t, err)
+	require.Equal(t, block, msg)
+
+	block, err = types.NewBlock(fakeResult{}, types.WithTerminal())
+	require.NoError(t, err)
+
+	msg, err = format.Decode(ctx, []byte(`{"Terminal":true}`))
+	require.NoError(t, err)
+	require.Equal(t, block, msg)
+
+	block, err = types.NewBlock(fakeResult{}, types.WithExtraData([]byte("v1.2.0")))
+	require.NoError(t, err)
+
+	data, err := format.Encode(ctx, block)
+	require.NoError(t, err)
+
+	msg, err = format.Decode(ctx, data)
+	require.NoError(t, err)
+	require.Equal(t, block, msg)
+
+	block, err = types.NewBlock(fakeResult{leaves: [][]byte{{1}}}, types.WithPayloadRoot())
+	require.NoError(t, err)
+
+	merkleCtx := serde.WithFactory(ctx, types.DataKey{}, fakeResultFac{leaves: [][]byte{{1}}})
+	data, err = format.Encode(merkleCtx, block)
+	require.NoError(t, err)
+
+	msg, err = format.Decode(merkleCtx, data)
+	require.NoError(t, err)
+	require.Equal(t, block, msg)
+
+	_, err = format.Decode(ctx, []byte(fmt.Sprintf(`{"ExtraData":"%s"}`,
+		base64.StdEncoding.EncodeToString(make([]byte, types.MaxExtraDataSize+1)))))
+	require.EqualError(t, err, "creating block: extra data of 257 bytes exceeds 256")
+
+	_, err = format.Decode(merkleCtx, []byte(`{"PayloadRoot":"AQ=="}`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "mismatch payload root '01000000' != ")
+
 	_, err = format.Decode(fake.NewBadContext(), []byte(`{}`))
 	require.EqualError(t, err, fake.Err("failed to unmarshal"))
 
@@ -127,6 +200,60 @@ func TestBlockFormat_Decode(t *testing.T) {
 	require.Contains(t, err.Error(), "creating block: fingerprint failed: ")
 }
 
+func TestHeaderFormat_Encode(t *testing.T) {
+	format := headerFormat{}
+
+	ctx := fake.NewContext()
+
+	header := types.NewBlockHeader(types.BlockHeaderParam{Digest: types.Digest{1}, Index: 2})
+
+	data, err := format.Encode(ctx, header)
+	require.NoError(t, err)
+	require.Regexp(t, `{"Index":2,"Digest":"AQ[A]+=","TreeRoot":"[A]+="}`, string(data))
+
+	header = types.NewBlockHeader(types.BlockHeaderParam{
+		RosterDigest: types.Digest{2},
+		PayloadRoot:  types.Digest{3},
+		Terminal:     true,
+	})
+
+	data, err = format.Encode(ctx, header)
+	require.NoError(t, err)
+	require.Regexp(t, `"RosterDigest":"Ag[A]+=","PayloadRoot":"Aw[A]+=","Terminal":true}`, string(data))
+
+	_, err = format.Encode(ctx, fake.Message{})
+	require.EqualError(t, err, "invalid block header 'fake.Message'")
+
+	_, err = format.Encode(fake.NewBadContext(), header)
+	require.EqualError(t, err, fake.Err("failed to marshal"))
+}
+
+func TestHeaderFormat_Decode(t *testing.T) {
+	format := headerFormat{}
+
+	ctx := fake.NewContext()
+	ctx = serde.WithFactory(ctx, types.DataKey{}, fakeResultFac{})
+
+	block, err := types.NewBlock(fakeResult{leaves: [][]byte{{1}}},
+		types.WithIndex(5),
+		types.WithTreeRoot(types.Digest{1}),
+		types.WithRosterDigest(types.Digest{2}))
+	require.NoError(t, err)
+
+	data, err := format.Encode(ctx, block.GetHeader())
+	require.NoError(t, err)
+
+	msg, err := format.Decode(ctx, data)
+	require.NoError(t, err)
+	require.Equal(t, block.GetHeader(), msg)
+
+	_, err = format.Decode(ctx, []byte(`{"Digest":"AQ=="}`))
+	require.EqualError(t, err, "invalid digest of 1 bytes")
+
+	_, err = format.Decode(fake.NewBadContext(), []byte(`{}`))
+	require.EqualError(t, err, fake.Err("failed to unmarshal"))
+}
+
 func TestMsgFormat_Encode(t *testing.T) {
 	format := msgFormat{}
 
@@ -151,7 +278,7 @@ func TestMsgFormat_Encode(t *testing.T) {
 	data, err = format.Encode(ctx, types.NewBlockMessage(block, views))
 	require.NoError(t, err)
 	require.Regexp(t,
-		`{"Block":{"Block":{},"Views":{"[^"]+":{"Leader":5,"ID":"[^"]+","Signature":{}}}}}`, string(data))
+		`{"Block":{"Index":0,"Block":{},"Views":{"[^"]+":{"Leader":5,"ID":"[^"]+","Signature":{}}}}}`, string(data))
 
 	views[fake.NewAddress(0)] = types.NewViewMessage(types.Digest{}, 0, fake.NewBadSignature())
 	_, err = format.Encode(ctx, types.NewBlockMessage(block, views))
@@ -165,6 +292,21 @@ func TestMsgFormat_Encode(t *testing.T) {
 	_, err = format.Encode(fake.NewBadContext(), types.NewBlockMessage(block, nil))
 	require.EqualError(t, err, fake.Err("block: encoding failed"))
 
+	data, err = format.Encode(ctx, types.NewBlockMessage(block, nil,
+		types.WithProposerSignature(fake.Signature{})))
+	require.NoError(t, err)
+	require.Equal(t, `{"Block":{"Index":0,"Block":{},"Views":{},"Signature":{}}}`, string(data))
+
+	data, err = format.Encode(ctx, types.NewBlockMessage(block, nil,
+		types.WithPrevious(types.Digest{1})))
+	require.NoError(t, err)
+	require.Equal(t, `{"Block":{"Index":0,"Previous":"AQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",`+
+		`"Block":{},"Views":{}}}`, string(data))
+
+	_, err = format.Encode(ctx, types.NewBlockMessage(block, nil,
+		types.WithProposerSignature(fake.NewBadSignature())))
+	require.EqualError(t, err, fake.Err("failed to serialize signature"))
+
 	data, err = format.Encode(ctx, types.NewCommit(types.Digest{}, fake.Signature{}))
 	require.NoError(t, err)
 	require.Regexp(t, `{"Commit":{"ID":"[^"]+","Signature":{}}}`, string(data))
@@ -186,8 +328,29 @@ func TestMsgFormat_Encode(t *testing.T) {
 	_, err = format.Encode(ctx, types.NewViewMessage(types.Digest{}, 0, fake.NewBadSignature()))
 	require.EqualError(t, err, fake.Err("view: failed to serialize signature"))
 
+	cert := types.NewViewCertificate([]uint16{0, 2}, fake.Signature{})
+	data, err = format.Encode(ctx, types.NewViewMessage(types.Digest{}, 5, fake.Signature{},
+		types.WithViewCertificate(cert)))
+	require.NoError(t, err)
+	require.Regexp(t,
+		`{"View":{"Leader":5,"ID":"[^"]+","Signature":{},"Certificate":{"Signers":\[0,2\],"Signature":{}}}}`,
+		string(data))
+
+	cert = types.NewViewCertificate([]uint16{0}, fake.NewBadSignature())
+	_, err = format.Encode(ctx, types.NewViewMessage(types.Digest{}, 5, fake.Signature{},
+		types.WithViewCertificate(cert)))
+	require.EqualError(t, err, fake.Err("view: failed to serialize certificate"))
+
 	_, err = format.Encode(fake.NewBadContext(), types.NewViewMessage(types.Digest{}, 0, fake.Signature{}))
 	require.EqualError(t, err, fake.Err("failed to marshal"))
+
+	data, err = format.Encode(ctx, types.NewAbortMessage(types.Digest{1}))
+	require.NoError(t, err)
+	require.Regexp(t, `{"Abort":{"ID":"[^"]+"}}`, string(data))
+
+	data, err = format.Encode(ctx, types.NewStoredMessage(2, types.Digest{1}))
+	require.NoError(t, err)
+	require.Regexp(t, `{"Stored":{"Index":2,"Root":"[^"]+"}}`, string(data))
 }
 
 func TestMsgFormat_Decode(t *testing.T) {
@@ -241,18 +404,53 @@ func TestMsgFormat_Decode(t *testing.T) {
 	_, err = format.Decode(badCtx, []byte(`{"Block":{"Views":{"":{}}}}`))
 	require.EqualError(t, err, "view: signature: invalid signature factory '<nil>'")
 
-	msg, err = format.Decode(ctx, []byte(`{"Commit":{}}`))
+	msg, err = format.Decode(ctx, []byte(`{"Block":{"Signature":{}}}`))
+	require.NoError(t, err)
+	require.Equal(t, fake.Signature{}, msg.(types.BlockMessage).GetSignature())
+
+	badCtx = serde.WithFactory(ctx, types.SignatureKey{}, fake.NewBadSignatureFactory())
+	_, err = format.Decode(badCtx, []byte(`{"Block":{"Signature":{}}}`))
+	require.EqualError(t, err, fake.Err("proposer signature: factory failed"))
+
+	msg, err = format.Decode(ctx, []byte(`{"Block":{"Previous":"AQ=="}}`))
+	require.NoError(t, err)
+	require.Equal(t, types.Digest{1}, msg.(types.BlockMessage).GetPrevious())
+
+	_, err = format.Decode(ctx, []byte(`{"Block":{"Index":2}}`))
+	require.EqualError(t, err, "mismatch index 2 != 0")
+
+	// The index is checked before the block is decoded.
+	checker := &fakeIndexChecker{err: fake.GetError()}
+	badCtx = serde.WithFactory(ctx, types.BlockKey{}, checker)
+	_, err = format.Decode(badCtx, []byte(`{"Block":{"Index":2}}`))
+	require.EqualError(t, err, fake.Err("invalid index"))
+	require.Equal(t, 0, checker.calls)
+
+	msg, err = format.Decode(ctx, []byte(`{"Commit":{"ID":"AQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="}}`))
 	require.NoError(t, err)
 	require.IsType(t, types.CommitMessage{}, msg)
+	require.Equal(t, types.Digest{1}, msg.(types.CommitMessage).GetTo())
+
+	_, err = format.Decode(ctx, []byte(`{"Commit":{}}`))
+	require.EqualError(t, err, "commit failed: invalid digest of 0 bytes")
+
+	_, err = format.Decode(ctx, []byte(`{"Commit":{"ID":"AQ=="}}`))
+	require.EqualError(t, err, "commit failed: invalid digest of 1 bytes")
+
+	_, err = format.Decode(ctx, []byte(`{"Commit":{"ID":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="}}`))
+	require.EqualError(t, err, "commit failed: zero digest")
 
 	badCtx = serde.WithFactory(ctx, types.AggregateKey{}, nil)
 	_, err = format.Decode(badCtx, []byte(`{"Commit":{}}`))
 	require.EqualError(t, err, "commit failed: invalid signature factory '<nil>'")
 
-	msg, err = format.Decode(ctx, []byte(`{"Done":{}}`))
+	msg, err = format.Decode(ctx, []byte(`{"Done":{"ID":"AQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="}}`))
 	require.NoError(t, err)
 	require.IsType(t, types.DoneMessage{}, msg)
 
+	_, err = format.Decode(ctx, []byte(`{"Done":{}}`))
+	require.EqualError(t, err, "done failed: invalid digest of 0 bytes")
+
 	_, err = format.Decode(badCtx, []byte(`{"Done":{}}`))
 	require.EqualError(t, err, "done failed: invalid signature factory '<nil>'")
 
@@ -264,6 +462,26 @@ func TestMsgFormat_Decode(t *testing.T) {
 	_, err = format.Decode(badCtx, []byte(`{"View":{}}`))
 	require.EqualError(t, err, "signature: invalid signature factory '<nil>'")
 
+	msg, err = format.Decode(ctx, []byte(`{"View":{"Certificate":{"Signers":[0,2]}}}`))
+	require.NoError(t, err)
+	require.NotNil(t, msg.(types.ViewMessage).GetCertificate())
+	require.Equal(t, []uint16{0, 2}, msg.(types.ViewMessage).GetCertificate().GetSigners())
+
+	badCtx = serde.WithFactory(ctx, types.SignatureKey{}, fake.NewBadSignatureFactoryWithDelay(1))
+	_, err = format.Decode(badCtx, []byte(`{"View":{"Certificate":{}}}`))
+	require.EqualError(t, err, fake.Err("certificate: factory failed"))
+
+	msg, err = format.Decode(ctx, []byte(`{"Abort":{"ID":"AQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="}}`))
+	require.NoError(t, err)
+	require.Equal(t, types.NewAbortMessage(types.Digest{1}), msg)
+
+	_, err = format.Decode(ctx, []byte(`{"Abort":{"ID":"AQ=="}}`))
+	require.EqualError(t, err, "abort failed: invalid digest of 1 bytes")
+
+	msg, err = format.Decode(ctx, []byte(`{"Stored":{"Index":2,"Root":"AQ=="}}`))
+	require.NoError(t, err)
+	require.Equal(t, types.NewStoredMessage(2, types.Digest{1}), msg)
+
 	_, err = format.Decode(fake.NewBadContext(), []byte(`{}`))
 	require.EqualError(t, err, fake.Err("failed to unmarshal"))
 
@@ -314,3 +532,17 @@ func (fakeGenesisFormat) Decode(serde.Context, []byte) (serde.Message, error) {
 
 	return genesis, nil
 }
+
+type fakeIndexChecker struct {
+	calls int
+	err   error
+}
+
+func (f *fakeIndexChecker) Deserialize(serde.Context, []byte) (serde.Message, error) {
+	f.calls++
+	return types.Block{}, nil
+}
+
+func (f *fakeIndexChecker) CheckIndex(uint64) error {
+	return f.err
+}
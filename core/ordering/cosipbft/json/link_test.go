@@ -166,7 +166,8 @@ func (fac fakeChangeSetFac) ChangeSetOf(serde.Context, []byte) (authority.Change
 type fakeResult struct {
 	validation.Result
 
-	err error
+	leaves [][]byte
+	err    error
 }
 
 func (data fakeResult) Serialize(serde.Context) ([]byte, error) {
@@ -177,14 +178,19 @@ func (fakeResult) Fingerprint(io.Writer) error {
 	return nil
 }
 
+func (data fakeResult) GetLeaves() ([][]byte, error) {
+	return data.leaves, nil
+}
+
 type fakeResultFac struct {
 	validation.ResultFactory
 
-	err error
+	leaves [][]byte
+	err    error
 }
 
 func (fac fakeResultFac) ResultOf(serde.Context, []byte) (validation.Result, error) {
-	return fakeResult{}, fac.err
+	return fakeResult{leaves: fac.leaves}, fac.err
 }
 
 type fakeBlockFormat struct {
@@ -0,0 +1,122 @@
+// This file contains the rules to elect the leader of the next view after a
+// view change.
+//
+
+package pbft
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"go.dedis.ch/dela/core/ordering/cosipbft/types"
+)
+
+// LeaderElection is the rule that elects the leader of the next view when the
+// current one is expired. Every participant must use the same rule so that they
+// agree on the leader.
+type LeaderElection byte
+
+const (
+	// RoundRobin elects the participant that follows the current leader in
+	// the roster.
+	RoundRobin LeaderElection = iota
+
+	// SeededOrder elects the participant that follows the current leader in a
+	// permutation of the roster seeded by the digest of the latest block. The
+	// order changes with each block but is the same for every participant.
+	SeededOrder
+)
+
+// String implements fmt.Stringer. It returns a human-readable name of the rule.
+func (le LeaderElection) String() string {
+	switch le {
+	case RoundRobin:
+		return "round-robin"
+	case SeededOrder:
+		return "seeded-order"
+	default:
+		return "unknown"
+	}
+}
+
+// next returns the index of the leader that follows the current one in a
+// roster of the given size, for the latest block.
+func (le LeaderElection) next(current uint16, latest types.Digest, n int) uint16 {
+	if le != SeededOrder {
+		return (current + 1) % uint16(n)
+	}
+
+	order := LeaderOrder(latest, n)
+
+	for i, index := range order {
+		if index == current {
+			return order[(i+1)%n]
+		}
+	}
+
+	return order[0]
+}
+
+// LeaderOrder returns a permutation of the indices of a roster of the given
+// size, which depends only on the seed.
+func LeaderOrder(seed types.Digest, n int) []uint16 {
+	order := make([]uint16, n)
+	for i := range order {
+		order[i] = uint16(i)
+	}
+
+	rng := NewLeaderRNG(seed)
+
+	// Fisher-Yates shuffle.
+	for i := n - 1; i > 0; i-- {
+		j := rng.Intn(i + 1)
+		order[i], order[j] = order[j], order[i]
+	}
+
+	return order
+}
+
+// LeaderRNG is a deterministic generator of numbers seeded by a digest of the
+// chain, so that participants starting from the same block draw the same
+// numbers. Each draw is the hash of the seed and a counter.
+type LeaderRNG struct {
+	seed    types.Digest
+	counter uint64
+}
+
+// NewLeaderRNG returns a new generator for the seed.
+func NewLeaderRNG(seed types.Digest) *LeaderRNG {
+	return &LeaderRNG{seed: seed}
+}
+
+// Uint64 returns the next number of the sequence.
+func (r *LeaderRNG) Uint64() uint64 {
+	buffer := make([]byte, len(r.seed)+8)
+	copy(buffer, r.seed[:])
+	binary.LittleEndian.PutUint64(buffer[len(r.seed):], r.counter)
+
+	r.counter++
+
+	digest := sha256.Sum256(buffer)
+
+	return binary.LittleEndian.Uint64(digest[:8])
+}
+
+// Intn returns the next number of the sequence in [0, n). It panics if n is
+// not positive.
+func (r *LeaderRNG) Intn(n int) int {
+	if n <= 0 {
+		panic("invalid argument to Intn")
+	}
+
+	// Numbers above the largest multiple of n are rejected so that the result
+	// is not biased toward the lower values.
+	max := ^uint64(0) - ^uint64(0)%uint64(n)
+
+	for {
+		v := r.Uint64()
+		if v < max {
+			return int(v % uint64(n))
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package pbft
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/ordering/cosipbft/types"
+)
+
+func TestLeaderElection_String(t *testing.T) {
+	require.Equal(t, "round-robin", RoundRobin.String())
+	require.Equal(t, "seeded-order", SeededOrder.String())
+	require.Equal(t, "unknown", LeaderElection(99).String())
+}
+
+func TestLeaderElection_Next(t *testing.T) {
+	seed := types.Digest{1}
+
+	require.Equal(t, uint16(1), RoundRobin.next(0, seed, 4))
+	require.Equal(t, uint16(0), RoundRobin.next(3, seed, 4))
+
+	// Successive view changes on the same block visit every participant.
+	visited := make(map[uint16]struct{})
+	leader := uint16(0)
+	for i := 0; i < 7; i++ {
+		leader = SeededOrder.next(leader, seed, 7)
+		visited[leader] = struct{}{}
+	}
+	require.Len(t, visited, 7)
+	require.Equal(t, uint16(0), leader)
+
+	// Unknown index falls back to the first of the order.
+	require.Equal(t, LeaderOrder(seed, 4)[0], SeededOrder.next(10, seed, 4))
+}
+
+func TestLeaderElection_SameChain(t *testing.T) {
+	chain := makeChain(20)
+
+	first := leaderSequence(chain, 10)
+	second := leaderSequence(chain, 10)
+	require.Equal(t, first, second)
+
+	other := leaderSequence(makeChain(21)[1:], 10)
+	require.NotEqual(t, first, other)
+}
+
+func TestLeaderOrder(t *testing.T) {
+	order := LeaderOrder(types.Digest{1}, 10)
+	require.Len(t, order, 10)
+	require.ElementsMatch(t, []uint16{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, order)
+	require.Equal(t, order, LeaderOrder(types.Digest{1}, 10))
+	require.NotEqual(t, order, LeaderOrder(types.Digest{2}, 10))
+
+	require.Empty(t, LeaderOrder(types.Digest{}, 0))
+	require.Equal(t, []uint16{0}, LeaderOrder(types.Digest{}, 1))
+}
+
+func TestLeaderRNG_Intn(t *testing.T) {
+	a := NewLeaderRNG(types.Digest{1})
+	b := NewLeaderRNG(types.Digest{1})
+
+	for i := 0; i < 100; i++ {
+		v := a.Intn(7)
+		require.Equal(t, v, b.Intn(7))
+		require.GreaterOrEqual(t, v, 0)
+		require.Less(t, v, 7)
+	}
+
+	require.Equal(t, uint64(100), a.counter)
+
+	require.Panics(t, func() { a.Intn(0) })
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+// makeChain returns the digests of a chain of blocks where each one is derived
+// from the previous.
+func makeChain(n int) []types.Digest {
+	chain := make([]types.Digest, n)
+	for i := 1; i < n; i++ {
+		chain[i] = sha256.Sum256(chain[i-1][:])
+	}
+
+	return chain
+}
+
+// leaderSequence returns the leader elected after a view change on each block
+// of the chain, starting from a fresh instance of the rule.
+func leaderSequence(chain []types.Digest, n int) []uint16 {
+	election := SeededOrder
+
+	leaders := make([]uint16, len(chain))
+	leader := uint16(0)
+	for i, id := range chain {
+		leader = election.next(leader, id, n)
+		leaders[i] = leader
+	}
+
+	return leaders
+}
@@ -23,6 +23,7 @@ import (
 	"go.dedis.ch/dela/core"
 	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
 	"go.dedis.ch/dela/core/ordering/cosipbft/blockstore"
+	"go.dedis.ch/dela/core/ordering/cosipbft/txindex"
 	"go.dedis.ch/dela/core/ordering/cosipbft/types"
 	"go.dedis.ch/dela/core/store"
 	"go.dedis.ch/dela/core/store/hashtree"
@@ -133,14 +134,25 @@ type StateMachine interface {
 	// Finalize finalizes a round if the signature is a valid commit signature.
 	Finalize(types.Digest, crypto.Signature) error
 
-	// Accept processes the view during a view change state, and moves to a new
-	// round if enough have been received.
-	Accept(View) error
+	// Abort cancels the round in progress for the given candidate and moves
+	// the state machine back to the initial state. A round that is already
+	// committed cannot be aborted.
+	Abort(types.Digest) error
+
+	// Vote verifies the view of a participant that requests the agreement of
+	// the node to a view change, and returns the view of the node for the same
+	// leader if the round has expired for the node too. It never moves the
+	// state machine, which requires a certificate.
+	Vote(View) (View, error)
 
 	// AcceptAll processes the list of views so that it may proceed to a future
 	// round if the list contains enough valid views.
 	AcceptAll([]View) error
 
+	// AcceptCertified processes a view for the next leader that comes with the
+	// proof that a quorum agreed to it, and moves to the new round right away.
+	AcceptCertified(View, types.ViewCertificate) error
+
 	// Expire announces that the round has expired and moves the state machine
 	// to a view change state.
 	Expire(addr mino.Address) (View, error)
@@ -154,6 +166,64 @@ type StateMachine interface {
 	Watch(context.Context) <-chan State
 }
 
+// ErrSealed is the error returned when a block is proposed after the terminal
+// block of the chain.
+var ErrSealed = xerrors.New("chain sealed")
+
+// ErrEquivocation is the error returned when a leader proposes a block that is
+// different from the one accepted for the round.
+//
+// There is deliberately no tie-break between two proposals of a round. The
+// followers sign the proposal they prepare, therefore moving to another one
+// would let an equivocating leader, or a participant claiming a better rank,
+// collect two prepare certificates for the same round. Simultaneous proposals
+// are instead resolved by the leader of the view: every follower accepts only
+// its proposal, and a leader that equivocates is replaced by a view change.
+var ErrEquivocation = xerrors.New("equivocation")
+
+// IsSealed returns true if the last block of the store is a terminal block,
+// which means that the chain does not accept new blocks.
+func IsSealed(blocks blockstore.BlockStore) (bool, error) {
+	if blocks.Len() == 0 {
+		return false, nil
+	}
+
+	last, err := blocks.Last()
+	if err != nil {
+		return false, xerrors.Errorf("couldn't read last block: %v", err)
+	}
+
+	return last.GetBlock().IsTerminal(), nil
+}
+
+// TransientError is the error returned when an operation failed for a reason
+// that might disappear when retrying, like a failure of the storage.
+type TransientError struct {
+	err error
+}
+
+// NewTransientError wraps the error to mark it as transient.
+func NewTransientError(err error) TransientError {
+	return TransientError{err: err}
+}
+
+// Error implements error. It returns the message of the underlying error.
+func (e TransientError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e TransientError) Unwrap() error {
+	return e.err
+}
+
+// IsTransient returns true if the error is transient, which means the
+// operation can be retried.
+func IsTransient(err error) bool {
+	var terr TransientError
+	return xerrors.As(err, &terr)
+}
+
 type round struct {
 	leader     uint16
 	threshold  int
@@ -165,6 +235,8 @@ type round struct {
 	committed  bool
 	prevViews  map[mino.Address]View
 	views      map[mino.Address]View
+	// vote is the view of the node when the round has expired.
+	vote *View
 
 	// allows a node to catch up on a new leader
 	tentativeRound  types.Digest
@@ -193,6 +265,17 @@ type pbftsm struct {
 
 	// verifierFac creates a verifier for the aggregated signature.
 	verifierFac crypto.VerifierFactory
+	// encoding returns the representation of the prepare signature that is
+	// signed in the commit phase.
+	encoding types.SignatureEncoding
+	// version is the signing protocol that defines the messages signed during
+	// the prepare and the commit phases.
+	version types.ProtocolVersion
+	// indexTxs is true when the transactions are indexed by identity in the
+	// tree.
+	indexTxs bool
+	// election is the rule that elects the leader after a view change.
+	election LeaderElection
 	// signer signs and verify single signature for the view change.
 	signer crypto.Signer
 
@@ -212,6 +295,23 @@ type StateMachineParam struct {
 	Tree            blockstore.TreeCache
 	AuthorityReader AuthorityReader
 	DB              kv.DB
+
+	// CommitEncoding is the encoding of the prepare signature that is signed
+	// in the commit phase. It is optional and defaults to the binary format of
+	// the signature.
+	CommitEncoding types.SignatureEncoding
+
+	// ProtocolVersion is the version of the signing protocol. It defaults to
+	// the initial version.
+	ProtocolVersion types.ProtocolVersion
+
+	// IndexTransactions enables the index of the transactions by identity in
+	// the tree.
+	IndexTransactions bool
+
+	// LeaderElection is the rule that elects the leader of the next view. It
+	// defaults to the round-robin over the roster.
+	LeaderElection LeaderElection
 }
 
 // NewStateMachine returns a new state machine.
@@ -229,6 +329,10 @@ func NewStateMachine(param StateMachineParam) StateMachine {
 		db:          param.DB,
 		state:       NoneState,
 		authReader:  param.AuthorityReader,
+		encoding:    param.CommitEncoding,
+		version:     param.ProtocolVersion,
+		indexTxs:    param.IndexTransactions,
+		election:    param.LeaderElection,
 	}
 }
 
@@ -317,8 +421,14 @@ func (m *pbftsm) Prepare(from mino.Address, block types.Block) (types.Digest, er
 	// Check the state after verifying that the proposal comes from the right
 	// leader.
 	if m.state == PrepareState || m.state == CommitState {
-		// The leader should only propose one block, therefore the accepted
-		// proposal identifier is sent back, whatever the input is.
+		// The leader should only propose one block, therefore a re-send of the
+		// accepted proposal gets the same identifier back, whereas a different
+		// proposal is an equivocation of the leader.
+		if !block.Equal(m.round.block) {
+			return id, xerrors.Errorf("proposal '%v' differs from '%v': %w",
+				block.GetHash(), m.round.block.GetHash(), ErrEquivocation)
+		}
+
 		return id, nil
 	}
 
@@ -390,6 +500,7 @@ func (m *pbftsm) Finalize(id types.Digest, sig crypto.Signature) error {
 
 	m.round.prevViews = nil
 	m.round.views = nil
+	m.round.vote = nil
 	m.round.committed = false
 
 	m.setState(InitialState)
@@ -397,20 +508,75 @@ func (m *pbftsm) Finalize(id types.Digest, sig crypto.Signature) error {
 	return nil
 }
 
-// Accept implements pbft.StateMachine. It processes view change messages and
-// reset the current PBFT if it receives enough of them.
-func (m *pbftsm) Accept(view View) error {
+// Abort implements pbft.StateMachine. It discards the candidate and the partial
+// signatures of the round if the identifier matches, so that a new candidate
+// can be prepared.
+func (m *pbftsm) Abort(id types.Digest) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.state != PrepareState {
+		return xerrors.Errorf("cannot abort from %v state", m.state)
+	}
+
+	if id != m.round.id {
+		return xerrors.Errorf("mismatch id '%v' != '%v'", id, m.round.id)
+	}
+
+	m.logger.Info().Stringer("id", id).Msg("round aborted")
+
+	m.round.id = types.Digest{}
+	m.round.block = types.Block{}
+	m.round.tree = nil
+	m.round.prepareSig = nil
+	m.round.changeset = nil
+
+	m.setState(InitialState)
+
+	return nil
+}
+
+// Vote implements pbft.StateMachine. It verifies the view of a participant and
+// returns the view of the node for the same leader, which exists only if the
+// node has expired the round too.
+func (m *pbftsm) Vote(view View) (View, error) {
 	m.Lock()
 	defer m.Unlock()
 
 	_, err := m.init()
+	if err != nil {
+		return View{}, xerrors.Errorf("init: %v", err)
+	}
+
+	err = m.verifyViews(false, view)
+	if err != nil {
+		return View{}, xerrors.Errorf("invalid view: %v", err)
+	}
+
+	if m.state != ViewChangeState || m.round.vote == nil {
+		return View{}, xerrors.New("round has not expired")
+	}
+
+	m.logger.Trace().
+		Str("from", view.from.String()).
+		Msg("view voted")
+
+	return *m.round.vote, nil
+}
+
+// AcceptCertified implements pbft.StateMachine. It verifies the view and its
+// certificate before moving to the new round. The certificate is only valid for
+// the next leader of the latest block so that it can't be replayed later.
+func (m *pbftsm) AcceptCertified(view View, cert types.ViewCertificate) error {
+	m.Lock()
+	defer m.Unlock()
+
+	roster, err := m.init()
 	if err != nil {
 		return xerrors.Errorf("init: %v", err)
 	}
 
 	if view.leader == m.round.leader {
-		// Ignore view coming for the current leader as we already accepted this
-		// leader.
 		return nil
 	}
 
@@ -419,17 +585,27 @@ func (m *pbftsm) Accept(view View) error {
 		return xerrors.Errorf("invalid view: %v", err)
 	}
 
-	if m.round.views == nil {
-		m.round.views = make(map[mino.Address]View)
+	// The views are signed individually by the signers of the participants, so
+	// the certificate is an aggregate of such signatures.
+	signer, ok := m.signer.(crypto.AggregateSigner)
+	if !ok {
+		return xerrors.Errorf("signer '%T' can't aggregate", m.signer)
+	}
+
+	err = view.VerifyCertificate(cert, roster, signer.GetVerifierFactory())
+	if err != nil {
+		return xerrors.Errorf("invalid certificate: %v", err)
 	}
 
 	m.logger.Trace().
 		Str("from", view.from.String()).
-		Msg("view accepted")
+		Uint16("leader", view.leader).
+		Msg("certified view accepted")
 
-	m.round.views[view.from] = view
-
-	m.checkViewChange(view)
+	// The individual views are unknown, so there is none to forward to the
+	// participants that fall behind.
+	m.round.views = nil
+	m.changeView(nil, view.leader)
 
 	return nil
 }
@@ -490,7 +666,12 @@ func (m *pbftsm) verifyViews(skip bool, views ...View) error {
 			return xerrors.Errorf("invalid signature: %v", err)
 		}
 
-		nextLeader := (m.round.leader + 1) % uint16(roster.Len())
+		latestID, err := m.getLatestID()
+		if err != nil {
+			return xerrors.Errorf("failed to read latest id: %v", err)
+		}
+
+		nextLeader := m.election.next(m.round.leader, latestID, roster.Len())
 		if !skip && view.leader != nextLeader {
 			// The state machine ignore view messages from different rounds. It only
 			// accepts views for the next leader even if the state machine is not in
@@ -501,11 +682,6 @@ func (m *pbftsm) verifyViews(skip bool, views ...View) error {
 			return xerrors.Errorf("mismatch leader %d != %d", view.leader, nextLeader)
 		}
 
-		latestID, err := m.getLatestID()
-		if err != nil {
-			return xerrors.Errorf("failed to read latest id: %v", err)
-		}
-
 		if view.id != latestID {
 			return xerrors.Errorf("mismatch id %v != %v", view.id, latestID)
 		}
@@ -533,7 +709,7 @@ func (m *pbftsm) Expire(addr mino.Address) (View, error) {
 		return View{}, xerrors.Errorf("couldn't get latest digest: %v", err)
 	}
 
-	newLeader := (m.round.leader + 1) % uint16(roster.Len())
+	newLeader := m.election.next(m.round.leader, lastID, roster.Len())
 
 	param := ViewParam{
 		From:   addr,
@@ -546,15 +722,9 @@ func (m *pbftsm) Expire(addr mino.Address) (View, error) {
 		return view, xerrors.Errorf("create view: %v", err)
 	}
 
-	m.setState(ViewChangeState)
-
-	if m.round.views == nil {
-		m.round.views = make(map[mino.Address]View)
-	}
-
-	m.round.views[addr] = view
+	m.round.vote = &view
 
-	m.checkViewChange(view)
+	m.setState(ViewChangeState)
 
 	return view, nil
 }
@@ -600,6 +770,7 @@ func (m *pbftsm) CatchUp(link types.BlockLink) error {
 
 	m.round.views = nil
 	m.round.prevViews = nil
+	m.round.vote = nil
 	m.setState(InitialState)
 
 	return nil
@@ -632,6 +803,13 @@ func (m *pbftsm) verifyPrepare(tree hashtree.Tree, block types.Block, r *round,
 			return xerrors.Errorf("validation failed: %v", err)
 		}
 
+		if m.indexTxs {
+			err = txindex.Update(snap, block.GetIndex(), txs)
+			if err != nil {
+				return xerrors.Errorf("couldn't index transactions: %v", err)
+			}
+		}
+
 		for _, r := range res.GetTransactionResults() {
 			accepted, reason := r.GetStatus()
 			if !accepted {
@@ -657,6 +835,15 @@ func (m *pbftsm) verifyPrepare(tree hashtree.Tree, block types.Block, r *round,
 		return xerrors.Errorf("mismatch tree root '%v' != '%v'", root, block.GetTreeRoot())
 	}
 
+	sealed, err := IsSealed(m.blocks)
+	if err != nil {
+		return xerrors.Errorf("sealed: %v", err)
+	}
+
+	if sealed {
+		return ErrSealed
+	}
+
 	if m.blocks.Len() != block.GetIndex() {
 		return xerrors.Errorf("mismatch index %d != %d", block.GetIndex(), m.blocks.Len())
 	}
@@ -673,6 +860,20 @@ func (m *pbftsm) verifyPrepare(tree hashtree.Tree, block types.Block, r *round,
 		return xerrors.Errorf("failed to read next roster: %v", err)
 	}
 
+	if block.GetRosterDigest() != (types.Digest{}) {
+		// The roster embedded in the block must be the one stored in the tree
+		// once the block is applied.
+		digest, err := types.RosterDigest(roster, m.hashFac)
+		if err != nil {
+			return xerrors.Errorf("roster digest failed: %v", err)
+		}
+
+		if digest != block.GetRosterDigest() {
+			return xerrors.Errorf("mismatch roster digest '%v' != '%v'",
+				digest, block.GetRosterDigest())
+		}
+	}
+
 	changeset := ro.Diff(roster)
 	opts := []types.LinkOption{
 		types.WithChangeSet(changeset),
@@ -698,7 +899,12 @@ func (m *pbftsm) verifyCommit(r *round, sig crypto.Signature, ro authority.Autho
 		return xerrors.Errorf("couldn't make verifier: %v", err)
 	}
 
-	err = verifier.Verify(r.id[:], sig)
+	chainID, err := m.getChainID()
+	if err != nil {
+		return xerrors.Errorf("couldn't get chain: %v", err)
+	}
+
+	err = m.version.VerifyPrepare(chainID, r.id, sig, verifier)
 	if err != nil {
 		return xerrors.Errorf("verifier failed: %v", err)
 	}
@@ -708,18 +914,38 @@ func (m *pbftsm) verifyCommit(r *round, sig crypto.Signature, ro authority.Autho
 	return nil
 }
 
+// getChainID returns the digest of the genesis block when the signing protocol
+// binds the signatures to the chain, otherwise an empty digest.
+func (m *pbftsm) getChainID() (types.Digest, error) {
+	if m.version < types.ProtocolV1 {
+		return types.Digest{}, nil
+	}
+
+	genesis, err := m.genesis.Get()
+	if err != nil {
+		return types.Digest{}, xerrors.Errorf("read genesis: %v", err)
+	}
+
+	return genesis.GetHash(), nil
+}
+
 func (m *pbftsm) verifyFinalize(r *round, sig crypto.Signature, ro authority.Authority) error {
 	verifier, err := m.verifierFac.FromAuthority(ro)
 	if err != nil {
 		return xerrors.Errorf("couldn't make verifier: %v", err)
 	}
 
-	buffer, err := r.prepareSig.MarshalBinary()
+	buffer, err := m.encoding.Encode(r.prepareSig)
 	if err != nil {
 		return xerrors.Errorf("couldn't marshal signature: %v", err)
 	}
 
-	err = verifier.Verify(buffer, sig)
+	chainID, err := m.getChainID()
+	if err != nil {
+		return xerrors.Errorf("couldn't get chain: %v", err)
+	}
+
+	err = verifier.Verify(m.version.CommitMessage(chainID, buffer), sig)
 	if err != nil {
 		return xerrors.Errorf("verifier failed: %v", err)
 	}
@@ -729,6 +955,10 @@ func (m *pbftsm) verifyFinalize(r *round, sig crypto.Signature, ro authority.Aut
 		return xerrors.Errorf("couldn't get latest digest: %v", err)
 	}
 
+	// Set when the failure does not come from the storage, in which case
+	// retrying would fail the same way.
+	permanent := false
+
 	// Persist to the database in a transaction so that it can revert to the
 	// previous state for either the tree or the block if something goes wrong.
 	err = m.db.Update(func(txn kv.WritableTx) error {
@@ -755,6 +985,7 @@ func (m *pbftsm) verifyFinalize(r *round, sig crypto.Signature, ro authority.Aut
 
 		link, err := types.NewBlockLink(lastID, r.block, opts...)
 		if err != nil {
+			permanent = true
 			return xerrors.Errorf("creating link: %v", err)
 		}
 
@@ -775,7 +1006,13 @@ func (m *pbftsm) verifyFinalize(r *round, sig crypto.Signature, ro authority.Aut
 	})
 
 	if err != nil {
-		return xerrors.Errorf("database failed: %v", err)
+		err = xerrors.Errorf("database failed: %v", err)
+		if permanent {
+			return err
+		}
+
+		// The round is left untouched so that the finalization can be retried.
+		return NewTransientError(err)
 	}
 
 	return nil
@@ -805,15 +1042,22 @@ func (m *pbftsm) setState(s State) {
 
 func (m *pbftsm) checkViewChange(view View) {
 	if m.state == ViewChangeState && len(m.round.views) > m.round.threshold {
-		m.round.prevViews = m.round.views
+		views := m.round.views
 		m.round.views = nil
-		m.round.leader = view.leader
 
-		if m.round.committed {
-			m.setState(CommitState)
-		} else {
-			m.setState(InitialState)
-		}
+		m.changeView(views, view.leader)
+	}
+}
+
+func (m *pbftsm) changeView(views map[mino.Address]View, leader uint16) {
+	m.round.prevViews = views
+	m.round.leader = leader
+	m.round.vote = nil
+
+	if m.round.committed {
+		m.setState(CommitState)
+	} else {
+		m.setState(InitialState)
 	}
 }
 
@@ -844,10 +1088,10 @@ func (obs observer) NotifyCallback(event interface{}) {
 }
 
 // CalculateThreshold returns the number of messages that a node needs to
-// receive before confirming the view change. The threshold is 2*f where f can
-// be found with n = 3*f+1 where n is the number of participants.
+// receive before confirming the view change. The threshold is 2*f where f is
+// the number of faulty participants tolerated by the quorum of the roster.
 func calculateThreshold(n int) int {
-	f := (n - 1) / 3
+	f := n - authority.QuorumThreshold(n)
 	if f == 0 {
 		return n
 	}
@@ -2,6 +2,7 @@ package pbft
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -23,6 +24,7 @@ import (
 	"go.dedis.ch/dela/crypto/bls"
 	"go.dedis.ch/dela/internal/testing/fake"
 	"go.dedis.ch/dela/mino"
+	"golang.org/x/xerrors"
 )
 
 func TestState_String(t *testing.T) {
@@ -137,6 +139,105 @@ func TestStateMachine_Prepare(t *testing.T) {
 	require.Equal(t, sm.round.id, id)
 }
 
+func TestStateMachine_Equivocation_Prepare(t *testing.T) {
+	tree, _, clean := makeTree(t)
+	defer clean()
+
+	proposal, err := types.NewBlock(simple.NewResult(nil), types.WithIndex(0))
+	require.NoError(t, err)
+
+	other, err := types.NewBlock(simple.NewResult(nil), types.WithIndex(1))
+	require.NoError(t, err)
+
+	for _, state := range []State{PrepareState, CommitState} {
+		sm := &pbftsm{
+			state:      state,
+			authReader: goodReader,
+			tree:       blockstore.NewTreeCache(tree),
+			round: round{
+				id:    types.Digest{1},
+				block: proposal,
+			},
+		}
+
+		// A re-send of the same proposal is idempotent.
+		id, err := sm.Prepare(fake.NewAddress(0), proposal)
+		require.NoError(t, err)
+		require.Equal(t, types.Digest{1}, id)
+		require.Equal(t, state, sm.state)
+
+		_, err = sm.Prepare(fake.NewAddress(0), other)
+		require.True(t, xerrors.Is(err, ErrEquivocation))
+		require.EqualError(t, err, fmt.Sprintf("proposal '%v' differs from '%v': equivocation",
+			other.GetHash(), proposal.GetHash()))
+		require.Equal(t, state, sm.state)
+		require.Equal(t, proposal, sm.round.block)
+	}
+}
+
+func TestStateMachine_SimultaneousProposals_Prepare(t *testing.T) {
+	ro := authority.FromAuthority(fake.NewAuthority(3, fake.NewSigner))
+
+	newFollower := func() *pbftsm {
+		tree, db, clean := makeTree(t)
+		t.Cleanup(clean)
+
+		param := StateMachineParam{
+			Validation: simple.NewService(fakeExec{}, nil),
+			Blocks:     blockstore.NewInMemory(),
+			Genesis:    blockstore.NewGenesisStore(),
+			Tree:       blockstore.NewTreeCache(tree),
+			AuthorityReader: func(hashtree.Tree) (authority.Authority, error) {
+				return ro, nil
+			},
+			DB: db,
+		}
+
+		param.Genesis.Set(types.Genesis{})
+
+		sm := NewStateMachine(param).(*pbftsm)
+		sm.state = InitialState
+
+		return sm
+	}
+
+	tree, _, clean := makeTree(t)
+	defer clean()
+
+	root := types.Digest{}
+	copy(root[:], tree.GetRoot())
+
+	// Both participants believe they lead the round, but only the first one
+	// is the leader of the view.
+	leader, err := types.NewBlock(simple.NewResult(nil), types.WithTreeRoot(root))
+	require.NoError(t, err)
+
+	racer, err := types.NewBlock(simple.NewResult(nil), types.WithTreeRoot(root),
+		types.WithExtraData([]byte("racer")))
+	require.NoError(t, err)
+
+	first := newFollower()
+
+	_, err = first.Prepare(fake.NewAddress(0), leader)
+	require.NoError(t, err)
+
+	_, err = first.Prepare(fake.NewAddress(1), racer)
+	require.EqualError(t, err, fmt.Sprintf("'%v' is not the leader", fake.NewAddress(1)))
+
+	second := newFollower()
+
+	_, err = second.Prepare(fake.NewAddress(1), racer)
+	require.EqualError(t, err, fmt.Sprintf("'%v' is not the leader", fake.NewAddress(1)))
+
+	_, err = second.Prepare(fake.NewAddress(0), leader)
+	require.NoError(t, err)
+
+	// The followers converge on the proposal of the leader whatever the order
+	// of arrival.
+	require.Equal(t, leader, first.round.block)
+	require.Equal(t, first.round.id, second.round.id)
+}
+
 func TestStateMachine_WhileViewChange_Prepare(t *testing.T) {
 	sm := &pbftsm{
 		state: ViewChangeState,
@@ -288,6 +389,136 @@ func TestStateMachine_FailReadRosterInStageTree_Prepare(t *testing.T) {
 	require.EqualError(t, err, fake.Err("failed to read next roster"))
 }
 
+func TestStateMachine_RosterDigest_Prepare(t *testing.T) {
+	tree, db, clean := makeTree(t)
+	defer clean()
+
+	ro := authority.FromAuthority(fake.NewAuthority(3, fake.NewSigner))
+
+	sm := &pbftsm{
+		state: InitialState,
+		val:   simple.NewService(fakeExec{}, nil),
+		tree:  blockstore.NewTreeCache(tree),
+		db:    db,
+		authReader: func(hashtree.Tree) (authority.Authority, error) {
+			return ro, nil
+		},
+		genesis: blockstore.NewGenesisStore(),
+		blocks:  blockstore.NewInMemory(),
+		hashFac: crypto.NewSha256Factory(),
+		watcher: core.NewWatcher(),
+	}
+
+	sm.genesis.Set(types.Genesis{})
+
+	root := types.Digest{}
+	copy(root[:], tree.GetRoot())
+
+	block, err := types.NewBlock(simple.NewResult(nil), types.WithTreeRoot(root),
+		types.WithRosterDigest(types.Digest{1}))
+	require.NoError(t, err)
+
+	_, err = sm.Prepare(fake.NewAddress(0), block)
+	require.Error(t, err)
+	require.Regexp(t, "^mismatch roster digest '[0-9a-f]{8}' != '01000000'$", err.Error())
+
+	digest, err := types.RosterDigest(ro, sm.hashFac)
+	require.NoError(t, err)
+
+	block, err = types.NewBlock(simple.NewResult(nil), types.WithTreeRoot(root),
+		types.WithRosterDigest(digest))
+	require.NoError(t, err)
+
+	_, err = sm.Prepare(fake.NewAddress(0), block)
+	require.NoError(t, err)
+	require.Equal(t, PrepareState, sm.state)
+
+	sm.state = InitialState
+	sm.hashFac = fake.NewHashFactory(fake.NewBadHash())
+	_, err = sm.Prepare(fake.NewAddress(0), block)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "roster digest failed: couldn't write member: ")
+}
+
+func TestStateMachine_Sealed_Prepare(t *testing.T) {
+	tree, db, clean := makeTree(t)
+	defer clean()
+
+	ro := authority.FromAuthority(fake.NewAuthority(3, fake.NewSigner))
+
+	sm := &pbftsm{
+		state: InitialState,
+		val:   simple.NewService(fakeExec{}, nil),
+		tree:  blockstore.NewTreeCache(tree),
+		db:    db,
+		authReader: func(hashtree.Tree) (authority.Authority, error) {
+			return ro, nil
+		},
+		genesis: blockstore.NewGenesisStore(),
+		blocks:  blockstore.NewInMemory(),
+		hashFac: crypto.NewSha256Factory(),
+		watcher: core.NewWatcher(),
+	}
+
+	sm.genesis.Set(types.Genesis{})
+
+	root := types.Digest{}
+	copy(root[:], tree.GetRoot())
+
+	terminal, err := types.NewBlock(simple.NewResult(nil), types.WithTreeRoot(root),
+		types.WithTerminal())
+	require.NoError(t, err)
+
+	link, err := types.NewBlockLink(types.Digest{}, terminal)
+	require.NoError(t, err)
+	require.NoError(t, sm.blocks.Store(link))
+
+	block, err := types.NewBlock(simple.NewResult(nil), types.WithTreeRoot(root),
+		types.WithIndex(1))
+	require.NoError(t, err)
+
+	_, err = sm.Prepare(fake.NewAddress(0), block)
+	require.Equal(t, ErrSealed, err)
+
+	sm.blocks = badBlockStore{length: 1}
+	_, err = sm.Prepare(fake.NewAddress(0), block)
+	require.EqualError(t, err, fake.Err("sealed: couldn't read last block"))
+}
+
+func TestIsSealed(t *testing.T) {
+	blocks := blockstore.NewInMemory()
+
+	sealed, err := IsSealed(blocks)
+	require.NoError(t, err)
+	require.False(t, sealed)
+
+	block, err := types.NewBlock(simple.NewResult(nil))
+	require.NoError(t, err)
+
+	link, err := types.NewBlockLink(types.Digest{}, block)
+	require.NoError(t, err)
+	require.NoError(t, blocks.Store(link))
+
+	sealed, err = IsSealed(blocks)
+	require.NoError(t, err)
+	require.False(t, sealed)
+
+	terminal, err := types.NewBlock(simple.NewResult(nil), types.WithIndex(1),
+		types.WithTerminal())
+	require.NoError(t, err)
+
+	link, err = types.NewBlockLink(block.GetHash(), terminal)
+	require.NoError(t, err)
+	require.NoError(t, blocks.Store(link))
+
+	sealed, err = IsSealed(blocks)
+	require.NoError(t, err)
+	require.True(t, sealed)
+
+	_, err = IsSealed(badBlockStore{length: 1})
+	require.EqualError(t, err, fake.Err("couldn't read last block"))
+}
+
 func TestStateMachine_FailCreateLink_Prepare(t *testing.T) {
 	tree, db, clean := makeTree(t)
 	defer clean()
@@ -336,6 +567,62 @@ func TestStateMachine_Commit(t *testing.T) {
 	require.NotNil(t, sm.round.prepareSig)
 }
 
+func TestStateMachine_ProtocolVersion_Commit(t *testing.T) {
+	signer := bls.NewSigner()
+
+	ro := authority.New([]mino.Address{fake.NewAddress(0)}, []crypto.PublicKey{signer.GetPublicKey()})
+
+	genesisA, err := types.NewGenesis(ro, types.WithGenesisRoot(types.Digest{1}))
+	require.NoError(t, err)
+
+	genesisB, err := types.NewGenesis(ro, types.WithGenesisRoot(types.Digest{2}))
+	require.NoError(t, err)
+
+	id := types.Digest{1}
+
+	sigA, err := signer.Sign(types.ProtocolV1.PrepareMessage(genesisA.GetHash(), id))
+	require.NoError(t, err)
+
+	sigB, err := signer.Sign(types.ProtocolV1.PrepareMessage(genesisB.GetHash(), id))
+	require.NoError(t, err)
+
+	newSM := func(genesis *types.Genesis) *pbftsm {
+		store := blockstore.NewGenesisStore()
+		if genesis != nil {
+			require.NoError(t, store.Set(*genesis))
+		}
+
+		return &pbftsm{
+			state:       PrepareState,
+			verifierFac: signer.GetVerifierFactory(),
+			watcher:     core.NewWatcher(),
+			tree:        blockstore.NewTreeCache(badTree{}),
+			genesis:     store,
+			version:     types.ProtocolV1,
+			authReader: func(hashtree.Tree) (authority.Authority, error) {
+				return ro, nil
+			},
+			round: round{
+				id: id,
+			},
+		}
+	}
+
+	sm := newSM(&genesisA)
+	err = sm.Commit(id, sigA)
+	require.NoError(t, err)
+
+	// The signature of a different chain is rejected.
+	sm = newSM(&genesisA)
+	err = sm.Commit(id, sigB)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "verifier failed: ")
+
+	sm = newSM(nil)
+	err = sm.Commit(id, sigA)
+	require.EqualError(t, err, "couldn't get chain: read genesis: missing genesis block")
+}
+
 func TestStateMachine_WhileViewChange_Commit(t *testing.T) {
 	sm := &pbftsm{
 		state: ViewChangeState,
@@ -467,6 +754,24 @@ func TestStateMachine_WrongSignature_Finalize(t *testing.T) {
 	require.EqualError(t, err, fake.Err("verifier failed"))
 }
 
+func TestStateMachine_BadEncoding_Finalize(t *testing.T) {
+	sm := &pbftsm{
+		state:       CommitState,
+		tree:        blockstore.NewTreeCache(badTree{}),
+		authReader:  goodReader,
+		verifierFac: fake.NewVerifierFactory(fake.Verifier{}),
+		encoding: func(crypto.Signature) ([]byte, error) {
+			return nil, fake.GetError()
+		},
+		round: round{
+			prepareSig: fake.Signature{},
+		},
+	}
+
+	err := sm.Finalize(types.Digest{}, fake.Signature{})
+	require.EqualError(t, err, fake.Err("couldn't marshal signature"))
+}
+
 func TestStateMachine_MissingGenesis_Finalize(t *testing.T) {
 	sm := &pbftsm{
 		state:       CommitState,
@@ -523,6 +828,7 @@ func TestStateMachine_FailCommitTree_Finalize(t *testing.T) {
 
 	err := sm.Finalize(types.Digest{}, fake.Signature{})
 	require.EqualError(t, err, fake.Err("database failed: while committing tree"))
+	require.True(t, IsTransient(err))
 }
 
 func TestStateMachine_FailCreateLink_Finalize(t *testing.T) {
@@ -549,6 +855,7 @@ func TestStateMachine_FailCreateLink_Finalize(t *testing.T) {
 	err := sm.Finalize(types.Digest{1}, fake.Signature{})
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "database failed: creating link:")
+	require.False(t, IsTransient(err))
 }
 
 func TestStateMachine_FailStoreBlock_Finalize(t *testing.T) {
@@ -574,13 +881,76 @@ func TestStateMachine_FailStoreBlock_Finalize(t *testing.T) {
 
 	err := sm.Finalize(types.Digest{1}, fake.Signature{})
 	require.EqualError(t, err, fake.Err("database failed: store block"))
+	require.True(t, IsTransient(err))
+}
+
+func TestStateMachine_Abort(t *testing.T) {
+	tree, db, clean := makeTree(t)
+	defer clean()
+
+	ro := authority.FromAuthority(fake.NewAuthority(3, fake.NewSigner))
+
+	param := StateMachineParam{
+		Validation:      simple.NewService(fakeExec{}, nil),
+		VerifierFactory: fake.NewVerifierFactory(fake.Verifier{}),
+		Blocks:          blockstore.NewInMemory(),
+		Genesis:         blockstore.NewGenesisStore(),
+		Tree:            blockstore.NewTreeCache(tree),
+		AuthorityReader: func(hashtree.Tree) (authority.Authority, error) {
+			return ro, nil
+		},
+		DB: db,
+	}
+
+	param.Genesis.Set(types.Genesis{})
+
+	root := types.Digest{}
+	copy(root[:], tree.GetRoot())
+
+	block, err := types.NewBlock(simple.NewResult(nil), types.WithTreeRoot(root))
+	require.NoError(t, err)
+
+	sm := NewStateMachine(param).(*pbftsm)
+	sm.state = InitialState
+
+	err = sm.Abort(types.Digest{})
+	require.EqualError(t, err, "cannot abort from initial state")
+
+	id, err := sm.Prepare(fake.NewAddress(0), block)
+	require.NoError(t, err)
+
+	err = sm.Abort(types.Digest{1})
+	require.EqualError(t, err, fmt.Sprintf("mismatch id '%v' != '%v'", types.Digest{1}, id))
+
+	err = sm.Abort(id)
+	require.NoError(t, err)
+	require.Equal(t, InitialState, sm.state)
+	require.Equal(t, types.Digest{}, sm.round.id)
+	require.Nil(t, sm.round.tree)
+	require.Nil(t, sm.round.prepareSig)
+
+	// A fresh round can start after the abort.
+	other, err := types.NewBlock(simple.NewResult(nil), types.WithTreeRoot(root),
+		types.WithHashFactory(fake.NewHashFactory(&fake.Hash{})))
+	require.NoError(t, err)
+
+	next, err := sm.Prepare(fake.NewAddress(0), other)
+	require.NoError(t, err)
+	require.NotEqual(t, id, next)
+	require.Equal(t, PrepareState, sm.state)
+
+	err = sm.Commit(next, fake.Signature{})
+	require.NoError(t, err)
+
+	err = sm.Abort(next)
+	require.EqualError(t, err, "cannot abort from commit state")
 }
 
-func TestStateMachine_Accept(t *testing.T) {
+func TestStateMachine_Vote(t *testing.T) {
 	ro := authority.FromAuthority(fake.NewAuthority(4, fake.NewSigner))
 
 	sm := &pbftsm{
-		state:   ViewChangeState,
+		state:   InitialState,
 		blocks:  blockstore.NewInMemory(),
 		genesis: blockstore.NewGenesisStore(),
 		watcher: core.NewWatcher(),
@@ -594,51 +964,40 @@ func TestStateMachine_Accept(t *testing.T) {
 	sm.genesis.Set(types.Genesis{})
 	sm.round.threshold = 2
 
-	err := sm.Accept(View{from: fake.NewAddress(0), leader: 1})
-	require.NoError(t, err)
-	require.Equal(t, 2, sm.round.threshold)
-	require.Len(t, sm.round.views, 1)
+	// The node doesn't vote while its round has not expired.
+	_, err := sm.Vote(View{from: fake.NewAddress(1), leader: 1})
+	require.EqualError(t, err, "round has not expired")
 
-	err = sm.Accept(View{from: fake.NewAddress(1), leader: 1})
+	own, err := sm.Expire(fake.NewAddress(0))
 	require.NoError(t, err)
-	require.Len(t, sm.round.views, 2)
 
-	// Ignore view for the same leader.
-	err = sm.Accept(View{from: fake.NewAddress(2), leader: 0})
+	vote, err := sm.Vote(View{from: fake.NewAddress(1), leader: 1})
 	require.NoError(t, err)
-	require.Len(t, sm.round.views, 2)
+	require.Equal(t, own, vote)
 
-	// Ignore duplicate.
-	err = sm.Accept(View{from: fake.NewAddress(0), leader: 1})
-	require.NoError(t, err)
-	require.Len(t, sm.round.views, 2)
+	// A vote never moves the state machine, whatever the number of views.
+	for i := 2; i < ro.Len(); i++ {
+		_, err = sm.Vote(View{from: fake.NewAddress(i), leader: 1})
+		require.NoError(t, err)
+	}
 
-	// Finalize view change from a commit state
-	sm.round.committed = true
-	err = sm.Accept(View{from: fake.NewAddress(3), leader: 1})
-	require.NoError(t, err)
-	require.Equal(t, CommitState, sm.state)
+	require.Equal(t, ViewChangeState, sm.state)
+	require.Equal(t, uint16(0), sm.round.leader)
 
 	// Ignore views for a different leader than the next one.
-	err = sm.Accept(View{from: fake.NewAddress(2), leader: 5})
-	require.EqualError(t, err, "invalid view: mismatch leader 5 != 2")
-	require.Len(t, sm.round.views, 0)
-
-	sm.genesis = blockstore.NewGenesisStore()
-	err = sm.Accept(View{from: fake.NewAddress(0), leader: 2})
-	require.EqualError(t, err, "invalid view: failed to read latest id: missing genesis block")
+	_, err = sm.Vote(View{from: fake.NewAddress(2), leader: 5})
+	require.EqualError(t, err, "invalid view: mismatch leader 5 != 1")
 
 	// Only accept views for the current round ID.
-	sm.genesis.Set(types.Genesis{})
-	err = sm.Accept(View{from: fake.NewAddress(3), leader: 2, id: types.Digest{1}})
+	_, err = sm.Vote(View{from: fake.NewAddress(3), leader: 1, id: types.Digest{1}})
 	require.EqualError(t, err, "invalid view: mismatch id 01000000 != 00000000")
 
-	sm.authReader = badReader
-	err = sm.Accept(View{leader: 2})
-	require.EqualError(t, err, fake.Err("init: failed to read roster"))
+	sm.genesis = blockstore.NewGenesisStore()
+	_, err = sm.Vote(View{from: fake.NewAddress(0), leader: 1})
+	require.EqualError(t, err, "invalid view: failed to read latest id: missing genesis block")
 
 	// Ignore view with an invalid signature.
-	sm.state = InitialState
+	sm.genesis.Set(types.Genesis{})
 	sm.authReader = func(hashtree.Tree) (authority.Authority, error) {
 		ro := authority.New(
 			[]mino.Address{fake.NewAddress(0)},
@@ -646,8 +1005,95 @@ func TestStateMachine_Accept(t *testing.T) {
 		)
 		return ro, nil
 	}
-	err = sm.Accept(View{from: fake.NewAddress(0), leader: 2})
+	_, err = sm.Vote(View{from: fake.NewAddress(0), leader: 1})
 	require.EqualError(t, err, fake.Err("invalid view: invalid signature: verify"))
+
+	sm.state = NoneState
+	sm.authReader = badReader
+	_, err = sm.Vote(View{leader: 1})
+	require.EqualError(t, err, fake.Err("init: failed to read roster"))
+}
+
+func TestStateMachine_AcceptCertified(t *testing.T) {
+	ca := fake.NewAuthority(4, bls.Generate)
+	ro := authority.FromAuthority(ca)
+
+	signer := ca.GetSigner(0).(crypto.AggregateSigner)
+
+	sm := &pbftsm{
+		state:   InitialState,
+		blocks:  blockstore.NewInMemory(),
+		genesis: blockstore.NewGenesisStore(),
+		watcher: core.NewWatcher(),
+		tree:    blockstore.NewTreeCache(badTree{}),
+		signer:  signer,
+		authReader: func(hashtree.Tree) (authority.Authority, error) {
+			return ro, nil
+		},
+	}
+
+	sm.genesis.Set(types.Genesis{})
+	sm.round.threshold = 2
+
+	views := makeViews(t, ca, 3, 1, types.Digest{})
+
+	// A view certified by a single participant is rejected.
+	single := types.NewViewCertificate([]uint16{0}, views[0].GetSignature())
+
+	err := sm.AcceptCertified(views[0], single)
+	require.EqualError(t, err, "invalid certificate: got 1 <= 2: not enough signers")
+	require.Equal(t, uint16(0), sm.round.leader)
+	require.Equal(t, InitialState, sm.state)
+
+	// A certified view moves to the new leader right away.
+	cert, err := NewViewCertificate(views, ro, signer)
+	require.NoError(t, err)
+
+	err = sm.AcceptCertified(views[0], cert)
+	require.NoError(t, err)
+	require.Equal(t, uint16(1), sm.round.leader)
+	require.Equal(t, InitialState, sm.state)
+	require.Nil(t, sm.round.views)
+	require.Nil(t, sm.round.prevViews)
+
+	// Ignore a certified view for the current leader.
+	err = sm.AcceptCertified(views[0], cert)
+	require.NoError(t, err)
+
+	// The certificate can't be replayed to skip a leader.
+	skipped := makeViews(t, ca, 3, 3, types.Digest{})
+
+	cert, err = NewViewCertificate(skipped, ro, signer)
+	require.NoError(t, err)
+
+	err = sm.AcceptCertified(skipped[0], cert)
+	require.EqualError(t, err, "invalid view: mismatch leader 3 != 2")
+
+	// The certificate of a view change is bound to the view.
+	next := makeViews(t, ca, 3, 2, types.Digest{})
+
+	err = sm.AcceptCertified(next[0], cert)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid certificate: verify: ")
+
+	// A view change from a committed round goes back to the commit state.
+	sm.round.committed = true
+
+	cert, err = NewViewCertificate(next, ro, signer)
+	require.NoError(t, err)
+
+	err = sm.AcceptCertified(next[0], cert)
+	require.NoError(t, err)
+	require.Equal(t, CommitState, sm.state)
+
+	sm.signer = nil
+	sm.round.leader = 1
+	err = sm.AcceptCertified(next[0], cert)
+	require.EqualError(t, err, "signer '<nil>' can't aggregate")
+
+	sm.authReader = badReader
+	err = sm.AcceptCertified(next[0], cert)
+	require.EqualError(t, err, fake.Err("init: failed to read roster"))
 }
 
 func TestStateMachine_verifyViews(t *testing.T) {
@@ -742,6 +1188,55 @@ func TestStateMachine_Expire(t *testing.T) {
 	require.EqualError(t, err, fake.Err("init: failed to read roster"))
 }
 
+func TestStateMachine_Expire_SeededOrder(t *testing.T) {
+	ro := authority.FromAuthority(fake.NewAuthority(7, fake.NewSigner))
+
+	newSM := func() *pbftsm {
+		sm := NewStateMachine(StateMachineParam{
+			Signer:         bls.NewSigner(),
+			Blocks:         blockstore.NewInMemory(),
+			Genesis:        blockstore.NewGenesisStore(),
+			Tree:           blockstore.NewTreeCache(badTree{}),
+			LeaderElection: SeededOrder,
+			AuthorityReader: func(hashtree.Tree) (authority.Authority, error) {
+				return ro, nil
+			},
+		}).(*pbftsm)
+
+		sm.genesis.Set(types.Genesis{})
+
+		return sm
+	}
+
+	// Two independent state machines on the same chain elect the same leaders.
+	first := newSM()
+	second := newSM()
+
+	latestID, err := first.getLatestID()
+	require.NoError(t, err)
+
+	for i := 1; i < ro.Len(); i++ {
+		a, err := first.Expire(fake.NewAddress(0))
+		require.NoError(t, err)
+
+		b, err := second.Expire(fake.NewAddress(0))
+		require.NoError(t, err)
+
+		require.Equal(t, a.leader, b.leader)
+		require.Equal(t, SeededOrder.next(first.round.leader, latestID, ro.Len()), a.leader)
+
+		first.round.leader = a.leader
+		second.round.leader = b.leader
+		first.round.views = nil
+		second.round.views = nil
+	}
+
+	// The views for the elected leader are accepted by the other participant.
+	view, err := first.Expire(fake.NewAddress(0))
+	require.NoError(t, err)
+	require.NoError(t, second.verifyViews(false, view))
+}
+
 func TestStateMachine_CatchUp(t *testing.T) {
 	tree, db, clean := makeTree(t)
 	defer clean()
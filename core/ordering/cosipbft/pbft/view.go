@@ -9,12 +9,17 @@ package pbft
 import (
 	"encoding/binary"
 
+	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
 	"go.dedis.ch/dela/core/ordering/cosipbft/types"
 	"go.dedis.ch/dela/crypto"
 	"go.dedis.ch/dela/mino"
 	"golang.org/x/xerrors"
 )
 
+// ErrNotEnoughSigners is the error returned when a certificate is not signed
+// by more than the threshold of participants.
+var ErrNotEnoughSigners = xerrors.New("not enough signers")
+
 // View is the view change request sent to other participants.
 type View struct {
 	from      mino.Address
@@ -89,6 +94,90 @@ func (v View) Verify(pubkey crypto.PublicKey) error {
 	return nil
 }
 
+// VerifyCertificate verifies that the certificate proves that more than the
+// threshold of distinct participants of the roster signed the view.
+func (v View) VerifyCertificate(cert types.ViewCertificate, roster authority.Authority,
+	fac crypto.VerifierFactory) error {
+
+	signers := cert.GetSigners()
+
+	threshold := calculateThreshold(roster.Len())
+	if len(signers) <= threshold {
+		return xerrors.Errorf("got %d <= %d: %w", len(signers), threshold, ErrNotEnoughSigners)
+	}
+
+	pubkeys := make([]crypto.PublicKey, 0, len(signers))
+	seen := make(map[uint16]struct{})
+
+	for _, index := range signers {
+		if int(index) >= roster.Len() {
+			return xerrors.Errorf("unknown signer %d", index)
+		}
+
+		_, found := seen[index]
+		if found {
+			return xerrors.Errorf("duplicate signer %d", index)
+		}
+
+		seen[index] = struct{}{}
+
+		iter := roster.PublicKeyIterator()
+		iter.Seek(int(index))
+
+		pubkeys = append(pubkeys, iter.GetNext())
+	}
+
+	verifier, err := fac.FromArray(pubkeys)
+	if err != nil {
+		return xerrors.Errorf("verifier: %v", err)
+	}
+
+	err = verifier.Verify(v.bytes(), cert.GetSignature())
+	if err != nil {
+		return xerrors.Errorf("verify: %v", err)
+	}
+
+	return nil
+}
+
+// NewViewCertificate aggregates the signatures of the views into a certificate
+// that proves the agreement of their participants. The views must be for the
+// same block and leader, which is the case of the ones of a view change, and
+// there must be more than the threshold of them.
+func NewViewCertificate(views []View, roster authority.Authority,
+	signer crypto.AggregateSigner) (types.ViewCertificate, error) {
+
+	threshold := calculateThreshold(roster.Len())
+	if len(views) <= threshold {
+		return types.ViewCertificate{}, xerrors.Errorf("got %d <= %d: %w",
+			len(views), threshold, ErrNotEnoughSigners)
+	}
+
+	signers := make([]uint16, len(views))
+	sigs := make([]crypto.Signature, len(views))
+
+	for i, view := range views {
+		if view.leader != views[0].leader || view.id != views[0].id {
+			return types.ViewCertificate{}, xerrors.Errorf("mismatch view from %v", view.from)
+		}
+
+		_, index := roster.GetPublicKey(view.from)
+		if index < 0 {
+			return types.ViewCertificate{}, xerrors.Errorf("unknown peer: %v", view.from)
+		}
+
+		signers[i] = uint16(index)
+		sigs[i] = view.signature
+	}
+
+	sig, err := signer.Aggregate(sigs...)
+	if err != nil {
+		return types.ViewCertificate{}, xerrors.Errorf("aggregate: %v", err)
+	}
+
+	return types.NewViewCertificate(signers, sig), nil
+}
+
 func (v View) bytes() []byte {
 	buffer := make([]byte, 2)
 	binary.LittleEndian.PutUint16(buffer, v.leader)
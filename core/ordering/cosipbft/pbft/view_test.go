@@ -4,7 +4,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
 	"go.dedis.ch/dela/core/ordering/cosipbft/types"
+	"go.dedis.ch/dela/crypto"
 	"go.dedis.ch/dela/crypto/bls"
 	"go.dedis.ch/dela/internal/testing/fake"
 )
@@ -43,3 +45,104 @@ func TestView_Verify(t *testing.T) {
 	err = view.Verify(fake.NewBadPublicKey())
 	require.EqualError(t, err, fake.Err("verify"))
 }
+
+func TestView_VerifyCertificate(t *testing.T) {
+	ca := fake.NewAuthority(4, bls.Generate)
+	ro := authority.FromAuthority(ca)
+
+	signer := ca.GetSigner(0).(crypto.AggregateSigner)
+	fac := signer.GetVerifierFactory()
+
+	views := makeViews(t, ca, 3, 1, types.Digest{1})
+
+	cert, err := NewViewCertificate(views, ro, signer)
+	require.NoError(t, err)
+	require.Equal(t, []uint16{0, 1, 2}, cert.GetSigners())
+
+	err = views[0].VerifyCertificate(cert, ro, fac)
+	require.NoError(t, err)
+
+	// A single participant can't certify a view change.
+	single := types.NewViewCertificate([]uint16{0}, views[0].GetSignature())
+
+	err = views[0].VerifyCertificate(single, ro, fac)
+	require.EqualError(t, err, "got 1 <= 2: not enough signers")
+
+	// The certificate is bound to the leader and the block of the view.
+	other := makeViews(t, ca, 1, 2, types.Digest{1})[0]
+	err = other.VerifyCertificate(cert, ro, fac)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "verify: ")
+
+	cert = types.NewViewCertificate([]uint16{0, 1, 4}, cert.GetSignature())
+	err = views[0].VerifyCertificate(cert, ro, fac)
+	require.EqualError(t, err, "unknown signer 4")
+
+	cert = types.NewViewCertificate([]uint16{0, 1, 1}, cert.GetSignature())
+	err = views[0].VerifyCertificate(cert, ro, fac)
+	require.EqualError(t, err, "duplicate signer 1")
+
+	cert = types.NewViewCertificate([]uint16{0, 1, 2}, cert.GetSignature())
+	err = views[0].VerifyCertificate(cert, ro, fake.NewBadVerifierFactory())
+	require.EqualError(t, err, fake.Err("verifier"))
+}
+
+func TestNewViewCertificate(t *testing.T) {
+	ca := fake.NewAuthority(4, bls.Generate)
+	ro := authority.FromAuthority(ca)
+
+	signer := ca.GetSigner(0).(crypto.AggregateSigner)
+
+	views := makeViews(t, ca, 3, 1, types.Digest{1})
+
+	_, err := NewViewCertificate(views[:2], ro, signer)
+	require.EqualError(t, err, "got 2 <= 2: not enough signers")
+	require.ErrorIs(t, err, ErrNotEnoughSigners)
+
+	views = views[:2]
+
+	_, err = NewViewCertificate(append(views, View{from: fake.NewAddress(3), leader: 2}), ro, signer)
+	require.EqualError(t, err, "mismatch view from fake.Address[3]")
+
+	_, err = NewViewCertificate(append(views, View{from: fake.NewAddress(5), leader: 1, id: types.Digest{1}}),
+		ro, signer)
+	require.EqualError(t, err, "unknown peer: fake.Address[5]")
+
+	views = makeViews(t, ca, 3, 1, types.Digest{1})
+
+	_, err = NewViewCertificate(views, ro, badAggregateSigner{AggregateSigner: signer})
+	require.EqualError(t, err, fake.Err("aggregate"))
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+// makeViews returns the views of the first n participants of the authority for
+// the given leader and block.
+func makeViews(t *testing.T, ca fake.CollectiveAuthority, n int, leader uint16, id types.Digest) []View {
+	views := make([]View, n)
+
+	iter := ca.AddressIterator()
+	for i := range views {
+		param := ViewParam{
+			From:   iter.GetNext(),
+			ID:     id,
+			Leader: leader,
+		}
+
+		view, err := NewViewAndSign(param, ca.GetSigner(i))
+		require.NoError(t, err)
+
+		views[i] = view
+	}
+
+	return views
+}
+
+type badAggregateSigner struct {
+	crypto.AggregateSigner
+}
+
+func (badAggregateSigner) Aggregate(...crypto.Signature) (crypto.Signature, error) {
+	return nil, fake.GetError()
+}
@@ -7,9 +7,13 @@
 package cosipbft
 
 import (
+	"bytes"
 	"context"
+	"sync"
+	"time"
 
 	"github.com/rs/zerolog"
+	"go.dedis.ch/dela"
 	"go.dedis.ch/dela/core"
 	"go.dedis.ch/dela/core/access"
 	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
@@ -33,6 +37,30 @@ var (
 	keyAccess = [32]byte{1}
 )
 
+// reservedKeys are the keys of the tree written by the genesis block, which
+// must be free in the initial state of the tree.
+var reservedKeys = [][32]byte{keyRoster, keyAccess}
+
+// ErrReadOnly is the error returned when a proposal is received while the node
+// is in read-only mode.
+var ErrReadOnly = xerrors.New("read-only")
+
+// ErrNotBootstrapped is the error returned when the roster is read, or a
+// proposal is received, before the genesis block is stored.
+var ErrNotBootstrapped = xerrors.New("not bootstrapped")
+
+// ErrRosterNotFound is the error returned when the tree has no roster, which
+// happens when it is read from a tree that has not been bootstrapped.
+var ErrRosterNotFound = xerrors.New("roster not found in tree")
+
+// ErrSnapshotRequired is the error returned when a node is too far behind the
+// chain to catch up block by block. The synchronizer stops with the same error.
+var ErrSnapshotRequired = blocksync.ErrSnapshotRequired
+
+// ErrArchival is the error returned when a message of the consensus is received
+// by an archival node.
+var ErrArchival = xerrors.New("archival node")
+
 // Processor processes the messages to run a collective signing PBFT consensus.
 //
 // - implements cosi.Reactor
@@ -55,14 +83,58 @@ type processor struct {
 	genesis blockstore.GenesisStore
 	blocks  blockstore.BlockStore
 
+	finalizeAttempts int
+	finalizeBackoff  time.Duration
+
+	viewLock   sync.Mutex
+	lastLeader mino.Address
+
+	// readOnlyLock protects the read-only mode that is enabled when the node
+	// can't reach a quorum of participants, and the number of participants
+	// that answered the latest propagation of a block or a view. The number is
+	// UnknownReachable when they have not been contacted since the latest
+	// block.
+	readOnlyLock sync.Mutex
+	readOnly     bool
+	reachable    int
+
+	// genesisLock serializes the creation of the genesis block so that it is
+	// stored only once.
+	genesisLock sync.Mutex
+
+	genesisLoader  GenesisLoader
+	genesisState   GenesisStateInitializer
+	localGenesis   *types.Genesis
+	commitEncoding types.SignatureEncoding
+	version        types.ProtocolVersion
+	indexTxs       bool
+	maxCatchUpGap  uint64
+	catchUpTimeout time.Duration
+	verifyWorkers  int
+
+	// storageAck is true when the node acknowledges the blocks it stores.
+	storageAck bool
+
+	// archival is true when the node only follows the chain through the
+	// synchronizations and never takes part in the rounds.
+	archival bool
+
+	// faults injects failures in the operations, or nil when disabled.
+	faults *FaultInjector
+
 	started chan struct{}
 }
 
 func newProcessor() *processor {
 	return &processor{
-		watcher: core.NewWatcher(),
-		context: json.NewContext(),
-		started: make(chan struct{}),
+		logger:           dela.Logger,
+		watcher:          core.NewWatcher(),
+		context:          json.NewContext(),
+		started:          make(chan struct{}),
+		catchUpTimeout:   DefaultCatchUpTimeout,
+		finalizeAttempts: DefaultFinalizeAttempts,
+		finalizeBackoff:  DefaultFinalizeBackoff,
+		reachable:        UnknownReachable,
 	}
 }
 
@@ -70,9 +142,29 @@ func newProcessor() *processor {
 // signature module. The messages are either from the the prepare or the commit
 // phase.
 func (h *processor) Invoke(from mino.Address, msg serde.Message) ([]byte, error) {
+	if h.archival {
+		return nil, xerrors.Errorf("message '%T' rejected: %w", msg, ErrArchival)
+	}
+
 	switch in := msg.(type) {
 	case types.BlockMessage:
-		ctx, cancel := context.WithCancel(context.Background())
+		if !h.isBootstrapped() {
+			return nil, xerrors.Errorf("proposal rejected: %w", ErrNotBootstrapped)
+		}
+
+		if h.IsReadOnly() {
+			return nil, xerrors.Errorf("proposal rejected: %w", ErrReadOnly)
+		}
+
+		var ctx context.Context
+		var cancel context.CancelFunc
+
+		if h.catchUpTimeout > 0 {
+			ctx, cancel = context.WithTimeout(context.Background(), h.catchUpTimeout)
+		} else {
+			ctx, cancel = context.WithCancel(context.Background())
+		}
+
 		defer cancel()
 
 		blocks := h.blocks.Watch(ctx)
@@ -82,11 +174,33 @@ func (h *processor) Invoke(from mino.Address, msg serde.Message) ([]byte, error)
 		latest := h.sync.GetLatest()
 
 		if latest > h.blocks.Len() {
+			gap := latest - h.blocks.Len()
+			if h.maxCatchUpGap > 0 && gap > h.maxCatchUpGap {
+				return nil, xerrors.Errorf("behind by %d blocks: %w", gap, ErrSnapshotRequired)
+			}
+
+			// The channel is closed when the context is done, either because
+			// the block is reached or because the deadline has elapsed, so
+			// that the watch is always released.
+			reached := false
 			for link := range blocks {
 				if link.GetBlock().GetIndex() >= latest {
+					reached = true
 					cancel()
 				}
 			}
+
+			if !reached {
+				return nil, xerrors.Errorf("catch up to block %d timed out after %v: %w",
+					latest, h.catchUpTimeout, context.DeadlineExceeded)
+			}
+		}
+
+		// The metadata is verified before the block is processed so that an
+		// obviously wrong proposal is rejected early.
+		err := h.verifyMetadata(in)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid metadata: %v", err)
 		}
 
 		viewMsgs := in.GetViews()
@@ -112,26 +226,49 @@ func (h *processor) Invoke(from mino.Address, msg serde.Message) ([]byte, error)
 			}
 		}
 
+		err = h.verifyProposer(from, in)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid proposer: %v", err)
+		}
+
 		digest, err := h.pbftsm.Prepare(from, in.GetBlock())
+		if xerrors.Is(err, pbft.ErrEquivocation) {
+			h.logger.Warn().Err(err).Stringer("proposer", from).Msg("equivocation detected")
+		}
+
 		if err != nil {
 			return nil, xerrors.Errorf("pbft prepare failed: %v", err)
 		}
 
-		return digest[:], nil
+		chainID, err := h.getChainID()
+		if err != nil {
+			return nil, xerrors.Errorf("couldn't get chain: %v", err)
+		}
+
+		return h.version.PrepareMessage(chainID, digest), nil
 	case types.CommitMessage:
-		err := h.pbftsm.Commit(in.GetID(), in.GetSignature())
+		err := h.faults.inject(FaultCommit)
+		if err == nil {
+			err = h.pbftsm.Commit(in.GetID(), in.GetSignature())
+		}
+
 		if err != nil {
 			h.logger.Debug().Msg("commit failed")
 
 			return nil, xerrors.Errorf("pbft commit failed: %v", err)
 		}
 
-		buffer, err := in.GetSignature().MarshalBinary()
+		buffer, err := h.commitEncoding.Encode(in.GetSignature())
 		if err != nil {
 			return nil, xerrors.Errorf("couldn't marshal signature: %v", err)
 		}
 
-		return buffer, nil
+		chainID, err := h.getChainID()
+		if err != nil {
+			return nil, xerrors.Errorf("couldn't get chain: %v", err)
+		}
+
+		return h.version.CommitMessage(chainID, buffer), nil
 	default:
 		return nil, xerrors.Errorf("unsupported message of type '%T'", msg)
 	}
@@ -139,20 +276,65 @@ func (h *processor) Invoke(from mino.Address, msg serde.Message) ([]byte, error)
 
 // Process implements mino.Handler. It processes the messages from the RPC.
 func (h *processor) Process(req mino.Request) (serde.Message, error) {
+	_, isGenesis := req.Message.(types.GenesisMessage)
+	if h.archival && !isGenesis {
+		// The genesis is the only message an archival node needs, the blocks
+		// are received through the synchronization.
+		return nil, xerrors.Errorf("message '%T' rejected: %w", req.Message, ErrArchival)
+	}
+
 	switch msg := req.Message.(type) {
 	case types.GenesisMessage:
+		h.genesisLock.Lock()
+		defer h.genesisLock.Unlock()
+
 		if h.genesis.Exists() {
 			return nil, nil
 		}
 
-		root := msg.GetGenesis().GetRoot()
+		genesis, err := h.reconcileGenesis(*msg.GetGenesis())
+		if err != nil {
+			return nil, xerrors.Errorf("genesis rejected: %v", err)
+		}
+
+		root := genesis.GetRoot()
 
-		return nil, h.storeGenesis(msg.GetGenesis().GetRoster(), &root)
+		return nil, h.storeGenesis(genesis.GetRoster(), &root)
 	case types.DoneMessage:
-		err := h.pbftsm.Finalize(msg.GetID(), msg.GetSignature())
+		if h.storageAck && h.isStored(msg.GetID()) {
+			// The leader requests again the acknowledgement of a block that
+			// is already stored.
+			return h.makeStoredAck()
+		}
+
+		err := h.finalize(msg.GetID(), msg.GetSignature())
 		if err != nil {
 			return nil, xerrors.Errorf("pbftsm finalized failed: %v", err)
 		}
+
+		// A block has been committed by a quorum of participants, which means
+		// the node can reach them again, but only the leader knows how many
+		// of them answered.
+		h.SetReadOnly(false)
+		h.setReachable(UnknownReachable)
+
+		if h.storageAck {
+			return h.makeStoredAck()
+		}
+	case types.AbortMessage:
+		leader, err := h.pbftsm.GetLeader()
+		if err != nil {
+			return nil, xerrors.Errorf("reading leader: %v", err)
+		}
+
+		if !req.Address.Equal(leader) {
+			return nil, xerrors.Errorf("'%v' is not the leader", req.Address)
+		}
+
+		err = h.AbortRound(msg.GetID())
+		if err != nil {
+			return nil, xerrors.Errorf("abort failed: %v", err)
+		}
 	case types.ViewMessage:
 		param := pbft.ViewParam{
 			From:   req.Address,
@@ -160,10 +342,37 @@ func (h *processor) Process(req mino.Request) (serde.Message, error) {
 			Leader: msg.GetLeader(),
 		}
 
-		err := h.pbftsm.Accept(pbft.NewView(param, msg.GetSignature()))
+		view := pbft.NewView(param, msg.GetSignature())
+
+		cert := msg.GetCertificate()
+		if cert == nil {
+			// A view without a certificate never moves the state machine. It
+			// is a request for the view of the node, which is given only if
+			// the round has expired for the node too.
+			vote, err := h.pbftsm.Vote(view)
+			if err != nil {
+				h.logger.Debug().Err(err).Msg("view refused")
+				return nil, nil
+			}
+
+			return types.NewViewMessage(vote.GetID(), vote.GetLeader(), vote.GetSignature()), nil
+		}
+
+		prev, err := h.pbftsm.GetLeader()
+		if err != nil {
+			h.logger.Warn().Err(err).Msg("view message refused")
+			return nil, nil
+		}
+
+		// The certificate proves that a quorum agreed to the view change,
+		// which is verified before the view is accepted.
+		err = h.pbftsm.AcceptCertified(view, *cert)
 		if err != nil {
 			h.logger.Warn().Err(err).Msg("view message refused")
+			return nil, nil
 		}
+
+		h.notifyViewChange(prev, msg.GetLeader())
 	default:
 		return nil, xerrors.Errorf("unsupported message of type '%T'", req.Message)
 	}
@@ -171,16 +380,257 @@ func (h *processor) Process(req mino.Request) (serde.Message, error) {
 	return nil, nil
 }
 
+// isStored returns true if the latest block stored is the one of the digest.
+func (h *processor) isStored(id types.Digest) bool {
+	last, err := h.blocks.Last()
+	if err != nil {
+		return false
+	}
+
+	return last.GetTo() == id
+}
+
+// makeStoredAck returns the acknowledgement of the latest block stored, with
+// the root of the tree it has produced.
+func (h *processor) makeStoredAck() (serde.Message, error) {
+	last, err := h.blocks.Last()
+	if err != nil {
+		return nil, xerrors.Errorf("reading last block: %v", err)
+	}
+
+	root := types.Digest{}
+	copy(root[:], h.tree.Get().GetRoot())
+
+	return types.NewStoredMessage(last.GetBlock().GetIndex(), root), nil
+}
+
+// finalize finalizes the round and retries with an exponential backoff as long
+// as the failure is transient, so that a committed block is not lost because of
+// a temporary failure of the storage.
+func (h *processor) finalize(id types.Digest, sig crypto.Signature) error {
+	backoff := h.finalizeBackoff
+
+	attempts := h.finalizeAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			h.logger.Warn().Err(err).Int("attempt", i).Msg("retrying finalization")
+
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		err = h.faults.inject(FaultFinalize)
+		if err == nil {
+			err = h.pbftsm.Finalize(id, sig)
+		}
+
+		if err == nil || !pbft.IsTransient(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// SetReadOnly enables or disables the read-only mode. A node in read-only mode
+// rejects the proposals but keeps serving the blocks and the proofs.
+func (h *processor) SetReadOnly(enabled bool) {
+	h.readOnlyLock.Lock()
+	defer h.readOnlyLock.Unlock()
+
+	if h.readOnly != enabled {
+		h.logger.Info().Bool("enabled", enabled).Msg("read-only mode")
+	}
+
+	h.readOnly = enabled
+}
+
+// setReachable records the number of participants that answered the latest
+// propagation of a block or a view.
+func (h *processor) setReachable(num int) {
+	h.readOnlyLock.Lock()
+	h.reachable = num
+	h.readOnlyLock.Unlock()
+}
+
+// IsReadOnly returns true if the node is in read-only mode.
+func (h *processor) IsReadOnly() bool {
+	h.readOnlyLock.Lock()
+	defer h.readOnlyLock.Unlock()
+
+	return h.readOnly
+}
+
+// notifyViewChange notifies a view change event to the listeners if the leader
+// of the state machine is different from the previous one.
+func (h *processor) notifyViewChange(prev mino.Address, view uint16) {
+	leader, err := h.pbftsm.GetLeader()
+	if err != nil {
+		h.logger.Warn().Err(err).Msg("reading new leader")
+		return
+	}
+
+	h.viewLock.Lock()
+	defer h.viewLock.Unlock()
+
+	if leader == nil || leader.Equal(prev) {
+		return
+	}
+
+	// Views are processed concurrently, so the change might have already been
+	// notified.
+	if h.lastLeader != nil && leader.Equal(h.lastLeader) {
+		return
+	}
+
+	h.lastLeader = leader
+
+	h.logger.Info().
+		Stringer("leader", leader).
+		Uint16("view", view).
+		Msg("view change")
+
+	h.watcher.Notify(ViewChangeEvent{
+		Leader: leader,
+		View:   view,
+	})
+}
+
+// AbortRound cancels the round in progress for the given candidate so that the
+// state machine goes back to the initial state and a new round can start.
+func (h *processor) AbortRound(id types.Digest) error {
+	err := h.pbftsm.Abort(id)
+	if err != nil {
+		return xerrors.Errorf("pbft abort failed: %v", err)
+	}
+
+	return nil
+}
+
+// verifyProposer verifies that the proposer of the block is a member of the
+// current roster, and that the signature of the block matches its identity, so
+// that a proposer can be held accountable for the blocks it sends.
+func (h *processor) verifyProposer(from mino.Address, msg types.BlockMessage) error {
+	roster, err := h.getCurrentRoster()
+	if err != nil {
+		return xerrors.Errorf("read roster failed: %v", err)
+	}
+
+	pubkey, _ := roster.GetPublicKey(from)
+	if pubkey == nil {
+		return xerrors.Errorf("unauthorized proposer '%v'", from)
+	}
+
+	if msg.GetSignature() == nil {
+		return xerrors.New("missing signature")
+	}
+
+	digest := msg.GetBlock().GetHash()
+
+	err = pubkey.Verify(digest[:], msg.GetSignature())
+	if err != nil {
+		return xerrors.Errorf("signature: %v", err)
+	}
+
+	return nil
+}
+
+// checkIndex returns an error if a proposal at the index can't be accepted
+// anymore because the block of the index is already stored. A proposal further
+// in the chain is left to the catch up.
+func (h *processor) checkIndex(index uint64) error {
+	if index < h.blocks.Len() {
+		return xerrors.Errorf("proposal %d is behind %d", index, h.blocks.Len())
+	}
+
+	return nil
+}
+
+// verifyMetadata verifies that the proposal extends the latest block known by
+// the participant.
+func (h *processor) verifyMetadata(msg types.BlockMessage) error {
+	if msg.GetIndex() != h.blocks.Len() {
+		return xerrors.Errorf("mismatch index %d != %d", msg.GetIndex(), h.blocks.Len())
+	}
+
+	if msg.GetPrevious() == (types.Digest{}) {
+		return nil
+	}
+
+	latest, err := h.getLatestID()
+	if err != nil {
+		return xerrors.Errorf("couldn't get latest digest: %v", err)
+	}
+
+	if msg.GetPrevious() != latest {
+		return xerrors.Errorf("mismatch previous '%v' != '%v'", msg.GetPrevious(), latest)
+	}
+
+	return nil
+}
+
+func (h *processor) getLatestID() (types.Digest, error) {
+	if h.blocks.Len() == 0 {
+		genesis, err := h.genesis.Get()
+		if err != nil {
+			return types.Digest{}, xerrors.Errorf("read genesis: %v", err)
+		}
+
+		return genesis.GetHash(), nil
+	}
+
+	last, err := h.blocks.Last()
+	if err != nil {
+		return types.Digest{}, xerrors.Errorf("read last block: %v", err)
+	}
+
+	return last.GetTo(), nil
+}
+
+// getChainID returns the digest of the genesis block when the signing protocol
+// binds the signatures to the chain, otherwise an empty digest.
+func (h *processor) getChainID() (types.Digest, error) {
+	if h.version < types.ProtocolV1 {
+		return types.Digest{}, nil
+	}
+
+	genesis, err := h.genesis.Get()
+	if err != nil {
+		return types.Digest{}, xerrors.Errorf("read genesis: %v", err)
+	}
+
+	return genesis.GetHash(), nil
+}
+
 func (h *processor) getCurrentRoster() (authority.Authority, error) {
+	if !h.isBootstrapped() {
+		return nil, ErrNotBootstrapped
+	}
+
 	return h.readRoster(h.tree.Get())
 }
 
+// isBootstrapped returns true if the genesis block is stored.
+func (h *processor) isBootstrapped() bool {
+	return h.genesis != nil && h.genesis.Exists()
+}
+
 func (h *processor) readRoster(tree hashtree.Tree) (authority.Authority, error) {
 	data, err := tree.Get(keyRoster[:])
 	if err != nil {
 		return nil, xerrors.Errorf("read from tree: %v", err)
 	}
 
+	if len(data) == 0 {
+		return nil, ErrRosterNotFound
+	}
+
 	roster, err := h.rosterFac.AuthorityOf(h.context, data)
 	if err != nil {
 		return nil, xerrors.Errorf("decode failed: %v", err)
@@ -189,14 +639,52 @@ func (h *processor) readRoster(tree hashtree.Tree) (authority.Authority, error)
 	return roster, nil
 }
 
+// reconcileGenesis returns the genesis to store when the given one is
+// propagated, according to the precedence of the sources.
+func (h *processor) reconcileGenesis(propagated types.Genesis) (types.Genesis, error) {
+	candidates := map[GenesisSource]types.Genesis{
+		GenesisFromPropagate: propagated,
+	}
+
+	if h.localGenesis != nil {
+		candidates[GenesisFromFile] = *h.localGenesis
+	}
+
+	genesis, _, err := ReconcileGenesis(candidates)
+	if err != nil {
+		return genesis, err
+	}
+
+	return genesis, nil
+}
+
+// storeGenesis creates the genesis block of the roster and stores it alongside
+// the initial tree. The caller must hold the genesis lock.
 func (h *processor) storeGenesis(roster authority.Authority, match *types.Digest) error {
+	err := h.checkGenesis(roster)
+	if err != nil {
+		return xerrors.Errorf("genesis not allowed: %v", err)
+	}
+
 	value, err := roster.Serialize(h.context)
 	if err != nil {
 		return xerrors.Errorf("failed to serialize roster: %v", err)
 	}
 
 	stageTree, err := h.tree.Get().Stage(func(snap store.Snapshot) error {
-		err := h.makeAccess(snap, roster)
+		if h.genesisState != nil {
+			err := h.genesisState.Initialize(snap, roster)
+			if err != nil {
+				return xerrors.Errorf("failed to initialize state: %v", err)
+			}
+		}
+
+		err := checkReserved(snap)
+		if err != nil {
+			return err
+		}
+
+		err = h.makeAccess(snap, roster)
 		if err != nil {
 			return xerrors.Errorf("failed to set access: %v", err)
 		}
@@ -215,6 +703,10 @@ func (h *processor) storeGenesis(roster authority.Authority, match *types.Digest
 	root := types.Digest{}
 	copy(root[:], stageTree.GetRoot())
 
+	if h.faults.inject(FaultGenesisRoot) != nil {
+		root[0] ^= 0xff
+	}
+
 	if match != nil && *match != root {
 		return xerrors.Errorf("mismatch tree root '%v' != '%v'", match, root)
 	}
@@ -224,12 +716,19 @@ func (h *processor) storeGenesis(roster authority.Authority, match *types.Digest
 		return xerrors.Errorf("creating genesis: %v", err)
 	}
 
-	err = stageTree.Commit()
+	err = h.faults.inject(FaultGenesisCommit)
+	if err == nil {
+		err = stageTree.Commit()
+	}
+
 	if err != nil {
 		return xerrors.Errorf("tree commit failed: %v", err)
 	}
 
-	h.tree.Set(stageTree)
+	// The genesis is stored while holding the lock of the tree so that a
+	// reader always sees the tree and the genesis in a consistent state.
+	unlock := h.tree.SetWithLock(stageTree)
+	defer unlock()
 
 	err = h.genesis.Set(genesis)
 	if err != nil {
@@ -241,6 +740,70 @@ func (h *processor) storeGenesis(roster authority.Authority, match *types.Digest
 	return nil
 }
 
+// chainOptions returns the options to create the chains that match the
+// configuration of the processor.
+func (h *processor) chainOptions() []types.ChainOption {
+	var opts []types.ChainOption
+
+	if h.commitEncoding != nil {
+		opts = append(opts, types.WithCommitEncoding(h.commitEncoding))
+	}
+
+	if h.version != types.ProtocolV0 {
+		opts = append(opts, types.WithProtocolVersion(h.version))
+	}
+
+	if h.verifyWorkers > 1 {
+		opts = append(opts, types.WithVerifyWorkers(h.verifyWorkers))
+	}
+
+	return opts
+}
+
+// checkGenesis returns an error if an allow-list is configured and the digest
+// of the roster is not part of it.
+func (h *processor) checkGenesis(roster authority.Authority) error {
+	if h.genesisLoader == nil {
+		return nil
+	}
+
+	allowed, err := h.genesisLoader.Load()
+	if err != nil {
+		return xerrors.Errorf("couldn't load allow-list: %v", err)
+	}
+
+	digest, err := types.RosterDigest(roster, h.hashFactory)
+	if err != nil {
+		return xerrors.Errorf("couldn't compute roster digest: %v", err)
+	}
+
+	for _, candidate := range allowed {
+		if bytes.Equal(candidate, digest[:]) {
+			return nil
+		}
+	}
+
+	return xerrors.Errorf("roster digest %#x is not in the allow-list", digest[:])
+}
+
+// checkReserved returns an error if the initial state of the tree already has
+// a value at one of the keys reserved for the genesis block, as it would be
+// silently overwritten by the roster or the access rights.
+func checkReserved(snap store.Readable) error {
+	for _, key := range reservedKeys {
+		value, err := snap.Get(key[:])
+		if err != nil {
+			return xerrors.Errorf("failed to read reserved key: %v", err)
+		}
+
+		if len(value) > 0 {
+			return xerrors.Errorf("reserved key %#x is already set", key)
+		}
+	}
+
+	return nil
+}
+
 func (h *processor) makeAccess(store store.Snapshot, roster authority.Authority) error {
 	creds := viewchange.NewCreds(keyAccess[:])
 
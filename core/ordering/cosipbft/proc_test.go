@@ -2,7 +2,12 @@ package cosipbft
 
 import (
 	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
@@ -12,10 +17,14 @@ import (
 	"go.dedis.ch/dela/core/ordering/cosipbft/types"
 	"go.dedis.ch/dela/core/store"
 	"go.dedis.ch/dela/core/store/hashtree"
+	"go.dedis.ch/dela/core/store/hashtree/binprefix"
 	"go.dedis.ch/dela/core/validation/simple"
+	thresholdtypes "go.dedis.ch/dela/cosi/threshold/types"
 	"go.dedis.ch/dela/crypto"
+	"go.dedis.ch/dela/crypto/bls"
 	"go.dedis.ch/dela/internal/testing/fake"
 	"go.dedis.ch/dela/mino"
+	"go.dedis.ch/dela/serde"
 	"go.dedis.ch/dela/serde/json"
 )
 
@@ -23,7 +32,9 @@ func TestProcessor_BlockMessage_Invoke(t *testing.T) {
 	expected := types.Digest{1}
 
 	proc := newProcessor()
-	proc.rosterFac = authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
+	proc.rosterFac = fakeRosterFac{}
+	proc.tree = blockstore.NewTreeCache(fakeTree{})
+	proc.genesis = makeGenesisStore(t)
 	proc.sync = fakeSync{latest: 1}
 	proc.blocks = fakeStore{}
 	proc.pbftsm = fakeSM{
@@ -31,7 +42,7 @@ func TestProcessor_BlockMessage_Invoke(t *testing.T) {
 		id:    expected,
 	}
 
-	msg := types.NewBlockMessage(types.Block{}, nil)
+	msg := types.NewBlockMessage(types.Block{}, nil, types.WithProposerSignature(fake.Signature{}))
 
 	id, err := proc.Invoke(fake.NewAddress(0), msg)
 	require.NoError(t, err)
@@ -41,6 +52,10 @@ func TestProcessor_BlockMessage_Invoke(t *testing.T) {
 	_, err = proc.Invoke(fake.NewAddress(0), msg)
 	require.EqualError(t, err, fake.Err("pbft prepare failed"))
 
+	proc.pbftsm = fakeSM{state: pbft.InitialState, err: pbft.ErrEquivocation}
+	_, err = proc.Invoke(fake.NewAddress(0), msg)
+	require.EqualError(t, err, "pbft prepare failed: equivocation")
+
 	views := map[mino.Address]types.ViewMessage{fake.NewAddress(0): {}}
 	msg = types.NewBlockMessage(types.Block{}, views)
 	proc.pbftsm = fakeSM{err: fake.GetError()}
@@ -48,6 +63,275 @@ func TestProcessor_BlockMessage_Invoke(t *testing.T) {
 	require.EqualError(t, err, fake.Err("accept all"))
 }
 
+func TestProcessor_MaxCatchUpGap_Invoke(t *testing.T) {
+	expected := types.Digest{1}
+
+	proc := newProcessor()
+	proc.rosterFac = fakeRosterFac{}
+	proc.tree = blockstore.NewTreeCache(fakeTree{})
+	proc.genesis = makeGenesisStore(t)
+	proc.sync = fakeSync{latest: 1}
+	proc.blocks = fakeStore{}
+	proc.pbftsm = fakeSM{
+		state: pbft.InitialState,
+		id:    expected,
+	}
+	proc.maxCatchUpGap = 10
+
+	msg := types.NewBlockMessage(types.Block{}, nil, types.WithProposerSignature(fake.Signature{}))
+
+	// A small gap is caught up before the proposal is processed.
+	id, err := proc.Invoke(fake.NewAddress(0), msg)
+	require.NoError(t, err)
+	require.Equal(t, expected[:], id)
+
+	proc.sync = fakeSync{latest: 10000}
+	_, err = proc.Invoke(fake.NewAddress(0), msg)
+	require.EqualError(t, err, "behind by 10000 blocks: gap too large, snapshot required")
+	require.True(t, errors.Is(err, ErrSnapshotRequired))
+
+	// The gap is not limited by default.
+	proc.maxCatchUpGap = 0
+	proc.sync = fakeSync{latest: 1}
+	_, err = proc.Invoke(fake.NewAddress(0), msg)
+	require.NoError(t, err)
+}
+
+func TestProcessor_CatchUpTimeout_Invoke(t *testing.T) {
+	store := &watchStore{}
+
+	proc := newProcessor()
+	proc.rosterFac = fakeRosterFac{}
+	proc.tree = blockstore.NewTreeCache(fakeTree{})
+	proc.genesis = makeGenesisStore(t)
+	proc.sync = fakeSync{latest: 5}
+	proc.blocks = store
+	proc.pbftsm = fakeSM{state: pbft.InitialState}
+	proc.catchUpTimeout = 10 * time.Millisecond
+
+	require.Equal(t, DefaultCatchUpTimeout, newProcessor().catchUpTimeout)
+
+	msg := types.NewBlockMessage(types.Block{}, nil, types.WithProposerSignature(fake.Signature{}))
+
+	_, err := proc.Invoke(fake.NewAddress(0), msg)
+	require.EqualError(t, err, "catch up to block 5 timed out after 10ms: context deadline exceeded")
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	// The watch is released when the deadline elapses.
+	require.Equal(t, context.DeadlineExceeded, store.ctx.Err())
+
+	_, more := <-store.ch
+	require.False(t, more)
+}
+
+func TestProcessor_NotBootstrapped(t *testing.T) {
+	proc := newProcessor()
+	proc.rosterFac = fakeRosterFac{}
+	proc.tree = blockstore.NewTreeCache(fakeTree{})
+	proc.sync = fakeSync{}
+	proc.blocks = fakeStore{}
+	proc.genesis = blockstore.NewGenesisStore()
+	proc.access = fakeAccess{}
+	proc.pbftsm = fakeSM{state: pbft.InitialState}
+
+	msg := types.NewBlockMessage(types.Block{}, nil, types.WithProposerSignature(fake.Signature{}))
+
+	_, err := proc.getCurrentRoster()
+	require.True(t, errors.Is(err, ErrNotBootstrapped))
+
+	_, err = proc.Invoke(fake.NewAddress(0), msg)
+	require.EqualError(t, err, "proposal rejected: not bootstrapped")
+	require.True(t, errors.Is(err, ErrNotBootstrapped))
+
+	root := types.Digest{}
+	copy(root[:], []byte("root"))
+
+	genesis, err := types.NewGenesis(authority.FromAuthority(fake.NewAuthority(3, fake.NewSigner)),
+		types.WithGenesisRoot(root))
+	require.NoError(t, err)
+
+	_, err = proc.Process(mino.Request{Message: types.NewGenesisMessage(genesis)})
+	require.NoError(t, err)
+
+	roster, err := proc.getCurrentRoster()
+	require.NoError(t, err)
+	require.Equal(t, 3, roster.Len())
+
+	_, err = proc.Invoke(fake.NewAddress(0), msg)
+	require.NoError(t, err)
+}
+
+func TestProcessor_Archival_Invoke(t *testing.T) {
+	proc := newProcessor()
+	proc.genesis = makeGenesisStore(t)
+	proc.pbftsm = fakeSM{}
+	proc.blocks = blockstore.NewInMemory()
+	proc.archival = true
+
+	msg := types.NewBlockMessage(types.Block{}, nil, types.WithProposerSignature(fake.Signature{}))
+
+	_, err := proc.Invoke(fake.NewAddress(0), msg)
+	require.EqualError(t, err, "message 'types.BlockMessage' rejected: archival node")
+	require.True(t, errors.Is(err, ErrArchival))
+
+	_, err = proc.Invoke(fake.NewAddress(0), types.NewCommit(types.Digest{}, fake.Signature{}))
+	require.EqualError(t, err, "message 'types.CommitMessage' rejected: archival node")
+}
+
+func TestProcessor_Archival_Process(t *testing.T) {
+	proc := newProcessor()
+	proc.pbftsm = fakeSM{}
+	proc.tree = blockstore.NewTreeCache(fakeTree{})
+	proc.genesis = blockstore.NewGenesisStore()
+	proc.access = fakeAccess{}
+	proc.archival = true
+
+	msgs := []serde.Message{
+		types.NewDone(types.Digest{}, fake.Signature{}),
+		types.NewViewMessage(types.Digest{}, 1, fake.Signature{}),
+		types.NewAbortMessage(types.Digest{}),
+	}
+
+	for _, msg := range msgs {
+		_, err := proc.Process(mino.Request{Message: msg})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrArchival))
+	}
+
+	// The genesis is still accepted so that the node can follow the chain.
+	root := types.Digest{}
+	copy(root[:], []byte("root"))
+
+	genesis, err := types.NewGenesis(authority.FromAuthority(fake.NewAuthority(3, fake.NewSigner)),
+		types.WithGenesisRoot(root))
+	require.NoError(t, err)
+
+	_, err = proc.Process(mino.Request{Message: types.NewGenesisMessage(genesis)})
+	require.NoError(t, err)
+	require.True(t, proc.genesis.Exists())
+}
+
+func TestProcessor_ReadOnly_Invoke(t *testing.T) {
+	proc := newProcessor()
+	proc.genesis = makeGenesisStore(t)
+	proc.pbftsm = fakeSM{}
+	proc.blocks = blockstore.NewInMemory()
+	proc.blocks.Store(makeBlock(t, types.Digest{}))
+
+	require.False(t, proc.IsReadOnly())
+
+	proc.SetReadOnly(true)
+	require.True(t, proc.IsReadOnly())
+
+	msg := types.NewBlockMessage(types.Block{}, nil, types.WithProposerSignature(fake.Signature{}))
+
+	_, err := proc.Invoke(fake.NewAddress(0), msg)
+	require.EqualError(t, err, "proposal rejected: read-only")
+	require.True(t, errors.Is(err, ErrReadOnly))
+
+	// A committed block means a quorum is reachable again.
+	req := mino.Request{
+		Message: types.NewDone(types.Digest{}, fake.Signature{}),
+	}
+
+	_, err = proc.Process(req)
+	require.NoError(t, err)
+	require.False(t, proc.IsReadOnly())
+}
+
+func TestProcessor_BlockMessage_VerifyProposer(t *testing.T) {
+	proc := newProcessor()
+	proc.rosterFac = fakeRosterFac{}
+	proc.tree = blockstore.NewTreeCache(fakeTree{})
+	proc.genesis = makeGenesisStore(t)
+	proc.sync = fakeSync{}
+	proc.blocks = fakeStore{}
+	proc.pbftsm = fakeSM{state: pbft.InitialState}
+
+	msg := types.NewBlockMessage(types.Block{}, nil, types.WithProposerSignature(fake.Signature{}))
+
+	_, err := proc.Invoke(fake.NewAddress(0), msg)
+	require.NoError(t, err)
+
+	_, err = proc.Invoke(fake.NewAddress(5), msg)
+	require.EqualError(t, err, "invalid proposer: unauthorized proposer 'fake.Address[5]'")
+
+	_, err = proc.Invoke(fake.NewAddress(0), types.NewBlockMessage(types.Block{}, nil))
+	require.EqualError(t, err, "invalid proposer: missing signature")
+
+	proc.rosterFac = fakeRosterFac{
+		roster: authority.FromAuthority(fake.NewAuthority(3, func() crypto.Signer {
+			return fake.NewSignerWithPublicKey(fake.NewBadPublicKey())
+		})),
+	}
+	_, err = proc.Invoke(fake.NewAddress(0), msg)
+	require.EqualError(t, err, fake.Err("invalid proposer: signature"))
+
+	proc.tree = blockstore.NewTreeCache(fakeTree{err: fake.GetError()})
+	_, err = proc.Invoke(fake.NewAddress(0), msg)
+	require.EqualError(t, err, fake.Err("invalid proposer: read roster failed: read from tree"))
+}
+
+func TestProcessor_BlockMessage_VerifyMetadata(t *testing.T) {
+	proc := newProcessor()
+	proc.rosterFac = fakeRosterFac{}
+	proc.tree = blockstore.NewTreeCache(fakeTree{})
+	proc.sync = fakeSync{}
+	proc.blocks = fakeStore{}
+	proc.genesis = fakeGenesisStore{exists: true, errGet: fake.GetError()}
+	proc.pbftsm = fakeSM{err: fake.GetError()}
+
+	signature := types.WithProposerSignature(fake.Signature{})
+
+	// The proposal is rejected before the state machine is reached.
+	block, err := types.NewBlock(simple.NewResult(nil), types.WithIndex(2))
+	require.NoError(t, err)
+
+	_, err = proc.Invoke(fake.NewAddress(0), types.NewBlockMessage(block, nil, signature))
+	require.EqualError(t, err, "invalid metadata: mismatch index 2 != 0")
+
+	msg := types.NewBlockMessage(types.Block{}, nil, signature,
+		types.WithPrevious(types.Digest{1}))
+
+	_, err = proc.Invoke(fake.NewAddress(0), msg)
+	require.EqualError(t, err, fake.Err("invalid metadata: couldn't get latest digest: "+
+		"read genesis"))
+
+	genesis, err := types.NewGenesis(authority.New(nil, nil))
+	require.NoError(t, err)
+
+	proc.genesis = blockstore.NewGenesisStore()
+	require.NoError(t, proc.genesis.Set(genesis))
+
+	_, err = proc.Invoke(fake.NewAddress(0), msg)
+	require.EqualError(t, err, fmt.Sprintf("invalid metadata: mismatch previous "+
+		"'01000000' != '%v'", genesis.GetHash()))
+
+	msg = types.NewBlockMessage(types.Block{}, nil, signature,
+		types.WithPrevious(genesis.GetHash()))
+
+	_, err = proc.Invoke(fake.NewAddress(0), msg)
+	require.EqualError(t, err, fake.Err("pbft prepare failed"))
+}
+
+func TestProcessor_CheckIndex(t *testing.T) {
+	proc := newProcessor()
+	proc.blocks = blockstore.NewInMemory()
+
+	require.NoError(t, proc.checkIndex(0))
+	require.NoError(t, proc.checkIndex(5))
+
+	block, err := types.NewBlock(simple.NewResult(nil))
+	require.NoError(t, err)
+
+	link, err := types.NewBlockLink(types.Digest{}, block)
+	require.NoError(t, err)
+	require.NoError(t, proc.blocks.Store(link))
+
+	require.EqualError(t, proc.checkIndex(0), "proposal 0 is behind 1")
+	require.NoError(t, proc.checkIndex(1))
+}
+
 func TestProcessor_CommitMessage_Invoke(t *testing.T) {
 	proc := newProcessor()
 	proc.pbftsm = fakeSM{}
@@ -71,6 +355,86 @@ func TestProcessor_CommitMessage_Invoke(t *testing.T) {
 	require.EqualError(t, err, "unsupported message of type 'fake.Message'")
 }
 
+func TestProcessor_CommitEncoding_Invoke(t *testing.T) {
+	proc := newProcessor()
+	proc.pbftsm = fakeSM{}
+
+	signer := bls.NewSigner()
+
+	sig, err := signer.Sign([]byte("prepare"))
+	require.NoError(t, err)
+
+	msg := types.NewCommit(types.Digest{1}, sig)
+
+	// Binary encoding by default.
+	data, err := proc.Invoke(fake.NewAddress(0), msg)
+	require.NoError(t, err)
+	require.True(t, sig.Equal(bls.NewSignature(data)))
+
+	proc.commitEncoding = hexEncoding
+
+	data, err = proc.Invoke(fake.NewAddress(0), msg)
+	require.NoError(t, err)
+
+	raw, err := hex.DecodeString(string(data))
+	require.NoError(t, err)
+	require.True(t, sig.Equal(bls.NewSignature(raw)))
+
+	proc.commitEncoding = func(crypto.Signature) ([]byte, error) {
+		return nil, fake.GetError()
+	}
+
+	_, err = proc.Invoke(fake.NewAddress(0), msg)
+	require.EqualError(t, err, fake.Err("couldn't marshal signature"))
+}
+
+func TestProcessor_ProtocolVersion_Invoke(t *testing.T) {
+	genesis, err := types.NewGenesis(authority.FromAuthority(fake.NewAuthority(3, fake.NewSigner)))
+	require.NoError(t, err)
+
+	expected := types.Digest{1}
+
+	proc := newProcessor()
+	proc.rosterFac = fakeRosterFac{}
+	proc.tree = blockstore.NewTreeCache(fakeTree{})
+	proc.sync = fakeSync{latest: 1}
+	proc.blocks = fakeStore{}
+	proc.genesis = blockstore.NewGenesisStore()
+	proc.version = types.ProtocolV1
+	proc.pbftsm = fakeSM{
+		state: pbft.InitialState,
+		id:    expected,
+	}
+
+	require.NoError(t, proc.genesis.Set(genesis))
+
+	msg := types.NewBlockMessage(types.Block{}, nil, types.WithProposerSignature(fake.Signature{}))
+
+	data, err := proc.Invoke(fake.NewAddress(0), msg)
+	require.NoError(t, err)
+	require.Equal(t, types.ProtocolV1.PrepareMessage(genesis.GetHash(), expected), data)
+
+	sig, err := bls.NewSigner().Sign([]byte("prepare"))
+	require.NoError(t, err)
+
+	raw, err := sig.MarshalBinary()
+	require.NoError(t, err)
+
+	data, err = proc.Invoke(fake.NewAddress(0), types.NewCommit(expected, sig))
+	require.NoError(t, err)
+	require.Equal(t, types.ProtocolV1.CommitMessage(genesis.GetHash(), raw), data)
+
+	proc.genesis = fakeGenesisStore{exists: true, errGet: fake.GetError()}
+
+	_, err = proc.Invoke(fake.NewAddress(0), msg)
+	require.EqualError(t, err, fake.Err("couldn't get chain: read genesis"))
+
+	proc.genesis = blockstore.NewGenesisStore()
+
+	_, err = proc.Invoke(fake.NewAddress(0), types.NewCommit(expected, sig))
+	require.EqualError(t, err, "couldn't get chain: read genesis: missing genesis block")
+}
+
 func TestProcessor_GenesisMessage_Process(t *testing.T) {
 	proc := newProcessor()
 	proc.tree = blockstore.NewTreeCache(fakeTree{})
@@ -113,8 +477,27 @@ func TestProcessor_GenesisMessage_Process(t *testing.T) {
 	proc.access = fakeAccess{}
 	proc.tree = blockstore.NewTreeCache(fakeTree{errStore: fake.GetError()})
 	_, err = proc.Process(req)
+	require.EqualError(t, err, fake.Err("while updating tree: failed to read reserved key"))
+
+	proc.tree = blockstore.NewTreeCache(fakeTree{errSet: fake.GetError()})
+	_, err = proc.Process(req)
 	require.EqualError(t, err, fake.Err("while updating tree: failed to store roster"))
 
+	proc.tree = blockstore.NewTreeCache(fakeTree{})
+	proc.genesisState = seedState{err: fake.GetError()}
+	_, err = proc.Process(req)
+	require.EqualError(t, err, fake.Err("while updating tree: failed to initialize state"))
+
+	proc.genesisState = nil
+
+	// The initial state writes to the key of the roster.
+	proc.tree = blockstore.NewTreeCache(fakeTree{
+		values: map[string][]byte{string(keyRoster[:]): []byte("payload")},
+	})
+	_, err = proc.Process(req)
+	require.EqualError(t, err, "while updating tree: reserved key "+
+		"0x0000000000000000000000000000000000000000000000000000000000000000 is already set")
+
 	proc.tree = blockstore.NewTreeCache(fakeTree{errCommit: fake.GetError()})
 	_, err = proc.Process(req)
 	require.EqualError(t, err, fake.Err("tree commit failed"))
@@ -125,6 +508,161 @@ func TestProcessor_GenesisMessage_Process(t *testing.T) {
 	require.EqualError(t, err, fake.Err("set genesis failed"))
 }
 
+func TestProcessor_GenesisMessage_Concurrent(t *testing.T) {
+	proc := newProcessor()
+	proc.tree = blockstore.NewTreeCache(emptyTree{})
+	proc.genesis = blockstore.NewGenesisStore()
+	proc.access = fakeAccess{}
+
+	ro := authority.FromAuthority(fake.NewAuthority(3, fake.NewSigner))
+
+	root := types.Digest{}
+	copy(root[:], []byte("root"))
+
+	genesis, err := types.NewGenesis(ro, types.WithGenesisRoot(root))
+	require.NoError(t, err)
+
+	req := mino.Request{
+		Message: types.NewGenesisMessage(genesis),
+	}
+
+	done := make(chan struct{})
+	readers := sync.WaitGroup{}
+
+	// The readers hammer the cache while the genesis is stored and check that
+	// the tree is always consistent with the genesis block.
+	for i := 0; i < 10; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				tree, unlock := proc.tree.GetWithLock()
+				require.Equal(t, proc.genesis.Exists(), tree.GetRoot() != nil)
+				unlock()
+			}
+		}()
+	}
+
+	writers := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+
+			_, err := proc.Process(req)
+			require.NoError(t, err)
+		}()
+	}
+
+	writers.Wait()
+	close(done)
+	readers.Wait()
+
+	stored, err := proc.genesis.Get()
+	require.NoError(t, err)
+	require.Equal(t, genesis.GetHash(), stored.GetHash())
+
+	select {
+	case <-proc.started:
+	default:
+		t.Fatal("processor should be started")
+	}
+}
+
+func TestProcessor_GenesisMessage_LocalGenesis(t *testing.T) {
+	ro := authority.FromAuthority(fake.NewAuthority(3, fake.NewSigner))
+
+	root := types.Digest{}
+	copy(root[:], []byte("root"))
+
+	genesis, err := types.NewGenesis(ro, types.WithGenesisRoot(root))
+	require.NoError(t, err)
+
+	other, err := types.NewGenesis(ro)
+	require.NoError(t, err)
+
+	makeProc := func(local types.Genesis) *processor {
+		proc := newProcessor()
+		proc.tree = blockstore.NewTreeCache(fakeTree{})
+		proc.genesis = blockstore.NewGenesisStore()
+		proc.access = fakeAccess{}
+		proc.localGenesis = &local
+
+		return proc
+	}
+
+	// The local file and the propagated genesis agree.
+	proc := makeProc(genesis)
+	_, err = proc.Process(mino.Request{Message: types.NewGenesisMessage(genesis)})
+	require.NoError(t, err)
+	require.True(t, proc.genesis.Exists())
+
+	// The propagated genesis differs from the local file.
+	proc = makeProc(genesis)
+	_, err = proc.Process(mino.Request{Message: types.NewGenesisMessage(other)})
+	require.EqualError(t, err, fmt.Sprintf("genesis rejected: mismatch root of propagate '%v' != '%v' of file",
+		other.GetRoot(), root))
+	require.False(t, proc.genesis.Exists())
+}
+
+func TestProcessor_GenesisMessage_AllowList(t *testing.T) {
+	ro := authority.FromAuthority(fake.NewAuthority(3, fake.NewSigner))
+
+	digest, err := types.RosterDigest(ro, crypto.NewSha256Factory())
+	require.NoError(t, err)
+
+	genesis, err := types.NewGenesis(ro)
+	require.NoError(t, err)
+
+	req := mino.Request{
+		Message: types.NewGenesisMessage(genesis),
+	}
+
+	makeProc := func(loader GenesisLoader) *processor {
+		proc := newProcessor()
+		proc.tree = blockstore.NewTreeCache(fakeTree{})
+		proc.genesis = blockstore.NewGenesisStore()
+		proc.access = fakeAccess{}
+		proc.hashFactory = crypto.NewSha256Factory()
+		proc.genesisLoader = loader
+
+		return proc
+	}
+
+	// The tree root of the fake tree does not match the genesis, which proves
+	// the allow-list has been passed.
+	proc := makeProc(NewStaticGenesisLoader([]byte{1}, digest[:]))
+	_, err = proc.Process(req)
+	require.EqualError(t, err, "mismatch tree root '00000000' != '726f6f74'")
+
+	proc = makeProc(NewStaticGenesisLoader([]byte{1}))
+	_, err = proc.Process(req)
+	require.EqualError(t, err,
+		fmt.Sprintf("genesis not allowed: roster digest %#x is not in the allow-list", digest[:]))
+
+	proc = makeProc(NewStaticGenesisLoader())
+	_, err = proc.Process(req)
+	require.EqualError(t, err,
+		fmt.Sprintf("genesis not allowed: roster digest %#x is not in the allow-list", digest[:]))
+
+	proc = makeProc(badGenesisLoader{})
+	_, err = proc.Process(req)
+	require.EqualError(t, err, fake.Err("genesis not allowed: couldn't load allow-list"))
+
+	proc = makeProc(NewStaticGenesisLoader(digest[:]))
+	proc.hashFactory = fake.NewHashFactory(fake.NewBadHash())
+	_, err = proc.Process(req)
+	require.EqualError(t, err,
+		fake.Err("genesis not allowed: couldn't compute roster digest: couldn't write member"))
+}
+
 func TestProcessor_DoneMessage_Process(t *testing.T) {
 	proc := newProcessor()
 	proc.pbftsm = fakeSM{}
@@ -144,6 +682,199 @@ func TestProcessor_DoneMessage_Process(t *testing.T) {
 	require.EqualError(t, err, fake.Err("pbftsm finalized failed"))
 }
 
+func TestProcessor_DoneMessage_StoredAck(t *testing.T) {
+	proc := newProcessor()
+	proc.pbftsm = fakeSM{}
+	proc.tree = blockstore.NewTreeCache(fakeTree{})
+	proc.blocks = blockstore.NewInMemory()
+	proc.storageAck = true
+
+	req := mino.Request{
+		Message: types.NewDone(types.Digest{}, fake.Signature{}),
+	}
+
+	_, err := proc.Process(req)
+	require.EqualError(t, err, "reading last block: store empty: no block")
+
+	link := makeBlock(t, types.Digest{})
+	proc.blocks.Store(link)
+
+	root := types.Digest{}
+	copy(root[:], []byte("root"))
+
+	resp, err := proc.Process(req)
+	require.NoError(t, err)
+	require.Equal(t, types.NewStoredMessage(0, root), resp)
+
+	// A request for the block already stored is acknowledged without
+	// finalizing it again.
+	proc.pbftsm = fakeSM{err: fake.GetError()}
+	req.Message = types.NewDone(link.GetTo(), fake.Signature{})
+
+	resp, err = proc.Process(req)
+	require.NoError(t, err)
+	require.Equal(t, types.NewStoredMessage(0, root), resp)
+}
+
+func TestProcessor_RetryFinalize_Process(t *testing.T) {
+	proc := newProcessor()
+	proc.finalizeBackoff = time.Millisecond
+
+	sm := &flakySM{
+		counter: fake.NewCounter(2),
+		err:     pbft.NewTransientError(fake.GetError()),
+	}
+
+	proc.pbftsm = sm
+
+	req := mino.Request{
+		Message: types.NewDone(types.Digest{}, fake.Signature{}),
+	}
+
+	resp, err := proc.Process(req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+	require.Equal(t, 3, sm.calls)
+
+	// The failure persists longer than the number of attempts.
+	sm.counter = fake.NewCounter(3)
+	sm.calls = 0
+	_, err = proc.Process(req)
+	require.EqualError(t, err, fake.Err("pbftsm finalized failed"))
+	require.Equal(t, 3, sm.calls)
+
+	// A permanent failure is not retried.
+	sm.counter = fake.NewCounter(1)
+	sm.err = fake.GetError()
+	sm.calls = 0
+	_, err = proc.Process(req)
+	require.EqualError(t, err, fake.Err("pbftsm finalized failed"))
+	require.Equal(t, 1, sm.calls)
+}
+
+func TestProcessor_CommitFormatEncoding_Invoke(t *testing.T) {
+	signers := []bls.Signer{bls.NewSigner(), bls.NewSigner(), bls.NewSigner()}
+
+	sigs := make([]crypto.Signature, len(signers))
+	for i, signer := range signers {
+		sig, err := signer.Sign([]byte("prepare"))
+		require.NoError(t, err)
+
+		sigs[i] = sig
+	}
+
+	agg, err := signers[0].Aggregate(sigs...)
+	require.NoError(t, err)
+
+	ctx := json.NewContext()
+	sigFac := bls.NewSignatureFactory()
+	thresholdFac := thresholdtypes.NewSignatureFactory(sigFac)
+
+	proc := newProcessor()
+	proc.pbftsm = fakeSM{}
+
+	// Binary format of the aggregate.
+	proc.commitEncoding = types.BinaryEncoding
+
+	data, err := proc.Invoke(fake.NewAddress(0), types.NewCommit(types.Digest{}, agg))
+	require.NoError(t, err)
+	require.True(t, agg.Equal(bls.NewSignature(data)))
+
+	// JSON format of the aggregate, as stored in the blocks.
+	proc.commitEncoding = types.FormatEncoding(ctx)
+
+	data, err = proc.Invoke(fake.NewAddress(0), types.NewCommit(types.Digest{}, agg))
+	require.NoError(t, err)
+
+	decoded, err := sigFac.SignatureOf(ctx, data)
+	require.NoError(t, err)
+	require.True(t, agg.Equal(decoded))
+
+	// JSON format of the aggregate of a threshold of the participants.
+	thresholdSig := thresholdtypes.NewSignature(agg, []byte{0b101})
+
+	data, err = proc.Invoke(fake.NewAddress(0), types.NewCommit(types.Digest{}, thresholdSig))
+	require.NoError(t, err)
+
+	decoded, err = thresholdFac.SignatureOf(ctx, data)
+	require.NoError(t, err)
+	require.True(t, thresholdSig.Equal(decoded))
+
+	proc.commitEncoding = types.FormatEncoding(fake.NewBadContext())
+	_, err = proc.Invoke(fake.NewAddress(0), types.NewCommit(types.Digest{}, agg))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "couldn't marshal signature: failed to serialize signature: ")
+}
+
+func TestProcessor_FaultCommit_Invoke(t *testing.T) {
+	proc := newProcessor()
+	proc.pbftsm = fakeSM{}
+	proc.faults = NewFaultInjector()
+	proc.faults.Fail(FaultCommit, 1, fake.GetError())
+
+	msg := types.NewCommit(types.Digest{}, fake.Signature{})
+
+	_, err := proc.Invoke(fake.NewAddress(0), msg)
+	require.EqualError(t, err, fake.Err("pbft commit failed"))
+
+	_, err = proc.Invoke(fake.NewAddress(0), msg)
+	require.NoError(t, err)
+}
+
+func TestProcessor_FaultGenesisCommit_Process(t *testing.T) {
+	proc := newProcessor()
+	proc.tree = blockstore.NewTreeCache(fakeTree{})
+	proc.genesis = blockstore.NewGenesisStore()
+	proc.access = fakeAccess{}
+	proc.faults = NewFaultInjector()
+	proc.faults.Fail(FaultGenesisCommit, 1, fake.GetError())
+
+	ro := authority.FromAuthority(fake.NewAuthority(3, fake.NewSigner))
+
+	root := types.Digest{}
+	copy(root[:], []byte("root"))
+
+	genesis, err := types.NewGenesis(ro, types.WithGenesisRoot(root))
+	require.NoError(t, err)
+
+	req := mino.Request{Message: types.NewGenesisMessage(genesis)}
+
+	_, err = proc.Process(req)
+	require.EqualError(t, err, fake.Err("tree commit failed"))
+	require.False(t, proc.genesis.Exists())
+
+	_, err = proc.Process(req)
+	require.NoError(t, err)
+	require.True(t, proc.genesis.Exists())
+}
+
+func TestProcessor_AbortMessage_Process(t *testing.T) {
+	proc := newProcessor()
+	proc.pbftsm = fakeSM{}
+
+	req := mino.Request{
+		Address: fake.NewAddress(0),
+		Message: types.NewAbortMessage(types.Digest{1}),
+	}
+
+	resp, err := proc.Process(req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	proc.pbftsm = fakeSM{err: fake.GetError()}
+	_, err = proc.Process(req)
+	require.EqualError(t, err, fake.Err("abort failed: pbft abort failed"))
+
+	proc.pbftsm = fakeSM{errLeader: fake.GetError()}
+	_, err = proc.Process(req)
+	require.EqualError(t, err, fake.Err("reading leader"))
+
+	proc.pbftsm = fakeSM{}
+	req.Address = fake.NewAddress(1)
+	_, err = proc.Process(req)
+	require.EqualError(t, err, "'fake.Address[1]' is not the leader")
+}
+
 func TestProcessor_ViewMessage_Process(t *testing.T) {
 	proc := newProcessor()
 	proc.pbftsm = fakeSM{}
@@ -152,13 +883,71 @@ func TestProcessor_ViewMessage_Process(t *testing.T) {
 		Message: types.NewViewMessage(types.Digest{}, 0, fake.Signature{}),
 	}
 
+	// A view without a certificate is answered with the view of the node.
 	resp, err := proc.Process(req)
 	require.NoError(t, err)
+	require.IsType(t, types.ViewMessage{}, resp)
+
+	proc.pbftsm = fakeSM{err: fake.GetError()}
+	resp, err = proc.Process(req)
+	require.NoError(t, err)
 	require.Nil(t, resp)
+}
+
+func TestProcessor_CertifiedViewMessage_Process(t *testing.T) {
+	sm := &leaderSM{}
+
+	proc := newProcessor()
+	proc.pbftsm = sm
+
+	cert := types.NewViewCertificate([]uint16{0, 1, 2}, fake.Signature{})
 
+	req := mino.Request{
+		Message: types.NewViewMessage(types.Digest{}, 1, fake.Signature{}, types.WithViewCertificate(cert)),
+	}
+
+	_, err := proc.Process(req)
+	require.NoError(t, err)
+	require.True(t, sm.certified)
+	require.Equal(t, uint16(1), sm.leader)
+
+	// An invalid certificate refuses the view.
 	proc.pbftsm = fakeSM{err: fake.GetError()}
+	resp, err := proc.Process(req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+}
+
+func TestProcessor_ViewChangeEvent_Process(t *testing.T) {
+	proc := newProcessor()
+	proc.pbftsm = &leaderSM{}
+
+	obs := viewObserver{ch: make(chan ViewChangeEvent, 1)}
+	proc.watcher.Add(obs)
+
+	cert := types.NewViewCertificate([]uint16{0, 1, 2}, fake.Signature{})
+
+	req := mino.Request{
+		Message: types.NewViewMessage(types.Digest{}, 2, fake.Signature{}, types.WithViewCertificate(cert)),
+	}
+
+	_, err := proc.Process(req)
+	require.NoError(t, err)
+	require.Len(t, obs.ch, 1)
+
+	evt := <-obs.ch
+	require.Equal(t, uint16(2), evt.View)
+	require.Equal(t, fake.NewAddress(2), evt.Leader)
+
+	// Same leader, no view change.
+	_, err = proc.Process(req)
+	require.NoError(t, err)
+	require.Len(t, obs.ch, 0)
+
+	proc.pbftsm = fakeSM{errLeader: fake.GetError()}
 	_, err = proc.Process(req)
 	require.NoError(t, err)
+	require.Len(t, obs.ch, 0)
 }
 
 func TestProcessor_Unsupported_Process(t *testing.T) {
@@ -170,6 +959,44 @@ func TestProcessor_Unsupported_Process(t *testing.T) {
 	require.EqualError(t, err, "unsupported message of type 'fake.Message'")
 }
 
+func TestProcessor_ReadRoster(t *testing.T) {
+	proc := newProcessor()
+	proc.rosterFac = authority.NewFactory(fake.AddressFactory{}, fake.PublicKeyFactory{})
+
+	roster, err := proc.readRoster(valueTree{value: []byte(`[]`)})
+	require.NoError(t, err)
+	require.Equal(t, 0, roster.Len())
+
+	// A tree that has not been bootstrapped has no roster.
+	_, err = proc.readRoster(binprefix.NewMerkleTree(fake.NewInMemoryDB(), binprefix.Nonce{}))
+	require.EqualError(t, err, "roster not found in tree")
+	require.True(t, errors.Is(err, ErrRosterNotFound))
+
+	_, err = proc.readRoster(valueTree{value: []byte{}})
+	require.True(t, errors.Is(err, ErrRosterNotFound))
+
+	// A corrupted roster is reported as a decoding error.
+	_, err = proc.readRoster(valueTree{value: []byte("corrupted")})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "decode failed: ")
+	require.False(t, errors.Is(err, ErrRosterNotFound))
+
+	_, err = proc.readRoster(fakeTree{err: fake.GetError()})
+	require.EqualError(t, err, fake.Err("read from tree"))
+}
+
+func TestProcessor_ChainOptions(t *testing.T) {
+	proc := newProcessor()
+	require.Empty(t, proc.chainOptions())
+
+	proc.verifyWorkers = 1
+	require.Empty(t, proc.chainOptions())
+
+	proc.verifyWorkers = 4
+	proc.version = types.ProtocolV1
+	require.Len(t, proc.chainOptions(), 2)
+}
+
 // -----------------------------------------------------------------------------
 // Utility functions
 
@@ -221,11 +1048,59 @@ func (sm fakeSM) Finalize(types.Digest, crypto.Signature) error {
 	return sm.err
 }
 
+func (sm fakeSM) Abort(types.Digest) error {
+	return sm.err
+}
+
+// flakySM is a state machine that fails to finalize as long as the counter is
+// not done.
+type flakySM struct {
+	fakeSM
+
+	counter *fake.Counter
+	err     error
+	calls   int
+}
+
+func (sm *flakySM) Finalize(types.Digest, crypto.Signature) error {
+	sm.calls++
+
+	if !sm.counter.Done() {
+		sm.counter.Decrease()
+		return sm.err
+	}
+
+	return nil
+}
+
+// leaderSM is a state machine that moves to the leader of the views that it
+// accepts.
+type leaderSM struct {
+	fakeSM
+
+	leader    uint16
+	certified bool
+}
+
+func (sm *leaderSM) GetLeader() (mino.Address, error) {
+	return fake.NewAddress(int(sm.leader)), nil
+}
+
+func (sm *leaderSM) AcceptCertified(view pbft.View, cert types.ViewCertificate) error {
+	sm.leader = view.GetLeader()
+	sm.certified = true
+	return nil
+}
+
 func (sm fakeSM) Expire(mino.Address) (pbft.View, error) {
 	return pbft.View{}, sm.err
 }
 
-func (sm fakeSM) Accept(pbft.View) error {
+func (sm fakeSM) Vote(pbft.View) (pbft.View, error) {
+	return pbft.View{}, sm.err
+}
+
+func (sm fakeSM) AcceptCertified(pbft.View, types.ViewCertificate) error {
 	return sm.err
 }
 
@@ -255,14 +1130,25 @@ func (sync fakeSync) Sync(ctx context.Context, players mino.Players, cfg blocksy
 type fakeSnapshot struct {
 	store.Snapshot
 
-	err error
+	err    error
+	errSet error
+	values map[string][]byte
 }
 
 func (snap fakeSnapshot) Get(key []byte) ([]byte, error) {
-	return []byte{}, snap.err
+	value, found := snap.values[string(key)]
+	if !found {
+		value = []byte{}
+	}
+
+	return value, snap.err
 }
 
 func (snap fakeSnapshot) Set(key []byte, value []byte) error {
+	if snap.errSet != nil {
+		return snap.errSet
+	}
+
 	return snap.err
 }
 
@@ -277,12 +1163,34 @@ type fakeTree struct {
 	errStage  error
 	errCommit error
 	errStore  error
+	errSet    error
+	values    map[string][]byte
 }
 
 func (t fakeTree) GetRoot() []byte {
 	return []byte("root")
 }
 
+// valueTree is a tree that returns the same value for any key.
+type valueTree struct {
+	fakeTree
+
+	value []byte
+}
+
+func (t valueTree) Get(key []byte) ([]byte, error) {
+	return t.value, nil
+}
+
+// emptyTree is a tree without a root that stages a fake tree.
+type emptyTree struct {
+	fakeTree
+}
+
+func (t emptyTree) GetRoot() []byte {
+	return nil
+}
+
 func (t fakeTree) GetPath(key []byte) (hashtree.Path, error) {
 	return nil, t.err
 }
@@ -292,7 +1200,7 @@ func (t fakeTree) Get(key []byte) ([]byte, error) {
 }
 
 func (t fakeTree) Stage(fn func(store.Snapshot) error) (hashtree.StagingTree, error) {
-	err := fn(fakeSnapshot{err: t.errStore})
+	err := fn(fakeSnapshot{err: t.errStore, errSet: t.errSet, values: t.values})
 	if err != nil {
 		return nil, err
 	}
@@ -307,12 +1215,13 @@ func (t fakeTree) Commit() error {
 type fakeGenesisStore struct {
 	blockstore.GenesisStore
 
+	exists bool
 	errGet error
 	errSet error
 }
 
 func (s fakeGenesisStore) Exists() bool {
-	return false
+	return s.exists
 }
 
 func (s fakeGenesisStore) Get() (types.Genesis, error) {
@@ -323,6 +1232,15 @@ func (s fakeGenesisStore) Set(types.Genesis) error {
 	return s.errSet
 }
 
+// makeGenesisStore returns a genesis store with a genesis block so that the
+// processor is bootstrapped.
+func makeGenesisStore(t *testing.T) blockstore.GenesisStore {
+	store := blockstore.NewGenesisStore()
+	require.NoError(t, store.Set(types.Genesis{}))
+
+	return store
+}
+
 type fakeStore struct {
 	blockstore.BlockStore
 }
@@ -341,3 +1259,43 @@ func (fakeStore) Watch(context.Context) <-chan types.BlockLink {
 
 	return ch
 }
+
+// watchStore is a block store that never receives a block, and that closes
+// the watch channel when the context is done.
+type watchStore struct {
+	blockstore.BlockStore
+
+	ctx context.Context
+	ch  chan types.BlockLink
+}
+
+func (s *watchStore) Len() uint64 {
+	return 0
+}
+
+func (s *watchStore) Watch(ctx context.Context) <-chan types.BlockLink {
+	s.ctx = ctx
+	s.ch = make(chan types.BlockLink)
+
+	go func() {
+		<-ctx.Done()
+		close(s.ch)
+	}()
+
+	return s.ch
+}
+
+type badGenesisLoader struct{}
+
+func (badGenesisLoader) Load() ([][]byte, error) {
+	return nil, fake.GetError()
+}
+
+func hexEncoding(sig crypto.Signature) ([]byte, error) {
+	data, err := sig.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(hex.EncodeToString(data)), nil
+}
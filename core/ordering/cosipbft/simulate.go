@@ -0,0 +1,116 @@
+// This file contains the implementation of the simulation of a transaction
+// against the current state.
+//
+
+package cosipbft
+
+import (
+	"go.dedis.ch/dela/core/store"
+	"go.dedis.ch/dela/core/txn"
+	"golang.org/x/xerrors"
+)
+
+// StateChange is a modification of a key of the state. A nil value means that
+// the key is deleted.
+type StateChange struct {
+	Key   []byte
+	Value []byte
+}
+
+// SimulationResult is the outcome of the simulation of a transaction. The
+// changes are the ones that would be applied to the state if the transaction
+// was included in a block, in the order of the first write to each key.
+type SimulationResult struct {
+	Accepted bool
+	Message  string
+	Changes  []StateChange
+}
+
+// Simulate applies the transaction against a copy of the current tree that is
+// never committed, and returns whether it would be accepted alongside the
+// changes it would make to the state.
+func (s *Service) Simulate(tx txn.Transaction) (SimulationResult, error) {
+	var res SimulationResult
+
+	_, err := s.tree.Get().Stage(func(snap store.Snapshot) error {
+		rec := newRecordingSnapshot(snap)
+
+		data, err := s.val.Validate(rec, []txn.Transaction{tx})
+		if err != nil {
+			return xerrors.Errorf("validation failed: %v", err)
+		}
+
+		results := data.GetTransactionResults()
+		if len(results) != 1 {
+			return xerrors.Errorf("expected one result, got %d", len(results))
+		}
+
+		res.Accepted, res.Message = results[0].GetStatus()
+		res.Changes = rec.changes
+
+		return nil
+	})
+
+	if err != nil {
+		return SimulationResult{}, xerrors.Errorf("staging tree failed: %v", err)
+	}
+
+	return res, nil
+}
+
+// recordingSnapshot is a snapshot that records the writes applied to it.
+//
+// - implements store.Snapshot
+type recordingSnapshot struct {
+	store.Snapshot
+
+	changes []StateChange
+	indices map[string]int
+}
+
+func newRecordingSnapshot(snap store.Snapshot) *recordingSnapshot {
+	return &recordingSnapshot{
+		Snapshot: snap,
+		indices:  make(map[string]int),
+	}
+}
+
+// Set implements store.Writable. It sets the value of the key in the snapshot
+// and records the change.
+func (s *recordingSnapshot) Set(key, value []byte) error {
+	err := s.Snapshot.Set(key, value)
+	if err != nil {
+		return err
+	}
+
+	s.record(key, append([]byte{}, value...))
+
+	return nil
+}
+
+// Delete implements store.Writable. It deletes the key from the snapshot and
+// records the change.
+func (s *recordingSnapshot) Delete(key []byte) error {
+	err := s.Snapshot.Delete(key)
+	if err != nil {
+		return err
+	}
+
+	s.record(key, nil)
+
+	return nil
+}
+
+func (s *recordingSnapshot) record(key, value []byte) {
+	index, found := s.indices[string(key)]
+	if found {
+		s.changes[index].Value = value
+		return
+	}
+
+	s.indices[string(key)] = len(s.changes)
+	s.changes = append(s.changes, StateChange{
+		Key:   append([]byte{}, key...),
+		Value: value,
+	})
+}
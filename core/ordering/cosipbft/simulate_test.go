@@ -0,0 +1,86 @@
+package cosipbft
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/execution"
+	"go.dedis.ch/dela/core/execution/native"
+	"go.dedis.ch/dela/core/ordering/cosipbft/types"
+	"go.dedis.ch/dela/core/store"
+	"go.dedis.ch/dela/core/txn/signed"
+	"go.dedis.ch/dela/core/validation/simple"
+	"go.dedis.ch/dela/crypto/bls"
+	"go.dedis.ch/dela/internal/testing/fake"
+)
+
+func TestService_Simulate(t *testing.T) {
+	dir, err := os.MkdirTemp(os.TempDir(), "cosipbft")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	srvc := makeSnapshotService(t, filepath.Join(dir, "test.db"), types.Genesis{}, map[string]string{"A": "apple"})
+
+	exec := native.NewExecution()
+	exec.Set(testContractName, writeExec{})
+	srvc.val = simple.NewService(exec, signed.NewTransactionFactory())
+
+	root := srvc.tree.Get().GetRoot()
+
+	signer := bls.NewSigner()
+
+	res, err := srvc.Simulate(makeTx(t, 0, signer))
+	require.NoError(t, err)
+	require.True(t, res.Accepted)
+	require.Len(t, res.Changes, 3)
+	require.Contains(t, res.Changes, StateChange{Key: []byte("A"), Value: nil})
+	require.Contains(t, res.Changes, StateChange{Key: []byte("B"), Value: []byte("banana")})
+
+	// The state is left untouched by the simulation.
+	require.Equal(t, root, srvc.tree.Get().GetRoot())
+
+	value, err := srvc.tree.Get().Get([]byte("A"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("apple"), value)
+
+	exec.Set(testContractName, testExec{err: fake.GetError()})
+
+	// The nonce is updated even if the execution fails.
+	res, err = srvc.Simulate(makeTx(t, 0, signer))
+	require.NoError(t, err)
+	require.False(t, res.Accepted)
+	require.Equal(t, fake.GetError().Error(), res.Message)
+	require.Len(t, res.Changes, 1)
+
+	res, err = srvc.Simulate(makeTx(t, 5, signer))
+	require.NoError(t, err)
+	require.False(t, res.Accepted)
+	require.Equal(t, "nonce is invalid, expected 0, got 5", res.Message)
+	require.Empty(t, res.Changes)
+
+	srvc.val = fakeValidation{err: fake.GetError()}
+	_, err = srvc.Simulate(makeTx(t, 0, signer))
+	require.EqualError(t, err, fake.Err("staging tree failed: callback failed: validation failed"))
+
+	srvc.val = fakeValidation{}
+	_, err = srvc.Simulate(makeTx(t, 0, signer))
+	require.EqualError(t, err, "staging tree failed: callback failed: expected one result, got 0")
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+// writeExec is an execution that replaces the key "A" by the key "B".
+type writeExec struct{}
+
+func (writeExec) Execute(snap store.Snapshot, step execution.Step) error {
+	err := snap.Delete([]byte("A"))
+	if err != nil {
+		return err
+	}
+
+	return snap.Set([]byte("B"), []byte("banana"))
+}
@@ -0,0 +1,277 @@
+// This file contains the implementation of the export and the import of a
+// snapshot of the state.
+//
+// A snapshot is made of the chain up to the latest block, the blocks that the
+// importing node is missing, and the key/value pairs of the tree at the
+// latest block. It allows a node that is far behind the chain to jump to a
+// recent state without replaying every block. The chain is verified from the
+// genesis block so that the snapshot doesn't need to be trusted.
+//
+
+package cosipbft
+
+import (
+	"encoding/json"
+	"io"
+
+	"go.dedis.ch/dela/core/ordering/cosipbft/types"
+	"go.dedis.ch/dela/core/store"
+	"go.dedis.ch/dela/core/store/hashtree"
+	"go.dedis.ch/dela/core/store/kv"
+	"golang.org/x/xerrors"
+)
+
+// snapshotJSON is the JSON representation of a snapshot.
+type snapshotJSON struct {
+	Chain  json.RawMessage
+	Blocks []json.RawMessage
+	Pairs  []pairJSON
+}
+
+// pairJSON is the JSON representation of a key/value pair of the tree.
+type pairJSON struct {
+	Key   []byte
+	Value []byte
+}
+
+// ExportSnapshot writes the chain to the latest block, the blocks from the
+// given index, and the key/value pairs of the tree to the writer. The index
+// is usually the number of blocks of the node that imports the snapshot.
+func (s *Service) ExportSnapshot(w io.Writer, from uint64) error {
+	tree, unlock := s.tree.GetWithLock()
+	defer unlock()
+
+	iterable, ok := tree.(hashtree.IterableTree)
+	if !ok {
+		return xerrors.Errorf("tree '%T' is not iterable", tree)
+	}
+
+	// The chain is fetched while having the lock of the tree cache so that it
+	// matches the state of the tree.
+	chain, err := s.blocks.GetChain()
+	if err != nil {
+		return xerrors.Errorf("reading chain: %v", err)
+	}
+
+	chainData, err := chain.Serialize(s.context)
+	if err != nil {
+		return xerrors.Errorf("serializing chain: %v", err)
+	}
+
+	m := snapshotJSON{
+		Chain:  chainData,
+		Blocks: []json.RawMessage{},
+		Pairs:  []pairJSON{},
+	}
+
+	for index := from; index < s.blocks.Len(); index++ {
+		link, err := s.blocks.GetByIndex(index)
+		if err != nil {
+			return xerrors.Errorf("reading block %d: %v", index, err)
+		}
+
+		data, err := link.GetBlock().Serialize(s.context)
+		if err != nil {
+			return xerrors.Errorf("serializing block %d: %v", index, err)
+		}
+
+		m.Blocks = append(m.Blocks, data)
+	}
+
+	err = iterable.ForEach(func(key, value []byte) error {
+		m.Pairs = append(m.Pairs, pairJSON{
+			Key:   append([]byte{}, key...),
+			Value: append([]byte{}, value...),
+		})
+
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("reading tree: %v", err)
+	}
+
+	err = json.NewEncoder(w).Encode(m)
+	if err != nil {
+		return xerrors.Errorf("writing snapshot: %v", err)
+	}
+
+	return nil
+}
+
+// ImportSnapshot reads a snapshot from the reader and replaces the state of the
+// tree with it. The chain of the snapshot is verified from the genesis block,
+// and the blocks must extend the local blocks up to the last block of the
+// chain. The root of the new tree must match the one of the last block. The
+// tree and the blocks are stored in a single transaction.
+func (s *Service) ImportSnapshot(r io.Reader) error {
+	var m snapshotJSON
+
+	err := json.NewDecoder(r).Decode(&m)
+	if err != nil {
+		return xerrors.Errorf("couldn't decode snapshot: %v", err)
+	}
+
+	genesis, err := s.genesis.Get()
+	if err != nil {
+		return xerrors.Errorf("couldn't read genesis: %v", err)
+	}
+
+	chain, err := types.VerifyChainData(s.context, m.Chain, genesis, s.chainFac, s.verifierFac)
+	if err != nil {
+		return xerrors.Errorf("invalid chain: %v", err)
+	}
+
+	links, err := s.makeSnapshotLinks(chain, m.Blocks)
+	if err != nil {
+		return xerrors.Errorf("invalid blocks: %v", err)
+	}
+
+	block := chain.GetBlock()
+
+	tree := s.tree.Get()
+
+	iterable, ok := tree.(hashtree.IterableTree)
+	if !ok {
+		return xerrors.Errorf("tree '%T' is not iterable", tree)
+	}
+
+	// The keys that are not in the snapshot are removed so that the state is
+	// exactly the one of the snapshot.
+	var stale [][]byte
+
+	err = iterable.ForEach(func(key, value []byte) error {
+		stale = append(stale, append([]byte{}, key...))
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("reading tree: %v", err)
+	}
+
+	stageTree, err := tree.Stage(func(snap store.Snapshot) error {
+		for _, key := range stale {
+			err := snap.Delete(key)
+			if err != nil {
+				return xerrors.Errorf("couldn't delete key: %v", err)
+			}
+		}
+
+		for _, pair := range m.Pairs {
+			err := snap.Set(pair.Key, pair.Value)
+			if err != nil {
+				return xerrors.Errorf("couldn't set key: %v", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("staging tree failed: %v", err)
+	}
+
+	root := types.Digest{}
+	copy(root[:], stageTree.GetRoot())
+
+	if root != block.GetTreeRoot() {
+		return xerrors.Errorf("mismatch tree root '%v' != '%v'", root, block.GetTreeRoot())
+	}
+
+	// Like the finalization of a block, the tree and the blocks are persisted
+	// in a transaction so that the block store never falls behind the tree.
+	err = s.db.Update(func(txn kv.WritableTx) error {
+		err := stageTree.WithTx(txn).Commit()
+		if err != nil {
+			return xerrors.Errorf("tree commit failed: %v", err)
+		}
+
+		var unlock func()
+
+		txn.OnCommit(func() {
+			unlock = s.tree.SetWithLock(stageTree)
+		})
+
+		blocks := s.blocks.WithTx(txn)
+
+		for _, link := range links {
+			err := blocks.Store(link)
+			if err != nil {
+				return xerrors.Errorf("store block %d: %v", link.GetBlock().GetIndex(), err)
+			}
+		}
+
+		txn.OnCommit(func() {
+			unlock()
+		})
+
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("database failed: %v", err)
+	}
+
+	return nil
+}
+
+// makeSnapshotLinks decodes the blocks of a snapshot and creates their links
+// from the verified chain, so that the blocks are accepted only if they are the
+// ones of the chain that follow the local blocks, up to the last block of the
+// chain.
+func (s *Service) makeSnapshotLinks(chain types.Chain, data []json.RawMessage) ([]types.BlockLink, error) {
+	verified := chain.GetLinks()
+	next := s.blocks.Len()
+
+	if next > uint64(len(verified)) {
+		return nil, xerrors.Errorf("chain of %d links is behind %d blocks", len(verified), next)
+	}
+
+	if next > 0 {
+		last, err := s.blocks.Last()
+		if err != nil {
+			return nil, xerrors.Errorf("reading last block: %v", err)
+		}
+
+		if last.GetTo() != verified[next-1].GetTo() {
+			return nil, xerrors.Errorf("block %d is not part of the chain", next-1)
+		}
+	}
+
+	if int(next)+len(data) != len(verified) {
+		return nil, xerrors.Errorf("got %d blocks from %d for a chain of %d",
+			len(data), next, len(verified))
+	}
+
+	links := make([]types.BlockLink, len(data))
+
+	for i, raw := range data {
+		msg, err := s.blockFac.Deserialize(s.context, raw)
+		if err != nil {
+			return nil, xerrors.Errorf("couldn't decode block: %v", err)
+		}
+
+		block, ok := msg.(types.Block)
+		if !ok {
+			return nil, xerrors.Errorf("invalid block '%T'", msg)
+		}
+
+		index := next + uint64(i)
+		expected := verified[index]
+
+		if block.GetIndex() != index || block.GetHash() != expected.GetTo() {
+			return nil, xerrors.Errorf("block %d is not part of the chain", index)
+		}
+
+		opts := []types.LinkOption{
+			types.WithSignatures(expected.GetPrepareSignature(), expected.GetCommitSignature()),
+			types.WithChangeSet(expected.GetChangeSet()),
+			types.WithLinkHashFactory(s.hashFactory),
+		}
+
+		link, err := types.NewBlockLink(expected.GetFrom(), block, opts...)
+		if err != nil {
+			return nil, xerrors.Errorf("creating link: %v", err)
+		}
+
+		links[i] = link
+	}
+
+	return links, nil
+}
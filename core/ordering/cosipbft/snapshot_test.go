@@ -0,0 +1,308 @@
+package cosipbft
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
+	"go.dedis.ch/dela/core/ordering/cosipbft/blockstore"
+	"go.dedis.ch/dela/core/ordering/cosipbft/types"
+	"go.dedis.ch/dela/core/store"
+	"go.dedis.ch/dela/core/store/hashtree/binprefix"
+	"go.dedis.ch/dela/core/store/kv"
+	"go.dedis.ch/dela/core/txn/signed"
+	"go.dedis.ch/dela/core/validation/simple"
+	"go.dedis.ch/dela/crypto"
+	"go.dedis.ch/dela/crypto/bls"
+	"go.dedis.ch/dela/internal/testing/fake"
+	"go.dedis.ch/dela/mino"
+)
+
+func TestService_Snapshot_RoundTrip(t *testing.T) {
+	dir, err := os.MkdirTemp(os.TempDir(), "cosipbft")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	pairs := map[string]string{
+		"A": "apple",
+		"B": "banana",
+		"C": "cherry",
+		"D": "date",
+	}
+
+	signer := bls.NewSigner()
+	genesis := makeSnapshotGenesis(t, signer)
+
+	src := makeSnapshotService(t, filepath.Join(dir, "src.db"), genesis, pairs)
+
+	root := types.Digest{}
+	copy(root[:], src.tree.Get().GetRoot())
+
+	first := makeSnapshotLink(t, signer, genesis.GetHash(), 0, types.Digest{})
+	last := makeSnapshotLink(t, signer, first.GetTo(), 1, root)
+
+	src.blocks = blockstore.NewInMemory()
+	require.NoError(t, src.blocks.Store(first))
+	require.NoError(t, src.blocks.Store(last))
+
+	// The destination has a stale key and a different value that must be
+	// replaced by the snapshot, and it already has the first block.
+	dst := makeSnapshotService(t, filepath.Join(dir, "dst.db"), genesis, map[string]string{
+		"A": "avocado",
+		"Z": "zucchini",
+	})
+	require.NoError(t, dst.blocks.Store(first))
+
+	out := new(bytes.Buffer)
+	err = src.ExportSnapshot(out, dst.blocks.Len())
+	require.NoError(t, err)
+
+	err = dst.ImportSnapshot(bytes.NewReader(out.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, root.Bytes(), dst.tree.Get().GetRoot())
+	require.Equal(t, uint64(2), dst.blocks.Len())
+
+	stored, err := dst.blocks.Last()
+	require.NoError(t, err)
+	require.Equal(t, last.GetHash(), stored.GetHash())
+
+	for key, value := range pairs {
+		stored, err := dst.tree.Get().Get([]byte(key))
+		require.NoError(t, err)
+		require.Equal(t, []byte(value), stored)
+	}
+
+	value, err := dst.tree.Get().Get([]byte("Z"))
+	require.NoError(t, err)
+	require.Nil(t, value)
+
+	// A node without any block imports the whole chain.
+	fresh := makeSnapshotService(t, filepath.Join(dir, "fresh.db"), genesis, nil)
+
+	out = new(bytes.Buffer)
+	err = src.ExportSnapshot(out, 0)
+	require.NoError(t, err)
+
+	err = fresh.ImportSnapshot(bytes.NewReader(out.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), fresh.blocks.Len())
+	require.Equal(t, root.Bytes(), fresh.tree.Get().GetRoot())
+}
+
+func TestService_ExportSnapshot(t *testing.T) {
+	srvc := &Service{processor: newProcessor()}
+	srvc.tree = blockstore.NewTreeCache(fakeTree{})
+
+	err := srvc.ExportSnapshot(new(bytes.Buffer), 0)
+	require.EqualError(t, err, "tree 'cosipbft.fakeTree' is not iterable")
+
+	dir, err := os.MkdirTemp(os.TempDir(), "cosipbft")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	srvc = makeSnapshotService(t, filepath.Join(dir, "test.db"), types.Genesis{}, nil)
+
+	err = srvc.ExportSnapshot(new(bytes.Buffer), 0)
+	require.EqualError(t, err, "reading chain: store is empty")
+
+	signer := bls.NewSigner()
+	require.NoError(t, srvc.blocks.Store(makeSnapshotLink(t, signer, types.Digest{}, 0, types.Digest{})))
+
+	err = srvc.ExportSnapshot(new(bytes.Buffer), 2)
+	require.NoError(t, err)
+
+	err = srvc.ExportSnapshot(new(bytes.Buffer), 0)
+	require.NoError(t, err)
+}
+
+func TestService_ImportSnapshot(t *testing.T) {
+	dir, err := os.MkdirTemp(os.TempDir(), "cosipbft")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	signer := bls.NewSigner()
+	genesis := makeSnapshotGenesis(t, signer)
+
+	srvc := makeSnapshotService(t, filepath.Join(dir, "test.db"), genesis, map[string]string{"A": "apple"})
+
+	err = srvc.ImportSnapshot(bytes.NewBufferString("{"))
+	require.EqualError(t, err, "couldn't decode snapshot: unexpected EOF")
+
+	err = srvc.ImportSnapshot(bytes.NewBufferString(`{"Chain":{}}`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid chain: couldn't decode chain: ")
+
+	first := makeSnapshotLink(t, signer, genesis.GetHash(), 0, types.Digest{})
+	last := makeSnapshotLink(t, signer, first.GetTo(), 1, types.Digest{1})
+
+	src := makeSnapshotService(t, filepath.Join(dir, "src.db"), genesis, nil)
+	require.NoError(t, src.blocks.Store(first))
+	require.NoError(t, src.blocks.Store(last))
+
+	// The chain is signed by a signer that is not in the genesis roster.
+	other := makeSnapshotService(t, filepath.Join(dir, "other.db"), makeSnapshotGenesis(t, bls.NewSigner()), nil)
+
+	err = other.ImportSnapshot(exportSnapshot(t, src, 0))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid chain: couldn't verify chain: ")
+
+	err = srvc.ImportSnapshot(exportSnapshot(t, src, 1))
+	require.EqualError(t, err, "invalid blocks: got 1 blocks from 0 for a chain of 2")
+
+	err = srvc.ImportSnapshot(exportSnapshot(t, src, 0))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "mismatch tree root ")
+
+	// The state is unchanged after a failure.
+	value, err := srvc.tree.Get().Get([]byte("A"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("apple"), value)
+	require.Equal(t, uint64(0), srvc.blocks.Len())
+
+	// The local blocks are not part of the chain of the snapshot.
+	diverged := makeSnapshotService(t, filepath.Join(dir, "diverged.db"), genesis, nil)
+	require.NoError(t, diverged.blocks.Store(makeSnapshotLink(t, signer, genesis.GetHash(), 0, types.Digest{2})))
+
+	err = diverged.ImportSnapshot(exportSnapshot(t, src, 1))
+	require.EqualError(t, err, "invalid blocks: block 0 is not part of the chain")
+
+	srvc.tree.Set(fakeTree{})
+	err = srvc.ImportSnapshot(exportSnapshot(t, src, 0))
+	require.EqualError(t, err, "tree 'cosipbft.fakeTree' is not iterable")
+
+	srvc.genesis = blockstore.NewGenesisStore()
+	err = srvc.ImportSnapshot(exportSnapshot(t, src, 0))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "couldn't read genesis: ")
+}
+
+func TestService_MakeSnapshotLinks(t *testing.T) {
+	dir, err := os.MkdirTemp(os.TempDir(), "cosipbft")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	signer := bls.NewSigner()
+	genesis := makeSnapshotGenesis(t, signer)
+
+	first := makeSnapshotLink(t, signer, genesis.GetHash(), 0, types.Digest{})
+	chain := types.NewChain(first, nil)
+
+	srvc := makeSnapshotService(t, filepath.Join(dir, "test.db"), genesis, nil)
+
+	data, err := first.GetBlock().Serialize(srvc.context)
+	require.NoError(t, err)
+
+	links, err := srvc.makeSnapshotLinks(chain, []json.RawMessage{data})
+	require.NoError(t, err)
+	require.Len(t, links, 1)
+	require.Equal(t, first.GetHash(), links[0].GetHash())
+
+	_, err = srvc.makeSnapshotLinks(chain, []json.RawMessage{[]byte("{")})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "couldn't decode block: ")
+
+	// A block that is not the one of the chain.
+	other := makeSnapshotLink(t, signer, genesis.GetHash(), 0, types.Digest{3})
+
+	data, err = other.GetBlock().Serialize(srvc.context)
+	require.NoError(t, err)
+
+	_, err = srvc.makeSnapshotLinks(chain, []json.RawMessage{data})
+	require.EqualError(t, err, "block 0 is not part of the chain")
+
+	require.NoError(t, srvc.blocks.Store(first))
+	require.NoError(t, srvc.blocks.Store(makeSnapshotLink(t, signer, first.GetTo(), 1, types.Digest{})))
+
+	_, err = srvc.makeSnapshotLinks(chain, nil)
+	require.EqualError(t, err, "chain of 1 links is behind 2 blocks")
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+func makeSnapshotService(t *testing.T, path string, genesis types.Genesis, pairs map[string]string) *Service {
+	db, err := kv.New(path)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { db.Close() })
+
+	tree, err := binprefix.NewMerkleTree(db, binprefix.Nonce{}).Stage(func(snap store.Snapshot) error {
+		for key, value := range pairs {
+			err := snap.Set([]byte(key), []byte(value))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, tree.Commit())
+
+	blockFac := types.NewBlockFactory(simple.NewResultFactory(signed.NewTransactionFactory()))
+	csFac := authority.NewChangeSetFactory(fake.AddressFactory{}, bls.NewPublicKeyFactory())
+	linkFac := types.NewLinkFactory(blockFac, bls.NewSignatureFactory(), csFac)
+
+	srvc := &Service{processor: newProcessor()}
+	srvc.tree = blockstore.NewTreeCache(tree)
+	srvc.blocks = blockstore.NewInMemory()
+	srvc.genesis = blockstore.NewGenesisStore()
+	srvc.hashFactory = crypto.NewSha256Factory()
+	srvc.blockFac = blockFac
+	srvc.linkFac = linkFac
+	srvc.chainFac = types.NewChainFactory(linkFac)
+	srvc.verifierFac = bls.NewSigner().GetVerifierFactory()
+	srvc.db = db
+
+	require.NoError(t, srvc.genesis.Set(genesis))
+
+	return srvc
+}
+
+func makeSnapshotGenesis(t *testing.T, signer crypto.Signer) types.Genesis {
+	ro := authority.New([]mino.Address{fake.NewAddress(0)}, []crypto.PublicKey{signer.GetPublicKey()})
+
+	genesis, err := types.NewGenesis(ro)
+	require.NoError(t, err)
+
+	return genesis
+}
+
+func makeSnapshotLink(t *testing.T, signer crypto.Signer, from types.Digest,
+	index uint64, root types.Digest) types.BlockLink {
+
+	block, err := types.NewBlock(simple.NewResult(nil), types.WithIndex(index), types.WithTreeRoot(root))
+	require.NoError(t, err)
+
+	unsigned, err := types.NewBlockLink(from, block)
+	require.NoError(t, err)
+
+	prepare, err := signer.Sign(unsigned.GetHash().Bytes())
+	require.NoError(t, err)
+
+	data, err := prepare.MarshalBinary()
+	require.NoError(t, err)
+
+	commit, err := signer.Sign(data)
+	require.NoError(t, err)
+
+	link, err := types.NewBlockLink(from, block, types.WithSignatures(prepare, commit))
+	require.NoError(t, err)
+
+	return link
+}
+
+func exportSnapshot(t *testing.T, srvc *Service, from uint64) *bytes.Buffer {
+	out := new(bytes.Buffer)
+	require.NoError(t, srvc.ExportSnapshot(out, from))
+
+	return out
+}
@@ -0,0 +1,103 @@
+// This file contains the implementation of the asynchronous submission of a
+// transaction.
+//
+
+package cosipbft
+
+import (
+	"bytes"
+	"context"
+
+	"go.dedis.ch/dela/core/ordering"
+	"go.dedis.ch/dela/core/txn"
+	"go.dedis.ch/dela/core/validation"
+	"golang.org/x/xerrors"
+)
+
+// ErrExpired is the error returned by a confirmation when the transaction has
+// not been committed in time.
+var ErrExpired = xerrors.New("transaction expired")
+
+// Confirmation is a handle to await the inclusion of a submitted transaction
+// in a block. It is resolved when the transaction is committed, or when it
+// expires.
+type Confirmation struct {
+	done   chan struct{}
+	index  uint64
+	result validation.TransactionResult
+	err    error
+}
+
+func newConfirmation() *Confirmation {
+	return &Confirmation{
+		done: make(chan struct{}),
+	}
+}
+
+// Done returns a channel that is closed when the confirmation is resolved.
+func (c *Confirmation) Done() <-chan struct{} {
+	return c.done
+}
+
+// Wait blocks until the confirmation is resolved, or the context is done. It
+// returns the index of the block and the result of the transaction, or an error
+// if the transaction has expired.
+func (c *Confirmation) Wait(ctx context.Context) (uint64, validation.TransactionResult, error) {
+	select {
+	case <-c.done:
+		return c.index, c.result, c.err
+	case <-ctx.Done():
+		return 0, nil, ctx.Err()
+	}
+}
+
+// await resolves the confirmation with the first event that includes the
+// transaction. The confirmation expires when the channel is closed beforehand.
+func (c *Confirmation) await(events <-chan ordering.Event, id []byte) {
+	defer close(c.done)
+
+	for evt := range events {
+		for _, res := range evt.Transactions {
+			if bytes.Equal(res.GetTransaction().GetID(), id) {
+				c.index = evt.Index
+				c.result = res
+
+				return
+			}
+		}
+	}
+
+	c.err = ErrExpired
+}
+
+// Submit adds the transaction to the pool and returns a confirmation that is
+// resolved when the transaction is committed. The transaction expires after the
+// transaction timeout of the service.
+func (s *Service) Submit(tx txn.Transaction) (*Confirmation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.transactionTimeout)
+
+	// The service is watched before the transaction is added so that the
+	// block including it can't be missed.
+	events := s.Watch(ctx)
+
+	err := s.pool.Add(tx)
+	if err != nil {
+		cancel()
+
+		return nil, xerrors.Errorf("pool failed: %v", err)
+	}
+
+	conf := newConfirmation()
+
+	go func() {
+		conf.await(events, tx.GetID())
+		cancel()
+
+		// The channel is drained until it is closed so that the watcher is
+		// never blocked.
+		for range events {
+		}
+	}()
+
+	return conf, nil
+}
@@ -0,0 +1,101 @@
+package cosipbft
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core"
+	"go.dedis.ch/dela/core/ordering"
+	"go.dedis.ch/dela/core/txn"
+	"go.dedis.ch/dela/core/txn/pool"
+	"go.dedis.ch/dela/core/txn/pool/mem"
+	"go.dedis.ch/dela/core/validation"
+	"go.dedis.ch/dela/core/validation/simple"
+	"go.dedis.ch/dela/internal/testing/fake"
+)
+
+func TestService_Submit(t *testing.T) {
+	srvc := &Service{
+		processor:          newProcessor(),
+		transactionTimeout: time.Minute,
+	}
+	srvc.pool = mem.NewPool()
+
+	tx := makeTx(t, 0, fake.NewSigner())
+
+	conf, err := srvc.Submit(tx)
+	require.NoError(t, err)
+
+	srvc.watcher.Notify(ordering.Event{Index: 1})
+	srvc.watcher.Notify(ordering.Event{
+		Index: 2,
+		Transactions: []validation.TransactionResult{
+			simple.NewTransactionResult(makeTx(t, 1, fake.NewSigner()), true, ""),
+			simple.NewTransactionResult(tx, true, ""),
+		},
+	})
+
+	index, res, err := conf.Wait(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), index)
+	require.Equal(t, tx, res.GetTransaction())
+
+	select {
+	case <-conf.Done():
+	default:
+		t.Fatal("confirmation should be resolved")
+	}
+
+	// The watcher is not blocked by the resolved confirmation.
+	srvc.watcher.Notify(ordering.Event{Index: 3})
+	srvc.watcher.Notify(ordering.Event{Index: 4})
+}
+
+func TestService_Expired_Submit(t *testing.T) {
+	srvc := &Service{
+		processor:          newProcessor(),
+		transactionTimeout: 50 * time.Millisecond,
+	}
+	srvc.pool = mem.NewPool()
+
+	conf, err := srvc.Submit(makeTx(t, 0, fake.NewSigner()))
+	require.NoError(t, err)
+
+	_, _, err = conf.Wait(context.Background())
+	require.Equal(t, ErrExpired, err)
+}
+
+func TestService_BadPool_Submit(t *testing.T) {
+	srvc := &Service{
+		processor:          newProcessor(),
+		transactionTimeout: time.Minute,
+	}
+	srvc.watcher = core.NewWatcher()
+	srvc.pool = badAddPool{}
+
+	_, err := srvc.Submit(makeTx(t, 0, fake.NewSigner()))
+	require.EqualError(t, err, fake.Err("pool failed"))
+}
+
+func TestConfirmation_Wait(t *testing.T) {
+	conf := newConfirmation()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err := conf.Wait(ctx)
+	require.Equal(t, context.Canceled, err)
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+type badAddPool struct {
+	pool.Pool
+}
+
+func (badAddPool) Add(txn.Transaction) error {
+	return fake.GetError()
+}
@@ -0,0 +1,131 @@
+// Package txindex implements a secondary index of the transactions stored in
+// the tree. It maps an identity to the indices of the blocks that contain a
+// transaction of this identity, so that the transactions of an account can be
+// found without scanning every block. It also maps the ID of a transaction to
+// the index of the block that contains it.
+//
+// The index is part of the state, which means that every participant must
+// maintain it for the tree roots to match.
+package txindex
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"go.dedis.ch/dela/core/access"
+	"go.dedis.ch/dela/core/store"
+	"go.dedis.ch/dela/core/txn"
+	"golang.org/x/xerrors"
+)
+
+// keyPrefix separates the keys of the index from the other keys of the tree.
+var keyPrefix = []byte("txindex:")
+
+// txKeyPrefix separates the keys of the transactions from the keys of the
+// identities.
+var txKeyPrefix = []byte("txindex:tx:")
+
+// Key returns the key of the tree where the index of the identity is stored.
+func Key(identity access.Identity) ([]byte, error) {
+	text, err := identity.MarshalText()
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't marshal identity: %v", err)
+	}
+
+	h := sha256.New()
+	h.Write(keyPrefix)
+	h.Write(text)
+
+	return h.Sum(nil), nil
+}
+
+// TxKey returns the key of the tree where the block index of the transaction
+// is stored.
+func TxKey(id []byte) []byte {
+	h := sha256.New()
+	h.Write(txKeyPrefix)
+	h.Write(id)
+
+	return h.Sum(nil)
+}
+
+// Update appends the block index to the index of each identity that has a
+// transaction in the list, and stores the block index of each transaction.
+func Update(snap store.Snapshot, index uint64, txs []txn.Transaction) error {
+	done := make(map[string]struct{})
+
+	for _, tx := range txs {
+		key, err := Key(tx.GetIdentity())
+		if err != nil {
+			return xerrors.Errorf("key: %v", err)
+		}
+
+		_, found := done[string(key)]
+		if !found {
+			done[string(key)] = struct{}{}
+
+			value, err := snap.Get(key)
+			if err != nil {
+				return xerrors.Errorf("couldn't read index: %v", err)
+			}
+
+			value = binary.LittleEndian.AppendUint64(value, index)
+
+			err = snap.Set(key, value)
+			if err != nil {
+				return xerrors.Errorf("couldn't write index: %v", err)
+			}
+		}
+
+		err = snap.Set(TxKey(tx.GetID()), binary.LittleEndian.AppendUint64(nil, index))
+		if err != nil {
+			return xerrors.Errorf("couldn't write transaction: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Read returns the indices of the blocks that contain a transaction of the
+// identity, in ascending order.
+func Read(tree store.Readable, identity access.Identity) ([]uint64, error) {
+	key, err := Key(identity)
+	if err != nil {
+		return nil, xerrors.Errorf("key: %v", err)
+	}
+
+	value, err := tree.Get(key)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't read index: %v", err)
+	}
+
+	if len(value)%8 != 0 {
+		return nil, xerrors.Errorf("malformed index of %d bytes", len(value))
+	}
+
+	indices := make([]uint64, len(value)/8)
+	for i := range indices {
+		indices[i] = binary.LittleEndian.Uint64(value[i*8:])
+	}
+
+	return indices, nil
+}
+
+// Lookup returns the index of the block that contains the transaction, or false
+// if the transaction is unknown.
+func Lookup(tree store.Readable, id []byte) (bool, uint64, error) {
+	value, err := tree.Get(TxKey(id))
+	if err != nil {
+		return false, 0, xerrors.Errorf("couldn't read index: %v", err)
+	}
+
+	if len(value) == 0 {
+		return false, 0, nil
+	}
+
+	if len(value) != 8 {
+		return false, 0, xerrors.Errorf("malformed index of %d bytes", len(value))
+	}
+
+	return true, binary.LittleEndian.Uint64(value), nil
+}
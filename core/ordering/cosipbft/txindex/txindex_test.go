@@ -0,0 +1,162 @@
+package txindex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/access"
+	"go.dedis.ch/dela/core/txn"
+	"go.dedis.ch/dela/core/txn/signed"
+	"go.dedis.ch/dela/crypto"
+	"go.dedis.ch/dela/crypto/bls"
+	"go.dedis.ch/dela/internal/testing/fake"
+)
+
+func TestKey(t *testing.T) {
+	alice := bls.NewSigner().GetPublicKey()
+	bob := bls.NewSigner().GetPublicKey()
+
+	key, err := Key(alice)
+	require.NoError(t, err)
+	require.Len(t, key, 32)
+
+	other, err := Key(bob)
+	require.NoError(t, err)
+	require.NotEqual(t, key, other)
+
+	_, err = Key(fake.NewBadPublicKey())
+	require.EqualError(t, err, fake.Err("couldn't marshal identity"))
+}
+
+func TestUpdate_Read(t *testing.T) {
+	alice := bls.NewSigner().GetPublicKey()
+	bob := bls.NewSigner().GetPublicKey()
+	carol := bls.NewSigner().GetPublicKey()
+
+	snap := fake.NewSnapshot()
+
+	err := Update(snap, 0, []txn.Transaction{makeTx(t, alice), makeTx(t, alice), makeTx(t, bob)})
+	require.NoError(t, err)
+
+	err = Update(snap, 1, nil)
+	require.NoError(t, err)
+
+	err = Update(snap, 2, []txn.Transaction{makeTx(t, bob)})
+	require.NoError(t, err)
+
+	err = Update(snap, 3, []txn.Transaction{makeTx(t, alice)})
+	require.NoError(t, err)
+
+	indices, err := Read(snap, alice)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{0, 3}, indices)
+
+	indices, err = Read(snap, bob)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{0, 2}, indices)
+
+	indices, err = Read(snap, carol)
+	require.NoError(t, err)
+	require.Empty(t, indices)
+}
+
+func TestTxKey(t *testing.T) {
+	key := TxKey([]byte{1})
+	require.Len(t, key, 32)
+	require.NotEqual(t, key, TxKey([]byte{2}))
+}
+
+func TestUpdate_Lookup(t *testing.T) {
+	alice := bls.NewSigner().GetPublicKey()
+
+	snap := fake.NewSnapshot()
+
+	first := makeTx(t, alice)
+
+	second, err := signed.NewTransaction(1, alice)
+	require.NoError(t, err)
+
+	err = Update(snap, 2, []txn.Transaction{first})
+	require.NoError(t, err)
+
+	err = Update(snap, 5, []txn.Transaction{second})
+	require.NoError(t, err)
+
+	found, index, err := Lookup(snap, first.GetID())
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, uint64(2), index)
+
+	found, index, err = Lookup(snap, second.GetID())
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, uint64(5), index)
+
+	found, _, err = Lookup(snap, []byte("unknown"))
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+func TestUpdate_Failures(t *testing.T) {
+	txs := []txn.Transaction{makeTx(t, fake.PublicKey{})}
+
+	err := Update(fake.NewSnapshot(), 0, []txn.Transaction{fakeTx{identity: fake.NewBadPublicKey()}})
+	require.EqualError(t, err, fake.Err("key: couldn't marshal identity"))
+
+	snap := fake.NewSnapshot()
+	snap.ErrRead = fake.GetError()
+	err = Update(snap, 0, txs)
+	require.EqualError(t, err, fake.Err("couldn't read index"))
+
+	snap = fake.NewSnapshot()
+	snap.ErrWrite = fake.GetError()
+	err = Update(snap, 0, txs)
+	require.EqualError(t, err, fake.Err("couldn't write index"))
+}
+
+func TestRead_Failures(t *testing.T) {
+	_, err := Read(fake.NewSnapshot(), fake.NewBadPublicKey())
+	require.EqualError(t, err, fake.Err("key: couldn't marshal identity"))
+
+	_, err = Read(fake.NewBadSnapshot(), fake.PublicKey{})
+	require.EqualError(t, err, fake.Err("couldn't read index"))
+
+	snap := fake.NewSnapshot()
+	key, err := Key(fake.PublicKey{})
+	require.NoError(t, err)
+	require.NoError(t, snap.Set(key, []byte{1, 2, 3}))
+
+	_, err = Read(snap, fake.PublicKey{})
+	require.EqualError(t, err, "malformed index of 3 bytes")
+}
+
+func TestLookup_Failures(t *testing.T) {
+	_, _, err := Lookup(fake.NewBadSnapshot(), []byte{1})
+	require.EqualError(t, err, fake.Err("couldn't read index"))
+
+	snap := fake.NewSnapshot()
+	require.NoError(t, snap.Set(TxKey([]byte{1}), []byte{1, 2, 3}))
+
+	_, _, err = Lookup(snap, []byte{1})
+	require.EqualError(t, err, "malformed index of 3 bytes")
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+func makeTx(t *testing.T, pubkey crypto.PublicKey) txn.Transaction {
+	tx, err := signed.NewTransaction(0, pubkey)
+	require.NoError(t, err)
+
+	return tx
+}
+
+type fakeTx struct {
+	txn.Transaction
+
+	identity access.Identity
+}
+
+func (tx fakeTx) GetIdentity() access.Identity {
+	return tx.identity
+}
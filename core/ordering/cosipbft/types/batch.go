@@ -0,0 +1,48 @@
+// This file contains the decoding of a batch of block links.
+//
+
+package types
+
+import (
+	"fmt"
+
+	"go.dedis.ch/dela/serde"
+)
+
+// EntryError is the error of an entry of a batch that couldn't be decoded.
+type EntryError struct {
+	// Index is the position of the entry in the batch.
+	Index int
+	Err   error
+}
+
+// Error implements error. It returns the position and the reason of the
+// failure.
+func (e EntryError) Error() string {
+	return fmt.Sprintf("entry %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the reason of the failure.
+func (e EntryError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeBlockLinks decodes a batch of serialized block links. A corrupted entry
+// doesn't fail the batch: the links that are decoded are returned in the order
+// of the batch, together with an error for each entry that couldn't be.
+func DecodeBlockLinks(ctx serde.Context, fac LinkFactory, batch [][]byte) ([]BlockLink, []EntryError) {
+	links := make([]BlockLink, 0, len(batch))
+	var errs []EntryError
+
+	for i, data := range batch {
+		link, err := fac.BlockLinkOf(ctx, data)
+		if err != nil {
+			errs = append(errs, EntryError{Index: i, Err: err})
+			continue
+		}
+
+		links = append(links, link)
+	}
+
+	return links, errs
+}
@@ -0,0 +1,53 @@
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/internal/testing/fake"
+	"go.dedis.ch/dela/serde"
+	"golang.org/x/xerrors"
+)
+
+func TestDecodeBlockLinks(t *testing.T) {
+	fac := fakeBatchLinkFac{}
+
+	batch := [][]byte{{0}, {1}, []byte("corrupt"), {3}}
+
+	links, errs := DecodeBlockLinks(fake.NewContext(), fac, batch)
+	require.Len(t, links, 3)
+	require.Equal(t, uint64(0), links[0].GetBlock().GetIndex())
+	require.Equal(t, uint64(1), links[1].GetBlock().GetIndex())
+	require.Equal(t, uint64(3), links[2].GetBlock().GetIndex())
+
+	require.Len(t, errs, 1)
+	require.Equal(t, 2, errs[0].Index)
+	require.EqualError(t, errs[0], fake.Err("entry 2: corrupted"))
+	require.True(t, xerrors.Is(errs[0], fake.GetError()))
+
+	links, errs = DecodeBlockLinks(fake.NewContext(), fac, nil)
+	require.Empty(t, links)
+	require.Empty(t, errs)
+
+	links, errs = DecodeBlockLinks(fake.NewContext(), fac, [][]byte{[]byte("corrupt")})
+	require.Empty(t, links)
+	require.Len(t, errs, 1)
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+// fakeBatchLinkFac is a link factory that decodes a single byte as the index of
+// the block and fails on the corrupted entries.
+type fakeBatchLinkFac struct {
+	LinkFactory
+}
+
+func (fakeBatchLinkFac) BlockLinkOf(ctx serde.Context, data []byte) (BlockLink, error) {
+	if bytes.Equal(data, []byte("corrupt")) {
+		return nil, xerrors.Errorf("corrupted: %w", fake.GetError())
+	}
+
+	return blockLink{block: Block{index: uint64(data[0])}}, nil
+}
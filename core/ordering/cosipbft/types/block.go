@@ -6,9 +6,11 @@
 package types
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"io"
+	"sort"
 
 	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
 	"go.dedis.ch/dela/core/txn"
@@ -24,6 +26,18 @@ var (
 	blockFormats   = registry.NewSimpleRegistry()
 )
 
+// The optional fields of a block are each written in the fingerprint after a
+// distinct tag, so that a field can't be mistaken for another one.
+const (
+	tagPayloadRoot byte = iota + 1
+	tagRosterDigest
+	tagExtraData
+	tagTerminal
+)
+
+// MaxExtraDataSize is the maximum number of bytes of the extra data of a block.
+const MaxExtraDataSize = 256
+
 // RegisterGenesisFormat registers the engine for the provided format.
 func RegisterGenesisFormat(f serde.Format, e serde.FormatEngine) {
 	genesisFormats.Register(f, e)
@@ -185,19 +199,27 @@ func (f GenesisFactory) Deserialize(ctx serde.Context, data []byte) (serde.Messa
 
 // Block is a block of a chain. It holds an index which is the height of the
 // block from the genesis block, the Merkle tree root and the validation result
-// of the transactions.
+// of the transactions. It can optionally hold the digest of the roster that
+// applies after the block. A terminal block seals the chain so that no block
+// can follow it. When requested, the Merkle root of the payload is recorded in
+// the block.
 //
 // - implements serde.Message
 type Block struct {
-	digest   Digest
-	index    uint64
-	data     validation.Result
-	treeRoot Digest
+	digest       Digest
+	index        uint64
+	data         validation.Result
+	treeRoot     Digest
+	rosterDigest Digest
+	payloadRoot  Digest
+	terminal     bool
+	extraData    []byte
 }
 
 type blockTemplate struct {
 	Block
 	hashFactory crypto.HashFactory
+	merkle      bool
 }
 
 // BlockOption is the type of option to set some fields of a block.
@@ -217,6 +239,40 @@ func WithTreeRoot(root Digest) BlockOption {
 	}
 }
 
+// WithRosterDigest is an option to embed the digest of the roster in the block.
+func WithRosterDigest(digest Digest) BlockOption {
+	return func(tmpl *blockTemplate) {
+		tmpl.rosterDigest = digest
+	}
+}
+
+// WithTerminal is an option to mark the block as the terminal block of the
+// chain.
+func WithTerminal() BlockOption {
+	return func(tmpl *blockTemplate) {
+		tmpl.terminal = true
+	}
+}
+
+// WithExtraData is an option to stamp the block with opaque data defined by the
+// application, like a version or the digest of a configuration. The data is
+// part of the digest of the block and must not exceed MaxExtraDataSize.
+func WithExtraData(data []byte) BlockOption {
+	return func(tmpl *blockTemplate) {
+		tmpl.extraData = data
+	}
+}
+
+// WithPayloadRoot is an option to record the Merkle root of the payload in the
+// block, so that the inclusion of a transaction can be proven. The data of the
+// block must implement MerklePayload. It changes the digest of the block, so it
+// is disabled by default.
+func WithPayloadRoot() BlockOption {
+	return func(tmpl *blockTemplate) {
+		tmpl.merkle = true
+	}
+}
+
 // WithHashFactory is an option to set the hash factory for the block.
 func WithHashFactory(fac crypto.HashFactory) BlockOption {
 	return func(tmpl *blockTemplate) {
@@ -238,6 +294,25 @@ func NewBlock(data validation.Result, opts ...BlockOption) (Block, error) {
 		opt(&tmpl)
 	}
 
+	if len(tmpl.extraData) > MaxExtraDataSize {
+		return tmpl.Block, xerrors.Errorf("extra data of %d bytes exceeds %d",
+			len(tmpl.extraData), MaxExtraDataSize)
+	}
+
+	if tmpl.merkle {
+		payload, ok := data.(MerklePayload)
+		if !ok {
+			return tmpl.Block, xerrors.Errorf("payload '%T' is not a Merkle payload", data)
+		}
+
+		root, err := PayloadRoot(payload, tmpl.hashFactory)
+		if err != nil {
+			return tmpl.Block, xerrors.Errorf("couldn't compute payload root: %v", err)
+		}
+
+		tmpl.payloadRoot = root
+	}
+
 	h := tmpl.hashFactory.New()
 	err := tmpl.Fingerprint(h)
 	if err != nil {
@@ -254,6 +329,45 @@ func (b Block) GetHash() Digest {
 	return b.digest
 }
 
+// Validate recomputes the digest of the block with the hash factory and
+// compares it to the one of the block. A decoded block has its digest computed
+// from the decoded fields, but the fields can be altered afterwards through the
+// slices they share, like the extra data, in which case the block is rejected.
+func (b Block) Validate(fac crypto.HashFactory) error {
+	payload, ok := b.data.(MerklePayload)
+	if ok && b.payloadRoot != (Digest{}) {
+		root, err := PayloadRoot(payload, fac)
+		if err != nil {
+			return xerrors.Errorf("couldn't compute payload root: %v", err)
+		}
+
+		if root != b.payloadRoot {
+			return xerrors.Errorf("mismatch payload root '%v' != '%v'", root, b.payloadRoot)
+		}
+	}
+
+	h := fac.New()
+	err := b.Fingerprint(h)
+	if err != nil {
+		return xerrors.Errorf("fingerprint failed: %v", err)
+	}
+
+	var digest Digest
+	copy(digest[:], h.Sum(nil))
+
+	if digest != b.digest {
+		return xerrors.Errorf("mismatch digest '%v' != '%v'", digest, b.digest)
+	}
+
+	return nil
+}
+
+// Equal returns true if both blocks are the same proposal, which is decided by
+// their digests.
+func (b Block) Equal(other Block) bool {
+	return b.digest == other.digest
+}
+
 // GetIndex returns the index of the block.
 func (b Block) GetIndex() uint64 {
 	return b.index
@@ -282,6 +396,80 @@ func (b Block) GetTreeRoot() Digest {
 	return b.treeRoot
 }
 
+// GetExtraData returns the extra data of the block, or nil if it has none.
+func (b Block) GetExtraData() []byte {
+	return b.extraData
+}
+
+// RosterDigest computes the canonical digest of the members of the roster,
+// which can be embedded in a block. The members are sorted so that two rosters
+// with the same participants produce the same digest regardless of their
+// order.
+func RosterDigest(roster authority.Authority, fac crypto.HashFactory) (Digest, error) {
+	digest := Digest{}
+
+	members := make([][]byte, 0, roster.Len())
+
+	addrs := roster.AddressIterator()
+	pubkeys := roster.PublicKeyIterator()
+
+	for addrs.HasNext() && pubkeys.HasNext() {
+		addrData, err := addrs.GetNext().MarshalText()
+		if err != nil {
+			return digest, xerrors.Errorf("couldn't marshal address: %v", err)
+		}
+
+		pubkeyData, err := pubkeys.GetNext().MarshalBinary()
+		if err != nil {
+			return digest, xerrors.Errorf("couldn't marshal public key: %v", err)
+		}
+
+		// Each field is prefixed with its length so that the concatenation is
+		// unambiguous.
+		member := make([]byte, 0, 8+len(addrData)+len(pubkeyData))
+		member = binary.LittleEndian.AppendUint32(member, uint32(len(addrData)))
+		member = append(member, addrData...)
+		member = binary.LittleEndian.AppendUint32(member, uint32(len(pubkeyData)))
+		member = append(member, pubkeyData...)
+
+		members = append(members, member)
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		return bytes.Compare(members[i], members[j]) < 0
+	})
+
+	h := fac.New()
+
+	for _, member := range members {
+		_, err := h.Write(member)
+		if err != nil {
+			return digest, xerrors.Errorf("couldn't write member: %v", err)
+		}
+	}
+
+	copy(digest[:], h.Sum(nil))
+
+	return digest, nil
+}
+
+// GetRosterDigest returns the digest of the roster embedded in the block, or
+// an empty digest if none is embedded.
+func (b Block) GetRosterDigest() Digest {
+	return b.rosterDigest
+}
+
+// GetPayloadRoot returns the Merkle root of the payload, or an empty digest if
+// the payload is not a Merkle payload.
+func (b Block) GetPayloadRoot() Digest {
+	return b.payloadRoot
+}
+
+// IsTerminal returns true if the block seals the chain.
+func (b Block) IsTerminal() bool {
+	return b.terminal
+}
+
 // Fingerprint implements serde.Fingerprinter. It deterministically writes a
 // binary representation of the block into the writer.
 func (b Block) Fingerprint(w io.Writer) error {
@@ -302,6 +490,41 @@ func (b Block) Fingerprint(w io.Writer) error {
 		return xerrors.Errorf("data fingerprint failed: %v", err)
 	}
 
+	// The optional fields are written only when they are set so that the
+	// digest of a block without them is unchanged.
+	if b.payloadRoot != (Digest{}) {
+		_, err = w.Write(append([]byte{tagPayloadRoot}, b.payloadRoot[:]...))
+		if err != nil {
+			return xerrors.Errorf("couldn't write payload root: %v", err)
+		}
+	}
+
+	if b.rosterDigest != (Digest{}) {
+		_, err = w.Write(append([]byte{tagRosterDigest}, b.rosterDigest[:]...))
+		if err != nil {
+			return xerrors.Errorf("couldn't write roster digest: %v", err)
+		}
+	}
+
+	// The extra data is also prefixed with its length as it has no fixed size.
+	if len(b.extraData) > 0 {
+		buffer := make([]byte, 9, 9+len(b.extraData))
+		buffer[0] = tagExtraData
+		binary.LittleEndian.PutUint64(buffer[1:], uint64(len(b.extraData)))
+
+		_, err = w.Write(append(buffer, b.extraData...))
+		if err != nil {
+			return xerrors.Errorf("couldn't write extra data: %v", err)
+		}
+	}
+
+	if b.terminal {
+		_, err = w.Write([]byte{tagTerminal})
+		if err != nil {
+			return xerrors.Errorf("couldn't write terminal marker: %v", err)
+		}
+	}
+
 	return nil
 }
 
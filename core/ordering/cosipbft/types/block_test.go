@@ -10,7 +10,11 @@ import (
 	"go.dedis.ch/dela/core/txn/signed"
 	"go.dedis.ch/dela/core/validation"
 	"go.dedis.ch/dela/core/validation/simple"
+	"go.dedis.ch/dela/crypto"
+	"go.dedis.ch/dela/crypto/bls"
 	"go.dedis.ch/dela/internal/testing/fake"
+	"go.dedis.ch/dela/mino"
+	"go.dedis.ch/dela/serde"
 )
 
 func init() {
@@ -110,6 +114,20 @@ func TestBlock_GetHash(t *testing.T) {
 	require.NotEqual(t, Digest{}, block.GetHash())
 }
 
+func TestBlock_Equal(t *testing.T) {
+	block, err := NewBlock(simple.NewResult(nil), WithIndex(1))
+	require.NoError(t, err)
+
+	same, err := NewBlock(simple.NewResult(nil), WithIndex(1))
+	require.NoError(t, err)
+	require.True(t, block.Equal(same))
+
+	other, err := NewBlock(simple.NewResult(nil), WithIndex(2))
+	require.NoError(t, err)
+	require.False(t, block.Equal(other))
+	require.False(t, block.Equal(Block{}))
+}
+
 func TestBlock_GetIndex(t *testing.T) {
 	block, err := NewBlock(simple.NewResult(nil), WithIndex(2))
 	require.NoError(t, err)
@@ -136,6 +154,171 @@ func TestBlock_GetTreeRoot(t *testing.T) {
 	require.Equal(t, Digest{3}, block.GetTreeRoot())
 }
 
+func TestBlock_GetRosterDigest(t *testing.T) {
+	block, err := NewBlock(simple.NewResult(nil))
+	require.NoError(t, err)
+	require.Equal(t, Digest{}, block.GetRosterDigest())
+
+	other, err := NewBlock(simple.NewResult(nil), WithRosterDigest(Digest{5}))
+	require.NoError(t, err)
+	require.Equal(t, Digest{5}, other.GetRosterDigest())
+	require.NotEqual(t, block.GetHash(), other.GetHash())
+}
+
+func TestBlock_GetPayloadRoot(t *testing.T) {
+	payload := makePayload(3)
+	payload.Result = simple.NewResult(nil)
+
+	// The payload root is recorded only when requested, so that the digest of
+	// the blocks is unchanged by default.
+	block, err := NewBlock(payload)
+	require.NoError(t, err)
+	require.Equal(t, Digest{}, block.GetPayloadRoot())
+
+	plain, err := NewBlock(simple.NewResult(nil))
+	require.NoError(t, err)
+	require.Equal(t, plain.GetHash(), block.GetHash())
+
+	other, err := NewBlock(payload, WithPayloadRoot())
+	require.NoError(t, err)
+	require.NotEqual(t, Digest{}, other.GetPayloadRoot())
+	require.NotEqual(t, block.GetHash(), other.GetHash())
+
+	proof, err := NewPayloadProof(payload, 1, crypto.NewSha256Factory())
+	require.NoError(t, err)
+	require.NoError(t, proof.Verify(other.GetPayloadRoot(), crypto.NewSha256Factory()))
+
+	_, err = NewBlock(fakePayload{err: fake.GetError()}, WithPayloadRoot())
+	require.EqualError(t, err, fake.Err("couldn't compute payload root: couldn't read leaves"))
+
+	_, err = NewBlock(badData{}, WithPayloadRoot())
+	require.EqualError(t, err, "payload 'types.badData' is not a Merkle payload")
+}
+
+func TestBlock_IsTerminal(t *testing.T) {
+	block, err := NewBlock(simple.NewResult(nil))
+	require.NoError(t, err)
+	require.False(t, block.IsTerminal())
+
+	other, err := NewBlock(simple.NewResult(nil), WithTerminal())
+	require.NoError(t, err)
+	require.True(t, other.IsTerminal())
+	require.NotEqual(t, block.GetHash(), other.GetHash())
+}
+
+func TestBlock_GetExtraData(t *testing.T) {
+	block, err := NewBlock(simple.NewResult(nil))
+	require.NoError(t, err)
+	require.Nil(t, block.GetExtraData())
+
+	other, err := NewBlock(simple.NewResult(nil), WithExtraData([]byte("v1.2.0")))
+	require.NoError(t, err)
+	require.Equal(t, []byte("v1.2.0"), other.GetExtraData())
+	require.NotEqual(t, block.GetHash(), other.GetHash())
+
+	another, err := NewBlock(simple.NewResult(nil), WithExtraData([]byte("v1.3.0")))
+	require.NoError(t, err)
+	require.NotEqual(t, other.GetHash(), another.GetHash())
+
+	_, err = NewBlock(simple.NewResult(nil), WithExtraData(make([]byte, MaxExtraDataSize+1)))
+	require.EqualError(t, err, "extra data of 257 bytes exceeds 256")
+}
+
+func TestBlock_Validate(t *testing.T) {
+	fac := crypto.NewSha256Factory()
+
+	extra := []byte("v1.2.0")
+
+	block, err := NewBlock(simple.NewResult(nil), WithIndex(2), WithExtraData(extra))
+	require.NoError(t, err)
+	require.NoError(t, block.Validate(fac))
+
+	// The extra data is shared with the block, which doesn't match its digest
+	// anymore when it is altered.
+	extra[0] = 'w'
+	err = block.Validate(fac)
+	require.Error(t, err)
+	require.Regexp(t, "^mismatch digest '[0-9a-f]{8}' != '[0-9a-f]{8}'$", err.Error())
+
+	payload := makePayload(3)
+	payload.Result = simple.NewResult(nil)
+
+	block, err = NewBlock(payload, WithPayloadRoot())
+	require.NoError(t, err)
+	require.NoError(t, block.Validate(fac))
+
+	payload.leaves[1][0] = 'x'
+	err = block.Validate(fac)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "mismatch payload root ")
+
+	block.data = fakePayload{err: fake.GetError()}
+	err = block.Validate(fac)
+	require.EqualError(t, err, fake.Err("couldn't compute payload root: couldn't read leaves"))
+
+	block, err = NewBlock(simple.NewResult(nil))
+	require.NoError(t, err)
+
+	err = block.Validate(fake.NewHashFactory(fake.NewBadHash()))
+	require.EqualError(t, err, fake.Err("fingerprint failed: couldn't write index"))
+}
+
+func TestRosterDigest(t *testing.T) {
+	fac := crypto.NewSha256Factory()
+
+	signers := []crypto.Signer{bls.NewSigner(), bls.NewSigner(), bls.NewSigner()}
+	addrs := []mino.Address{fake.NewAddress(0), fake.NewAddress(1), fake.NewAddress(2)}
+
+	roster := authority.New(addrs, []crypto.PublicKey{
+		signers[0].GetPublicKey(),
+		signers[1].GetPublicKey(),
+		signers[2].GetPublicKey(),
+	})
+
+	shuffled := authority.New(
+		[]mino.Address{addrs[2], addrs[0], addrs[1]},
+		[]crypto.PublicKey{
+			signers[2].GetPublicKey(),
+			signers[0].GetPublicKey(),
+			signers[1].GetPublicKey(),
+		},
+	)
+
+	digest, err := RosterDigest(roster, fac)
+	require.NoError(t, err)
+	require.NotEqual(t, Digest{}, digest)
+
+	other, err := RosterDigest(shuffled, fac)
+	require.NoError(t, err)
+	require.Equal(t, digest, other)
+
+	// Same addresses but the public keys are assigned differently.
+	swapped := authority.New(addrs, []crypto.PublicKey{
+		signers[1].GetPublicKey(),
+		signers[0].GetPublicKey(),
+		signers[2].GetPublicKey(),
+	})
+
+	other, err = RosterDigest(swapped, fac)
+	require.NoError(t, err)
+	require.NotEqual(t, digest, other)
+
+	other, err = RosterDigest(roster.Take(mino.RangeFilter(0, 2)).(authority.Authority), fac)
+	require.NoError(t, err)
+	require.NotEqual(t, digest, other)
+
+	bad := authority.New([]mino.Address{fake.NewBadAddress()}, []crypto.PublicKey{fake.PublicKey{}})
+	_, err = RosterDigest(bad, fac)
+	require.EqualError(t, err, fake.Err("couldn't marshal address"))
+
+	bad = authority.New([]mino.Address{fake.NewAddress(0)}, []crypto.PublicKey{fake.NewBadPublicKey()})
+	_, err = RosterDigest(bad, fac)
+	require.EqualError(t, err, fake.Err("couldn't marshal public key"))
+
+	_, err = RosterDigest(roster, fake.NewHashFactory(fake.NewBadHash()))
+	require.EqualError(t, err, fake.Err("couldn't write member"))
+}
+
 func TestBlock_Fingerprint(t *testing.T) {
 	block := Block{
 		index:    3,
@@ -155,6 +338,32 @@ func TestBlock_Fingerprint(t *testing.T) {
 	err = block.Fingerprint(fake.NewBadHashWithDelay(1))
 	require.EqualError(t, err, fake.Err("couldn't write root"))
 
+	block.rosterDigest = Digest{5}
+	buffer.Reset()
+	err = block.Fingerprint(buffer)
+	require.NoError(t, err)
+	require.Regexp(t, "^\x03(\x00){7}\x04(\x00){31}\x02\x05(\x00){31}$", buffer.String())
+
+	err = block.Fingerprint(fake.NewBadHashWithDelay(2))
+	require.EqualError(t, err, fake.Err("couldn't write roster digest"))
+
+	block.payloadRoot = Digest{6}
+	buffer.Reset()
+	err = block.Fingerprint(buffer)
+	require.NoError(t, err)
+	require.Regexp(t, "^\x03(\x00){7}\x04(\x00){31}\x01\x06(\x00){31}\x02\x05(\x00){31}$",
+		buffer.String())
+
+	err = block.Fingerprint(fake.NewBadHashWithDelay(2))
+	require.EqualError(t, err, fake.Err("couldn't write payload root"))
+
+	block.extraData = []byte("A")
+	block.terminal = true
+	buffer.Reset()
+	err = block.Fingerprint(buffer)
+	require.NoError(t, err)
+	require.Regexp(t, "\x03\x01(\x00){7}A\x04$", buffer.String())
+
 	block.data = badData{}
 	err = block.Fingerprint(io.Discard)
 	require.EqualError(t, err, fake.Err("data fingerprint failed"))
@@ -163,6 +372,32 @@ func TestBlock_Fingerprint(t *testing.T) {
 	require.EqualError(t, err, fake.Err("fingerprint failed: couldn't write index"))
 }
 
+func TestBlock_OptionalFields_Fingerprint(t *testing.T) {
+	digest := func(block Block) string {
+		buffer := new(bytes.Buffer)
+		require.NoError(t, block.Fingerprint(buffer))
+
+		return buffer.String()
+	}
+
+	data := simple.NewResult(nil)
+
+	withPayload := Block{data: data, payloadRoot: Digest{1}}
+	withRoster := Block{data: data, rosterDigest: Digest{1}}
+	require.NotEqual(t, digest(withPayload), digest(withRoster))
+
+	// An extra data of 24 bytes has the size of a digest once prefixed with
+	// its length.
+	extra := make([]byte, 24)
+	withExtra := Block{data: data, extraData: extra}
+
+	prefixed := Digest{24}
+	copy(prefixed[8:], extra)
+	withDigest := Block{data: data, rosterDigest: prefixed}
+
+	require.NotEqual(t, digest(withExtra), digest(withDigest))
+}
+
 func TestBlock_Serialize(t *testing.T) {
 	block, err := NewBlock(simple.NewResult(nil))
 	require.NoError(t, err)
@@ -175,6 +410,20 @@ func TestBlock_Serialize(t *testing.T) {
 	require.EqualError(t, err, fake.Err("encoding failed"))
 }
 
+func TestBlock_Serialize_ReportSize(t *testing.T) {
+	block, err := NewBlock(simple.NewResult(nil))
+	require.NoError(t, err)
+
+	sink := &fakeSizeSink{}
+	ctx := serde.WithSizeSink(fake.NewContext(), sink)
+
+	data, err := block.Serialize(ctx)
+	require.NoError(t, err)
+	require.Len(t, sink.messages, 1)
+	require.Equal(t, block, sink.messages[0])
+	require.Equal(t, []int{len(data)}, sink.sizes)
+}
+
 func TestBlockFactory_Deserialize(t *testing.T) {
 	txFac := signed.NewTransactionFactory()
 	fac := NewBlockFactory(simple.NewResultFactory(txFac))
@@ -205,3 +454,13 @@ type badData struct {
 func (d badData) Fingerprint(io.Writer) error {
 	return fake.GetError()
 }
+
+type fakeSizeSink struct {
+	messages []serde.Message
+	sizes    []int
+}
+
+func (s *fakeSizeSink) ObserveSize(msg serde.Message, size int) {
+	s.messages = append(s.messages, msg)
+	s.sizes = append(s.sizes, size)
+}
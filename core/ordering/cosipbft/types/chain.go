@@ -7,6 +7,7 @@ package types
 
 import (
 	"io"
+	"sync"
 
 	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
 	"go.dedis.ch/dela/crypto"
@@ -289,23 +290,125 @@ func (fac linkFac) BlockLinkOf(ctx serde.Context, data []byte) (BlockLink, error
 	return link, nil
 }
 
+// SignatureEncoding is the function that returns the binary representation of
+// the prepare signature, which is signed during the commit phase.
+type SignatureEncoding func(sig crypto.Signature) ([]byte, error)
+
+// BinaryEncoding is the default signature encoding. It uses the binary format
+// of the signature itself.
+func BinaryEncoding(sig crypto.Signature) ([]byte, error) {
+	return sig.MarshalBinary()
+}
+
+// FormatEncoding returns a signature encoding that serializes the signature in
+// the format of the context, like the signatures stored in the blocks. It gives
+// the verifiers a documented representation of the aggregate, for instance the
+// JSON format of a BLS signature, rather than the binary form of the curve.
+func FormatEncoding(ctx serde.Context) SignatureEncoding {
+	return func(sig crypto.Signature) ([]byte, error) {
+		data, err := sig.Serialize(ctx)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to serialize signature: %v", err)
+		}
+
+		return data, nil
+	}
+}
+
+// Encode returns the representation of the signature. A nil encoding falls
+// back to the binary encoding.
+func (enc SignatureEncoding) Encode(sig crypto.Signature) ([]byte, error) {
+	if enc == nil {
+		return BinaryEncoding(sig)
+	}
+
+	return enc(sig)
+}
+
 // Chain is a combination of ordered links that will define a proof of existence
 // for a block. It does not include the genesis block which is assumed to be
 // known beforehands.
 //
 // - implements types.Chain
 type chain struct {
-	last  BlockLink
-	prevs []Link
+	last      BlockLink
+	prevs     []Link
+	encoding  SignatureEncoding
+	version   ProtocolVersion
+	workers   int
+	maxLength int
+}
+
+// DefaultMaxChainLength is the default maximum number of links of a chain
+// decoded by a factory.
+const DefaultMaxChainLength = 1 << 20
+
+// ChainOption is the type of option to create a chain.
+type ChainOption func(*chain)
+
+// WithCommitEncoding is the option to set the encoding of the prepare
+// signatures that are signed by the commit signatures of the links. The binary
+// format of the signature is used by default.
+func WithCommitEncoding(encoding SignatureEncoding) ChainOption {
+	return func(c *chain) {
+		c.encoding = encoding
+	}
+}
+
+// WithProtocolVersion is the option to set the version of the signing protocol
+// that the links of the chain have been signed with. The initial version is
+// used by default.
+func WithProtocolVersion(version ProtocolVersion) ChainOption {
+	return func(c *chain) {
+		c.version = version
+	}
+}
+
+// WithVerifyWorkers is the option to set the maximum number of links whose
+// signatures are verified in parallel. The verification is serial by default.
+func WithVerifyWorkers(num int) ChainOption {
+	return func(c *chain) {
+		c.workers = num
+	}
+}
+
+// WithMaxLength is the option to set the maximum number of links of a chain
+// decoded by a factory, so that an overly long chain is rejected before it is
+// verified. DefaultMaxChainLength is used by default.
+func WithMaxLength(num int) ChainOption {
+	return func(c *chain) {
+		c.maxLength = num
+	}
 }
 
 // NewChain creates a new chain from the block link and the previous forward
 // links.
-func NewChain(last BlockLink, prevs []Link) Chain {
-	return chain{
+func NewChain(last BlockLink, prevs []Link, opts ...ChainOption) Chain {
+	c := chain{
 		last:  last,
 		prevs: prevs,
 	}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return c
+}
+
+// ConfigureChain returns a copy of the chain with the options applied. Chains of
+// a different implementation are returned as is.
+func ConfigureChain(c Chain, opts ...ChainOption) Chain {
+	impl, ok := c.(chain)
+	if !ok {
+		return c
+	}
+
+	for _, opt := range opts {
+		opt(&impl)
+	}
+
+	return impl
 }
 
 // GetLinks implements types.Chain. It returns all the links of the chain in
@@ -331,6 +434,11 @@ func (c chain) Verify(genesis Genesis, from Digest, fac crypto.VerifierFactory)
 
 	toProcess := false
 
+	// The links are checked to be consistent first, so that their signatures,
+	// which are independent, can be verified in parallel.
+	var tasks []linkTask
+	var mismatch error
+
 	for _, link := range c.GetLinks() {
 		// Skip the verification until we reach the provided Digest. We still
 		// have to update the roster though.
@@ -344,50 +452,130 @@ func (c chain) Verify(genesis Genesis, from Digest, fac crypto.VerifierFactory)
 
 		// It makes sure that the chain of links is consistent.
 		if prev != link.GetFrom() {
-			return xerrors.Errorf("mismatch from: '%v' != '%v'", link.GetFrom(), prev)
+			mismatch = xerrors.Errorf("mismatch from: '%v' != '%v'", link.GetFrom(), prev)
+			break
 		}
 
-		// The verifier can be used to verify the signature of the link, but it
-		// needs to be created for every link as the roster can change.
-		verifier, err := fac.FromAuthority(authority)
-		if err != nil {
-			return xerrors.Errorf("verifier factory failed: %v", err)
-		}
+		tasks = append(tasks, linkTask{link: link, roster: authority})
 
-		if link.GetPrepareSignature() == nil {
-			return xerrors.New("unexpected nil prepare signature in link")
-		}
+		prev = link.GetTo()
 
-		if link.GetCommitSignature() == nil {
-			return xerrors.New("unexpected nil commit signature in link")
-		}
+		authority = authority.Apply(link.GetChangeSet())
+	}
 
-		// 1. Verify the prepare signature that signs the integrity of the
-		// forward link.
-		err = verifier.Verify(link.GetHash().Bytes(), link.GetPrepareSignature())
-		if err != nil {
-			return xerrors.Errorf("invalid prepare signature: %v", err)
-		}
+	// The signatures of the links preceding an inconsistency are verified so
+	// that the error is the same as if the links were verified one by one.
+	_, err := c.verifyLinks(tasks, genesis.GetHash(), fac)
+	if err != nil {
+		return err
+	}
 
-		// 2. Verify the commit signature that signs the binary representation
-		// of the prepare signature.
-		msg, err := link.GetPrepareSignature().MarshalBinary()
-		if err != nil {
-			return xerrors.Errorf("failed to marshal signature: %v", err)
+	if mismatch != nil {
+		return mismatch
+	}
+
+	if !toProcess {
+		return xerrors.Errorf("no verification made (from Digest %v)", from)
+	}
+
+	return nil
+}
+
+// linkTask is a link and the roster that must have signed it.
+type linkTask struct {
+	link   Link
+	roster authority.Authority
+}
+
+// verifyLinks verifies the signatures of the links using up to the number of
+// workers of the chain. When several links are invalid, it returns the index
+// and the error of the first one, whatever the number of workers.
+func (c chain) verifyLinks(tasks []linkTask, chainID Digest, fac crypto.VerifierFactory) (int, error) {
+	workers := c.workers
+	if workers > len(tasks) {
+		workers = len(tasks)
+	}
+
+	if workers <= 1 {
+		for i, task := range tasks {
+			err := c.verifyLink(task.link, chainID, task.roster, fac)
+			if err != nil {
+				return i, err
+			}
 		}
 
-		err = verifier.Verify(msg, link.GetCommitSignature())
+		return 0, nil
+	}
+
+	errs := make([]error, len(tasks))
+	jobs := make(chan int)
+
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				errs[i] = c.verifyLink(tasks[i].link, chainID, tasks[i].roster, fac)
+			}
+		}()
+	}
+
+	for i := range tasks {
+		jobs <- i
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	for i, err := range errs {
 		if err != nil {
-			return xerrors.Errorf("invalid commit signature: %v", err)
+			return i, err
 		}
+	}
 
-		prev = link.GetTo()
+	return 0, nil
+}
 
-		authority = authority.Apply(link.GetChangeSet())
+// verifyLink verifies the prepare and the commit signatures of the link against
+// the roster.
+func (c chain) verifyLink(link Link, chainID Digest, ro authority.Authority, fac crypto.VerifierFactory) error {
+	// The verifier can be used to verify the signature of the link, but it
+	// needs to be created for every link as the roster can change.
+	verifier, err := fac.FromAuthority(ro)
+	if err != nil {
+		return xerrors.Errorf("verifier factory failed: %v", err)
 	}
 
-	if !toProcess {
-		return xerrors.Errorf("no verification made (from Digest %v)", from)
+	if link.GetPrepareSignature() == nil {
+		return xerrors.New("unexpected nil prepare signature in link")
+	}
+
+	if link.GetCommitSignature() == nil {
+		return xerrors.New("unexpected nil commit signature in link")
+	}
+
+	// 1. Verify the prepare signature that signs the integrity of the
+	// forward link.
+	err = c.version.VerifyPrepare(chainID, link.GetHash(), link.GetPrepareSignature(), verifier)
+	if err != nil {
+		return xerrors.Errorf("invalid prepare signature: %v", err)
+	}
+
+	// 2. Verify the commit signature that signs the binary representation
+	// of the prepare signature.
+	buffer, err := c.encoding.Encode(link.GetPrepareSignature())
+	if err != nil {
+		return xerrors.Errorf("failed to marshal signature: %v", err)
+	}
+
+	msg := c.version.CommitMessage(chainID, buffer)
+
+	err = verifier.Verify(msg, link.GetCommitSignature())
+	if err != nil {
+		return xerrors.Errorf("invalid commit signature: %v", err)
 	}
 
 	return nil
@@ -406,20 +594,47 @@ func (c chain) Serialize(ctx serde.Context) ([]byte, error) {
 	return data, nil
 }
 
+// ChainLengthLimiter is implemented by the chain factories that limit the
+// number of links of a chain, so that the format engines can refuse an overly
+// long chain before its links are decoded.
+type ChainLengthLimiter interface {
+	// GetMaxLength returns the maximum number of links of a chain, or zero
+	// when the length is not limited.
+	GetMaxLength() int
+}
+
 // ChainFactory is a factory to serialize and deserialize a chain.
 //
 // - implements types.ChainFactory
+// - implements types.ChainLengthLimiter
 type chainFactory struct {
-	linkFac LinkFactory
+	linkFac   LinkFactory
+	opts      []ChainOption
+	maxLength int
 }
 
-// NewChainFactory creates a new factory from the link factory.
-func NewChainFactory(fac LinkFactory) ChainFactory {
+// NewChainFactory creates a new factory from the link factory. The options are
+// applied to the chains that it deserializes.
+func NewChainFactory(fac LinkFactory, opts ...ChainOption) ChainFactory {
+	tmpl := chain{maxLength: DefaultMaxChainLength}
+
+	for _, opt := range opts {
+		opt(&tmpl)
+	}
+
 	return chainFactory{
-		linkFac: fac,
+		linkFac:   fac,
+		opts:      opts,
+		maxLength: tmpl.maxLength,
 	}
 }
 
+// GetMaxLength implements types.ChainLengthLimiter. It returns the maximum
+// number of links of the chains it decodes.
+func (fac chainFactory) GetMaxLength() int {
+	return fac.maxLength
+}
+
 // Deserialize implements serde.Factory. It returns the chain from the data if
 // appropriate, otherwise it returns an error.
 func (fac chainFactory) Deserialize(ctx serde.Context, data []byte) (serde.Message, error) {
@@ -432,6 +647,7 @@ func (fac chainFactory) ChainOf(ctx serde.Context, data []byte) (Chain, error) {
 	format := chainFormats.Get(ctx.GetFormat())
 
 	ctx = serde.WithFactory(ctx, LinkKey{}, fac.linkFac)
+	ctx = serde.WithFactory(ctx, ChainKey{}, fac)
 
 	msg, err := format.Decode(ctx, data)
 	if err != nil {
@@ -443,5 +659,163 @@ func (fac chainFactory) ChainOf(ctx serde.Context, data []byte) (Chain, error) {
 		return nil, xerrors.Errorf("invalid chain '%T'", msg)
 	}
 
+	return ConfigureChain(chain, fac.opts...), nil
+}
+
+// VerifyChainData decodes the chain from the data and verifies it from the
+// genesis block up to the last block. It returns the verified chain, whose
+// links can then be trusted. It only needs the factories and the genesis block,
+// which must be trusted, so that a block can be verified offline without
+// running a node.
+func VerifyChainData(ctx serde.Context, data []byte, genesis Genesis,
+	fac ChainFactory, verifierFac crypto.VerifierFactory) (Chain, error) {
+
+	chain, err := fac.ChainOf(ctx, data)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't decode chain: %v", err)
+	}
+
+	err = chain.Verify(genesis, genesis.GetHash(), verifierFac)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't verify chain: %v", err)
+	}
+
 	return chain, nil
 }
+
+// VerifyChainSegment verifies the links of the chain from the index `from` up
+// to the index `to` included, where the link at index i is the one leading to
+// the block of the same index. The signatures of every link are verified
+// against the roster valid at its index, as returned by rosterAt, so that a
+// segment can be verified without the links preceding it. The digest of the
+// genesis block is only signed from the protocol version 1.
+func VerifyChainSegment(c Chain, genesis Digest, from, to uint64,
+	rosterAt func(uint64) authority.Authority, fac crypto.VerifierFactory) error {
+
+	impl, ok := c.(chain)
+	if !ok {
+		return xerrors.Errorf("unsupported chain '%T'", c)
+	}
+
+	links := impl.GetLinks()
+
+	if from > to || to >= uint64(len(links)) {
+		return xerrors.Errorf("invalid segment [%d, %d] for a chain of %d links",
+			from, to, len(links))
+	}
+
+	tasks := make([]linkTask, 0, to-from+1)
+
+	for i := from; i <= to; i++ {
+		link := links[i]
+
+		if i > from && links[i-1].GetTo() != link.GetFrom() {
+			return xerrors.Errorf("link %d: mismatch from: '%v' != '%v'",
+				i, link.GetFrom(), links[i-1].GetTo())
+		}
+
+		ro := rosterAt(i)
+		if ro == nil {
+			return xerrors.Errorf("link %d: missing roster", i)
+		}
+
+		tasks = append(tasks, linkTask{link: link, roster: ro})
+	}
+
+	index, err := impl.verifyLinks(tasks, genesis, fac)
+	if err != nil {
+		return xerrors.Errorf("link %d: %v", from+uint64(index), err)
+	}
+
+	return nil
+}
+
+// CommonAncestor returns the index of the last block shared by both chains,
+// where the link at index i is the one leading to the block of the same index.
+// Both chains are expected to start from the genesis block. It returns false
+// when the chains diverge from their first block.
+func CommonAncestor(a, b Chain) (uint64, bool) {
+	linksA := a.GetLinks()
+	linksB := b.GetLinks()
+
+	num := len(linksA)
+	if len(linksB) < num {
+		num = len(linksB)
+	}
+
+	index := 0
+	for index < num && linksA[index].GetTo() == linksB[index].GetTo() {
+		index++
+	}
+
+	if index == 0 {
+		return 0, false
+	}
+
+	return uint64(index - 1), true
+}
+
+// ChainVerifier verifies a chain one link at a time, so that the links can be
+// read from a stream and dropped once verified instead of being decoded in a
+// chain first. Only the digest of the latest link and the current roster are
+// kept in memory, whatever the length of the chain.
+type ChainVerifier struct {
+	chain
+
+	genesis Digest
+	roster  authority.Authority
+	prev    Digest
+	fac     crypto.VerifierFactory
+	count   int
+}
+
+// NewChainVerifier creates a new verifier of a chain starting from the genesis
+// block. The options are the ones of the chain that is streamed.
+func NewChainVerifier(genesis Genesis, fac crypto.VerifierFactory, opts ...ChainOption) *ChainVerifier {
+	v := &ChainVerifier{
+		genesis: genesis.GetHash(),
+		roster:  genesis.GetRoster(),
+		prev:    genesis.GetHash(),
+		fac:     fac,
+	}
+
+	for _, opt := range opts {
+		opt(&v.chain)
+	}
+
+	return v
+}
+
+// Push verifies that the link follows the previous one and that it is signed by
+// the roster of its index.
+func (v *ChainVerifier) Push(link Link) error {
+	if link.GetFrom() != v.prev {
+		return xerrors.Errorf("link %d: mismatch from: '%v' != '%v'",
+			v.count, link.GetFrom(), v.prev)
+	}
+
+	err := v.verifyLink(link, v.genesis, v.roster, v.fac)
+	if err != nil {
+		return xerrors.Errorf("link %d: %v", v.count, err)
+	}
+
+	v.prev = link.GetTo()
+	v.roster = v.roster.Apply(link.GetChangeSet())
+	v.count++
+
+	return nil
+}
+
+// Done verifies that the block is the one that the last link points to. It
+// returns an error if no link has been pushed.
+func (v *ChainVerifier) Done(block Block) error {
+	if v.count == 0 {
+		return xerrors.New("no link verified")
+	}
+
+	if block.GetHash() != v.prev {
+		return xerrors.Errorf("mismatch block: '%v' != '%v'", block.GetHash(), v.prev)
+	}
+
+	return nil
+}
@@ -2,12 +2,19 @@ package types
 
 import (
 	"bytes"
+	"encoding/binary"
+	"encoding/hex"
 	"fmt"
+	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
+	"go.dedis.ch/dela/core/validation/simple"
+	"go.dedis.ch/dela/crypto"
+	"go.dedis.ch/dela/crypto/bls"
 	"go.dedis.ch/dela/internal/testing/fake"
+	"go.dedis.ch/dela/mino"
 	"go.dedis.ch/dela/serde"
 )
 
@@ -246,6 +253,77 @@ func TestChain_Verify(t *testing.T) {
 	require.EqualError(t, err, fake.Err("invalid commit signature"))
 }
 
+func TestChain_CommitEncoding_Verify(t *testing.T) {
+	signer := bls.NewSigner()
+
+	ro := authority.New([]mino.Address{fake.NewAddress(0)}, []crypto.PublicKey{signer.GetPublicKey()})
+
+	genesis, err := NewGenesis(ro)
+	require.NoError(t, err)
+
+	for _, encoding := range []SignatureEncoding{BinaryEncoding, hexEncoding} {
+		link := makeSignedLink(t, signer, genesis.digest, encoding)
+
+		c := NewChain(link, nil, WithCommitEncoding(encoding))
+		err = c.Verify(genesis, genesis.GetHash(), signer.GetVerifierFactory())
+		require.NoError(t, err)
+	}
+
+	// The commit signature does not match a different encoding.
+	link := makeSignedLink(t, signer, genesis.digest, hexEncoding)
+
+	c := NewChain(link, nil)
+	err = c.Verify(genesis, genesis.GetHash(), signer.GetVerifierFactory())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid commit signature: ")
+}
+
+func TestChain_ProtocolVersion_Verify(t *testing.T) {
+	signer := bls.NewSigner()
+
+	ro := authority.New([]mino.Address{fake.NewAddress(0)}, []crypto.PublicKey{signer.GetPublicKey()})
+
+	genesisA, err := NewGenesis(ro, WithGenesisRoot(Digest{1}))
+	require.NoError(t, err)
+
+	genesisB, err := NewGenesis(ro, WithGenesisRoot(Digest{2}))
+	require.NoError(t, err)
+
+	// Both chains share the same history after the first block, so that the
+	// second link is the same.
+	linkA, err := NewForwardLink(genesisA.GetHash(), digest(0x1))
+	require.NoError(t, err)
+
+	linkB, err := NewForwardLink(genesisB.GetHash(), digest(0x1))
+	require.NoError(t, err)
+
+	for _, version := range []ProtocolVersion{ProtocolV0, ProtocolV1} {
+		last := makeVersionedLink(t, signer, genesisA.GetHash(), digest(0x1), version)
+
+		chainA := NewChain(last, []Link{linkA}, WithProtocolVersion(version))
+		err = chainA.Verify(genesisA, digest(0x1), signer.GetVerifierFactory())
+		require.NoError(t, err)
+
+		chainB := NewChain(last, []Link{linkB}, WithProtocolVersion(version))
+		err = chainB.Verify(genesisB, digest(0x1), signer.GetVerifierFactory())
+		if version == ProtocolV0 {
+			require.NoError(t, err)
+		} else {
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "invalid prepare signature: ")
+		}
+	}
+
+	// A chain signed with the initial protocol does not verify with the new
+	// one.
+	last := makeVersionedLink(t, signer, genesisA.GetHash(), digest(0x1), ProtocolV0)
+
+	c := NewChain(last, []Link{linkA}, WithProtocolVersion(ProtocolV1))
+	err = c.Verify(genesisA, digest(0x1), signer.GetVerifierFactory())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid prepare signature: ")
+}
+
 func TestChain_Verify_Skip(t *testing.T) {
 	ro := authority.FromAuthority(fake.NewAuthority(3, fake.NewSigner))
 
@@ -316,6 +394,42 @@ func TestChain_Serialize(t *testing.T) {
 	require.EqualError(t, err, fake.Err("encoding chain failed"))
 }
 
+func TestFormatEncoding(t *testing.T) {
+	encoding := FormatEncoding(fake.NewContext())
+
+	data, err := encoding.Encode(fake.Signature{})
+	require.NoError(t, err)
+	require.Equal(t, []byte("{}"), data)
+
+	_, err = encoding.Encode(fake.NewBadSignature())
+	require.EqualError(t, err, fake.Err("failed to serialize signature"))
+}
+
+func TestSignatureEncoding_Encode(t *testing.T) {
+	var encoding SignatureEncoding
+
+	data, err := encoding.Encode(fake.Signature{})
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xfe}, data)
+
+	encoding = hexEncoding
+
+	data, err = encoding.Encode(fake.Signature{})
+	require.NoError(t, err)
+	require.Equal(t, []byte("fe"), data)
+
+	_, err = encoding.Encode(fake.NewBadSignature())
+	require.EqualError(t, err, fake.GetError().Error())
+}
+
+func TestConfigureChain(t *testing.T) {
+	c := ConfigureChain(NewChain(nil, nil), WithCommitEncoding(hexEncoding))
+	require.NotNil(t, c.(chain).encoding)
+
+	c = ConfigureChain(fakeChain{})
+	require.Equal(t, fakeChain{}, c)
+}
+
 func TestChainFactory_Deserialize(t *testing.T) {
 	fac := NewChainFactory(linkFac{})
 
@@ -326,10 +440,290 @@ func TestChainFactory_Deserialize(t *testing.T) {
 	_, err = fac.Deserialize(fake.NewBadContext(), nil)
 	require.EqualError(t, err, fake.Err("decoding chain failed"))
 
+	fac = NewChainFactory(linkFac{}, WithCommitEncoding(hexEncoding))
+
+	msg, err = fac.Deserialize(fake.NewContext(), nil)
+	require.NoError(t, err)
+	require.NotNil(t, msg.(chain).encoding)
+
 	_, err = fac.Deserialize(fake.NewContextWithFormat(serde.Format("badtype")), nil)
 	require.EqualError(t, err, "invalid chain 'fake.Message'")
 }
 
+func TestChainFactory_MaxLength(t *testing.T) {
+	format := &limitFormat{}
+	RegisterChainFormat(serde.Format("limit"), format)
+
+	ctx := fake.NewContextWithFormat(serde.Format("limit"))
+
+	fac := NewChainFactory(linkFac{})
+	require.Equal(t, DefaultMaxChainLength, fac.(ChainLengthLimiter).GetMaxLength())
+
+	_, err := fac.ChainOf(ctx, nil)
+	require.NoError(t, err)
+	require.Equal(t, DefaultMaxChainLength, format.limiter.GetMaxLength())
+
+	fac = NewChainFactory(linkFac{}, WithMaxLength(2))
+
+	// The limit is available to the format so that it can refuse a chain
+	// before decoding the links.
+	_, err = fac.ChainOf(ctx, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, format.limiter.GetMaxLength())
+}
+
+func TestVerifyChainData(t *testing.T) {
+	signer := bls.NewSigner()
+
+	ro := authority.New([]mino.Address{fake.NewAddress(0)}, []crypto.PublicKey{signer.GetPublicKey()})
+
+	genesis, err := NewGenesis(ro)
+	require.NoError(t, err)
+
+	good := NewChain(makeSignedLink(t, signer, genesis.GetHash(), BinaryEncoding), nil)
+	RegisterChainFormat(serde.Format("verify-good"), fake.Format{Msg: good})
+
+	// The last link is signed by a different signer than the roster of the
+	// genesis block.
+	tampered := NewChain(makeSignedLink(t, bls.NewSigner(), genesis.GetHash(), BinaryEncoding), nil)
+	RegisterChainFormat(serde.Format("verify-tampered"), fake.Format{Msg: tampered})
+
+	fac := NewChainFactory(linkFac{})
+
+	ctx := fake.NewContextWithFormat(serde.Format("verify-good"))
+	chain, err := VerifyChainData(ctx, nil, genesis, fac, signer.GetVerifierFactory())
+	require.NoError(t, err)
+	require.Equal(t, good.GetBlock(), chain.GetBlock())
+
+	ctx = fake.NewContextWithFormat(serde.Format("verify-tampered"))
+	_, err = VerifyChainData(ctx, nil, genesis, fac, signer.GetVerifierFactory())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "couldn't verify chain: invalid prepare signature: ")
+
+	_, err = VerifyChainData(fake.NewBadContext(), nil, genesis, fac, signer.GetVerifierFactory())
+	require.EqualError(t, err, fake.Err("couldn't decode chain: decoding chain failed"))
+}
+
+func TestVerifyChainSegment(t *testing.T) {
+	signerA := bls.NewSigner()
+	signerB := bls.NewSigner()
+
+	roA := authority.New([]mino.Address{fake.NewAddress(0)}, []crypto.PublicKey{signerA.GetPublicKey()})
+	roB := authority.New([]mino.Address{fake.NewAddress(1)}, []crypto.PublicKey{signerB.GetPublicKey()})
+
+	// The roster changes from the link at index 2.
+	rosterAt := func(index uint64) authority.Authority {
+		if index < 2 {
+			return roA
+		}
+
+		return roB
+	}
+
+	genesis := digest(0xa)
+
+	prevs := []Link{
+		makeSegmentLink(t, signerA, genesis, digest(0x1)),
+		makeSegmentLink(t, signerA, digest(0x1), digest(0x2)),
+	}
+
+	c := NewChain(makeSegmentLink(t, signerB, digest(0x2), digest(0x3)), prevs)
+
+	for _, segment := range [][2]uint64{{0, 2}, {1, 2}, {2, 2}, {0, 1}} {
+		err := VerifyChainSegment(c, genesis, segment[0], segment[1], rosterAt, signerA.GetVerifierFactory())
+		require.NoError(t, err)
+	}
+
+	// The last link is signed by the roster that precedes the change.
+	stale := NewChain(makeSegmentLink(t, signerA, digest(0x2), digest(0x3)), prevs)
+
+	err := VerifyChainSegment(stale, genesis, 0, 1, rosterAt, signerA.GetVerifierFactory())
+	require.NoError(t, err)
+
+	err = VerifyChainSegment(stale, genesis, 1, 2, rosterAt, signerA.GetVerifierFactory())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "link 2: invalid prepare signature: ")
+
+	err = VerifyChainSegment(c, genesis, 2, 1, rosterAt, signerA.GetVerifierFactory())
+	require.EqualError(t, err, "invalid segment [2, 1] for a chain of 3 links")
+
+	err = VerifyChainSegment(c, genesis, 1, 3, rosterAt, signerA.GetVerifierFactory())
+	require.EqualError(t, err, "invalid segment [1, 3] for a chain of 3 links")
+
+	broken := NewChain(makeSegmentLink(t, signerB, digest(0x4), digest(0x3)), prevs)
+	err = VerifyChainSegment(broken, genesis, 1, 2, rosterAt, signerA.GetVerifierFactory())
+	require.EqualError(t, err, fmt.Sprintf("link 2: mismatch from: '%v' != '%v'", digest(0x4), digest(0x2)))
+
+	err = VerifyChainSegment(c, genesis, 0, 0,
+		func(uint64) authority.Authority { return nil }, signerA.GetVerifierFactory())
+	require.EqualError(t, err, "link 0: missing roster")
+
+	err = VerifyChainSegment(fakeChain{}, genesis, 0, 0, rosterAt, signerA.GetVerifierFactory())
+	require.EqualError(t, err, "unsupported chain 'types.fakeChain'")
+}
+
+func TestCommonAncestor(t *testing.T) {
+	local := makeChain(t, Digest{}, 1, 2, 3, 4)
+
+	// The competing chain forks after the block at index 1.
+	competing := makeChain(t, Digest{}, 1, 2, 13, 14, 15)
+
+	index, found := CommonAncestor(local, competing)
+	require.True(t, found)
+	require.Equal(t, uint64(1), index)
+
+	index, found = CommonAncestor(competing, local)
+	require.True(t, found)
+	require.Equal(t, uint64(1), index)
+
+	// The ancestor of identical chains is the head.
+	index, found = CommonAncestor(local, makeChain(t, Digest{}, 1, 2, 3, 4))
+	require.True(t, found)
+	require.Equal(t, uint64(3), index)
+
+	// ... and the head of the shortest one when one extends the other.
+	index, found = CommonAncestor(local, makeChain(t, Digest{}, 1, 2))
+	require.True(t, found)
+	require.Equal(t, uint64(1), index)
+
+	_, found = CommonAncestor(local, makeChain(t, Digest{}, 11, 12))
+	require.False(t, found)
+}
+
+func TestChain_VerifyWorkers(t *testing.T) {
+	signer := bls.NewSigner()
+
+	ro := authority.New([]mino.Address{fake.NewAddress(0)}, []crypto.PublicKey{signer.GetPublicKey()})
+
+	genesis, err := NewGenesis(ro)
+	require.NoError(t, err)
+
+	good := makeSignedChain(t, signer, genesis.GetHash(), 8)
+
+	// Two links are signed by a different signer.
+	bad := makeSignedChain(t, signer, genesis.GetHash(), 8, 3, 6)
+
+	for _, workers := range []int{0, 1, 2, 4, 16} {
+		opt := WithVerifyWorkers(workers)
+
+		err := ConfigureChain(good, opt).Verify(genesis, genesis.GetHash(), signer.GetVerifierFactory())
+		require.NoError(t, err)
+
+		serial := bad.Verify(genesis, genesis.GetHash(), signer.GetVerifierFactory())
+		require.Error(t, serial)
+
+		err = ConfigureChain(bad, opt).Verify(genesis, genesis.GetHash(), signer.GetVerifierFactory())
+		require.EqualError(t, err, serial.Error())
+
+		rosterAt := func(uint64) authority.Authority { return ro }
+
+		// The first invalid link is reported whatever the order of completion.
+		err = VerifyChainSegment(ConfigureChain(bad, opt), genesis.GetHash(), 2, 7,
+			rosterAt, signer.GetVerifierFactory())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "link 3: invalid prepare signature: ")
+
+		err = VerifyChainSegment(ConfigureChain(bad, opt), genesis.GetHash(), 4, 7,
+			rosterAt, signer.GetVerifierFactory())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "link 6: invalid prepare signature: ")
+	}
+}
+
+func BenchmarkChain_Verify(b *testing.B) {
+	signer := bls.NewSigner()
+
+	ro := authority.New([]mino.Address{fake.NewAddress(0)}, []crypto.PublicKey{signer.GetPublicKey()})
+
+	genesis, err := NewGenesis(ro)
+	require.NoError(b, err)
+
+	c := makeSignedChain(b, signer, genesis.GetHash(), 32)
+
+	for _, workers := range []int{1, 4, runtime.NumCPU()} {
+		c := ConfigureChain(c, WithVerifyWorkers(workers))
+
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				err := c.Verify(genesis, genesis.GetHash(), signer.GetVerifierFactory())
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+func TestChainVerifier_Push(t *testing.T) {
+	ro := authority.FromAuthority(fake.NewAuthority(3, fake.NewSigner))
+
+	genesis, err := NewGenesis(ro)
+	require.NoError(t, err)
+
+	n := 20000
+
+	block, err := NewBlock(simple.NewResult(nil), WithIndex(uint64(n-1)))
+	require.NoError(t, err)
+
+	var before runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	v := NewChainVerifier(genesis, fake.VerifierFactory{})
+
+	prev := genesis.GetHash()
+
+	for i := 0; i < n; i++ {
+		next := block.GetHash()
+		if i < n-1 {
+			binary.LittleEndian.PutUint64(next[:], uint64(i+1))
+		}
+
+		err = v.Push(makeLink(t, prev, next))
+		require.NoError(t, err)
+
+		prev = next
+	}
+
+	err = v.Done(block)
+	require.NoError(t, err)
+
+	var after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	// The links are dropped once verified, so that the memory does not grow
+	// with the length of the chain.
+	require.Less(t, int64(after.HeapAlloc)-int64(before.HeapAlloc), int64(1<<20))
+	require.Equal(t, n, v.count)
+}
+
+func TestChainVerifier_Failures(t *testing.T) {
+	ro := authority.FromAuthority(fake.NewAuthority(3, fake.NewSigner))
+
+	genesis, err := NewGenesis(ro)
+	require.NoError(t, err)
+
+	block, err := NewBlock(simple.NewResult(nil))
+	require.NoError(t, err)
+
+	v := NewChainVerifier(genesis, fake.VerifierFactory{})
+
+	err = v.Done(block)
+	require.EqualError(t, err, "no link verified")
+
+	err = v.Push(makeLink(t, Digest{}, Digest{}))
+	require.EqualError(t, err, fmt.Sprintf("link 0: mismatch from: '00000000' != '%v'", genesis.GetHash()))
+
+	err = v.Push(makeLink(t, genesis.GetHash(), Digest{1}))
+	require.NoError(t, err)
+
+	err = v.Done(block)
+	require.EqualError(t, err, fmt.Sprintf("mismatch block: '%v' != '01000000'", block.GetHash()))
+
+	v = NewChainVerifier(genesis, fake.NewVerifierFactory(fake.NewBadVerifier()))
+	err = v.Push(makeLink(t, genesis.GetHash(), Digest{1}))
+	require.EqualError(t, err, fake.Err("link 0: invalid prepare signature"))
+}
+
 // -----------------------------------------------------------------------------
 // Utility functions
 
@@ -340,8 +734,127 @@ func makeLink(t *testing.T, from, to Digest) BlockLink {
 	return blockLink{forwardLink: link.(forwardLink)}
 }
 
+// makeChain returns a chain starting from the genesis digest where the block at
+// index i has the digest built from the i-th byte.
+func makeChain(t *testing.T, genesis Digest, digests ...byte) Chain {
+	links := make([]Link, len(digests))
+	prev := genesis
+
+	for i, b := range digests {
+		links[i] = makeLink(t, prev, digest(b))
+		prev = digest(b)
+	}
+
+	return NewChain(links[len(links)-1].(BlockLink), links[:len(links)-1])
+}
+
 func digest(b byte) Digest {
 	var d Digest
 	d[0] = b
 	return d
 }
+
+func hexEncoding(sig crypto.Signature) ([]byte, error) {
+	data, err := sig.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(hex.EncodeToString(data)), nil
+}
+
+func makeSignedLink(t *testing.T, signer crypto.Signer, from Digest, encoding SignatureEncoding) BlockLink {
+	unsigned, err := NewForwardLink(from, Digest{})
+	require.NoError(t, err)
+
+	prepare, err := signer.Sign(unsigned.GetHash().Bytes())
+	require.NoError(t, err)
+
+	data, err := encoding.Encode(prepare)
+	require.NoError(t, err)
+
+	commit, err := signer.Sign(data)
+	require.NoError(t, err)
+
+	link, err := NewForwardLink(from, Digest{}, WithSignatures(prepare, commit))
+	require.NoError(t, err)
+
+	return blockLink{forwardLink: link.(forwardLink)}
+}
+
+func makeVersionedLink(t *testing.T, signer crypto.Signer, chainID, from Digest, version ProtocolVersion) BlockLink {
+	unsigned, err := NewForwardLink(from, Digest{})
+	require.NoError(t, err)
+
+	prepare, err := signer.Sign(version.PrepareMessage(chainID, unsigned.GetHash()))
+	require.NoError(t, err)
+
+	data, err := prepare.MarshalBinary()
+	require.NoError(t, err)
+
+	commit, err := signer.Sign(version.CommitMessage(chainID, data))
+	require.NoError(t, err)
+
+	link, err := NewForwardLink(from, Digest{}, WithSignatures(prepare, commit))
+	require.NoError(t, err)
+
+	return blockLink{forwardLink: link.(forwardLink)}
+}
+
+type fakeChain struct {
+	Chain
+}
+
+func makeSegmentLink(t testing.TB, signer crypto.Signer, from, to Digest) BlockLink {
+	unsigned, err := NewForwardLink(from, to)
+	require.NoError(t, err)
+
+	prepare, err := signer.Sign(unsigned.GetHash().Bytes())
+	require.NoError(t, err)
+
+	data, err := prepare.MarshalBinary()
+	require.NoError(t, err)
+
+	commit, err := signer.Sign(data)
+	require.NoError(t, err)
+
+	link, err := NewForwardLink(from, to, WithSignatures(prepare, commit))
+	require.NoError(t, err)
+
+	return blockLink{forwardLink: link.(forwardLink)}
+}
+
+// makeSignedChain returns a chain of the given number of links starting from the
+// genesis block. The links at the indices in bad are signed by another signer.
+func makeSignedChain(t testing.TB, signer crypto.Signer, genesis Digest, n int, bad ...int) Chain {
+	other := bls.NewSigner()
+
+	links := make([]Link, n)
+	prev := genesis
+
+	for i := range links {
+		s := signer
+		for _, index := range bad {
+			if index == i {
+				s = other
+			}
+		}
+
+		links[i] = makeSegmentLink(t, s, prev, digest(byte(i+1)))
+		prev = links[i].GetTo()
+	}
+
+	return NewChain(links[n-1].(BlockLink), links[:n-1])
+}
+
+type limitFormat struct {
+	fake.Format
+
+	limiter ChainLengthLimiter
+}
+
+func (f *limitFormat) Decode(ctx serde.Context, data []byte) (serde.Message, error) {
+	f.limiter, _ = ctx.GetFactory(ChainKey{}).(ChainLengthLimiter)
+
+	return NewChain(blockLink{}, nil), nil
+}
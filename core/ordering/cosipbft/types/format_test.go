@@ -0,0 +1,83 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/ordering/cosipbft/types"
+	"go.dedis.ch/dela/core/txn/signed"
+	"go.dedis.ch/dela/core/validation/simple"
+	"go.dedis.ch/dela/crypto"
+	"go.dedis.ch/dela/crypto/bls"
+	internal "go.dedis.ch/dela/internal/testing"
+	"go.dedis.ch/dela/serde/json"
+)
+
+func TestBlock_Formats(t *testing.T) {
+	res := simple.NewResult([]simple.TransactionResult{
+		simple.NewTransactionResult(makeTx(t, 0), true, ""),
+		simple.NewTransactionResult(makeTx(t, 1), false, "refused"),
+	})
+
+	opts := []types.BlockOption{
+		types.WithIndex(5),
+		types.WithTreeRoot(types.Digest{1}),
+		types.WithExtraData([]byte("extra")),
+	}
+
+	block, err := types.NewBlock(res, opts...)
+	require.NoError(t, err)
+
+	internal.RequireRoundTrip(t, block, makeBlockFactory(), json.NewContext())
+}
+
+func TestBlock_Formats_Validate(t *testing.T) {
+	block, err := types.NewBlock(simple.NewResult(nil), types.WithExtraData([]byte("extra")))
+	require.NoError(t, err)
+
+	ctx := json.NewContext()
+
+	data, err := block.Serialize(ctx)
+	require.NoError(t, err)
+
+	msg, err := makeBlockFactory().Deserialize(ctx, data)
+	require.NoError(t, err)
+
+	decoded := msg.(types.Block)
+	require.NoError(t, decoded.Validate(crypto.NewSha256Factory()))
+
+	// Tampering with the decoded bytes is detected.
+	decoded.GetExtraData()[0] = 'X'
+	require.Error(t, decoded.Validate(crypto.NewSha256Factory()))
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+func makeBlockFactory() types.BlockFactory {
+	return types.NewBlockFactory(simple.NewResultFactory(signed.NewTransactionFactory()))
+}
+
+func makeTx(t *testing.T, nonce uint64) *signed.Transaction {
+	signer := bls.NewSigner()
+
+	// The key is decoded from its binary form so that the point has the same
+	// representation as once deserialized.
+	data, err := signer.GetPublicKey().MarshalBinary()
+	require.NoError(t, err)
+
+	pubkey, err := bls.NewPublicKey(data)
+	require.NoError(t, err)
+
+	opts := []signed.TransactionOption{
+		signed.WithArg("A", []byte{1}),
+		signed.WithFee(5),
+		signed.WithCondition([]byte("K"), []byte{2}),
+	}
+
+	tx, err := signed.NewTransaction(nonce, pubkey, opts...)
+	require.NoError(t, err)
+	require.NoError(t, tx.Sign(signer))
+
+	return tx
+}
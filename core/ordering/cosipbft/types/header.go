@@ -0,0 +1,132 @@
+// This file contains the implementation of the header of a block.
+
+package types
+
+import (
+	"go.dedis.ch/dela/serde"
+	"go.dedis.ch/dela/serde/registry"
+	"golang.org/x/xerrors"
+)
+
+var headerFormats = registry.NewSimpleRegistry()
+
+// RegisterBlockHeaderFormat registers the engine for the provided format.
+func RegisterBlockHeaderFormat(f serde.Format, e serde.FormatEngine) {
+	headerFormats.Register(f, e)
+}
+
+// BlockHeaderParam is the parameter to create a block header.
+type BlockHeaderParam struct {
+	Digest       Digest
+	Index        uint64
+	TreeRoot     Digest
+	RosterDigest Digest
+	PayloadRoot  Digest
+	Terminal     bool
+}
+
+// BlockHeader is the compact description of a block without its data, for
+// instance to announce that a node has a block. The digest can't be verified
+// without the data of the block.
+//
+// - implements serde.Message
+type BlockHeader struct {
+	digest       Digest
+	index        uint64
+	treeRoot     Digest
+	rosterDigest Digest
+	payloadRoot  Digest
+	terminal     bool
+}
+
+// NewBlockHeader creates a new block header from the parameter.
+func NewBlockHeader(param BlockHeaderParam) BlockHeader {
+	return BlockHeader{
+		digest:       param.Digest,
+		index:        param.Index,
+		treeRoot:     param.TreeRoot,
+		rosterDigest: param.RosterDigest,
+		payloadRoot:  param.PayloadRoot,
+		terminal:     param.Terminal,
+	}
+}
+
+// GetHeader returns the header of the block.
+func (b Block) GetHeader() BlockHeader {
+	return BlockHeader{
+		digest:       b.digest,
+		index:        b.index,
+		treeRoot:     b.treeRoot,
+		rosterDigest: b.rosterDigest,
+		payloadRoot:  b.payloadRoot,
+		terminal:     b.terminal,
+	}
+}
+
+// GetHash returns the digest of the block.
+func (h BlockHeader) GetHash() Digest {
+	return h.digest
+}
+
+// GetIndex returns the index of the block.
+func (h BlockHeader) GetIndex() uint64 {
+	return h.index
+}
+
+// GetTreeRoot returns the tree root of the block.
+func (h BlockHeader) GetTreeRoot() Digest {
+	return h.treeRoot
+}
+
+// GetRosterDigest returns the digest of the roster embedded in the block, or
+// an empty digest if none is embedded.
+func (h BlockHeader) GetRosterDigest() Digest {
+	return h.rosterDigest
+}
+
+// GetPayloadRoot returns the Merkle root of the payload of the block, or an
+// empty digest if the payload is not a Merkle payload.
+func (h BlockHeader) GetPayloadRoot() Digest {
+	return h.payloadRoot
+}
+
+// IsTerminal returns true if the block seals the chain.
+func (h BlockHeader) IsTerminal() bool {
+	return h.terminal
+}
+
+// Serialize implements serde.Message. It returns the serialized data of the
+// header.
+func (h BlockHeader) Serialize(ctx serde.Context) ([]byte, error) {
+	format := headerFormats.Get(ctx.GetFormat())
+
+	data, err := format.Encode(ctx, h)
+	if err != nil {
+		return nil, xerrors.Errorf("encoding failed: %v", err)
+	}
+
+	return data, nil
+}
+
+// BlockHeaderFactory is a factory to deserialize block headers.
+//
+// - implements serde.Factory
+type BlockHeaderFactory struct{}
+
+// NewBlockHeaderFactory returns a new block header factory.
+func NewBlockHeaderFactory() BlockHeaderFactory {
+	return BlockHeaderFactory{}
+}
+
+// Deserialize implements serde.Factory. It populates the block header from the
+// data if appropriate, otherwise it returns an error.
+func (f BlockHeaderFactory) Deserialize(ctx serde.Context, data []byte) (serde.Message, error) {
+	format := headerFormats.Get(ctx.GetFormat())
+
+	msg, err := format.Decode(ctx, data)
+	if err != nil {
+		return nil, xerrors.Errorf("decoding header failed: %v", err)
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,62 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/validation/simple"
+	"go.dedis.ch/dela/internal/testing/fake"
+)
+
+func init() {
+	RegisterBlockHeaderFormat(fake.GoodFormat, fake.Format{Msg: BlockHeader{}})
+	RegisterBlockHeaderFormat(fake.BadFormat, fake.NewBadFormat())
+}
+
+func TestBlock_GetHeader(t *testing.T) {
+	block, err := NewBlock(simple.NewResult(nil),
+		WithIndex(2),
+		WithTreeRoot(Digest{1}),
+		WithRosterDigest(Digest{2}),
+		WithTerminal())
+	require.NoError(t, err)
+
+	header := block.GetHeader()
+	require.Equal(t, block.GetHash(), header.GetHash())
+	require.Equal(t, uint64(2), header.GetIndex())
+	require.Equal(t, Digest{1}, header.GetTreeRoot())
+	require.Equal(t, Digest{2}, header.GetRosterDigest())
+	require.Equal(t, Digest{}, header.GetPayloadRoot())
+	require.True(t, header.IsTerminal())
+
+	expected := NewBlockHeader(BlockHeaderParam{
+		Digest:       block.GetHash(),
+		Index:        2,
+		TreeRoot:     Digest{1},
+		RosterDigest: Digest{2},
+		Terminal:     true,
+	})
+	require.Equal(t, expected, header)
+}
+
+func TestBlockHeader_Serialize(t *testing.T) {
+	header := NewBlockHeader(BlockHeaderParam{Index: 1})
+
+	data, err := header.Serialize(fake.NewContext())
+	require.NoError(t, err)
+	require.Equal(t, fake.GetFakeFormatValue(), data)
+
+	_, err = header.Serialize(fake.NewBadContext())
+	require.EqualError(t, err, fake.Err("encoding failed"))
+}
+
+func TestBlockHeaderFactory_Deserialize(t *testing.T) {
+	fac := NewBlockHeaderFactory()
+
+	msg, err := fac.Deserialize(fake.NewContext(), nil)
+	require.NoError(t, err)
+	require.IsType(t, BlockHeader{}, msg)
+
+	_, err = fac.Deserialize(fake.NewBadContext(), nil)
+	require.EqualError(t, err, fake.Err("decoding header failed"))
+}
@@ -54,20 +54,53 @@ func (m GenesisMessage) Serialize(ctx serde.Context) ([]byte, error) {
 	return data, nil
 }
 
-// BlockMessage is a message sent to participants to share a block.
+// BlockMessage is a message sent to participants to share a block. It can hold
+// the signature of the proposer over the block digest, and the digest of the
+// previous block so that a participant can reject a proposal that does not
+// extend its chain without processing the block.
 //
 // - implements serde.Message
 type BlockMessage struct {
-	block Block
-	views map[mino.Address]ViewMessage
+	index     uint64
+	block     Block
+	views     map[mino.Address]ViewMessage
+	signature crypto.Signature
+	previous  Digest
+}
+
+// BlockMessageOption is the type of option to set some fields of a block
+// message.
+type BlockMessageOption func(*BlockMessage)
+
+// WithProposerSignature is an option to set the signature of the proposer over
+// the digest of the block.
+func WithProposerSignature(sig crypto.Signature) BlockMessageOption {
+	return func(m *BlockMessage) {
+		m.signature = sig
+	}
+}
+
+// WithPrevious is an option to set the digest of the block that the proposal
+// extends.
+func WithPrevious(digest Digest) BlockMessageOption {
+	return func(m *BlockMessage) {
+		m.previous = digest
+	}
 }
 
 // NewBlockMessage creates a new block message with the provided block.
-func NewBlockMessage(block Block, views map[mino.Address]ViewMessage) BlockMessage {
-	return BlockMessage{
+func NewBlockMessage(block Block, views map[mino.Address]ViewMessage, opts ...BlockMessageOption) BlockMessage {
+	m := BlockMessage{
+		index: block.GetIndex(),
 		block: block,
 		views: views,
 	}
+
+	for _, opt := range opts {
+		opt(&m)
+	}
+
+	return m
 }
 
 // GetBlock returns the block of the message.
@@ -75,11 +108,28 @@ func (m BlockMessage) GetBlock() Block {
 	return m.block
 }
 
+// GetIndex returns the index of the proposed block. It is serialized next to
+// the block so that it can be read without decoding the block.
+func (m BlockMessage) GetIndex() uint64 {
+	return m.index
+}
+
+// GetPrevious returns the digest of the block that the proposal extends, or an
+// empty digest if it is not set.
+func (m BlockMessage) GetPrevious() Digest {
+	return m.previous
+}
+
 // GetViews returns the view messages if any.
 func (m BlockMessage) GetViews() map[mino.Address]ViewMessage {
 	return m.views
 }
 
+// GetSignature returns the signature of the proposer, or nil if it is not set.
+func (m BlockMessage) GetSignature() crypto.Signature {
+	return m.signature
+}
+
 // Serialize implements serde.Message. It returns the serialized data of the
 // block.
 func (m BlockMessage) Serialize(ctx serde.Context) ([]byte, error) {
@@ -93,6 +143,27 @@ func (m BlockMessage) Serialize(ctx serde.Context) ([]byte, error) {
 	return data, nil
 }
 
+// NewRoundID returns the identifier of a round, which is the digest of the
+// forward link to the proposed block, from its bytes. It returns an error if the
+// bytes are not exactly a digest, or if the digest is zero as it never
+// identifies a proposal. The messages of a round must be decoded with it so
+// that a truncated identifier is not padded with zeros silently.
+func NewRoundID(data []byte) (Digest, error) {
+	id := Digest{}
+
+	if len(data) != len(id) {
+		return id, xerrors.Errorf("invalid digest of %d bytes", len(data))
+	}
+
+	copy(id[:], data)
+
+	if id == (Digest{}) {
+		return id, xerrors.New("zero digest")
+	}
+
+	return id, nil
+}
+
 // CommitMessage is a message containing the signature of the prepare phase of a
 // PBFT execution.
 //
@@ -115,6 +186,13 @@ func (m CommitMessage) GetID() Digest {
 	return m.id
 }
 
+// GetTo returns the digest of the forward link to the block to commit, which
+// is the identifier signed during the prepare phase. It is equal to the
+// identifier of the message.
+func (m CommitMessage) GetTo() Digest {
+	return m.id
+}
+
 // GetSignature returns the prepare signature.
 func (m CommitMessage) GetSignature() crypto.Signature {
 	return m.signature
@@ -174,22 +252,69 @@ func (m DoneMessage) Serialize(ctx serde.Context) ([]byte, error) {
 	return data, nil
 }
 
-// ViewMessage is a message to announce a view change request.
+// ViewCertificate is the proof that a quorum of participants agreed to a view
+// change. It holds the indices in the roster of the participants, and the
+// aggregate of their signatures over the view.
+type ViewCertificate struct {
+	signers   []uint16
+	signature crypto.Signature
+}
+
+// NewViewCertificate creates a new view certificate.
+func NewViewCertificate(signers []uint16, sig crypto.Signature) ViewCertificate {
+	return ViewCertificate{
+		signers:   signers,
+		signature: sig,
+	}
+}
+
+// GetSigners returns the indices in the roster of the participants that signed
+// the view.
+func (c ViewCertificate) GetSigners() []uint16 {
+	return append([]uint16{}, c.signers...)
+}
+
+// GetSignature returns the aggregate signature of the view.
+func (c ViewCertificate) GetSignature() crypto.Signature {
+	return c.signature
+}
+
+// ViewMessage is a message to announce a view change request. It can hold a
+// certificate that proves that a quorum of participants already agreed to the
+// view change.
 //
 // - implements serde.Message
 type ViewMessage struct {
-	id        Digest
-	leader    uint16
-	signature crypto.Signature
+	id          Digest
+	leader      uint16
+	signature   crypto.Signature
+	certificate *ViewCertificate
+}
+
+// ViewMessageOption is the type of option to set some fields of a view
+// message.
+type ViewMessageOption func(*ViewMessage)
+
+// WithViewCertificate is an option to set the certificate of the view.
+func WithViewCertificate(cert ViewCertificate) ViewMessageOption {
+	return func(m *ViewMessage) {
+		m.certificate = &cert
+	}
 }
 
 // NewViewMessage creates a new view message.
-func NewViewMessage(id Digest, leader uint16, sig crypto.Signature) ViewMessage {
-	return ViewMessage{
+func NewViewMessage(id Digest, leader uint16, sig crypto.Signature, opts ...ViewMessageOption) ViewMessage {
+	m := ViewMessage{
 		id:        id,
 		leader:    leader,
 		signature: sig,
 	}
+
+	for _, opt := range opts {
+		opt(&m)
+	}
+
+	return m
 }
 
 // GetID returns the digest of the latest block.
@@ -207,6 +332,11 @@ func (m ViewMessage) GetSignature() crypto.Signature {
 	return m.signature
 }
 
+// GetCertificate returns the certificate of the view, or nil if it is not set.
+func (m ViewMessage) GetCertificate() *ViewCertificate {
+	return m.certificate
+}
+
 // Serialize implements serde.Message. It returns the serialized data for this
 // view message.
 func (m ViewMessage) Serialize(ctx serde.Context) ([]byte, error) {
@@ -220,6 +350,80 @@ func (m ViewMessage) Serialize(ctx serde.Context) ([]byte, error) {
 	return data, nil
 }
 
+// AbortMessage is a message sent by the leader to announce that the round in
+// progress for the given candidate is cancelled.
+//
+// - implements serde.Message
+type AbortMessage struct {
+	id Digest
+}
+
+// NewAbortMessage creates a new abort message for the candidate.
+func NewAbortMessage(id Digest) AbortMessage {
+	return AbortMessage{
+		id: id,
+	}
+}
+
+// GetID returns the digest of the candidate to abort.
+func (m AbortMessage) GetID() Digest {
+	return m.id
+}
+
+// Serialize implements serde.Message. It returns the serialized data for this
+// abort message.
+func (m AbortMessage) Serialize(ctx serde.Context) ([]byte, error) {
+	format := msgFormats.Get(ctx.GetFormat())
+
+	data, err := format.Encode(ctx, m)
+	if err != nil {
+		return nil, xerrors.Errorf("encoding failed: %v", err)
+	}
+
+	return data, nil
+}
+
+// StoredMessage is the acknowledgement of a participant that it has stored
+// the block of the index, alongside the root of its tree once the block is
+// applied.
+//
+// - implements serde.Message
+type StoredMessage struct {
+	index uint64
+	root  Digest
+}
+
+// NewStoredMessage creates a new acknowledgement for the block of the index.
+func NewStoredMessage(index uint64, root Digest) StoredMessage {
+	return StoredMessage{
+		index: index,
+		root:  root,
+	}
+}
+
+// GetIndex returns the index of the block stored.
+func (m StoredMessage) GetIndex() uint64 {
+	return m.index
+}
+
+// GetRoot returns the root of the tree after the block is applied.
+func (m StoredMessage) GetRoot() Digest {
+	return m.root
+}
+
+// Serialize implements serde.Message. It returns the serialized data for this
+// acknowledgement.
+func (m StoredMessage) Serialize(ctx serde.Context) ([]byte, error) {
+	format := msgFormats.Get(ctx.GetFormat())
+
+	data, err := format.Encode(ctx, m)
+	if err != nil {
+		return nil, xerrors.Errorf("encoding failed: %v", err)
+	}
+
+	return data, nil
+}
+
 // GenesisKey is the key of the genesis factory.
 type GenesisKey struct{}
 
@@ -229,6 +433,9 @@ type BlockKey struct{}
 // LinkKey is the key of the link factory.
 type LinkKey struct{}
 
+// ChainKey is the key of the chain factory.
+type ChainKey struct{}
+
 // AggregateKey is the key of the collective signature factory.
 type AggregateKey struct{}
 
@@ -238,6 +445,31 @@ type SignatureKey struct{}
 // AddressKey is the key of the address factory.
 type AddressKey struct{}
 
+// BlockIndexChecker is implemented by the block factories that can verify the
+// index of a proposed block before the block is decoded, so that an obviously
+// wrong proposal doesn't pay the cost of the decoding.
+type BlockIndexChecker interface {
+	// CheckIndex returns an error if a block at the index must be refused.
+	CheckIndex(index uint64) error
+}
+
+// checkedBlockFactory is a block factory bundled with the check of the index of
+// the proposed blocks so that the format engines can look it up from the
+// context.
+//
+// - implements types.BlockIndexChecker
+type checkedBlockFactory struct {
+	serde.Factory
+
+	check func(index uint64) error
+}
+
+// CheckIndex implements types.BlockIndexChecker. It returns the error of the
+// check.
+func (f checkedBlockFactory) CheckIndex(index uint64) error {
+	return f.check(index)
+}
+
 // MessageFactory is the factory to deserialize messages.
 //
 // - implements serde.Factory
@@ -248,6 +480,7 @@ type MessageFactory struct {
 	sigFac     crypto.SignatureFactory
 	csFac      authority.ChangeSetFactory
 	addrFac    mino.AddressFactory
+	indexCheck func(index uint64) error
 }
 
 // NewMessageFactory creates a new message factory.
@@ -263,13 +496,26 @@ func NewMessageFactory(gf, bf serde.Factory, addrFac mino.AddressFactory,
 	}
 }
 
+// WithIndexCheck returns a copy of the factory that verifies the index of the
+// proposed blocks with the check before the blocks are decoded.
+func (f MessageFactory) WithIndexCheck(check func(index uint64) error) MessageFactory {
+	f.indexCheck = check
+
+	return f
+}
+
 // Deserialize implements serde.Factory. It populates the message if
 // appropriate, otherwise it returns an error.
 func (f MessageFactory) Deserialize(ctx serde.Context, data []byte) (serde.Message, error) {
 	format := msgFormats.Get(ctx.GetFormat())
 
+	blockFac := f.blockFac
+	if f.indexCheck != nil {
+		blockFac = checkedBlockFactory{Factory: f.blockFac, check: f.indexCheck}
+	}
+
 	ctx = serde.WithFactory(ctx, GenesisKey{}, f.genesisFac)
-	ctx = serde.WithFactory(ctx, BlockKey{}, f.blockFac)
+	ctx = serde.WithFactory(ctx, BlockKey{}, blockFac)
 	ctx = serde.WithFactory(ctx, AggregateKey{}, f.aggFac)
 	ctx = serde.WithFactory(ctx, SignatureKey{}, f.sigFac)
 	ctx = serde.WithFactory(ctx, LinkKey{}, NewLinkFactory(f.blockFac, f.aggFac, f.csFac))
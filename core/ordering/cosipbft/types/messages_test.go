@@ -7,6 +7,8 @@ import (
 	"go.dedis.ch/dela/core/ordering/cosipbft/authority"
 	"go.dedis.ch/dela/internal/testing/fake"
 	"go.dedis.ch/dela/mino"
+	"go.dedis.ch/dela/serde"
+	"golang.org/x/xerrors"
 )
 
 func init() {
@@ -47,6 +49,28 @@ func TestBlockMessage_GetViews(t *testing.T) {
 	require.Len(t, msg.GetViews(), 1)
 }
 
+func TestBlockMessage_GetSignature(t *testing.T) {
+	msg := NewBlockMessage(Block{}, nil)
+	require.Nil(t, msg.GetSignature())
+
+	msg = NewBlockMessage(Block{}, nil, WithProposerSignature(fake.Signature{}))
+	require.Equal(t, fake.Signature{}, msg.GetSignature())
+}
+
+func TestBlockMessage_GetIndex(t *testing.T) {
+	msg := NewBlockMessage(Block{index: 3}, nil)
+
+	require.Equal(t, uint64(3), msg.GetIndex())
+}
+
+func TestBlockMessage_GetPrevious(t *testing.T) {
+	msg := NewBlockMessage(Block{}, nil)
+	require.Equal(t, Digest{}, msg.GetPrevious())
+
+	msg = NewBlockMessage(Block{}, nil, WithPrevious(Digest{2}))
+	require.Equal(t, Digest{2}, msg.GetPrevious())
+}
+
 func TestBlockMessage_Serialize(t *testing.T) {
 	msg := NewBlockMessage(Block{}, nil)
 
@@ -58,12 +82,34 @@ func TestBlockMessage_Serialize(t *testing.T) {
 	require.EqualError(t, err, fake.Err("encoding failed"))
 }
 
+func TestNewRoundID(t *testing.T) {
+	id, err := NewRoundID(append([]byte{1}, make([]byte, 31)...))
+	require.NoError(t, err)
+	require.Equal(t, Digest{1}, id)
+
+	_, err = NewRoundID(nil)
+	require.EqualError(t, err, "invalid digest of 0 bytes")
+
+	_, err = NewRoundID(make([]byte, 33))
+	require.EqualError(t, err, "invalid digest of 33 bytes")
+
+	_, err = NewRoundID(make([]byte, 32))
+	require.EqualError(t, err, "zero digest")
+}
+
 func TestCommitMessage_GetID(t *testing.T) {
 	msg := NewCommit(Digest{1}, fake.Signature{})
 
 	require.Equal(t, Digest{1}, msg.GetID())
 }
 
+func TestCommitMessage_GetTo(t *testing.T) {
+	msg := NewCommit(Digest{1}, fake.Signature{})
+
+	require.Equal(t, Digest{1}, msg.GetTo())
+	require.Equal(t, msg.GetID(), msg.GetTo())
+}
+
 func TestCommitMessage_GetSignature(t *testing.T) {
 	msg := NewCommit(Digest{}, fake.Signature{})
 
@@ -122,6 +168,18 @@ func TestViewMessage_GetSignature(t *testing.T) {
 	require.Equal(t, fake.Signature{}, msg.GetSignature())
 }
 
+func TestViewMessage_GetCertificate(t *testing.T) {
+	msg := NewViewMessage(Digest{}, 0, fake.Signature{})
+	require.Nil(t, msg.GetCertificate())
+
+	cert := NewViewCertificate([]uint16{1, 2}, fake.Signature{})
+
+	msg = NewViewMessage(Digest{}, 0, fake.Signature{}, WithViewCertificate(cert))
+	require.NotNil(t, msg.GetCertificate())
+	require.Equal(t, []uint16{1, 2}, msg.GetCertificate().GetSigners())
+	require.Equal(t, fake.Signature{}, msg.GetCertificate().GetSignature())
+}
+
 func TestViewMessage_Serialize(t *testing.T) {
 	msg := NewViewMessage(Digest{}, 3, fake.Signature{})
 
@@ -133,6 +191,41 @@ func TestViewMessage_Serialize(t *testing.T) {
 	require.EqualError(t, err, fake.Err("encoding failed"))
 }
 
+func TestAbortMessage_GetID(t *testing.T) {
+	msg := NewAbortMessage(Digest{1})
+
+	require.Equal(t, Digest{1}, msg.GetID())
+}
+
+func TestAbortMessage_Serialize(t *testing.T) {
+	msg := NewAbortMessage(Digest{})
+
+	data, err := msg.Serialize(fake.NewContext())
+	require.NoError(t, err)
+	require.Equal(t, fake.GetFakeFormatValue(), data)
+
+	_, err = msg.Serialize(fake.NewBadContext())
+	require.EqualError(t, err, fake.Err("encoding failed"))
+}
+
+func TestStoredMessage_Getters(t *testing.T) {
+	msg := NewStoredMessage(3, Digest{1})
+
+	require.Equal(t, uint64(3), msg.GetIndex())
+	require.Equal(t, Digest{1}, msg.GetRoot())
+}
+
+func TestStoredMessage_Serialize(t *testing.T) {
+	msg := NewStoredMessage(0, Digest{})
+
+	data, err := msg.Serialize(fake.NewContext())
+	require.NoError(t, err)
+	require.Equal(t, fake.GetFakeFormatValue(), data)
+
+	_, err = msg.Serialize(fake.NewBadContext())
+	require.EqualError(t, err, fake.Err("encoding failed"))
+}
+
 func TestMessageFactory_Deserialize(t *testing.T) {
 	fac := NewMessageFactory(
 		GenesisFactory{},
@@ -149,3 +242,43 @@ func TestMessageFactory_Deserialize(t *testing.T) {
 	_, err = fac.Deserialize(fake.NewBadContext(), nil)
 	require.EqualError(t, err, fake.Err("decoding failed"))
 }
+
+func TestMessageFactory_WithIndexCheck(t *testing.T) {
+	fac := NewMessageFactory(
+		GenesisFactory{},
+		BlockFactory{},
+		fake.AddressFactory{},
+		fake.SignatureFactory{},
+		authority.NewChangeSetFactory(fake.AddressFactory{}, fake.PublicKeyFactory{}),
+	)
+
+	format := &indexCheckFormat{}
+	RegisterMessageFormat(serde.Format("INDEX_CHECK"), format)
+
+	ctx := fake.NewContextWithFormat(serde.Format("INDEX_CHECK"))
+
+	_, err := fac.Deserialize(ctx, nil)
+	require.NoError(t, err)
+	require.Nil(t, format.checker)
+
+	fac = fac.WithIndexCheck(func(index uint64) error {
+		return xerrors.Errorf("index %d", index)
+	})
+
+	_, err = fac.Deserialize(ctx, nil)
+	require.NoError(t, err)
+	require.NotNil(t, format.checker)
+	require.EqualError(t, format.checker.CheckIndex(2), "index 2")
+}
+
+type indexCheckFormat struct {
+	fake.Format
+
+	checker BlockIndexChecker
+}
+
+func (f *indexCheckFormat) Decode(ctx serde.Context, data []byte) (serde.Message, error) {
+	f.checker, _ = ctx.GetFactory(BlockKey{}).(BlockIndexChecker)
+
+	return BlockMessage{}, nil
+}
@@ -0,0 +1,204 @@
+// This file contains the implementation of the Merkle tree over the payload of
+// a block.
+//
+
+package types
+
+import (
+	"bytes"
+
+	"go.dedis.ch/dela/core/validation"
+	"go.dedis.ch/dela/crypto"
+	"golang.org/x/xerrors"
+)
+
+var (
+	// leafPrefix and nodePrefix separate the hashes of the leaves from the ones
+	// of the internal nodes so that a node can't be presented as a leaf.
+	leafPrefix = []byte{0}
+	nodePrefix = []byte{1}
+)
+
+// MerklePayload is an optional interface of the data of a block that exposes
+// the leaves of a Merkle tree. The root of the tree is recorded in the block so
+// that the inclusion of a leaf can be proven without the whole payload.
+type MerklePayload interface {
+	validation.Result
+
+	// GetLeaves returns the leaves of the payload in a deterministic order.
+	GetLeaves() ([][]byte, error)
+}
+
+// PayloadRoot returns the Merkle root of the leaves of the payload. The root of
+// a payload without leaves is an empty digest.
+func PayloadRoot(payload MerklePayload, fac crypto.HashFactory) (Digest, error) {
+	root := Digest{}
+
+	levels, err := buildLevels(payload, fac)
+	if err != nil {
+		return root, err
+	}
+
+	if len(levels) > 0 {
+		copy(root[:], levels[len(levels)-1][0])
+	}
+
+	return root, nil
+}
+
+// PayloadProof is a proof of inclusion of a leaf in the Merkle tree of a
+// payload.
+type PayloadProof struct {
+	index    int
+	total    int
+	leaf     []byte
+	siblings [][]byte
+}
+
+// NewPayloadProof creates the proof of inclusion of the leaf at the given index
+// of the payload.
+func NewPayloadProof(payload MerklePayload, index int, fac crypto.HashFactory) (PayloadProof, error) {
+	leaves, err := payload.GetLeaves()
+	if err != nil {
+		return PayloadProof{}, xerrors.Errorf("couldn't read leaves: %v", err)
+	}
+
+	if index < 0 || index >= len(leaves) {
+		return PayloadProof{}, xerrors.Errorf("index %d out of range [0, %d)", index, len(leaves))
+	}
+
+	levels, err := buildLevels(payload, fac)
+	if err != nil {
+		return PayloadProof{}, err
+	}
+
+	proof := PayloadProof{
+		index: index,
+		total: len(leaves),
+		leaf:  leaves[index],
+	}
+
+	pos := index
+	for _, level := range levels[:len(levels)-1] {
+		sibling := pos ^ 1
+		if sibling < len(level) {
+			proof.siblings = append(proof.siblings, level[sibling])
+		}
+
+		pos /= 2
+	}
+
+	return proof, nil
+}
+
+// GetLeaf returns the leaf that the proof is for.
+func (p PayloadProof) GetLeaf() []byte {
+	return p.leaf
+}
+
+// Verify returns nil if the proof leads to the given root, otherwise an error.
+func (p PayloadProof) Verify(root Digest, fac crypto.HashFactory) error {
+	hash, err := hashWithPrefix(fac, leafPrefix, p.leaf)
+	if err != nil {
+		return xerrors.Errorf("couldn't hash leaf: %v", err)
+	}
+
+	pos := p.index
+	size := p.total
+	siblings := p.siblings
+
+	for size > 1 {
+		// The last node of a level with an odd size is promoted without a
+		// sibling.
+		if pos^1 < size {
+			if len(siblings) == 0 {
+				return xerrors.New("missing sibling")
+			}
+
+			left, right := siblings[0], hash
+			if pos%2 == 0 {
+				left, right = hash, siblings[0]
+			}
+
+			hash, err = hashWithPrefix(fac, nodePrefix, left, right)
+			if err != nil {
+				return xerrors.Errorf("couldn't hash node: %v", err)
+			}
+
+			siblings = siblings[1:]
+		}
+
+		pos /= 2
+		size = (size + 1) / 2
+	}
+
+	if !bytes.Equal(hash, root[:]) {
+		return xerrors.Errorf("mismatch root %#x != %#x", hash, root[:])
+	}
+
+	return nil
+}
+
+// buildLevels returns the levels of the Merkle tree from the hashes of the
+// leaves up to the root. It returns no level when the payload has no leaf.
+func buildLevels(payload MerklePayload, fac crypto.HashFactory) ([][][]byte, error) {
+	leaves, err := payload.GetLeaves()
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't read leaves: %v", err)
+	}
+
+	if len(leaves) == 0 {
+		return nil, nil
+	}
+
+	level := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		level[i], err = hashWithPrefix(fac, leafPrefix, leaf)
+		if err != nil {
+			return nil, xerrors.Errorf("couldn't hash leaf: %v", err)
+		}
+	}
+
+	levels := [][][]byte{level}
+
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+
+			node, err := hashWithPrefix(fac, nodePrefix, level[i], level[i+1])
+			if err != nil {
+				return nil, xerrors.Errorf("couldn't hash node: %v", err)
+			}
+
+			next = append(next, node)
+		}
+
+		levels = append(levels, next)
+		level = next
+	}
+
+	return levels, nil
+}
+
+func hashWithPrefix(fac crypto.HashFactory, prefix []byte, parts ...[]byte) ([]byte, error) {
+	h := fac.New()
+
+	_, err := h.Write(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, part := range parts {
+		_, err = h.Write(part)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,123 @@
+package types
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/validation"
+	"go.dedis.ch/dela/crypto"
+	"go.dedis.ch/dela/internal/testing/fake"
+)
+
+func TestPayloadRoot(t *testing.T) {
+	fac := crypto.NewSha256Factory()
+
+	root, err := PayloadRoot(fakePayload{}, fac)
+	require.NoError(t, err)
+	require.Equal(t, Digest{}, root)
+
+	root, err = PayloadRoot(makePayload(3), fac)
+	require.NoError(t, err)
+	require.NotEqual(t, Digest{}, root)
+
+	other, err := PayloadRoot(makePayload(4), fac)
+	require.NoError(t, err)
+	require.NotEqual(t, root, other)
+
+	_, err = PayloadRoot(fakePayload{err: fake.GetError()}, fac)
+	require.EqualError(t, err, fake.Err("couldn't read leaves"))
+
+	_, err = PayloadRoot(makePayload(1), fake.NewHashFactory(fake.NewBadHash()))
+	require.EqualError(t, err, fake.Err("couldn't hash leaf"))
+
+	_, err = PayloadRoot(makePayload(2), fake.NewHashFactory(fake.NewBadHashWithDelay(4)))
+	require.EqualError(t, err, fake.Err("couldn't hash node"))
+}
+
+func TestPayloadProof_Verify(t *testing.T) {
+	fac := crypto.NewSha256Factory()
+
+	for n := 1; n <= 9; n++ {
+		payload := makePayload(n)
+
+		root, err := PayloadRoot(payload, fac)
+		require.NoError(t, err)
+
+		for i := 0; i < n; i++ {
+			proof, err := NewPayloadProof(payload, i, fac)
+			require.NoError(t, err)
+			require.Equal(t, []byte(fmt.Sprintf("leaf%d", i)), proof.GetLeaf())
+			require.NoError(t, proof.Verify(root, fac), "leaf %d of %d", i, n)
+		}
+	}
+
+	payload := makePayload(5)
+
+	root, err := PayloadRoot(payload, fac)
+	require.NoError(t, err)
+
+	proof, err := NewPayloadProof(payload, 2, fac)
+	require.NoError(t, err)
+
+	err = proof.Verify(Digest{}, fac)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "mismatch root ")
+
+	proof.leaf = []byte("leaf3")
+	err = proof.Verify(root, fac)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "mismatch root ")
+
+	proof.siblings = nil
+	err = proof.Verify(root, fac)
+	require.EqualError(t, err, "missing sibling")
+
+	err = proof.Verify(root, fake.NewHashFactory(fake.NewBadHash()))
+	require.EqualError(t, err, fake.Err("couldn't hash leaf"))
+
+	proof, err = NewPayloadProof(payload, 2, fac)
+	require.NoError(t, err)
+
+	err = proof.Verify(root, fake.NewHashFactory(fake.NewBadHashWithDelay(2)))
+	require.EqualError(t, err, fake.Err("couldn't hash node"))
+}
+
+func TestPayloadProof_New(t *testing.T) {
+	fac := crypto.NewSha256Factory()
+
+	_, err := NewPayloadProof(makePayload(2), 2, fac)
+	require.EqualError(t, err, "index 2 out of range [0, 2)")
+
+	_, err = NewPayloadProof(makePayload(2), -1, fac)
+	require.EqualError(t, err, "index -1 out of range [0, 2)")
+
+	_, err = NewPayloadProof(fakePayload{err: fake.GetError()}, 0, fac)
+	require.EqualError(t, err, fake.Err("couldn't read leaves"))
+
+	_, err = NewPayloadProof(makePayload(2), 0, fake.NewHashFactory(fake.NewBadHash()))
+	require.EqualError(t, err, fake.Err("couldn't hash leaf"))
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+type fakePayload struct {
+	validation.Result
+
+	leaves [][]byte
+	err    error
+}
+
+func makePayload(n int) fakePayload {
+	leaves := make([][]byte, n)
+	for i := range leaves {
+		leaves[i] = []byte(fmt.Sprintf("leaf%d", i))
+	}
+
+	return fakePayload{leaves: leaves}
+}
+
+func (p fakePayload) GetLeaves() ([][]byte, error) {
+	return p.leaves, p.err
+}
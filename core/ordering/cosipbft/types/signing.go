@@ -0,0 +1,66 @@
+// This file contains the definition of the messages signed during the phases
+// of the consensus.
+//
+
+package types
+
+import "go.dedis.ch/dela/crypto"
+
+var (
+	prepareContext = []byte("prepare")
+	commitContext  = []byte("commit")
+)
+
+// ProtocolVersion is the version of the signing protocol. It defines the
+// messages that are signed during the prepare and the commit phases.
+type ProtocolVersion uint16
+
+const (
+	// ProtocolV0 is the initial protocol where the prepare phase signs the
+	// digest of the link, and the commit phase the prepare signature.
+	ProtocolV0 ProtocolVersion = iota
+
+	// ProtocolV1 mixes the digest of the genesis block and the phase in the
+	// signed messages, so that a signature can't be replayed on a different
+	// chain sharing the same keys, or on a different phase.
+	ProtocolV1
+)
+
+// PrepareMessage returns the message signed during the prepare phase for the
+// link of the given digest.
+func (v ProtocolVersion) PrepareMessage(genesis Digest, id Digest) []byte {
+	if v < ProtocolV1 {
+		return append([]byte{}, id[:]...)
+	}
+
+	return bindMessage(prepareContext, genesis, id[:])
+}
+
+// VerifyPrepare recomputes the message signed during the prepare phase for the
+// link of the given digest and verifies that the signature covers it. It
+// returns the error of the verifier if the signature doesn't match, for
+// instance when it was produced for a different link or chain.
+func (v ProtocolVersion) VerifyPrepare(genesis, id Digest,
+	sig crypto.Signature, verifier crypto.Verifier) error {
+
+	return verifier.Verify(v.PrepareMessage(genesis, id), sig)
+}
+
+// CommitMessage returns the message signed during the commit phase for the
+// given representation of the prepare signature.
+func (v ProtocolVersion) CommitMessage(genesis Digest, prepareSig []byte) []byte {
+	if v < ProtocolV1 {
+		return prepareSig
+	}
+
+	return bindMessage(commitContext, genesis, prepareSig)
+}
+
+func bindMessage(context []byte, genesis Digest, msg []byte) []byte {
+	buffer := make([]byte, 0, len(context)+len(genesis)+len(msg))
+	buffer = append(buffer, context...)
+	buffer = append(buffer, genesis[:]...)
+	buffer = append(buffer, msg...)
+
+	return buffer
+}
@@ -0,0 +1,55 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/crypto/bls"
+)
+
+func TestProtocolVersion_PrepareMessage(t *testing.T) {
+	id := Digest{1}
+
+	require.Equal(t, id[:], ProtocolV0.PrepareMessage(Digest{2}, id))
+
+	msg := ProtocolV1.PrepareMessage(Digest{2}, id)
+	require.Equal(t, append(append([]byte("prepare"), Digest{2}.Bytes()...), id[:]...), msg)
+	require.NotEqual(t, msg, ProtocolV1.PrepareMessage(Digest{3}, id))
+}
+
+func TestProtocolVersion_VerifyPrepare(t *testing.T) {
+	signer := bls.NewSigner()
+
+	for _, v := range []ProtocolVersion{ProtocolV0, ProtocolV1} {
+		sig, err := signer.Sign(v.PrepareMessage(Digest{2}, Digest{1}))
+		require.NoError(t, err)
+
+		err = v.VerifyPrepare(Digest{2}, Digest{1}, sig, signer.GetPublicKey())
+		require.NoError(t, err)
+
+		// The signature doesn't cover the digest of a different link.
+		err = v.VerifyPrepare(Digest{2}, Digest{3}, sig, signer.GetPublicKey())
+		require.Error(t, err)
+	}
+
+	sig, err := signer.Sign(ProtocolV1.PrepareMessage(Digest{2}, Digest{1}))
+	require.NoError(t, err)
+
+	// The signature of a different chain is rejected.
+	err = ProtocolV1.VerifyPrepare(Digest{3}, Digest{1}, sig, signer.GetPublicKey())
+	require.Error(t, err)
+
+	// ... as well as the one of a different protocol.
+	err = ProtocolV0.VerifyPrepare(Digest{2}, Digest{1}, sig, signer.GetPublicKey())
+	require.Error(t, err)
+}
+
+func TestProtocolVersion_CommitMessage(t *testing.T) {
+	sig := []byte{1, 2, 3}
+
+	require.Equal(t, sig, ProtocolV0.CommitMessage(Digest{2}, sig))
+
+	msg := ProtocolV1.CommitMessage(Digest{2}, sig)
+	require.Equal(t, append(append([]byte("commit"), Digest{2}.Bytes()...), sig...), msg)
+	require.NotEqual(t, msg, ProtocolV1.CommitMessage(Digest{3}, sig))
+}
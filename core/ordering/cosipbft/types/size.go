@@ -0,0 +1,85 @@
+// This file contains the implementation of the estimation of the size of a
+// serialized block while it is being filled with transactions.
+//
+
+package types
+
+import (
+	"go.dedis.ch/dela/core/txn"
+	"go.dedis.ch/dela/core/validation"
+	"go.dedis.ch/dela/serde"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// BlockSizeOverhead is an upper bound of the number of bytes of a
+	// serialized block without any transaction, that is the header and the
+	// envelope of the payload.
+	BlockSizeOverhead = 320
+
+	// TransactionSizeOverhead is an upper bound of the number of bytes that
+	// wrap a transaction in the payload of a block, like the status of the
+	// execution.
+	TransactionSizeOverhead = 64
+
+	// ReasonSizeOverhead is an upper bound of the number of bytes of the reason
+	// of a rejected transaction, whose characters might each be escaped in up
+	// to six bytes.
+	ReasonSizeOverhead = 6 * validation.MaxReasonSize
+
+	// ExtraDataSizeOverhead is an upper bound of the number of bytes that wrap
+	// the extra data of a block.
+	ExtraDataSizeOverhead = 32
+)
+
+// SizeEstimator estimates incrementally the size of a serialized block while
+// transactions are added to it, so that a proposer can stop before exceeding a
+// limit. The estimation is an upper bound as the reason of a rejected
+// transaction is bounded by the validation.
+type SizeEstimator struct {
+	context serde.Context
+	size    int
+}
+
+// NewSizeEstimator creates a new estimator of an empty block. The context must
+// be the one used to serialize the block.
+func NewSizeEstimator(ctx serde.Context) *SizeEstimator {
+	return &SizeEstimator{
+		context: ctx,
+		size:    BlockSizeOverhead,
+	}
+}
+
+// Size returns the estimated size of the block.
+func (e *SizeEstimator) Size() int {
+	return e.size
+}
+
+// AddExtraData adds the extra data of the block to the estimation. The data is
+// assumed to be at most twice as large once encoded, like in hexadecimal.
+func (e *SizeEstimator) AddExtraData(data []byte) int {
+	if len(data) > 0 {
+		e.size += 2*len(data) + ExtraDataSizeOverhead
+	}
+
+	return e.size
+}
+
+// Measure returns the estimated number of bytes that the transaction adds to
+// the block, without adding it.
+func (e *SizeEstimator) Measure(tx txn.Transaction) (int, error) {
+	data, err := tx.Serialize(e.context)
+	if err != nil {
+		return 0, xerrors.Errorf("failed to serialize: %v", err)
+	}
+
+	return len(data) + TransactionSizeOverhead + ReasonSizeOverhead, nil
+}
+
+// Add adds the size of a transaction, as returned by Measure, to the estimation
+// and returns the new estimated size of the block.
+func (e *SizeEstimator) Add(size int) int {
+	e.size += size
+
+	return e.size
+}
@@ -0,0 +1,53 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/txn"
+	"go.dedis.ch/dela/internal/testing/fake"
+	"go.dedis.ch/dela/serde"
+)
+
+func TestSizeEstimator_Add(t *testing.T) {
+	est := NewSizeEstimator(fake.NewContext())
+	require.Equal(t, BlockSizeOverhead, est.Size())
+
+	expected := 100 + TransactionSizeOverhead + ReasonSizeOverhead
+
+	size, err := est.Measure(fakeSizedTx{size: 100})
+	require.NoError(t, err)
+	require.Equal(t, expected, size)
+	require.Equal(t, BlockSizeOverhead, est.Size())
+
+	require.Equal(t, BlockSizeOverhead+expected, est.Add(size))
+	require.Equal(t, BlockSizeOverhead+expected, est.Size())
+
+	_, err = est.Measure(fakeSizedTx{err: fake.GetError()})
+	require.EqualError(t, err, fake.Err("failed to serialize"))
+	require.Equal(t, BlockSizeOverhead+expected, est.Size())
+}
+
+func TestSizeEstimator_AddExtraData(t *testing.T) {
+	est := NewSizeEstimator(fake.NewContext())
+
+	require.Equal(t, BlockSizeOverhead, est.AddExtraData(nil))
+
+	size := est.AddExtraData([]byte("v1.2.0"))
+	require.Equal(t, BlockSizeOverhead+12+ExtraDataSizeOverhead, size)
+	require.Equal(t, size, est.Size())
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+type fakeSizedTx struct {
+	txn.Transaction
+
+	size int
+	err  error
+}
+
+func (tx fakeSizedTx) Serialize(serde.Context) ([]byte, error) {
+	return make([]byte, tx.size), tx.err
+}
@@ -49,6 +49,7 @@ import (
 // Modifications on a staged tree are done in-memory.
 //
 // - implements hashtree.Tree
+// - implements hashtree.IterableTree
 type MerkleTree struct {
 	sync.Mutex
 
@@ -146,10 +147,33 @@ func (t *MerkleTree) GetPath(key []byte) (hashtree.Path, error) {
 	return path, nil
 }
 
+// ForEach implements hashtree.IterableTree. It calls the callback for each
+// key/value pair of the tree.
+func (t *MerkleTree) ForEach(fn func(key, value []byte) error) error {
+	t.Lock()
+	defer t.Unlock()
+
+	err := t.doView(func(tx kv.ReadableTx) error {
+		bucket := tx.GetBucket(t.bucket)
+
+		return t.tree.ForEach(bucket, fn)
+	})
+
+	if err != nil {
+		return xerrors.Errorf("couldn't iterate: %v", err)
+	}
+
+	return nil
+}
+
 // Stage implements hashtree.Tree. It executes the callback over a clone of the
 // current tree and return the clone with the root calculated.
 func (t *MerkleTree) Stage(fn func(store.Snapshot) error) (hashtree.StagingTree, error) {
+	// A search loads the children of the nodes lazily, therefore the clone
+	// must not run concurrently with a read of the tree.
+	t.Lock()
 	clone := t.clone()
+	t.Unlock()
 
 	err := t.doUpdate(func(tx kv.WritableTx) error {
 		b, err := tx.GetBucketOrCreate(t.bucket)
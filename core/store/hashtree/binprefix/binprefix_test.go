@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"testing/quick"
 
@@ -212,6 +213,26 @@ func TestMerkleTree_Get(t *testing.T) {
 		"couldn't search key: transaction 'binprefix.wrongTx' is not readable")
 }
 
+func TestMerkleTree_ForEach(t *testing.T) {
+	tree := NewMerkleTree(fakeDB{}, Nonce{})
+
+	err := tree.tree.Insert([]byte("ping"), []byte("pong"), &fakeBucket{})
+	require.NoError(t, err)
+
+	pairs := map[string]string{}
+	err = tree.ForEach(func(key, value []byte) error {
+		pairs[string(key)] = string(value)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"ping": "pong"}, pairs)
+
+	tree.tx = wrongTx{}
+	err = tree.ForEach(nil)
+	require.EqualError(t, err,
+		"couldn't iterate: transaction 'binprefix.wrongTx' is not readable")
+}
+
 func TestMerkleTree_GetRoot(t *testing.T) {
 	tree := NewMerkleTree(fakeDB{}, Nonce{})
 	// Tree is not yet updated.
@@ -281,6 +302,54 @@ func TestMerkleTree_Stage(t *testing.T) {
 	require.EqualError(t, err, "transaction 'binprefix.wrongTx' is not writable")
 }
 
+func TestMerkleTree_Stage_ConcurrentGet(t *testing.T) {
+	db, clean := makeDB(t)
+	defer clean()
+
+	tree := NewMerkleTree(db, Nonce{})
+	tree.tree.memDepth = 1
+
+	next, err := tree.Stage(func(snap store.Snapshot) error {
+		for i := byte(0); i < 32; i++ {
+			err := snap.Set([]byte{i}, []byte{i})
+			require.NoError(t, err)
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, next.Commit())
+
+	// The tree is loaded from the disk so that the searches populate the
+	// nodes while the tree is cloned.
+	tree = NewMerkleTree(db, Nonce{})
+	tree.tree.memDepth = 1
+	require.NoError(t, tree.Load())
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		for i := byte(0); i < 32; i++ {
+			_, err := tree.Get([]byte{i})
+			require.NoError(t, err)
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 32; i++ {
+			_, err := tree.Stage(func(store.Snapshot) error { return nil })
+			require.NoError(t, err)
+		}
+	}()
+
+	wg.Wait()
+}
+
 func TestMerkleTree_Commit(t *testing.T) {
 	tree := NewMerkleTree(fakeDB{err: fake.GetError()}, Nonce{})
 
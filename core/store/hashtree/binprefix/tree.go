@@ -228,6 +228,44 @@ func (t *Tree) Delete(key []byte, b kv.Bucket) error {
 	return nil
 }
 
+// ForEach calls the callback for each key/value pair stored in the tree. The
+// nodes stored on the disk are loaded from the bucket without being kept
+// in-memory.
+func (t *Tree) ForEach(b kv.Bucket, fn func(key, value []byte) error) error {
+	return t.forEach(t.root, new(big.Int), b, fn)
+}
+
+func (t *Tree) forEach(node TreeNode, index *big.Int, b kv.Bucket, fn func(key, value []byte) error) error {
+	switch n := node.(type) {
+	case *DiskNode:
+		if b == nil {
+			return xerrors.New("bucket is nil")
+		}
+
+		loaded, err := n.load(index, b)
+		if err != nil {
+			return xerrors.Errorf("failed to load node: %v", err)
+		}
+
+		return t.forEach(loaded, index, b, fn)
+	case *InteriorNode:
+		left := new(big.Int).SetBit(n.prefix, int(n.depth), 0)
+
+		err := t.forEach(n.left, left, b, fn)
+		if err != nil {
+			return err
+		}
+
+		right := new(big.Int).SetBit(n.prefix, int(n.depth), 1)
+
+		return t.forEach(n.right, right, b, fn)
+	case *LeafNode:
+		return fn(n.key.Bytes(), n.value)
+	}
+
+	return nil
+}
+
 // CalculateRoot updates the hashes of the tree.
 func (t *Tree) CalculateRoot(fac crypto.HashFactory, b kv.Bucket) error {
 	prefix := new(big.Int)
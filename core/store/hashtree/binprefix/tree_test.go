@@ -234,6 +234,51 @@ func TestTree_Persist(t *testing.T) {
 		fake.Err("visiting empty: failed to clean subtree"))
 }
 
+func TestTree_ForEach(t *testing.T) {
+	bucket := &fakeBucket{}
+
+	tree := NewTree(Nonce{})
+
+	for i := 1; i <= math.MaxUint8; i++ {
+		key := []byte{byte(i)}
+
+		err := tree.Insert(key, key, bucket)
+		require.NoError(t, err)
+	}
+
+	count := func(b kv.Bucket) int {
+		num := 0
+		err := tree.ForEach(b, func(key, value []byte) error {
+			require.Equal(t, key, value)
+			num++
+			return nil
+		})
+		require.NoError(t, err)
+
+		return num
+	}
+
+	require.Equal(t, math.MaxUint8, count(nil))
+
+	// Leaves are replaced by disk nodes that must be loaded.
+	err := tree.Persist(bucket)
+	require.NoError(t, err)
+
+	require.Equal(t, math.MaxUint8, count(bucket))
+
+	err = tree.ForEach(nil, nil)
+	require.EqualError(t, err, "bucket is nil")
+
+	err = tree.ForEach(&fakeBucket{}, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to load node: ")
+
+	err = tree.ForEach(bucket, func(key, value []byte) error {
+		return fake.GetError()
+	})
+	require.Equal(t, fake.GetError(), err)
+}
+
 func TestTree_Clone(t *testing.T) {
 	tree := NewTree(Nonce{})
 
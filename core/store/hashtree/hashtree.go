@@ -40,6 +40,15 @@ type Tree interface {
 	Stage(func(store.Snapshot) error) (StagingTree, error)
 }
 
+// IterableTree is a tree that can iterate over the key/value pairs it stores.
+type IterableTree interface {
+	Tree
+
+	// ForEach calls the callback for each key/value pair of the tree, and
+	// stops at the first error.
+	ForEach(fn func(key, value []byte) error) error
+}
+
 // StagingTree is a tree that has been modified in-memory but is yet to be
 // committed to the disk.
 type StagingTree interface {
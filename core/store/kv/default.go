@@ -17,17 +17,42 @@ import (
 // BoltDB is an adapter of the KV database using bboltdb.
 //
 // - implements kv.DB
+// - implements kv.Syncer
 type boltDB struct {
 	bolt *bbolt.DB
 }
 
+type dbTemplate struct {
+	noSync bool
+}
+
+// Option is the type of option to open a database.
+type Option func(*dbTemplate)
+
+// WithNoSync is an option to skip the synchronization of the file after each
+// update. The updates are faster, but the latest ones can be lost after a
+// crash unless the database is explicitly synced.
+func WithNoSync() Option {
+	return func(tmpl *dbTemplate) {
+		tmpl.noSync = true
+	}
+}
+
 // New opens a new database to the given file.
-func New(path string) (DB, error) {
+func New(path string, opts ...Option) (DB, error) {
+	tmpl := dbTemplate{}
+
+	for _, opt := range opts {
+		opt(&tmpl)
+	}
+
 	db, err := bbolt.Open(path, 0666, &bbolt.Options{})
 	if err != nil {
 		return nil, xerrors.Errorf("failed to open db: %v", err)
 	}
 
+	db.NoSync = tmpl.noSync
+
 	bdb := boltDB{
 		bolt: db,
 	}
@@ -51,6 +76,11 @@ func (db boltDB) Update(fn func(WritableTx) error) error {
 	})
 }
 
+// Sync implements kv.Syncer. It forces the writes of the database to the disk.
+func (db boltDB) Sync() error {
+	return db.bolt.Sync()
+}
+
 // Close implements kv.DB. It closes the database. Any view or update call will
 // result in an error after this function is called.
 func (db boltDB) Close() error {
@@ -64,6 +64,33 @@ func TestBoltDB_Close(t *testing.T) {
 	require.Error(t, db.(boltDB).bolt.Sync())
 }
 
+func TestBoltDB_Sync(t *testing.T) {
+	dir, err := os.MkdirTemp(os.TempDir(), delaTestDir)
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	db, err := New(filepath.Join(dir, "test.db"), WithNoSync())
+	require.NoError(t, err)
+	require.True(t, db.(boltDB).bolt.NoSync)
+
+	err = db.Update(func(tx WritableTx) error {
+		bucket, err := tx.GetBucketOrCreate([]byte("bucket"))
+		require.NoError(t, err)
+
+		return bucket.Set([]byte("ping"), []byte("pong"))
+	})
+	require.NoError(t, err)
+
+	err = db.(Syncer).Sync()
+	require.NoError(t, err)
+
+	require.NoError(t, db.Close())
+
+	err = db.(Syncer).Sync()
+	require.Error(t, err)
+}
+
 func TestBoltTx_GetBucket(t *testing.T) {
 	dir, err := os.MkdirTemp(os.TempDir(), delaTestDir)
 	require.NoError(t, err)
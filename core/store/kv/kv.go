@@ -61,3 +61,10 @@ type DB interface {
 	// Close closes the database and free the resources.
 	Close() error
 }
+
+// Syncer is an optional interface of a database that can force the writes to
+// the durable storage.
+type Syncer interface {
+	// Sync flushes the writes of the database to the durable storage.
+	Sync() error
+}
@@ -0,0 +1,198 @@
+// Package disk implements a transaction pool that persists the pending
+// transactions to a key/value database, so that they survive a restart of the
+// node.
+package disk
+
+import (
+	"github.com/rs/zerolog"
+	"go.dedis.ch/dela"
+	"go.dedis.ch/dela/core/store"
+	"go.dedis.ch/dela/core/store/kv"
+	"go.dedis.ch/dela/core/txn"
+	"go.dedis.ch/dela/core/txn/pool"
+	"go.dedis.ch/dela/core/validation"
+	"go.dedis.ch/dela/serde"
+	"go.dedis.ch/dela/serde/json"
+	"golang.org/x/xerrors"
+)
+
+// Committed is the function that tells if a transaction has already been
+// committed, in which case it must not be reloaded into the pool.
+type Committed func(tx txn.Transaction) (bool, error)
+
+// NonceCommitted returns a function that considers a transaction committed
+// when its nonce is lower than the next nonce expected by the validation
+// service for its identity.
+func NonceCommitted(val validation.Service, store store.Readable) Committed {
+	return func(tx txn.Transaction) (bool, error) {
+		nonce, err := val.GetNonce(store, tx.GetIdentity())
+		if err != nil {
+			return false, xerrors.Errorf("nonce: %v", err)
+		}
+
+		return tx.GetNonce() < nonce, nil
+	}
+}
+
+// Pool is a transaction pool that writes through the transactions of an
+// underlying pool to a database. The transactions are written when they are
+// added, and deleted when they are removed.
+//
+// - implements pool.Pool
+type Pool struct {
+	pool.Pool
+
+	logger  zerolog.Logger
+	db      kv.DB
+	bucket  []byte
+	context serde.Context
+	fac     txn.Factory
+}
+
+// NewPool creates a new persistent pool on top of the given pool. The factory
+// is used to decode the transactions when the pool is loaded.
+func NewPool(p pool.Pool, db kv.DB, fac txn.Factory) *Pool {
+	return &Pool{
+		Pool:    p,
+		logger:  dela.Logger,
+		db:      db,
+		bucket:  []byte("pool"),
+		context: json.NewContext(),
+		fac:     fac,
+	}
+}
+
+// Load reads the transactions of the database and adds them to the underlying
+// pool. The committed transactions, and the ones that the pool refuses, are
+// deleted instead. A malformed transaction is logged and deleted so that it
+// doesn't prevent the others from being reloaded. It returns the number of
+// transactions that are reloaded.
+func (p *Pool) Load(committed Committed) (int, error) {
+	var txs []txn.Transaction
+	var stale [][]byte
+
+	err := p.db.View(func(rtx kv.ReadableTx) error {
+		bucket := rtx.GetBucket(p.bucket)
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(key, value []byte) error {
+			tx, err := p.fac.TransactionOf(p.context, value)
+			if err != nil {
+				p.logger.Warn().Err(err).Hex("key", key).Msg("malformed transaction purged")
+
+				stale = append(stale, append([]byte{}, key...))
+				return nil
+			}
+
+			done, err := committed(tx)
+			if err != nil {
+				return xerrors.Errorf("committed: %v", err)
+			}
+
+			if done {
+				stale = append(stale, append([]byte{}, key...))
+			} else {
+				txs = append(txs, tx)
+			}
+
+			return nil
+		})
+	})
+
+	if err != nil {
+		return 0, xerrors.Errorf("while reading: %v", err)
+	}
+
+	reloaded := 0
+
+	for _, tx := range txs {
+		err = p.Pool.Add(tx)
+		if err != nil {
+			stale = append(stale, tx.GetID())
+			continue
+		}
+
+		reloaded++
+	}
+
+	err = p.db.Update(func(wtx kv.WritableTx) error {
+		bucket, err := wtx.GetBucketOrCreate(p.bucket)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range stale {
+			err = bucket.Delete(key)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, xerrors.Errorf("while purging: %v", err)
+	}
+
+	return reloaded, nil
+}
+
+// Add implements pool.Pool. It adds the transaction to the underlying pool and
+// writes it to the database.
+func (p *Pool) Add(tx txn.Transaction) error {
+	data, err := tx.Serialize(p.context)
+	if err != nil {
+		return xerrors.Errorf("failed to serialize: %v", err)
+	}
+
+	err = p.Pool.Add(tx)
+	if err != nil {
+		return err
+	}
+
+	err = p.db.Update(func(wtx kv.WritableTx) error {
+		bucket, err := wtx.GetBucketOrCreate(p.bucket)
+		if err != nil {
+			return err
+		}
+
+		return bucket.Set(tx.GetID(), data)
+	})
+
+	if err != nil {
+		// The transaction is removed so that the pool and the database stay
+		// consistent.
+		p.Pool.Remove(tx)
+
+		return xerrors.Errorf("failed to write: %v", err)
+	}
+
+	return nil
+}
+
+// Remove implements pool.Pool. It removes the transaction from the underlying
+// pool and deletes it from the database.
+func (p *Pool) Remove(tx txn.Transaction) error {
+	err := p.Pool.Remove(tx)
+	if err != nil {
+		return err
+	}
+
+	err = p.db.Update(func(wtx kv.WritableTx) error {
+		bucket, err := wtx.GetBucketOrCreate(p.bucket)
+		if err != nil {
+			return err
+		}
+
+		return bucket.Delete(tx.GetID())
+	})
+
+	if err != nil {
+		return xerrors.Errorf("failed to delete: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,251 @@
+package disk
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/access"
+	"go.dedis.ch/dela/core/store"
+	"go.dedis.ch/dela/core/store/kv"
+	"go.dedis.ch/dela/core/txn"
+	"go.dedis.ch/dela/core/txn/pool"
+	"go.dedis.ch/dela/core/txn/pool/mem"
+	"go.dedis.ch/dela/core/txn/signed"
+	"go.dedis.ch/dela/core/validation"
+	"go.dedis.ch/dela/crypto"
+	"go.dedis.ch/dela/crypto/bls"
+	"go.dedis.ch/dela/internal/testing/fake"
+	"go.dedis.ch/dela/serde"
+
+	_ "go.dedis.ch/dela/core/txn/signed/json"
+)
+
+func TestPool_Load(t *testing.T) {
+	db, clean := makeDB(t)
+	defer clean()
+
+	signer := bls.NewSigner()
+	fac := signed.NewTransactionFactory()
+
+	p := NewPool(mem.NewPool(), db, fac)
+
+	n, err := p.Load(notCommitted)
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+
+	txs := make([]txn.Transaction, 4)
+	for i := range txs {
+		txs[i] = makeTx(t, uint64(i), signer)
+
+		err = p.Add(txs[i])
+		require.NoError(t, err)
+	}
+
+	err = p.Remove(txs[3])
+	require.NoError(t, err)
+	require.Equal(t, 3, countTxs(t, db))
+
+	// The transactions with a nonce lower than 2 have been committed while the
+	// node was down.
+	committed := func(tx txn.Transaction) (bool, error) {
+		return tx.GetNonce() < 2, nil
+	}
+
+	restarted := NewPool(mem.NewPool(), db, fac)
+
+	n, err = restarted.Load(committed)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, 1, restarted.Stats().TxCount)
+	require.Equal(t, 1, countTxs(t, db))
+
+	restarted = NewPool(mem.NewPool(), db, fac)
+
+	n, err = restarted.Load(notCommitted)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	gathered := restarted.Gather(context.Background(), pool.Config{Min: 1})
+	require.Len(t, gathered, 1)
+	require.Equal(t, txs[2].GetID(), gathered[0].GetID())
+
+	_, err = restarted.Load(func(txn.Transaction) (bool, error) {
+		return false, fake.GetError()
+	})
+	require.EqualError(t, err, fake.Err("while reading: committed"))
+
+	// A malformed transaction is purged without failing the others.
+	err = db.Update(func(wtx kv.WritableTx) error {
+		bucket, err := wtx.GetBucketOrCreate([]byte("pool"))
+		require.NoError(t, err)
+
+		return bucket.Set([]byte("malformed"), []byte("{"))
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, countTxs(t, db))
+
+	restarted = NewPool(mem.NewPool(), db, fac)
+
+	n, err = restarted.Load(notCommitted)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, 1, countTxs(t, db))
+
+	restarted.fac = badTxFactory{}
+	n, err = restarted.Load(notCommitted)
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+	require.Equal(t, 0, countTxs(t, db))
+}
+
+func TestPool_Add(t *testing.T) {
+	db, clean := makeDB(t)
+	defer clean()
+
+	p := NewPool(mem.NewPool(), db, signed.NewTransactionFactory())
+
+	tx := makeTx(t, 0, bls.NewSigner())
+
+	err := p.Add(tx)
+	require.NoError(t, err)
+	require.Equal(t, 1, countTxs(t, db))
+
+	err = NewPool(mem.NewPool(), db, nil).Add(fakeTx{})
+	require.EqualError(t, err, fake.Err("failed to serialize"))
+
+	p.Pool = badPool{Pool: mem.NewPool()}
+	err = p.Add(makeTx(t, 1, bls.NewSigner()))
+	require.EqualError(t, err, fake.GetError().Error())
+	require.Equal(t, 1, countTxs(t, db))
+}
+
+func TestPool_Remove(t *testing.T) {
+	db, clean := makeDB(t)
+	defer clean()
+
+	p := NewPool(mem.NewPool(), db, signed.NewTransactionFactory())
+
+	tx := makeTx(t, 0, bls.NewSigner())
+
+	err := p.Add(tx)
+	require.NoError(t, err)
+
+	err = p.Remove(tx)
+	require.NoError(t, err)
+	require.Equal(t, 0, countTxs(t, db))
+
+	p.Pool = badPool{Pool: mem.NewPool()}
+	err = p.Remove(tx)
+	require.EqualError(t, err, fake.GetError().Error())
+}
+
+func TestNonceCommitted(t *testing.T) {
+	signer := bls.NewSigner()
+
+	committed := NonceCommitted(fakeValidation{nonce: 2}, nil)
+
+	done, err := committed(makeTx(t, 1, signer))
+	require.NoError(t, err)
+	require.True(t, done)
+
+	done, err = committed(makeTx(t, 2, signer))
+	require.NoError(t, err)
+	require.False(t, done)
+
+	committed = NonceCommitted(fakeValidation{err: fake.GetError()}, nil)
+
+	_, err = committed(makeTx(t, 2, signer))
+	require.EqualError(t, err, fake.Err("nonce"))
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+func notCommitted(txn.Transaction) (bool, error) {
+	return false, nil
+}
+
+func makeDB(t *testing.T) (kv.DB, func()) {
+	file, err := os.CreateTemp(os.TempDir(), "dela-pool")
+	require.NoError(t, err)
+
+	db, err := kv.New(file.Name())
+	require.NoError(t, err)
+
+	clean := func() {
+		db.Close()
+		file.Close()
+		os.Remove(file.Name())
+	}
+
+	return db, clean
+}
+
+func makeTx(t *testing.T, nonce uint64, signer crypto.Signer) txn.Transaction {
+	tx, err := signed.NewTransaction(nonce, signer.GetPublicKey())
+	require.NoError(t, err)
+
+	require.NoError(t, tx.Sign(signer))
+
+	return tx
+}
+
+func countTxs(t *testing.T, db kv.DB) int {
+	count := 0
+
+	err := db.View(func(tx kv.ReadableTx) error {
+		bucket := tx.GetBucket([]byte("pool"))
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			count++
+			return nil
+		})
+	})
+	require.NoError(t, err)
+
+	return count
+}
+
+type fakeTx struct {
+	txn.Transaction
+}
+
+func (fakeTx) Serialize(serde.Context) ([]byte, error) {
+	return nil, fake.GetError()
+}
+
+type badPool struct {
+	pool.Pool
+}
+
+func (badPool) Add(txn.Transaction) error {
+	return fake.GetError()
+}
+
+func (badPool) Remove(txn.Transaction) error {
+	return fake.GetError()
+}
+
+type fakeValidation struct {
+	validation.Service
+
+	nonce uint64
+	err   error
+}
+
+func (v fakeValidation) GetNonce(store.Readable, access.Identity) (uint64, error) {
+	return v.nonce, v.err
+}
+
+type badTxFactory struct {
+	txn.Factory
+}
+
+func (badTxFactory) TransactionOf(serde.Context, []byte) (txn.Transaction, error) {
+	return nil, fake.GetError()
+}
@@ -15,6 +15,48 @@ import (
 // transactions.
 const DefaultIdentitySize = 100
 
+// ErrPoolFull is the error returned when a transaction is submitted to a pool
+// that reached its maximum size.
+var ErrPoolFull = xerrors.New("pool is full")
+
+// ErrPoolClosed is the error returned when a transaction is submitted to a
+// gatherer that is closed.
+var ErrPoolClosed = xerrors.New("pool is closed")
+
+// FullPolicy is the behavior of a gatherer when a transaction is added while it
+// holds the maximum number of transactions.
+type FullPolicy byte
+
+const (
+	// RejectWhenFull rejects the transaction right away.
+	RejectWhenFull FullPolicy = iota
+
+	// BlockWhenFull waits for transactions to be removed until a timeout, and
+	// rejects the transaction if there is still no space.
+	BlockWhenFull
+)
+
+// GathererOption is the type of option to set some fields of a gatherer.
+type GathererOption func(*simpleGatherer)
+
+// WithMaxSize is an option to set the maximum number of transactions that the
+// gatherer holds across all the identities. By default, the size is unbounded.
+func WithMaxSize(size int) GathererOption {
+	return func(g *simpleGatherer) {
+		g.maxSize = size
+	}
+}
+
+// WithFullPolicy is an option to set the behavior when a transaction is added
+// to a full gatherer. The timeout is only used by the blocking policy, which
+// waits without a limit when it is not positive.
+func WithFullPolicy(policy FullPolicy, timeout time.Duration) GathererOption {
+	return func(g *simpleGatherer) {
+		g.policy = policy
+		g.fullTimeout = timeout
+	}
+}
+
 // Gatherer is a common tool to the pool implementations that helps to implement
 // the gathering process.
 type Gatherer interface {
@@ -25,11 +67,17 @@ type Gatherer interface {
 	// Add adds the transaction to the list of pending transactions.
 	Add(tx txn.Transaction) error
 
+	// TryAdd adds the transaction to the list of pending transactions, but it
+	// rejects it right away when there is no space, whatever the policy.
+	TryAdd(tx txn.Transaction) error
+
 	// Remove removes a transaction from the list of pending ones.
 	Remove(tx txn.Transaction) error
 
 	// Wait waits for a notification with sufficient transactions to return the
-	// array, or nil if the context ends.
+	// array, or nil if the context ends. The transactions of a same identity
+	// must be ordered by ascending nonce in the array, and a transaction ID
+	// must appear only once.
 	Wait(ctx context.Context, cfg Config) []txn.Transaction
 
 	// Close closes current operations and cleans the resources.
@@ -59,6 +107,14 @@ type simpleGatherer struct {
 	queue      []item
 	validators []Filter
 
+	closed      bool
+	maxSize     int
+	policy      FullPolicy
+	fullTimeout time.Duration
+	// freed is closed and renewed each time some space may have been released,
+	// so that blocked submissions can check the size again.
+	freed chan struct{}
+
 	// A string key is generated for each unique identity, which will have its
 	// own list of transactions, so that a limited size can be enforced
 	// independently of each other.
@@ -66,11 +122,18 @@ type simpleGatherer struct {
 }
 
 // NewSimpleGatherer creates a new gatherer.
-func NewSimpleGatherer() Gatherer {
-	return &simpleGatherer{
+func NewSimpleGatherer(opts ...GathererOption) Gatherer {
+	g := &simpleGatherer{
 		limit: DefaultIdentitySize,
 		txs:   make(map[string]transactions),
+		freed: make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(g)
 	}
+
+	return g
 }
 
 // AddFilter implements pool.Gatherer. It adds the filter to the list that a
@@ -86,7 +149,16 @@ func (g *simpleGatherer) AddFilter(filter Filter) {
 // Add implements pool.Gatherer. It adds the transaction to the set of available
 // transactions and notify the queue of the new length.
 func (g *simpleGatherer) Add(tx txn.Transaction) error {
+	return g.add(tx, g.policy)
+}
+
+// TryAdd implements pool.Gatherer. It adds the transaction like Add, but it
+// applies the reject policy when the gatherer is full.
+func (g *simpleGatherer) TryAdd(tx txn.Transaction) error {
+	return g.add(tx, RejectWhenFull)
+}
 
+func (g *simpleGatherer) add(tx txn.Transaction, policy FullPolicy) error {
 	for _, val := range g.validators {
 		// Make sure the transaction is not already known, or that is not in a
 		// distant future to limit the pool storage size.
@@ -103,6 +175,12 @@ func (g *simpleGatherer) Add(tx txn.Transaction) error {
 
 	g.Lock()
 
+	err = g.waitSpace(policy)
+	if err != nil {
+		g.Unlock()
+		return err
+	}
+
 	g.txs[key] = g.txs[key].Add(transactionStats{
 		tx,
 		time.Now(),
@@ -126,6 +204,7 @@ func (g *simpleGatherer) Remove(tx txn.Transaction) error {
 	g.Lock()
 
 	g.txs[key] = g.txs[key].Remove(tx)
+	g.release()
 
 	g.Unlock()
 
@@ -198,6 +277,7 @@ func (g *simpleGatherer) ResetStats() {
 func (g *simpleGatherer) Close() {
 	g.Lock()
 
+	g.closed = true
 	g.txs = make(map[string]transactions)
 
 	for _, item := range g.queue {
@@ -206,9 +286,55 @@ func (g *simpleGatherer) Close() {
 
 	g.queue = nil
 
+	g.release()
+
 	g.Unlock()
 }
 
+// waitSpace returns nil when the gatherer has space for a new transaction,
+// according to the policy, or an error if it is closed in the meantime. It must
+// be called with the lock, which is released while waiting.
+func (g *simpleGatherer) waitSpace(policy FullPolicy) error {
+	var timeout <-chan time.Time
+
+	for !g.closed && g.maxSize > 0 && g.calculateLength() >= g.maxSize {
+		if policy != BlockWhenFull {
+			return xerrors.Errorf("limit of %d reached: %w", g.maxSize, ErrPoolFull)
+		}
+
+		if timeout == nil && g.fullTimeout > 0 {
+			timer := time.NewTimer(g.fullTimeout)
+			defer timer.Stop()
+
+			timeout = timer.C
+		}
+
+		freed := g.freed
+
+		g.Unlock()
+
+		select {
+		case <-freed:
+			g.Lock()
+		case <-timeout:
+			g.Lock()
+			return xerrors.Errorf("no space after %v: %w", g.fullTimeout, ErrPoolFull)
+		}
+	}
+
+	if g.closed {
+		return ErrPoolClosed
+	}
+
+	return nil
+}
+
+// release wakes up the submissions waiting for space.
+func (g *simpleGatherer) release() {
+	close(g.freed)
+	g.freed = make(chan struct{})
+}
+
 // Notify triggers the elements of the queue that are waiting for at least the
 // length in parameter and remove them from the queue.
 func (g *simpleGatherer) notify(length int) {
@@ -235,6 +361,9 @@ func (g *simpleGatherer) calculateLength() int {
 
 func (g *simpleGatherer) makeStatsArray() []transactionStats {
 	txs := make([]transactionStats, 0, g.calculateLength())
+
+	// Each list is already sorted by nonce, which means that the transactions
+	// of an identity are appended in the order they must be applied.
 	for _, list := range g.txs {
 		txs = append(txs, list...)
 	}
@@ -242,17 +371,42 @@ func (g *simpleGatherer) makeStatsArray() []transactionStats {
 	return txs
 }
 
+// makeArray returns the list of transactions to propose. Transactions sharing
+// the same canonical ID are included only once, so that a transaction received under
+// different identities, or with the same idempotency key, is not duplicated in
+// a block.
 func (g *simpleGatherer) makeArray() []txn.Transaction {
 	stxs := g.makeStatsArray()
 	txs := make([]txn.Transaction, 0, len(stxs))
+	seen := make(map[string]struct{}, len(stxs))
 
 	for _, t := range stxs {
+		id := makeID(t)
+
+		_, found := seen[id]
+		if found {
+			continue
+		}
+
+		seen[id] = struct{}{}
 		txs = append(txs, t.Transaction)
 	}
 
 	return txs
 }
 
+// makeID returns the canonical ID of the transaction, which doesn't depend on
+// how the transaction has been decoded. It falls back to the ID advertised by
+// the transaction when the fingerprint fails.
+func makeID(tx txn.Transaction) string {
+	id, err := txn.TransactionID(tx)
+	if err != nil {
+		return string(tx.GetID())
+	}
+
+	return string(id)
+}
+
 func makeKey(id access.Identity) (string, error) {
 	data, err := id.MarshalText()
 	if err != nil {
@@ -2,14 +2,20 @@ package pool
 
 import (
 	"context"
+	"io"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"go.dedis.ch/dela/core/access"
 	"go.dedis.ch/dela/core/txn"
+	"go.dedis.ch/dela/core/txn/signed"
 	"go.dedis.ch/dela/core/validation"
+	"go.dedis.ch/dela/crypto"
+	"go.dedis.ch/dela/crypto/bls"
 	"go.dedis.ch/dela/internal/testing/fake"
+	"golang.org/x/xerrors"
 )
 
 func TestSimpleGatherer_Len(t *testing.T) {
@@ -54,6 +60,83 @@ func TestSimpleGatherer_Add(t *testing.T) {
 	require.EqualError(t, err, fake.Err("identity key failed"))
 }
 
+func TestSimpleGatherer_MaxSize_Reject(t *testing.T) {
+	gatherer := NewSimpleGatherer(WithMaxSize(2))
+
+	require.NoError(t, gatherer.Add(newTx(0, "Alice")))
+	require.NoError(t, gatherer.Add(newTx(0, "Bob")))
+
+	err := gatherer.Add(newTx(1, "Alice"))
+	require.EqualError(t, err, "limit of 2 reached: pool is full")
+	require.True(t, xerrors.Is(err, ErrPoolFull))
+	require.Equal(t, 2, gatherer.Stats().TxCount)
+
+	require.NoError(t, gatherer.Remove(newTx(0, "Bob")))
+	require.NoError(t, gatherer.Add(newTx(1, "Alice")))
+}
+
+func TestSimpleGatherer_MaxSize_Block(t *testing.T) {
+	gatherer := NewSimpleGatherer(WithMaxSize(1), WithFullPolicy(BlockWhenFull, 50*time.Millisecond))
+
+	require.NoError(t, gatherer.Add(newTx(0, "Alice")))
+
+	start := time.Now()
+	err := gatherer.Add(newTx(1, "Alice"))
+	require.EqualError(t, err, "no space after 50ms: pool is full")
+	require.True(t, xerrors.Is(err, ErrPoolFull))
+	require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// A removal frees the space for the submission that is waiting.
+	gatherer = NewSimpleGatherer(WithMaxSize(1), WithFullPolicy(BlockWhenFull, time.Minute))
+	require.NoError(t, gatherer.Add(newTx(0, "Alice")))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- gatherer.Add(newTx(1, "Alice"))
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("submission should block")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	require.NoError(t, gatherer.Remove(newTx(0, "Alice")))
+	require.NoError(t, <-done)
+	require.Equal(t, 1, gatherer.Stats().TxCount)
+
+	// Without a timeout, the submission waits until the gatherer is closed.
+	gatherer = NewSimpleGatherer(WithMaxSize(1), WithFullPolicy(BlockWhenFull, 0))
+	require.NoError(t, gatherer.Add(newTx(0, "Alice")))
+
+	go func() {
+		done <- gatherer.Add(newTx(1, "Alice"))
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	gatherer.Close()
+
+	err = <-done
+	require.True(t, xerrors.Is(err, ErrPoolClosed))
+	require.Equal(t, 0, gatherer.Stats().TxCount)
+
+	// The reject policy is applied by TryAdd whatever the policy.
+	gatherer = NewSimpleGatherer(WithMaxSize(1), WithFullPolicy(BlockWhenFull, time.Minute))
+	require.NoError(t, gatherer.TryAdd(newTx(0, "Alice")))
+
+	err = gatherer.TryAdd(newTx(1, "Alice"))
+	require.EqualError(t, err, "limit of 1 reached: pool is full")
+}
+
+func TestSimpleGatherer_Closed_Add(t *testing.T) {
+	gatherer := NewSimpleGatherer()
+	gatherer.Close()
+
+	err := gatherer.Add(newTx(0, "Alice"))
+	require.Equal(t, ErrPoolClosed, err)
+	require.Equal(t, 0, gatherer.Stats().TxCount)
+}
+
 func TestSimpleGatherer_Remove(t *testing.T) {
 	gatherer := NewSimpleGatherer().(*simpleGatherer)
 	gatherer.txs["Alice"] = transactions{newTx(0, "Alice"), newTx(1, "Alice")}
@@ -66,7 +149,12 @@ func TestSimpleGatherer_Remove(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, gatherer.txs["Alice"], 1)
 
-	err = gatherer.Remove(newTx(1, "Alice"))
+	// The transaction is found by its canonical ID even if the advertised ID
+	// differs, as when it is decoded with a different hash factory.
+	tx := newTx(1, "Alice")
+	tx.Transaction = fakeTx{id: 1, identity: fakeIdentity{text: "Alice"}, hash: []byte{0xff}}
+
+	err = gatherer.Remove(tx)
 	require.NoError(t, err)
 	require.Len(t, gatherer.txs["Alice"], 0)
 
@@ -100,6 +188,62 @@ func TestSimpleGatherer_Wait(t *testing.T) {
 	require.Nil(t, txs)
 }
 
+func TestSimpleGatherer_NonceOrder_Wait(t *testing.T) {
+	gatherer := NewSimpleGatherer().(*simpleGatherer)
+
+	require.NoError(t, gatherer.Add(newTx(7, "Alice")))
+	require.NoError(t, gatherer.Add(newTx(2, "Bob")))
+	require.NoError(t, gatherer.Add(newTx(5, "Alice")))
+	require.NoError(t, gatherer.Add(newTx(1, "Bob")))
+	require.NoError(t, gatherer.Add(newTx(6, "Alice")))
+
+	txs := gatherer.Wait(context.Background(), Config{Min: 5})
+	require.Len(t, txs, 5)
+
+	nonces := map[string][]uint64{}
+	for _, tx := range txs {
+		key, err := makeKey(tx.GetIdentity())
+		require.NoError(t, err)
+
+		nonces[key] = append(nonces[key], tx.GetNonce())
+	}
+
+	require.Equal(t, []uint64{5, 6, 7}, nonces["Alice"])
+	require.Equal(t, []uint64{1, 2}, nonces["Bob"])
+}
+
+func TestSimpleGatherer_Duplicates_Wait(t *testing.T) {
+	gatherer := NewSimpleGatherer().(*simpleGatherer)
+
+	// Both signers act for the same account, which means they produce the
+	// same transaction under two different identities.
+	binding := signed.WithIdentityBinding(func(crypto.PublicKey) ([]byte, error) {
+		return []byte("account"), nil
+	})
+
+	alice := bls.NewSigner()
+	bob := bls.NewSigner()
+
+	require.NoError(t, gatherer.Add(makeSignedTx(t, 1, alice, binding)))
+	require.NoError(t, gatherer.Add(makeSignedTx(t, 1, bob, binding)))
+	require.NoError(t, gatherer.Add(makeSignedTx(t, 2, bob, binding)))
+
+	// Same nonce for the same identity.
+	require.NoError(t, gatherer.Add(makeSignedTx(t, 2, bob, binding)))
+
+	require.Equal(t, 3, gatherer.Stats().TxCount)
+
+	txs := gatherer.Wait(context.Background(), Config{Min: 1})
+	require.Len(t, txs, 2)
+
+	nonces := map[uint64]int{}
+	for _, tx := range txs {
+		nonces[tx.GetNonce()]++
+	}
+
+	require.Equal(t, map[uint64]int{1: 1, 2: 1}, nonces)
+}
+
 func TestSimpleGatherer_Close(t *testing.T) {
 	gatherer := NewSimpleGatherer().(*simpleGatherer)
 
@@ -134,10 +278,22 @@ func TestSimpleGatherer_Close(t *testing.T) {
 // -----------------------------------------------------------------------------
 // Utility functions
 
+func makeSignedTx(t *testing.T, nonce uint64, signer crypto.Signer,
+	opts ...signed.TransactionOption) txn.Transaction {
+
+	tx, err := signed.NewTransaction(nonce, signer.GetPublicKey(), opts...)
+	require.NoError(t, err)
+
+	require.NoError(t, tx.Sign(signer))
+
+	return tx
+}
+
 type fakeTx struct {
 	txn.Transaction
 	id       uint64
 	identity access.Identity
+	hash     []byte
 }
 
 func emptyTx() transactionStats {
@@ -156,9 +312,18 @@ func newTx(nonce uint64, identity string) transactionStats {
 }
 
 func (tx fakeTx) GetID() []byte {
+	if tx.hash != nil {
+		return tx.hash
+	}
+
 	return []byte{byte(tx.id)}
 }
 
+func (tx fakeTx) Fingerprint(w io.Writer) error {
+	_, err := w.Write([]byte{byte(tx.id)})
+	return err
+}
+
 func (tx fakeTx) GetNonce() uint64 {
 	return tx.id
 }
@@ -26,7 +26,8 @@ type Pool struct {
 }
 
 // NewPool creates a new empty pool and starts to gossip incoming transaction.
-func NewPool(gossiper gossip.Gossiper) (*Pool, error) {
+// The options set the gatherer, for instance to bound the size of the pool.
+func NewPool(gossiper gossip.Gossiper, opts ...pool.GathererOption) (*Pool, error) {
 	actor, err := gossiper.Listen()
 	if err != nil {
 		return nil, xerrors.Errorf("failed to listen: %v", err)
@@ -35,7 +36,7 @@ func NewPool(gossiper gossip.Gossiper) (*Pool, error) {
 	p := &Pool{
 		logger:   dela.Logger,
 		actor:    actor,
-		gatherer: pool.NewSimpleGatherer(),
+		gatherer: pool.NewSimpleGatherer(opts...),
 		closing:  make(chan struct{}),
 	}
 
@@ -57,11 +58,12 @@ func (p *Pool) AddFilter(filter pool.Filter) {
 }
 
 // Add implements pool.Pool. It adds the transaction to the pool and gossips it
-// to other participants.
+// to other participants. The error wraps pool.ErrPoolFull when the pool is
+// full.
 func (p *Pool) Add(tx txn.Transaction) error {
 	err := p.gatherer.Add(tx)
 	if err != nil {
-		return xerrors.Errorf("store failed: %v", err)
+		return xerrors.Errorf("store failed: %w", err)
 	}
 
 	err = p.actor.Add(tx)
@@ -118,7 +120,9 @@ func (p *Pool) listenRumors(ch <-chan gossip.Rumor) {
 		case rumor := <-ch:
 			tx, ok := rumor.(txn.Transaction)
 			if ok {
-				err := p.gatherer.Add(tx)
+				// A full pool must not stall the rumors, therefore they are
+				// rejected right away whatever the policy.
+				err := p.gatherer.TryAdd(tx)
 				if err != nil {
 					p.logger.Debug().Err(err).Msg("failed to add transaction")
 				}
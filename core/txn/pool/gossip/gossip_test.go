@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"testing"
 	"time"
 
@@ -103,6 +104,13 @@ func TestPool_Add(t *testing.T) {
 	p.actor = fakeActor{err: fake.GetError()}
 	err = p.Add(makeFakeTx(0))
 	require.EqualError(t, err, fake.Err("failed to gossip tx"))
+
+	p.gatherer = pool.NewSimpleGatherer(pool.WithMaxSize(1))
+	p.actor = fakeActor{}
+	require.NoError(t, p.Add(makeFakeTx(0)))
+
+	err = p.Add(makeFakeTx(1))
+	require.EqualError(t, err, "store failed: limit of 1 reached: pool is full")
 }
 
 func TestPool_Remove(t *testing.T) {
@@ -194,6 +202,24 @@ func TestPool_ListenRumors(t *testing.T) {
 
 	p.listenRumors(ch)
 	require.NotEmpty(t, buffer.String())
+
+	// A rumor is refused right away by a full pool even if the submissions
+	// wait for space.
+	buffer.Reset()
+	p.gatherer = pool.NewSimpleGatherer(pool.WithMaxSize(1),
+		pool.WithFullPolicy(pool.BlockWhenFull, 0))
+	p.closing = make(chan struct{})
+
+	require.NoError(t, p.gatherer.Add(makeFakeTx(0)))
+
+	ch = make(chan gossip.Rumor)
+	go func() {
+		ch <- makeFakeTx(1)
+		close(p.closing)
+	}()
+
+	p.listenRumors(ch)
+	require.Contains(t, buffer.String(), "pool is full")
 }
 
 // -----------------------------------------------------------------------------
@@ -248,6 +274,11 @@ func (tx fakeTx) GetID() []byte {
 	return []byte{byte(tx.nonce)}
 }
 
+func (tx fakeTx) Fingerprint(w io.Writer) error {
+	_, err := w.Write(tx.GetID())
+	return err
+}
+
 func (tx fakeTx) Serialize(serde.Context) ([]byte, error) {
 	return tx.GetID(), nil
 }
@@ -295,6 +326,10 @@ func (g badGatherer) Add(tx txn.Transaction) error {
 	return fake.GetError()
 }
 
+func (g badGatherer) TryAdd(tx txn.Transaction) error {
+	return fake.GetError()
+}
+
 func (g badGatherer) Remove(tx txn.Transaction) error {
 	return fake.GetError()
 }
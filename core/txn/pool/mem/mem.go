@@ -17,10 +17,11 @@ type Pool struct {
 	gatherer pool.Gatherer
 }
 
-// NewPool creates a new service.
-func NewPool() *Pool {
+// NewPool creates a new service. The options set the gatherer, for instance to
+// bound the size of the pool.
+func NewPool(opts ...pool.GathererOption) *Pool {
 	return &Pool{
-		gatherer: pool.NewSimpleGatherer(),
+		gatherer: pool.NewSimpleGatherer(opts...),
 	}
 }
 
@@ -30,11 +31,11 @@ func (p *Pool) AddFilter(filter pool.Filter) {
 }
 
 // Add implements pool.Pool. It adds the transaction to the pool of waiting
-// transactions.
+// transactions. The error wraps pool.ErrPoolFull when the pool is full.
 func (p *Pool) Add(tx txn.Transaction) error {
 	err := p.gatherer.Add(tx)
 	if err != nil {
-		return xerrors.Errorf("store failed: %v", err)
+		return xerrors.Errorf("store failed: %w", err)
 	}
 
 	return nil
@@ -2,13 +2,16 @@ package mem
 
 import (
 	"context"
+	"io"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"go.dedis.ch/dela/core/access"
 	"go.dedis.ch/dela/core/txn"
 	"go.dedis.ch/dela/core/txn/pool"
 	"go.dedis.ch/dela/internal/testing/fake"
+	"golang.org/x/xerrors"
 )
 
 func TestPool_Len(t *testing.T) {
@@ -44,6 +47,24 @@ func TestPool_Add(t *testing.T) {
 	require.EqualError(t, err, fake.Err("store failed"))
 }
 
+func TestPool_Add_MaxSize(t *testing.T) {
+	p := NewPool(pool.WithMaxSize(1))
+
+	require.NoError(t, p.Add(fakeTx{id: []byte{1}}))
+
+	err := p.Add(fakeTx{id: []byte{2}})
+	require.EqualError(t, err, "store failed: limit of 1 reached: pool is full")
+	require.True(t, xerrors.Is(err, pool.ErrPoolFull))
+
+	p = NewPool(pool.WithMaxSize(1), pool.WithFullPolicy(pool.BlockWhenFull, 10*time.Millisecond))
+
+	require.NoError(t, p.Add(fakeTx{id: []byte{1}}))
+
+	err = p.Add(fakeTx{id: []byte{2}})
+	require.EqualError(t, err, "store failed: no space after 10ms: pool is full")
+	require.True(t, xerrors.Is(err, pool.ErrPoolFull))
+}
+
 func TestPool_Remove(t *testing.T) {
 	p := NewPool()
 
@@ -113,6 +134,11 @@ func (tx fakeTx) GetID() []byte {
 	return tx.id
 }
 
+func (tx fakeTx) Fingerprint(w io.Writer) error {
+	_, err := w.Write(tx.id)
+	return err
+}
+
 type badGatherer struct {
 	pool.Gatherer
 }
@@ -48,7 +48,8 @@ type Pool interface {
 	Remove(txn.Transaction) error
 
 	// Gather is a blocking function to gather transactions from the pool. The
-	// configuration allows one to specify criterion before returning.
+	// configuration allows one to specify criterion before returning. The
+	// transactions of a same identity are returned by ascending nonce.
 	Gather(context.Context, Config) []txn.Transaction
 
 	// Stats gets the transactions statistics
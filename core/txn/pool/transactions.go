@@ -1,7 +1,6 @@
 package pool
 
 import (
-	"bytes"
 	"sort"
 
 	"go.dedis.ch/dela/core/txn"
@@ -46,8 +45,10 @@ func (txs transactions) Add(other transactionStats) transactions {
 // Remove removes the transaction from the list if it exists, while preserving
 // the order of the transactions.
 func (txs transactions) Remove(other txn.Transaction) transactions {
+	id := makeID(other)
+
 	for i, tx := range txs {
-		if bytes.Equal(tx.GetID(), other.GetID()) {
+		if makeID(tx) == id {
 			txs = append(txs[:i], txs[i+1:]...)
 			break
 		}
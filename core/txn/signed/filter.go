@@ -0,0 +1,230 @@
+// This file contains the implementation of a pool filter for signed
+// transactions.
+//
+
+package signed
+
+import (
+	"go.dedis.ch/dela/core/txn"
+	"go.dedis.ch/dela/core/validation"
+	"go.dedis.ch/dela/crypto"
+	"golang.org/x/xerrors"
+)
+
+// SignatureFilter is a pool filter that rejects the signed transactions that
+// are missing a signature, or whose signature does not match the identity.
+// Transactions of a different kind are ignored.
+//
+// - implements pool.Filter
+type SignatureFilter struct{}
+
+// NewSignatureFilter creates a new signature filter.
+func NewSignatureFilter() SignatureFilter {
+	return SignatureFilter{}
+}
+
+// Accept implements pool.Filter. It returns an error if the transaction is not
+// correctly signed by its identity.
+func (SignatureFilter) Accept(tx txn.Transaction, leeway validation.Leeway) error {
+	stx, ok := tx.(*Transaction)
+	if !ok {
+		return nil
+	}
+
+	if stx.sig == nil {
+		return xerrors.New("missing signature")
+	}
+
+	err := stx.pubkey.Verify(stx.hash, stx.sig)
+	if err != nil {
+		return xerrors.Errorf("invalid signature: %v", err)
+	}
+
+	return nil
+}
+
+// SizeFilter is a pool filter that rejects the signed transactions whose
+// payload, that is the total size of the arguments, exceeds a limit.
+// Transactions of a different kind are ignored.
+//
+// - implements pool.Filter
+type SizeFilter struct {
+	maxSize int
+}
+
+// NewSizeFilter creates a new size filter that accepts payloads up to the given
+// size in bytes. A size of zero or less disables the limit, as for the option
+// of the transaction factory.
+func NewSizeFilter(size int) SizeFilter {
+	return SizeFilter{
+		maxSize: size,
+	}
+}
+
+// Accept implements pool.Filter. It returns an error if the payload of the
+// transaction is bigger than the limit.
+func (f SizeFilter) Accept(tx txn.Transaction, leeway validation.Leeway) error {
+	stx, ok := tx.(*Transaction)
+	if !ok || f.maxSize <= 0 {
+		return nil
+	}
+
+	size := 0
+	for key, value := range stx.args {
+		size += len(key) + len(value)
+	}
+
+	if size > f.maxSize {
+		return xerrors.Errorf("payload of %d bytes exceeds the limit of %d bytes",
+			size, f.maxSize)
+	}
+
+	return nil
+}
+
+// ArgsFilter is a pool filter that rejects the signed transactions with more
+// arguments than a limit. Transactions of a different kind are ignored.
+//
+// - implements pool.Filter
+type ArgsFilter struct {
+	maxArgs int
+}
+
+// NewArgsFilter creates a new filter that accepts up to the given number of
+// arguments. A number of zero or less disables the limit.
+func NewArgsFilter(num int) ArgsFilter {
+	return ArgsFilter{
+		maxArgs: num,
+	}
+}
+
+// Accept implements pool.Filter. It returns an error if the transaction has more
+// arguments than the limit.
+func (f ArgsFilter) Accept(tx txn.Transaction, leeway validation.Leeway) error {
+	stx, ok := tx.(*Transaction)
+	if !ok || f.maxArgs <= 0 {
+		return nil
+	}
+
+	if len(stx.args) > f.maxArgs {
+		return xerrors.Errorf("transaction with %d arguments exceeds the limit of %d",
+			len(stx.args), f.maxArgs)
+	}
+
+	return nil
+}
+
+// ConditionFilter is a pool filter that rejects the signed transactions with a
+// condition on a key longer than a limit. Transactions of a different kind are
+// ignored.
+//
+// - implements pool.Filter
+type ConditionFilter struct {
+	maxKey int
+}
+
+// NewConditionFilter creates a new filter that accepts the condition keys up to
+// the given size in bytes. A size of zero or less disables the limit.
+func NewConditionFilter(size int) ConditionFilter {
+	return ConditionFilter{
+		maxKey: size,
+	}
+}
+
+// Accept implements pool.Filter. It returns an error if the key of a condition
+// of the transaction is longer than the limit.
+func (f ConditionFilter) Accept(tx txn.Transaction, leeway validation.Leeway) error {
+	stx, ok := tx.(*Transaction)
+	if !ok || f.maxKey <= 0 {
+		return nil
+	}
+
+	for _, cond := range stx.conditions {
+		if len(cond.Key) > f.maxKey {
+			return xerrors.Errorf("condition key of %d bytes exceeds the limit of %d bytes",
+				len(cond.Key), f.maxKey)
+		}
+	}
+
+	return nil
+}
+
+// NoncePolicy defines how the nonce of zero is handled at the admission of a
+// transaction.
+type NoncePolicy int
+
+const (
+	// AllowZeroNonce is the policy that accepts a nonce of zero.
+	AllowZeroNonce NoncePolicy = iota
+
+	// RejectZeroNonce is the policy that rejects a nonce of zero, so that the
+	// clients must start at one. It catches the transactions created with an
+	// uninitialized nonce. The validation must expect the same first nonce,
+	// like the simple validation with simple.WithFirstNonce(1), otherwise the
+	// first transaction of an identity is never accepted.
+	RejectZeroNonce
+)
+
+// NonceFilter is a pool filter that applies a policy on the nonce of the signed
+// transactions. Transactions of a different kind are ignored.
+//
+// - implements pool.Filter
+type NonceFilter struct {
+	policy NoncePolicy
+}
+
+// NewNonceFilter creates a new nonce filter that applies the given policy.
+func NewNonceFilter(policy NoncePolicy) NonceFilter {
+	return NonceFilter{
+		policy: policy,
+	}
+}
+
+// Accept implements pool.Filter. It returns an error if the nonce of the
+// transaction is not allowed by the policy.
+func (f NonceFilter) Accept(tx txn.Transaction, leeway validation.Leeway) error {
+	stx, ok := tx.(*Transaction)
+	if !ok {
+		return nil
+	}
+
+	if f.policy == RejectZeroNonce && stx.nonce == 0 {
+		return xerrors.New("nonce zero is not allowed")
+	}
+
+	return nil
+}
+
+// IdentityFilter is a pool filter that rejects the signed transactions whose
+// identity is not in an allow-list, for a permissioned deployment.
+// Transactions of a different kind are ignored.
+//
+// - implements pool.Filter
+type IdentityFilter struct {
+	allowed []crypto.PublicKey
+}
+
+// NewIdentityFilter creates a new filter that accepts the transactions of the
+// given public keys. An empty list accepts any identity.
+func NewIdentityFilter(allowed ...crypto.PublicKey) IdentityFilter {
+	return IdentityFilter{
+		allowed: allowed,
+	}
+}
+
+// Accept implements pool.Filter. It returns an error if the identity of the
+// transaction is not in the allow-list.
+func (f IdentityFilter) Accept(tx txn.Transaction, leeway validation.Leeway) error {
+	stx, ok := tx.(*Transaction)
+	if !ok || len(f.allowed) == 0 {
+		return nil
+	}
+
+	for _, pubkey := range f.allowed {
+		if pubkey.Equal(stx.pubkey) {
+			return nil
+		}
+	}
+
+	return xerrors.Errorf("identity %v is not allowed", stx.pubkey)
+}
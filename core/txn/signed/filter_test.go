@@ -0,0 +1,183 @@
+package signed
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/txn"
+	"go.dedis.ch/dela/core/validation"
+	"go.dedis.ch/dela/crypto/bls"
+	"go.dedis.ch/dela/internal/testing/fake"
+)
+
+func TestSignatureFilter_Accept(t *testing.T) {
+	filter := NewSignatureFilter()
+
+	signer := bls.NewSigner()
+
+	tx, err := NewTransaction(0, signer.GetPublicKey())
+	require.NoError(t, err)
+
+	err = filter.Accept(tx, validation.Leeway{})
+	require.EqualError(t, err, "missing signature")
+
+	require.NoError(t, tx.Sign(signer))
+
+	err = filter.Accept(tx, validation.Leeway{})
+	require.NoError(t, err)
+
+	// Signature of a different transaction.
+	other, err := NewTransaction(1, signer.GetPublicKey())
+	require.NoError(t, err)
+	require.NoError(t, other.Sign(signer))
+
+	tx.sig = other.GetSignature()
+	err = filter.Accept(tx, validation.Leeway{})
+	require.EqualError(t, err, "invalid signature: bls verify failed: bls: invalid signature")
+
+	err = filter.Accept(fakeTx{}, validation.Leeway{})
+	require.NoError(t, err)
+
+	tx.pubkey = fake.NewBadPublicKey()
+	err = filter.Accept(tx, validation.Leeway{})
+	require.EqualError(t, err, fake.Err("invalid signature"))
+}
+
+func TestSizeFilter_Accept(t *testing.T) {
+	filter := NewSizeFilter(10)
+
+	tx, err := NewTransaction(0, fake.PublicKey{}, WithArg("A", make([]byte, 9)))
+	require.NoError(t, err)
+
+	err = filter.Accept(tx, validation.Leeway{})
+	require.NoError(t, err)
+
+	tx, err = NewTransaction(0, fake.PublicKey{}, WithArg("A", make([]byte, 10)))
+	require.NoError(t, err)
+
+	err = filter.Accept(tx, validation.Leeway{})
+	require.EqualError(t, err, "payload of 11 bytes exceeds the limit of 10 bytes")
+
+	err = filter.Accept(fakeTx{}, validation.Leeway{})
+	require.NoError(t, err)
+
+	filter = NewSizeFilter(0)
+
+	tx, err = NewTransaction(0, fake.PublicKey{}, WithArg("A", make([]byte, DefaultMaxSize)))
+	require.NoError(t, err)
+
+	err = filter.Accept(tx, validation.Leeway{})
+	require.NoError(t, err)
+}
+
+func TestArgsFilter_Accept(t *testing.T) {
+	filter := NewArgsFilter(2)
+
+	tx, err := NewTransaction(0, fake.PublicKey{}, WithArg("A", nil), WithArg("B", nil))
+	require.NoError(t, err)
+
+	err = filter.Accept(tx, validation.Leeway{})
+	require.NoError(t, err)
+
+	tx, err = NewTransaction(0, fake.PublicKey{}, WithArg("A", nil), WithArg("B", nil), WithArg("C", nil))
+	require.NoError(t, err)
+
+	err = filter.Accept(tx, validation.Leeway{})
+	require.EqualError(t, err, "transaction with 3 arguments exceeds the limit of 2")
+
+	err = filter.Accept(fakeTx{}, validation.Leeway{})
+	require.NoError(t, err)
+
+	filter = NewArgsFilter(0)
+
+	err = filter.Accept(tx, validation.Leeway{})
+	require.NoError(t, err)
+}
+
+func TestConditionFilter_Accept(t *testing.T) {
+	filter := NewConditionFilter(DefaultMaxConditionKey)
+
+	tx, err := NewTransaction(0, fake.PublicKey{}, WithCondition(make([]byte, 32), nil))
+	require.NoError(t, err)
+
+	err = filter.Accept(tx, validation.Leeway{})
+	require.NoError(t, err)
+
+	tx, err = NewTransaction(0, fake.PublicKey{},
+		WithCondition([]byte("A"), nil), WithCondition(make([]byte, 33), nil))
+	require.NoError(t, err)
+
+	err = filter.Accept(tx, validation.Leeway{})
+	require.EqualError(t, err, "condition key of 33 bytes exceeds the limit of 32 bytes")
+
+	err = filter.Accept(fakeTx{}, validation.Leeway{})
+	require.NoError(t, err)
+
+	filter = NewConditionFilter(0)
+
+	err = filter.Accept(tx, validation.Leeway{})
+	require.NoError(t, err)
+}
+
+func TestNonceFilter_Accept(t *testing.T) {
+	zero, err := NewTransaction(0, fake.PublicKey{})
+	require.NoError(t, err)
+
+	one, err := NewTransaction(1, fake.PublicKey{})
+	require.NoError(t, err)
+
+	filter := NewNonceFilter(AllowZeroNonce)
+
+	err = filter.Accept(zero, validation.Leeway{})
+	require.NoError(t, err)
+
+	err = filter.Accept(one, validation.Leeway{})
+	require.NoError(t, err)
+
+	filter = NewNonceFilter(RejectZeroNonce)
+
+	err = filter.Accept(zero, validation.Leeway{})
+	require.EqualError(t, err, "nonce zero is not allowed")
+
+	err = filter.Accept(one, validation.Leeway{})
+	require.NoError(t, err)
+
+	err = filter.Accept(fakeTx{}, validation.Leeway{})
+	require.NoError(t, err)
+}
+
+func TestIdentityFilter_Accept(t *testing.T) {
+	allowed := bls.NewSigner().GetPublicKey()
+	other := bls.NewSigner().GetPublicKey()
+
+	tx, err := NewTransaction(1, allowed)
+	require.NoError(t, err)
+
+	txOther, err := NewTransaction(1, other)
+	require.NoError(t, err)
+
+	filter := NewIdentityFilter(allowed)
+
+	err = filter.Accept(tx, validation.Leeway{})
+	require.NoError(t, err)
+
+	err = filter.Accept(txOther, validation.Leeway{})
+	require.EqualError(t, err, fmt.Sprintf("identity %v is not allowed", other))
+
+	err = filter.Accept(fakeTx{}, validation.Leeway{})
+	require.NoError(t, err)
+
+	// An empty allow-list accepts any identity.
+	filter = NewIdentityFilter()
+
+	err = filter.Accept(txOther, validation.Leeway{})
+	require.NoError(t, err)
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+type fakeTx struct {
+	txn.Transaction
+}
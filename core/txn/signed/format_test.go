@@ -0,0 +1,44 @@
+package signed_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/txn/signed"
+	"go.dedis.ch/dela/crypto/bls"
+	internal "go.dedis.ch/dela/internal/testing"
+	"go.dedis.ch/dela/serde/json"
+)
+
+func TestTransaction_Formats(t *testing.T) {
+	tx := makeTx(t, 3)
+
+	internal.RequireRoundTrip(t, tx, signed.NewTransactionFactory(), json.NewContext())
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+func makeTx(t *testing.T, nonce uint64) *signed.Transaction {
+	signer := bls.NewSigner()
+
+	// The key is decoded from its binary form so that the point has the same
+	// representation as once deserialized.
+	data, err := signer.GetPublicKey().MarshalBinary()
+	require.NoError(t, err)
+
+	pubkey, err := bls.NewPublicKey(data)
+	require.NoError(t, err)
+
+	opts := []signed.TransactionOption{
+		signed.WithArg("A", []byte{1}),
+		signed.WithFee(5),
+		signed.WithCondition([]byte("K"), []byte{2}),
+	}
+
+	tx, err := signed.NewTransaction(nonce, pubkey, opts...)
+	require.NoError(t, err)
+	require.NoError(t, tx.Sign(signer))
+
+	return tx
+}
@@ -14,12 +14,35 @@ func init() {
 	signed.RegisterTransactionFormat(serde.FormatJSON, txFormat{})
 }
 
-// TransactionJSON is the JSON message of a transaction.
+const (
+	// txVersion1 is the initial format of a transaction. A transaction without
+	// a version is decoded in this format.
+	txVersion1 = 1
+
+	// txVersion2 adds the fee of the transaction.
+	txVersion2 = 2
+
+	// txVersion3 adds the conditions on the state.
+	txVersion3 = 3
+)
+
+// ConditionJSON is the JSON message of a condition of a transaction.
+type ConditionJSON struct {
+	Key   []byte
+	Value []byte
+}
+
+// TransactionJSON is the JSON message of a transaction. The version defines the
+// format of the message so that the decoder can dispatch accordingly. The
+// arguments are encoded in the order of the keys.
 type TransactionJSON struct {
-	Nonce     uint64
-	Args      map[string][]byte
-	PublicKey json.RawMessage
-	Signature json.RawMessage
+	Version    uint16
+	Nonce      uint64
+	Fee        uint64          `json:",omitempty"`
+	Conditions []ConditionJSON `json:",omitempty"`
+	Args       serde.CanonicalMap
+	PublicKey  json.RawMessage
+	Signature  json.RawMessage
 }
 
 // TxFormat is the JSON format engine for transactions.
@@ -41,7 +64,7 @@ func (fmt txFormat) Encode(ctx serde.Context, msg serde.Message) ([]byte, error)
 		return nil, xerrors.New("signature is missing")
 	}
 
-	args := map[string][]byte{}
+	args := serde.CanonicalMap{}
 	for _, arg := range tx.GetArgs() {
 		args[arg] = tx.GetArg(arg)
 	}
@@ -57,12 +80,30 @@ func (fmt txFormat) Encode(ctx serde.Context, msg serde.Message) ([]byte, error)
 	}
 
 	m := TransactionJSON{
+		Version:   txVersion1,
 		Nonce:     tx.GetNonce(),
 		Args:      args,
 		PublicKey: pubkey,
 		Signature: sig,
 	}
 
+	// The latest version is only used when necessary so that a transaction
+	// without a fee can still be decoded by older nodes.
+	if tx.GetFee() > 0 {
+		m.Version = txVersion2
+		m.Fee = tx.GetFee()
+	}
+
+	conditions := tx.GetConditions()
+	if len(conditions) > 0 {
+		m.Version = txVersion3
+		m.Conditions = make([]ConditionJSON, len(conditions))
+
+		for i, cond := range conditions {
+			m.Conditions[i] = ConditionJSON{Key: cond.Key, Value: cond.Value}
+		}
+	}
+
 	data, err := ctx.Marshal(m)
 	if err != nil {
 		return nil, xerrors.Errorf("failed to marshal: %v", err)
@@ -90,17 +131,44 @@ func (fmt txFormat) Decode(ctx serde.Context, data []byte) (serde.Message, error
 		return nil, xerrors.Errorf("signature: %v", err)
 	}
 
-	args := make([]signed.TransactionOption, 0, len(m.Args)+2)
+	args := make([]signed.TransactionOption, 0, len(m.Args)+4)
 	for key, value := range m.Args {
 		args = append(args, signed.WithArg(key, value))
 	}
 
+	if m.Version < txVersion3 && len(m.Conditions) > 0 {
+		return nil, xerrors.Errorf("conditions are not supported in version %d", m.Version)
+	}
+
+	switch m.Version {
+	case 0, txVersion1:
+		if m.Fee > 0 {
+			return nil, xerrors.Errorf("fee is not supported in version %d", m.Version)
+		}
+	case txVersion2:
+		args = append(args, signed.WithFee(m.Fee))
+	case txVersion3:
+		args = append(args, signed.WithFee(m.Fee))
+
+		for _, cond := range m.Conditions {
+			args = append(args, signed.WithCondition(cond.Key, cond.Value))
+		}
+	default:
+		return nil, serde.ErrUnsupportedVersion{Version: uint64(m.Version)}
+	}
+
 	args = append(args, signed.WithSignature(sig))
 
 	if fmt.hashFactory != nil {
 		args = append(args, signed.WithHashFactory(fmt.hashFactory))
 	}
 
+	// The identity binding is the one of the transaction factory, if any.
+	fac, ok := ctx.GetFactory(signed.BindingFac{}).(signed.TransactionFactory)
+	if ok && fac.GetBinding() != nil {
+		args = append(args, signed.WithIdentityBinding(fac.GetBinding()))
+	}
+
 	tx, err := signed.NewTransaction(m.Nonce, pubkey, args...)
 	if err != nil {
 		return nil, xerrors.Errorf("failed to create tx: %v", err)
@@ -7,8 +7,12 @@ import (
 	"go.dedis.ch/dela/core/txn"
 	"go.dedis.ch/dela/core/txn/signed"
 	"go.dedis.ch/dela/crypto"
+	"go.dedis.ch/dela/crypto/bls"
+	_ "go.dedis.ch/dela/crypto/bls/json"
+	_ "go.dedis.ch/dela/crypto/common/json"
 	"go.dedis.ch/dela/internal/testing/fake"
 	"go.dedis.ch/dela/serde"
+	"golang.org/x/xerrors"
 )
 
 func TestTxFormat_Encode(t *testing.T) {
@@ -20,7 +24,20 @@ func TestTxFormat_Encode(t *testing.T) {
 
 	data, err := format.Encode(ctx, tx)
 	require.NoError(t, err)
-	require.Equal(t, `{"Nonce":1,"Args":{"A":"AQ=="},"PublicKey":{},"Signature":{}}`, string(data))
+	require.Equal(t, `{"Version":1,"Nonce":1,"Args":{"A":"AQ=="},"PublicKey":{},"Signature":{}}`, string(data))
+
+	tx = makeTx(t, 1, fake.PublicKey{}, signed.WithFee(5))
+
+	data, err = format.Encode(ctx, tx)
+	require.NoError(t, err)
+	require.Equal(t, `{"Version":2,"Nonce":1,"Fee":5,"Args":{},"PublicKey":{},"Signature":{}}`, string(data))
+
+	tx = makeTx(t, 1, fake.PublicKey{}, signed.WithCondition([]byte("K"), []byte{1}))
+
+	data, err = format.Encode(ctx, tx)
+	require.NoError(t, err)
+	require.Equal(t, `{"Version":3,"Nonce":1,"Conditions":[{"Key":"Sw==","Value":"AQ=="}],`+
+		`"Args":{},"PublicKey":{},"Signature":{}}`, string(data))
 
 	_, err = format.Encode(ctx, fake.Message{})
 	require.EqualError(t, err, "unsupported message of type 'fake.Message'")
@@ -40,6 +57,34 @@ func TestTxFormat_Encode(t *testing.T) {
 	require.EqualError(t, err, fake.Err("failed to encode public key"))
 }
 
+func TestTxFormat_Encode_Deterministic(t *testing.T) {
+	format := txFormat{}
+
+	opts := make([]signed.TransactionOption, 0, 26)
+	for c := 'z'; c >= 'a'; c-- {
+		opts = append(opts, signed.WithArg(string(c), []byte{byte(c)}))
+	}
+
+	tx := makeTx(t, 1, fake.PublicKey{}, opts...)
+
+	var warnings []string
+	ctx := serde.WithMapCheck(fake.NewContext(), func(path string) {
+		warnings = append(warnings, path)
+	})
+
+	expected, err := format.Encode(ctx, tx)
+	require.NoError(t, err)
+	require.Regexp(t, `"Args":{"a":"YQ==","b":"Yg==",.*"z":"eg=="}`, string(expected))
+
+	for i := 0; i < 20; i++ {
+		data, err := format.Encode(ctx, tx)
+		require.NoError(t, err)
+		require.Equal(t, expected, data)
+	}
+
+	require.Empty(t, warnings)
+}
+
 func TestTxFormat_Decode(t *testing.T) {
 	format := txFormat{}
 
@@ -77,6 +122,124 @@ func TestTxFormat_Decode(t *testing.T) {
 	require.EqualError(t, err, fake.Err("signature: malformed"))
 }
 
+func TestTxFormat_Version_Decode(t *testing.T) {
+	format := txFormat{}
+
+	ctx := fake.NewContext()
+	ctx = serde.WithFactory(ctx, signed.PublicKeyFac{}, fake.PublicKeyFactory{})
+	ctx = serde.WithFactory(ctx, signed.SignatureFac{}, fake.SignatureFactory{})
+
+	msg, err := format.Decode(ctx, []byte(`{"Version":1,"Nonce":2}`))
+	require.NoError(t, err)
+	require.Equal(t, makeTx(t, 2, fake.PublicKey{}), msg)
+	require.Equal(t, uint64(0), msg.(*signed.Transaction).GetFee())
+
+	msg, err = format.Decode(ctx, []byte(`{"Version":2,"Nonce":2,"Fee":5}`))
+	require.NoError(t, err)
+	require.Equal(t, makeTx(t, 2, fake.PublicKey{}, signed.WithFee(5)), msg)
+	require.Equal(t, uint64(5), msg.(*signed.Transaction).GetFee())
+
+	_, err = format.Decode(ctx, []byte(`{"Version":1,"Nonce":2,"Fee":5}`))
+	require.EqualError(t, err, "fee is not supported in version 1")
+
+	msg, err = format.Decode(ctx, []byte(`{"Version":3,"Nonce":2,"Fee":5,`+
+		`"Conditions":[{"Key":"Sw==","Value":"AQ=="},{"Key":"TA=="}]}`))
+	require.NoError(t, err)
+	require.Equal(t, makeTx(t, 2, fake.PublicKey{}, signed.WithFee(5),
+		signed.WithCondition([]byte("K"), []byte{1}),
+		signed.WithCondition([]byte("L"), nil)), msg)
+
+	// The order of the conditions is preserved as it is part of the
+	// identifier.
+	reordered, err := format.Decode(ctx, []byte(`{"Version":3,"Nonce":2,"Fee":5,`+
+		`"Conditions":[{"Key":"TA=="},{"Key":"Sw==","Value":"AQ=="}]}`))
+	require.NoError(t, err)
+	require.Equal(t, []byte("L"), reordered.(*signed.Transaction).GetConditions()[0].Key)
+	require.NotEqual(t, msg.(*signed.Transaction).GetID(), reordered.(*signed.Transaction).GetID())
+
+	_, err = format.Decode(ctx, []byte(`{"Version":2,"Nonce":2,"Conditions":[{"Key":"Sw=="}]}`))
+	require.EqualError(t, err, "conditions are not supported in version 2")
+
+	_, err = format.Decode(ctx, []byte(`{"Version":4}`))
+	require.EqualError(t, err, "unsupported version 4")
+
+	var versionErr serde.ErrUnsupportedVersion
+	require.True(t, xerrors.As(err, &versionErr))
+	require.Equal(t, uint64(4), versionErr.Version)
+}
+
+func TestTxFormat_IdentityBinding_Decode(t *testing.T) {
+	binding := signed.NewAccountBinding(crypto.NewSha256Factory())
+	format := txFormat{}
+
+	ctx := fake.NewContext()
+	ctx = serde.WithFactory(ctx, signed.PublicKeyFac{}, fake.PublicKeyFactory{})
+	ctx = serde.WithFactory(ctx, signed.SignatureFac{}, fake.SignatureFactory{})
+	ctx = serde.WithFactory(ctx, signed.BindingFac{}, signed.NewTransactionFactory(signed.WithBinding(binding)))
+
+	msg, err := format.Decode(ctx, []byte(`{"Nonce":2}`))
+	require.NoError(t, err)
+
+	expected := makeTx(t, 2, fake.PublicKey{}, signed.WithIdentityBinding(binding))
+	require.Equal(t, expected.GetID(), msg.(txn.Transaction).GetID())
+
+	other := makeTx(t, 2, fake.PublicKey{})
+	require.NotEqual(t, other.GetID(), msg.(txn.Transaction).GetID())
+}
+
+func TestTransactionFactory_Binding(t *testing.T) {
+	binding := signed.NewAccountBinding(crypto.NewSha256Factory())
+	signer := bls.NewSigner()
+
+	tx, err := signed.NewTransaction(0, signer.GetPublicKey(), signed.WithIdentityBinding(binding))
+	require.NoError(t, err)
+	require.NoError(t, tx.Sign(signer))
+
+	ctx := fake.NewContextWithFormat(serde.FormatJSON)
+
+	data, err := tx.Serialize(ctx)
+	require.NoError(t, err)
+
+	fac := signed.NewTransactionFactory(signed.WithBinding(binding))
+
+	decoded, err := fac.TransactionOf(ctx, data)
+	require.NoError(t, err)
+	require.Equal(t, tx.GetID(), decoded.GetID())
+
+	// The signature doesn't verify with the default binding.
+	_, err = signed.NewTransactionFactory().TransactionOf(ctx, data)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid signature: ")
+}
+
+func TestTransactionID_ClientServer(t *testing.T) {
+	format := txFormat{}
+
+	mgr := signed.NewManager(fake.NewSignerWithPublicKey(fake.PublicKey{}), nil)
+
+	clientTx, err := mgr.Make(txn.Arg{Key: "A", Value: []byte{1}}, txn.Arg{Key: "B", Value: []byte{2}})
+	require.NoError(t, err)
+
+	clientID, err := txn.TransactionID(clientTx)
+	require.NoError(t, err)
+	require.Equal(t, clientTx.GetID(), clientID)
+
+	ctx := fake.NewContext()
+	ctx = serde.WithFactory(ctx, signed.PublicKeyFac{}, fake.PublicKeyFactory{})
+	ctx = serde.WithFactory(ctx, signed.SignatureFac{}, fake.SignatureFactory{})
+
+	data, err := format.Encode(ctx, clientTx)
+	require.NoError(t, err)
+
+	serverTx, err := format.Decode(ctx, data)
+	require.NoError(t, err)
+
+	serverID, err := txn.TransactionID(serverTx.(txn.Transaction))
+	require.NoError(t, err)
+	require.Equal(t, clientID, serverID)
+	require.Equal(t, serverTx.(txn.Transaction).GetID(), serverID)
+}
+
 // -----------------------------------------------------------------------------
 // Utility functions
 
@@ -34,11 +34,45 @@ func RegisterTransactionFormat(f serde.Format, e serde.FormatEngine) {
 //
 // - implements txn.Transaction
 type Transaction struct {
-	nonce  uint64
-	args   map[string][]byte
-	pubkey crypto.PublicKey
-	sig    crypto.Signature
-	hash   []byte
+	nonce      uint64
+	fee        uint64
+	args       map[string][]byte
+	conditions []txn.Condition
+	pubkey     crypto.PublicKey
+	sig        crypto.Signature
+	hash       []byte
+	binding    IdentityBinding
+}
+
+// IdentityBinding is the function that returns the representation of the
+// identity written in the fingerprint of a transaction, so that the signature
+// binds the transaction to the identity.
+type IdentityBinding func(pubkey crypto.PublicKey) ([]byte, error)
+
+// KeyBinding is the default identity binding. It binds the transaction to the
+// public key itself.
+func KeyBinding(pubkey crypto.PublicKey) ([]byte, error) {
+	return pubkey.MarshalBinary()
+}
+
+// NewAccountBinding returns an identity binding for identities that are
+// accounts derived from the public key. It binds the transaction to the digest
+// of the public key, computed with the hash factory.
+func NewAccountBinding(fac crypto.HashFactory) IdentityBinding {
+	return func(pubkey crypto.PublicKey) ([]byte, error) {
+		data, err := KeyBinding(pubkey)
+		if err != nil {
+			return nil, err
+		}
+
+		h := fac.New()
+		_, err = h.Write(data)
+		if err != nil {
+			return nil, xerrors.Errorf("couldn't hash public key: %v", err)
+		}
+
+		return h.Sum(nil), nil
+	}
 }
 
 type template struct {
@@ -57,6 +91,25 @@ func WithArg(key string, value []byte) TransactionOption {
 	}
 }
 
+// WithFee is an option to set the fee that the identity is willing to pay for
+// the transaction.
+func WithFee(fee uint64) TransactionOption {
+	return func(tmpl *template) {
+		tmpl.fee = fee
+	}
+}
+
+// WithCondition is an option to apply the transaction only if the key has the
+// given value in the state. An empty value expects the key to be unset. The
+// conditions are kept in the order of the options, which is part of the
+// identifier, so that the same conditions in a different order produce a
+// different transaction.
+func WithCondition(key, value []byte) TransactionOption {
+	return func(tmpl *template) {
+		tmpl.conditions = append(tmpl.conditions, txn.Condition{Key: key, Value: value})
+	}
+}
+
 // WithSignature is an option to set a valid signature. The signature will be
 // verified against the identity.
 func WithSignature(sig crypto.Signature) TransactionOption {
@@ -65,6 +118,14 @@ func WithSignature(sig crypto.Signature) TransactionOption {
 	}
 }
 
+// WithIdentityBinding is an option to set how the identity is bound to the
+// transaction. The default binding uses the public key.
+func WithIdentityBinding(binding IdentityBinding) TransactionOption {
+	return func(tmpl *template) {
+		tmpl.binding = binding
+	}
+}
+
 // WithHashFactory is an option to set a different hash factory when creating a
 // transaction.
 func WithHashFactory(f crypto.HashFactory) TransactionOption {
@@ -106,7 +167,9 @@ func NewTransaction(nonce uint64, pk crypto.PublicKey, opts ...TransactionOption
 	return &tmpl.Transaction, nil
 }
 
-// GetID implements txn.Transaction. It returns the ID of the transaction.
+// GetID implements txn.Transaction. It returns the ID of the transaction. With
+// the default hash factory, it is equal to the canonical ID computed by
+// txn.TransactionID.
 func (t *Transaction) GetID() []byte {
 	return t.hash
 }
@@ -116,6 +179,17 @@ func (t *Transaction) GetNonce() uint64 {
 	return t.nonce
 }
 
+// GetFee returns the fee of the transaction, or zero if it has none.
+func (t *Transaction) GetFee() uint64 {
+	return t.fee
+}
+
+// GetConditions implements txn.ConditionalTransaction. It returns the
+// conditions of the transaction, in the order they have been set.
+func (t *Transaction) GetConditions() []txn.Condition {
+	return append([]txn.Condition{}, t.conditions...)
+}
+
 // GetIdentity implements txn.Transaction. It returns nil.
 func (t *Transaction) GetIdentity() access.Identity {
 	return t.pubkey
@@ -188,7 +262,12 @@ func (t *Transaction) Fingerprint(w io.Writer) error {
 		}
 	}
 
-	buffer, err = t.pubkey.MarshalBinary()
+	binding := t.binding
+	if binding == nil {
+		binding = KeyBinding
+	}
+
+	buffer, err = binding(t.pubkey)
 	if err != nil {
 		return xerrors.Errorf("failed to marshal public key: %v", err)
 	}
@@ -198,6 +277,32 @@ func (t *Transaction) Fingerprint(w io.Writer) error {
 		return xerrors.Errorf("couldn't write public key: %v", err)
 	}
 
+	// The fee and the number of conditions are always written so that the
+	// fields that follow can't be mistaken for one another.
+	buffer = make([]byte, 12)
+	binary.LittleEndian.PutUint64(buffer, t.fee)
+	binary.LittleEndian.PutUint32(buffer[8:], uint32(len(t.conditions)))
+
+	_, err = w.Write(buffer)
+	if err != nil {
+		return xerrors.Errorf("couldn't write fee: %v", err)
+	}
+
+	// The conditions are written in the order they were submitted, unlike the
+	// arguments which are sorted as they come from a map. They are prefixed
+	// with their length as the keys and the values are arbitrary bytes.
+	for _, cond := range t.conditions {
+		for _, part := range [][]byte{cond.Key, cond.Value} {
+			buffer = make([]byte, 4, 4+len(part))
+			binary.LittleEndian.PutUint32(buffer, uint32(len(part)))
+
+			_, err = w.Write(append(buffer, part...))
+			if err != nil {
+				return xerrors.Errorf("couldn't write condition: %v", err)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -220,20 +325,74 @@ type PublicKeyFac struct{}
 // SignatureFac is the key of the signature factory.
 type SignatureFac struct{}
 
+// BindingFac is the key of the transaction factory that holds the identity
+// binding of the transactions being decoded.
+type BindingFac struct{}
+
+// DefaultMaxSize is the default upper bound in bytes of the payload of a
+// transaction.
+const DefaultMaxSize = 1 << 20
+
+// DefaultMaxArgs is the default upper bound of the number of arguments of a
+// transaction admitted in a pool. It is not enforced when decoding so that the
+// committed transactions can always be read.
+const DefaultMaxArgs = 256
+
+// DefaultMaxConditionKey is the default upper bound in bytes of the key of a
+// condition admitted in a pool. It matches the length of the keys of the hash
+// tree so that the conditions can always be read from the state.
+const DefaultMaxConditionKey = 32
+
 // TransactionFactory is a factory to deserialize transactions.
 //
 // - implements serde.Factory
 type TransactionFactory struct {
 	pubkeyFac common.PublicKeyFactory
 	sigFac    common.SignatureFactory
+	maxSize   int
+	binding   IdentityBinding
+}
+
+// FactoryOption is the type of options to create a transaction factory.
+type FactoryOption func(*TransactionFactory)
+
+// WithMaxSize is an option to set the maximum size in bytes of a serialized
+// transaction that the factory accepts to decode. A size of zero or less
+// disables the limit.
+func WithMaxSize(size int) FactoryOption {
+	return func(f *TransactionFactory) {
+		f.maxSize = size
+	}
+}
+
+// WithBinding is an option to set the identity binding of the transactions
+// that the factory decodes. It must match the binding used to sign them, and
+// the default binding uses the public key.
+func WithBinding(binding IdentityBinding) FactoryOption {
+	return func(f *TransactionFactory) {
+		f.binding = binding
+	}
 }
 
 // NewTransactionFactory returns a new factory.
-func NewTransactionFactory() TransactionFactory {
-	return TransactionFactory{
+func NewTransactionFactory(opts ...FactoryOption) TransactionFactory {
+	f := TransactionFactory{
 		pubkeyFac: common.NewPublicKeyFactory(),
 		sigFac:    common.NewSignatureFactory(),
+		maxSize:   DefaultMaxSize,
+	}
+
+	for _, opt := range opts {
+		opt(&f)
 	}
+
+	return f
+}
+
+// GetBinding returns the identity binding of the transactions, or nil for the
+// default one.
+func (f TransactionFactory) GetBinding() IdentityBinding {
+	return f.binding
 }
 
 // Deserialize implements serde.Factory. It populates the transaction from the
@@ -245,14 +404,22 @@ func (f TransactionFactory) Deserialize(ctx serde.Context, data []byte) (serde.M
 // TransactionOf implements txn.TransactionFactory. It populates the transaction
 // from the data if appropriate, otherwise it returns an error.
 func (f TransactionFactory) TransactionOf(ctx serde.Context, data []byte) (txn.Transaction, error) {
+	// The size is checked before decoding so that an oversized payload is
+	// rejected without being processed.
+	if f.maxSize > 0 && len(data) > f.maxSize {
+		return nil, xerrors.Errorf("transaction of %d bytes exceeds the limit of %d bytes",
+			len(data), f.maxSize)
+	}
+
 	format := txFormats.Get(ctx.GetFormat())
 
 	ctx = serde.WithFactory(ctx, PublicKeyFac{}, f.pubkeyFac)
 	ctx = serde.WithFactory(ctx, SignatureFac{}, f.sigFac)
+	ctx = serde.WithFactory(ctx, BindingFac{}, f)
 
 	msg, err := format.Decode(ctx, data)
 	if err != nil {
-		return nil, xerrors.Errorf("failed to decode: %v", err)
+		return nil, xerrors.Errorf("failed to decode: %w", err)
 	}
 
 	tx, ok := msg.(*Transaction)
@@ -2,6 +2,8 @@ package signed
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -11,12 +13,14 @@ import (
 	"go.dedis.ch/dela/crypto/bls"
 	"go.dedis.ch/dela/internal/testing/fake"
 	"go.dedis.ch/dela/serde"
+	"golang.org/x/xerrors"
 )
 
 func init() {
 	RegisterTransactionFormat(fake.GoodFormat, fake.Format{Msg: &Transaction{}})
 	RegisterTransactionFormat(fake.BadFormat, fake.NewBadFormat())
 	RegisterTransactionFormat(serde.Format("BAD_TYPE"), fake.Format{Msg: fake.Message{}})
+	RegisterTransactionFormat(serde.Format("FUTURE"), futureFormat{})
 }
 
 func TestTransaction_New(t *testing.T) {
@@ -55,6 +59,56 @@ func TestTransaction_GetNonce(t *testing.T) {
 	require.Equal(t, uint64(123), nonce)
 }
 
+func TestTransaction_GetFee(t *testing.T) {
+	tx, err := NewTransaction(0, fake.PublicKey{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), tx.GetFee())
+
+	tx, err = NewTransaction(0, fake.PublicKey{}, WithFee(42))
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), tx.GetFee())
+}
+
+func TestTransaction_GetConditions(t *testing.T) {
+	tx, err := NewTransaction(0, fake.PublicKey{})
+	require.NoError(t, err)
+	require.Empty(t, tx.GetConditions())
+
+	tx, err = NewTransaction(0, fake.PublicKey{},
+		WithCondition([]byte("A"), []byte{1}),
+		WithCondition([]byte("B"), nil))
+	require.NoError(t, err)
+	require.Equal(t, []txn.Condition{
+		{Key: []byte("A"), Value: []byte{1}},
+		{Key: []byte("B")},
+	}, tx.GetConditions())
+
+	// Conditions change the identifier of the transaction.
+	other, err := NewTransaction(0, fake.PublicKey{}, WithCondition([]byte("A"), []byte{2}))
+	require.NoError(t, err)
+	require.NotEqual(t, tx.GetID(), other.GetID())
+}
+
+func TestTransaction_ConditionOrder(t *testing.T) {
+	opts := []TransactionOption{
+		WithCondition([]byte("A"), []byte{1}),
+		WithCondition([]byte("B"), []byte{2}),
+		WithCondition([]byte("C"), nil),
+	}
+
+	tx, err := NewTransaction(0, fake.PublicKey{}, opts...)
+	require.NoError(t, err)
+
+	same, err := NewTransaction(0, fake.PublicKey{}, opts...)
+	require.NoError(t, err)
+	require.Equal(t, tx.GetID(), same.GetID())
+
+	reordered, err := NewTransaction(0, fake.PublicKey{}, opts[2], opts[0], opts[1])
+	require.NoError(t, err)
+	require.NotEqual(t, tx.GetID(), reordered.GetID())
+	require.Equal(t, []byte("C"), reordered.GetConditions()[0].Key)
+}
+
 func TestTransaction_GetIdentity(t *testing.T) {
 	tx, err := NewTransaction(1, fake.PublicKey{})
 	require.NoError(t, err)
@@ -114,7 +168,8 @@ func TestTransaction_Fingerprint(t *testing.T) {
 	buffer := new(bytes.Buffer)
 	err = tx.Fingerprint(buffer)
 	require.NoError(t, err)
-	require.Equal(t, "\x02\x00\x00\x00\x00\x00\x00\x00A\x01\x02\x03PK", buffer.String())
+	require.Equal(t, "\x02\x00\x00\x00\x00\x00\x00\x00A\x01\x02\x03PK"+
+		"\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00", buffer.String())
 
 	err = tx.Fingerprint(fake.NewBadHash())
 	require.EqualError(t, err, fake.Err("couldn't write nonce"))
@@ -128,6 +183,98 @@ func TestTransaction_Fingerprint(t *testing.T) {
 	tx.pubkey = fake.NewBadPublicKey()
 	err = tx.Fingerprint(buffer)
 	require.EqualError(t, err, fake.Err("failed to marshal public key"))
+
+	tx, err = NewTransaction(2, fake.PublicKey{}, WithFee(3))
+	require.NoError(t, err)
+
+	buffer.Reset()
+	err = tx.Fingerprint(buffer)
+	require.NoError(t, err)
+	require.Equal(t, "\x02\x00\x00\x00\x00\x00\x00\x00PK"+
+		"\x03\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00", buffer.String())
+
+	err = tx.Fingerprint(fake.NewBadHashWithDelay(2))
+	require.EqualError(t, err, fake.Err("couldn't write fee"))
+
+	tx, err = NewTransaction(2, fake.PublicKey{}, WithCondition([]byte("K"), []byte{1}))
+	require.NoError(t, err)
+
+	buffer.Reset()
+	err = tx.Fingerprint(buffer)
+	require.NoError(t, err)
+	require.Equal(t, "\x02\x00\x00\x00\x00\x00\x00\x00PK"+
+		"\x00\x00\x00\x00\x00\x00\x00\x00\x01\x00\x00\x00"+
+		"\x01\x00\x00\x00K\x01\x00\x00\x00\x01", buffer.String())
+
+	err = tx.Fingerprint(fake.NewBadHashWithDelay(3))
+	require.EqualError(t, err, fake.Err("couldn't write condition"))
+}
+
+func TestTransaction_FeeAndConditions_Fingerprint(t *testing.T) {
+	key, value := []byte("K"), []byte{1}
+
+	// The encoding of the condition (K, 1) is used as the value of a condition
+	// on an empty key.
+	enc := []byte{1, 0, 0, 0, 'K', 1, 0, 0, 0, 1}
+
+	withFee, err := NewTransaction(2, fake.PublicKey{},
+		WithFee(uint64(len(enc))<<32), WithCondition(key, value))
+	require.NoError(t, err)
+
+	withoutFee, err := NewTransaction(2, fake.PublicKey{}, WithCondition(nil, enc))
+	require.NoError(t, err)
+
+	require.NotEqual(t, withFee.GetID(), withoutFee.GetID())
+}
+
+func TestTransaction_IdentityBinding(t *testing.T) {
+	signer := bls.NewSigner()
+
+	keyTx, err := NewTransaction(2, signer.GetPublicKey())
+	require.NoError(t, err)
+
+	binding := NewAccountBinding(crypto.NewSha256Factory())
+
+	accountTx, err := NewTransaction(2, signer.GetPublicKey(), WithIdentityBinding(binding))
+	require.NoError(t, err)
+	require.NotEqual(t, keyTx.GetID(), accountTx.GetID())
+
+	pubkey, err := signer.GetPublicKey().MarshalBinary()
+	require.NoError(t, err)
+
+	account := sha256.Sum256(pubkey)
+
+	buffer := new(bytes.Buffer)
+	require.NoError(t, keyTx.Fingerprint(buffer))
+	require.Equal(t, pubkey, buffer.Bytes()[8:buffer.Len()-12])
+	buffer.Reset()
+	require.NoError(t, accountTx.Fingerprint(buffer))
+	require.Equal(t, account[:], buffer.Bytes()[8:buffer.Len()-12])
+
+	require.NoError(t, accountTx.Sign(signer))
+	require.NoError(t, signer.GetPublicKey().Verify(accountTx.GetID(), accountTx.GetSignature()))
+
+	_, err = NewTransaction(2, signer.GetPublicKey(),
+		WithIdentityBinding(binding), WithSignature(accountTx.GetSignature()))
+	require.NoError(t, err)
+
+	_, err = NewTransaction(2, signer.GetPublicKey(), WithSignature(accountTx.GetSignature()))
+	require.EqualError(t, err, "invalid signature: bls verify failed: bls: invalid signature")
+}
+
+func TestAccountBinding(t *testing.T) {
+	binding := NewAccountBinding(crypto.NewSha256Factory())
+
+	account, err := binding(fake.PublicKey{})
+	require.NoError(t, err)
+	require.Len(t, account, 32)
+
+	_, err = binding(fake.NewBadPublicKey())
+	require.EqualError(t, err, fake.GetError().Error())
+
+	binding = NewAccountBinding(fake.NewHashFactory(fake.NewBadHash()))
+	_, err = binding(fake.PublicKey{})
+	require.EqualError(t, err, fake.Err("couldn't hash public key"))
 }
 
 func TestTransaction_Serialize(t *testing.T) {
@@ -156,6 +303,61 @@ func TestTransactionFactory_Deserialize(t *testing.T) {
 	require.EqualError(t, err, "invalid transaction of type 'fake.Message'")
 }
 
+func TestTransactionFactory_UnsupportedVersion(t *testing.T) {
+	factory := NewTransactionFactory()
+
+	_, err := factory.Deserialize(fake.NewContextWithFormat(serde.Format("FUTURE")), nil)
+	require.EqualError(t, err, "failed to decode: unsupported version 9")
+
+	var versionErr serde.ErrUnsupportedVersion
+	require.True(t, xerrors.As(err, &versionErr))
+	require.Equal(t, uint64(9), versionErr.Version)
+}
+
+func TestTransactionFactory_MaxSize(t *testing.T) {
+	factory := NewTransactionFactory(WithMaxSize(4))
+
+	_, err := factory.Deserialize(fake.NewContext(), make([]byte, 4))
+	require.NoError(t, err)
+
+	_, err = factory.Deserialize(fake.NewContext(), make([]byte, 5))
+	require.EqualError(t, err, "transaction of 5 bytes exceeds the limit of 4 bytes")
+
+	factory = NewTransactionFactory(WithMaxSize(0))
+	_, err = factory.Deserialize(fake.NewContext(), make([]byte, DefaultMaxSize+1))
+	require.NoError(t, err)
+
+	factory = NewTransactionFactory()
+	_, err = factory.Deserialize(fake.NewContext(), make([]byte, DefaultMaxSize+1))
+	require.Error(t, err)
+}
+
+func TestTransactionFactory_ManyArgs(t *testing.T) {
+	opts := make([]TransactionOption, DefaultMaxArgs+1)
+	for i := range opts {
+		opts[i] = WithArg(fmt.Sprintf("arg%d", i), nil)
+	}
+
+	tx, err := NewTransaction(0, fake.PublicKey{}, opts...)
+	require.NoError(t, err)
+
+	RegisterTransactionFormat(serde.Format("ARGS"), fake.Format{Msg: tx})
+
+	// The number of arguments is only limited at the admission in the pool, so
+	// that a committed transaction can always be decoded.
+	msg, err := NewTransactionFactory().Deserialize(fake.NewContextWithFormat(serde.Format("ARGS")), nil)
+	require.NoError(t, err)
+	require.Len(t, msg.(*Transaction).GetArgs(), DefaultMaxArgs+1)
+}
+
+func TestTransactionFactory_WithBinding(t *testing.T) {
+	factory := NewTransactionFactory()
+	require.Nil(t, factory.GetBinding())
+
+	factory = NewTransactionFactory(WithBinding(KeyBinding))
+	require.NotNil(t, factory.GetBinding())
+}
+
 func TestManager_Make(t *testing.T) {
 	mgr := NewManager(fake.NewSigner(), nil)
 
@@ -197,3 +399,13 @@ type fakeClient struct {
 func (c fakeClient) GetNonce(access.Identity) (uint64, error) {
 	return 42, c.err
 }
+
+// futureFormat is a format engine that decodes messages tagged with a version
+// that is not supported yet.
+type futureFormat struct {
+	fake.Format
+}
+
+func (futureFormat) Decode(serde.Context, []byte) (serde.Message, error) {
+	return nil, serde.ErrUnsupportedVersion{Version: 9}
+}
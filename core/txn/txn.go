@@ -12,8 +12,11 @@
 package txn
 
 import (
+	"crypto/sha256"
+
 	"go.dedis.ch/dela/core/access"
 	"go.dedis.ch/dela/serde"
+	"golang.org/x/xerrors"
 )
 
 // Transaction is what triggers a smart contract execution by passing it as part
@@ -36,6 +39,38 @@ type Transaction interface {
 	GetArg(key string) []byte
 }
 
+// Condition is an expectation on the value of a key of the state when the
+// transaction is applied. An empty value expects the key to be unset.
+type Condition struct {
+	Key   []byte
+	Value []byte
+}
+
+// ConditionalTransaction is an optional interface of a transaction that must
+// be applied only if its conditions on the current state are met, so that a
+// concurrent modification of the state is detected.
+type ConditionalTransaction interface {
+	Transaction
+
+	// GetConditions returns the conditions of the transaction.
+	GetConditions() []Condition
+}
+
+// TransactionID returns the canonical identifier of the transaction, which is
+// the SHA256 digest of its fingerprint. It only depends on the content of the
+// transaction so that clients and servers agree on the value, independently
+// of how the transaction has been serialized.
+func TransactionID(tx Transaction) ([]byte, error) {
+	h := sha256.New()
+
+	err := tx.Fingerprint(h)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't fingerprint tx: %v", err)
+	}
+
+	return h.Sum(nil), nil
+}
+
 // Factory is the definition of a factory to deserialize transaction
 // messages.
 type Factory interface {
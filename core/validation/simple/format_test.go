@@ -0,0 +1,56 @@
+package simple_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/core/txn/signed"
+	"go.dedis.ch/dela/core/validation/simple"
+	"go.dedis.ch/dela/crypto"
+	"go.dedis.ch/dela/crypto/bls"
+	internal "go.dedis.ch/dela/internal/testing"
+	"go.dedis.ch/dela/serde/json"
+)
+
+func TestTransactionResult_Formats_Resubmit(t *testing.T) {
+	txFac := signed.NewTransactionFactory()
+	ctx := json.NewContext()
+
+	res := simple.NewTransactionResult(makeTx(t, 2), false, "refused")
+
+	data, err := res.Serialize(ctx)
+	require.NoError(t, err)
+
+	msg, err := simple.NewTransactionResultFactory(txFac).Deserialize(ctx, data)
+	require.NoError(t, err)
+
+	// The transaction of the result read from a block is stripped of the
+	// status and still verifies.
+	tx := msg.(simple.TransactionResult).GetTransaction()
+
+	pubkey := tx.GetIdentity().(crypto.PublicKey)
+	require.NoError(t, pubkey.Verify(tx.GetID(), tx.(*signed.Transaction).GetSignature()))
+
+	internal.RequireRoundTrip(t, tx, txFac, ctx)
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+func makeTx(t *testing.T, nonce uint64) *signed.Transaction {
+	signer := bls.NewSigner()
+
+	// The key is decoded from its binary form so that the point has the same
+	// representation as once deserialized.
+	data, err := signer.GetPublicKey().MarshalBinary()
+	require.NoError(t, err)
+
+	pubkey, err := bls.NewPublicKey(data)
+	require.NoError(t, err)
+
+	tx, err := signed.NewTransaction(nonce, pubkey, signed.WithArg("A", []byte{1}))
+	require.NoError(t, err)
+	require.NoError(t, tx.Sign(signer))
+
+	return tx
+}
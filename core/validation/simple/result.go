@@ -6,6 +6,7 @@
 package simple
 
 import (
+	"bytes"
 	"io"
 
 	"go.dedis.ch/dela/core/txn"
@@ -51,7 +52,8 @@ func NewTransactionResult(tx txn.Transaction, accepted bool, reason string) Tran
 }
 
 // GetTransaction implements validation.TransactionResult. It returns the
-// transaction associated to the result.
+// transaction associated to the result. The status is not part of the
+// transaction, which keeps its signature and can be submitted again as is.
 func (res TransactionResult) GetTransaction() txn.Transaction {
 	return res.tx
 }
@@ -155,6 +157,25 @@ func (d Result) Fingerprint(w io.Writer) error {
 	return nil
 }
 
+// GetLeaves implements types.MerklePayload. It returns one leaf per transaction
+// result, made of the fingerprint of the transaction and its status.
+func (d Result) GetLeaves() ([][]byte, error) {
+	leaves := make([][]byte, len(d.txs))
+
+	for i, res := range d.txs {
+		buffer := new(bytes.Buffer)
+
+		err := NewResult([]TransactionResult{res}).Fingerprint(buffer)
+		if err != nil {
+			return nil, xerrors.Errorf("couldn't fingerprint result: %v", err)
+		}
+
+		leaves[i] = buffer.Bytes()
+	}
+
+	return leaves, nil
+}
+
 // Serialize implements serde.Message. It returns the serialized data of the
 // result.
 func (d Result) Serialize(ctx serde.Context) ([]byte, error) {
@@ -84,6 +84,23 @@ func TestResult_Fingerprint(t *testing.T) {
 	require.EqualError(t, err, fake.Err("couldn't fingerprint tx"))
 }
 
+func TestResult_GetLeaves(t *testing.T) {
+	res := Result{
+		txs: []TransactionResult{
+			{tx: fakeTx{}},
+			{tx: fakeTx{}, accepted: true},
+		},
+	}
+
+	leaves, err := res.GetLeaves()
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{0}, {1}}, leaves)
+
+	res.txs[1].tx = fakeTx{err: fake.GetError()}
+	_, err = res.GetLeaves()
+	require.EqualError(t, err, fake.Err("couldn't fingerprint result: couldn't fingerprint tx"))
+}
+
 func TestResult_Serialize(t *testing.T) {
 	res := NewResult(nil)
 
@@ -5,8 +5,10 @@
 package simple
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
+	"unicode/utf8"
 
 	"go.dedis.ch/dela/core/access"
 	"go.dedis.ch/dela/core/execution"
@@ -22,18 +24,39 @@ import (
 //
 // - implements validation.Service
 type Service struct {
-	execution execution.Service
-	fac       validation.ResultFactory
-	hashFac   crypto.HashFactory
+	execution  execution.Service
+	fac        validation.ResultFactory
+	hashFac    crypto.HashFactory
+	firstNonce uint64
+}
+
+// ServiceOption is the type of option to set some fields of the service.
+type ServiceOption func(*Service)
+
+// WithFirstNonce is an option to set the nonce expected from the first
+// transaction of an identity. It must be set to one when the pool rejects the
+// nonce of zero with signed.RejectZeroNonce, otherwise a new identity can never
+// commit a transaction. As it changes the transactions that are accepted, it
+// must be the same on every node. The default is zero.
+func WithFirstNonce(nonce uint64) ServiceOption {
+	return func(s *Service) {
+		s.firstNonce = nonce
+	}
 }
 
 // NewService creates a new validation service.
-func NewService(exec execution.Service, f txn.Factory) Service {
-	return Service{
+func NewService(exec execution.Service, f txn.Factory, opts ...ServiceOption) Service {
+	s := Service{
 		execution: exec,
 		fac:       NewResultFactory(f),
 		hashFac:   crypto.NewSha256Factory(),
 	}
+
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	return s
 }
 
 // GetFactory implements validation.Service. It returns the result factory.
@@ -42,7 +65,8 @@ func (s Service) GetFactory() validation.ResultFactory {
 }
 
 // GetNonce implements validation.Service. It reads the latest nonce in the
-// storage for the given identity and returns the next valid nonce.
+// storage for the given identity and returns the next valid nonce, or the first
+// nonce if the identity has no transaction yet.
 func (s Service) GetNonce(store store.Readable, ident access.Identity) (uint64, error) {
 	if ident == nil {
 		return 0, xerrors.New("missing identity in transaction")
@@ -59,7 +83,7 @@ func (s Service) GetNonce(store store.Readable, ident access.Identity) (uint64,
 	}
 
 	if value == nil || len(value) != 8 {
-		return 0, nil
+		return s.firstNonce, nil
 	}
 
 	return binary.LittleEndian.Uint64(value) + 1, nil
@@ -126,22 +150,21 @@ func (s Service) validateTx(store store.Snapshot, step execution.Step, r *Transa
 	}
 
 	if expectedNonce != step.Current.GetNonce() {
-		r.reason = fmt.Sprintf("nonce is invalid, expected %d, got %d",
-			expectedNonce, step.Current.GetNonce())
+		r.reason = truncateReason(fmt.Sprintf("nonce is invalid, expected %d, got %d",
+			expectedNonce, step.Current.GetNonce()))
 		r.accepted = false
 
 		return nil
 	}
 
-	res, err := s.execution.Execute(store, step)
-	// if the execution fail, we don't return an error, but we take it as an
-	// invalid transaction.
-	if err != nil {
-		r.reason = xerrors.Errorf("failed to execute transaction: %v", err).Error()
+	unmet := checkConditions(store, step.Current)
+	if unmet != "" {
+		// The state has been modified since the transaction has been created,
+		// therefore it is aborted without being executed.
+		r.reason = truncateReason(unmet)
 		r.accepted = false
 	} else {
-		r.reason = res.Message
-		r.accepted = res.Accepted
+		s.execute(store, step, r)
 	}
 
 	// Update the nonce associated to the identity so that this transaction
@@ -154,6 +177,58 @@ func (s Service) validateTx(store store.Snapshot, step execution.Step, r *Transa
 	return nil
 }
 
+func (s Service) execute(store store.Snapshot, step execution.Step, r *TransactionResult) {
+	res, err := s.execution.Execute(store, step)
+	// if the execution fail, we don't return an error, but we take it as an
+	// invalid transaction.
+	if err != nil {
+		r.reason = truncateReason(xerrors.Errorf("failed to execute transaction: %v", err).Error())
+		r.accepted = false
+	} else {
+		r.reason = truncateReason(res.Message)
+		r.accepted = res.Accepted
+	}
+}
+
+// truncateReason returns the reason cut to at most validation.MaxReasonSize
+// bytes, without splitting a character.
+func truncateReason(reason string) string {
+	if len(reason) <= validation.MaxReasonSize {
+		return reason
+	}
+
+	end := validation.MaxReasonSize
+	for end > 0 && !utf8.RuneStart(reason[end]) {
+		end--
+	}
+
+	return reason[:end]
+}
+
+// checkConditions returns the reason why a condition of the transaction is not
+// met by the current state, or an empty string if they are all met. The keys
+// are chosen by the client, therefore a condition that can't be read is unmet
+// rather than failing the validation of the whole batch.
+func checkConditions(store store.Readable, tx txn.Transaction) string {
+	ctx, ok := tx.(txn.ConditionalTransaction)
+	if !ok {
+		return ""
+	}
+
+	for _, cond := range ctx.GetConditions() {
+		value, err := store.Get(cond.Key)
+		if err != nil {
+			return fmt.Sprintf("condition on key %#x can't be read: %v", cond.Key, err)
+		}
+
+		if !bytes.Equal(value, cond.Value) {
+			return fmt.Sprintf("condition on key %#x is not met", cond.Key)
+		}
+	}
+
+	return ""
+}
+
 func (s Service) set(store store.Snapshot, ident access.Identity, nonce uint64) error {
 	key, err := s.keyFromIdentity(ident)
 	if err != nil {
@@ -1,13 +1,17 @@
 package simple
 
 import (
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/stretchr/testify/require"
 	"go.dedis.ch/dela/core/execution"
 	"go.dedis.ch/dela/core/store"
 	"go.dedis.ch/dela/core/txn"
+	"go.dedis.ch/dela/core/txn/signed"
 	"go.dedis.ch/dela/core/validation"
+	"go.dedis.ch/dela/crypto/bls"
 	"go.dedis.ch/dela/internal/testing/fake"
 	"golang.org/x/xerrors"
 )
@@ -30,6 +34,16 @@ func TestService_GetNonce(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, uint64(3), nonce)
 
+	srvc = NewService(&fakeExec{}, nil, WithFirstNonce(1))
+
+	nonce, err = srvc.GetNonce(fakeSnapshot{}, fake.PublicKey{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), nonce)
+
+	nonce, err = srvc.GetNonce(fakeSnapshot{value: buffer}, fake.PublicKey{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), nonce)
+
 	_, err = srvc.GetNonce(fakeSnapshot{}, fake.NewBadPublicKey())
 	require.EqualError(t, err, fake.Err("key: failed to marshal identity"))
 
@@ -92,6 +106,42 @@ func TestService_Validate(t *testing.T) {
 	require.False(t, status)
 }
 
+func TestService_FirstNonce_Validate(t *testing.T) {
+	srvc := NewService(&fakeExec{}, signed.NewTransactionFactory(), WithFirstNonce(1))
+	filter := signed.NewNonceFilter(signed.RejectZeroNonce)
+
+	signer := bls.NewSigner()
+	snap := fake.NewSnapshot()
+
+	// A new identity submits its first transaction with the nonce given by the
+	// service, which goes through the filter of the pool and is committed.
+	nonce, err := srvc.GetNonce(snap, signer.GetPublicKey())
+	require.NoError(t, err)
+
+	tx, err := signed.NewTransaction(nonce, signer.GetPublicKey())
+	require.NoError(t, err)
+	require.NoError(t, tx.Sign(signer))
+
+	require.NoError(t, filter.Accept(tx, validation.Leeway{}))
+	require.NoError(t, srvc.Accept(snap, tx, validation.Leeway{}))
+
+	res, err := srvc.Validate(snap, []txn.Transaction{tx})
+	require.NoError(t, err)
+
+	accepted, reason := res.GetTransactionResults()[0].GetStatus()
+	require.True(t, accepted, reason)
+
+	nonce, err = srvc.GetNonce(snap, signer.GetPublicKey())
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), nonce)
+
+	// The zero nonce is refused by the pool.
+	zero, err := signed.NewTransaction(0, signer.GetPublicKey())
+	require.NoError(t, err)
+	require.NoError(t, zero.Sign(signer))
+	require.EqualError(t, filter.Accept(zero, validation.Leeway{}), "nonce zero is not allowed")
+}
+
 func TestService_NilIdentity_Validate(t *testing.T) {
 	srvc := NewService(&fakeExec{}, nil)
 
@@ -127,9 +177,135 @@ func TestService_FailExecuteTx_Validate(t *testing.T) {
 	require.Equal(t, fake.Err("failed to execute transaction"), msg)
 }
 
+func TestService_LongReason_Validate(t *testing.T) {
+	srvc := NewService(&fakeExec{err: xerrors.New(strings.Repeat("é", validation.MaxReasonSize))}, nil)
+
+	res, err := srvc.Validate(fakeSnapshot{}, []txn.Transaction{newTx()})
+	require.NoError(t, err)
+
+	_, msg := res.GetTransactionResults()[0].GetStatus()
+	require.LessOrEqual(t, len(msg), validation.MaxReasonSize)
+	require.True(t, utf8.ValidString(msg))
+	require.True(t, strings.HasPrefix(msg, "failed to execute transaction: é"))
+}
+
+func TestTruncateReason(t *testing.T) {
+	require.Equal(t, "", truncateReason(""))
+	require.Equal(t, "short", truncateReason("short"))
+
+	long := strings.Repeat("a", validation.MaxReasonSize+1)
+	require.Equal(t, long[:validation.MaxReasonSize], truncateReason(long))
+
+	// The last character is not split.
+	long = strings.Repeat("a", validation.MaxReasonSize-1) + "é"
+	require.Equal(t, long[:validation.MaxReasonSize-1], truncateReason(long))
+}
+
+func TestService_Conditions_Validate(t *testing.T) {
+	exec := &fakeExec{}
+	srvc := NewService(exec, nil)
+
+	snap := fake.NewSnapshot()
+	require.NoError(t, snap.Set([]byte("K"), []byte{1}))
+
+	tx := condTx{
+		fakeTx: newTx(),
+		conditions: []txn.Condition{
+			{Key: []byte("K"), Value: []byte{1}},
+			{Key: []byte("L")},
+		},
+	}
+
+	res, err := srvc.Validate(snap, []txn.Transaction{tx})
+	require.NoError(t, err)
+	require.Equal(t, 1, exec.count)
+
+	status, _ := res.GetTransactionResults()[0].GetStatus()
+	require.True(t, status)
+
+	// The value has been modified concurrently, so the transaction is aborted
+	// without being executed, but the nonce is consumed.
+	require.NoError(t, snap.Set([]byte("K"), []byte{2}))
+
+	tx.nonce = 1
+	res, err = srvc.Validate(snap, []txn.Transaction{tx})
+	require.NoError(t, err)
+	require.Equal(t, 1, exec.count)
+
+	status, msg := res.GetTransactionResults()[0].GetStatus()
+	require.False(t, status)
+	require.Equal(t, "condition on key 0x4b is not met", msg)
+
+	nonce, err := srvc.GetNonce(snap, tx.GetIdentity())
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), nonce)
+
+	// A condition that can't be read is unmet and doesn't fail the batch.
+	tx.nonce = 2
+	res, err = srvc.Validate(badKeySnapshot{Snapshot: snap, key: "K"}, []txn.Transaction{tx})
+	require.NoError(t, err)
+	require.Equal(t, 1, exec.count)
+
+	status, msg = res.GetTransactionResults()[0].GetStatus()
+	require.False(t, status)
+	require.Equal(t, fake.Err("condition on key 0x4b can't be read"), msg)
+}
+
+func TestService_LongConditionKey_Validate(t *testing.T) {
+	exec := &fakeExec{}
+	srvc := NewService(exec, nil)
+
+	snap := keyLenSnapshot{Snapshot: fake.NewSnapshot(), max: 32}
+
+	// A client can't prevent the other transactions of the batch from being
+	// validated by using a key that the store refuses.
+	long := condTx{
+		fakeTx:     newTx(),
+		conditions: []txn.Condition{{Key: make([]byte, 33)}},
+	}
+
+	other := newTx()
+	other.nonce = 1
+
+	res, err := srvc.Validate(snap, []txn.Transaction{long, other})
+	require.NoError(t, err)
+	require.Equal(t, 1, exec.count)
+
+	status, msg := res.GetTransactionResults()[0].GetStatus()
+	require.False(t, status)
+	require.Regexp(t, "^condition on key 0x0+ can't be read: mismatch key length 33 > 32$", msg)
+
+	status, _ = res.GetTransactionResults()[1].GetStatus()
+	require.True(t, status)
+}
+
 // -----------------------------------------------------------------------------
 // Utility functions
 
+type condTx struct {
+	fakeTx
+
+	conditions []txn.Condition
+}
+
+func (tx condTx) GetConditions() []txn.Condition {
+	return tx.conditions
+}
+
+type badKeySnapshot struct {
+	store.Snapshot
+
+	key string
+}
+
+func (s badKeySnapshot) Get(key []byte) ([]byte, error) {
+	if string(key) == s.key {
+		return nil, fake.GetError()
+	}
+
+	return s.Snapshot.Get(key)
+}
+
 type fakeExec struct {
 	err   error
 	count int
@@ -160,3 +336,19 @@ func (s fakeSnapshot) Get(key []byte) ([]byte, error) {
 func (s fakeSnapshot) Set(key, value []byte) error {
 	return s.errSet
 }
+
+// keyLenSnapshot is a snapshot that refuses the keys longer than the maximum,
+// like the hash tree.
+type keyLenSnapshot struct {
+	store.Snapshot
+
+	max int
+}
+
+func (snap keyLenSnapshot) Get(key []byte) ([]byte, error) {
+	if len(key) > snap.max {
+		return nil, xerrors.Errorf("mismatch key length %d > %d", len(key), snap.max)
+	}
+
+	return snap.Snapshot.Get(key)
+}
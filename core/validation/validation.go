@@ -11,6 +11,11 @@ import (
 	"go.dedis.ch/dela/serde"
 )
 
+// MaxReasonSize is the maximum number of bytes of the reason of a rejected
+// transaction. A longer reason is truncated so that the size of a result is
+// bounded.
+const MaxReasonSize = 256
+
 // TransactionResult is the result of a transaction execution.
 type TransactionResult interface {
 	serde.Message
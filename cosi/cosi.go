@@ -35,6 +35,18 @@ type Reactor interface {
 	Invoke(addr mino.Address, in serde.Message) ([]byte, error)
 }
 
+// Interceptor is an optional interface of a reactor to transform the messages
+// of the collective signing, both the requests and the replies, after they are
+// serialized and before they hit the wire. Incoming must revert the
+// transformation of Outgoing.
+type Interceptor interface {
+	// Outgoing transforms the serialized message before it is sent.
+	Outgoing(data []byte) ([]byte, error)
+
+	// Incoming transforms the received data before it is deserialized.
+	Incoming(data []byte) ([]byte, error)
+}
+
 // Actor provides a primitive to sign a message.
 type Actor interface {
 	// Sign collects the signature of the collective authority and creates an
@@ -47,6 +59,19 @@ type Actor interface {
 // which means it is always positive and below or equal to n.
 type Threshold func(int) int
 
+// ByzantineThreshold returns the minimum number of honest nodes required given
+// `n` total nodes in a Byzantine Fault Tolerant system, which is 2f+1 when
+// n = 3f+1.
+func ByzantineThreshold(n int) int {
+	if n <= 0 {
+		return 0
+	}
+
+	f := (n - 1) / 3
+
+	return n - f
+}
+
 // CollectiveSigning is the interface that provides the primitives to sign a
 // message by members of a network.
 type CollectiveSigning interface {
@@ -0,0 +1,16 @@
+package cosi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestByzantineThreshold(t *testing.T) {
+	require.Equal(t, 0, ByzantineThreshold(-10))
+	require.Equal(t, 0, ByzantineThreshold(0))
+	require.Equal(t, 1, ByzantineThreshold(1))
+	require.Equal(t, 2, ByzantineThreshold(2))
+	require.Equal(t, 3, ByzantineThreshold(4))
+	require.Equal(t, 5, ByzantineThreshold(7))
+}
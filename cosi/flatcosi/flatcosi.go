@@ -111,7 +111,7 @@ func (a flatActor) Sign(ctx context.Context, msg serde.Message,
 		Value: msg,
 	}
 
-	msgs, err := a.rpc.Call(ctx, req, ca)
+	msgs, err := a.rpc.Call(ctx, cosi.Intercept(a.reactor, req), ca)
 	if err != nil {
 		return nil, xerrors.Errorf("call aborted: %v", err)
 	}
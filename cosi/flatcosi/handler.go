@@ -47,7 +47,7 @@ func (h handler) Process(req mino.Request) (serde.Message, error) {
 			Signature: sig,
 		}
 
-		return resp, nil
+		return cosi.Intercept(h.reactor, resp), nil
 
 	default:
 		return nil, xerrors.Errorf("invalid message type '%T'", msg)
@@ -59,6 +59,43 @@ func (resp SignatureResponse) Serialize(ctx serde.Context) ([]byte, error) {
 	return data, nil
 }
 
+// Intercept returns the message that is transformed by the reactor after being
+// serialized if it implements cosi.Interceptor, otherwise the message as is.
+func Intercept(r Reactor, msg serde.Message) serde.Message {
+	interceptor, ok := r.(Interceptor)
+	if !ok {
+		return msg
+	}
+
+	return interceptedMessage{Message: msg, interceptor: interceptor}
+}
+
+// interceptedMessage is a message that applies the interceptor after being
+// serialized.
+//
+// - implements serde.Message
+type interceptedMessage struct {
+	serde.Message
+
+	interceptor Interceptor
+}
+
+// Serialize implements serde.Message. It serializes the message and transforms
+// the result with the interceptor.
+func (m interceptedMessage) Serialize(ctx serde.Context) ([]byte, error) {
+	data, err := m.Message.Serialize(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't serialize message: %v", err)
+	}
+
+	data, err = m.interceptor.Outgoing(data)
+	if err != nil {
+		return nil, xerrors.Errorf("outgoing interceptor failed: %v", err)
+	}
+
+	return data, nil
+}
+
 // MsgKey is the key of the message factory.
 type MsgKey struct{}
 
@@ -82,8 +119,19 @@ func NewMessageFactory(msg serde.Factory, sig crypto.SignatureFactory) MessageFa
 	}
 }
 
-// Deserialize implements serde.Factory.
+// Deserialize implements serde.Factory. The data is first transformed by the
+// message factory if it implements cosi.Interceptor.
 func (f MessageFactory) Deserialize(ctx serde.Context, data []byte) (serde.Message, error) {
+	interceptor, ok := f.msgFactory.(Interceptor)
+	if ok {
+		var err error
+
+		data, err = interceptor.Incoming(data)
+		if err != nil {
+			return nil, xerrors.Errorf("incoming interceptor failed: %v", err)
+		}
+	}
+
 	format := msgFormats.Get(ctx.GetFormat())
 
 	ctx = serde.WithFactory(ctx, MsgKey{}, f.msgFactory)
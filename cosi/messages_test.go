@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"go.dedis.ch/dela/internal/testing/fake"
+	"go.dedis.ch/dela/mino"
 	"go.dedis.ch/dela/serde"
 )
 
@@ -37,6 +38,24 @@ func TestSignatureResponse(t *testing.T) {
 	require.EqualError(t, err, fake.Err("couldn't encode response"))
 }
 
+func TestIntercept(t *testing.T) {
+	msg := Intercept(fakeReactor{}, SignatureResponse{})
+	require.Equal(t, SignatureResponse{}, msg)
+
+	msg = Intercept(fakeInterceptor{}, SignatureResponse{})
+
+	data, err := msg.Serialize(fake.NewContext())
+	require.NoError(t, err)
+	require.Equal(t, append([]byte("#"), fake.GetFakeFormatValue()...), data)
+
+	_, err = msg.Serialize(fake.NewBadContext())
+	require.EqualError(t, err, fake.Err("couldn't serialize message: couldn't encode response"))
+
+	msg = Intercept(fakeInterceptor{err: fake.GetError()}, SignatureResponse{})
+	_, err = msg.Serialize(fake.NewContext())
+	require.EqualError(t, err, fake.Err("outgoing interceptor failed"))
+}
+
 func TestMessageFactory_Deserialize(t *testing.T) {
 	factory := NewMessageFactory(fake.MessageFactory{}, fake.SignatureFactory{})
 
@@ -54,3 +73,50 @@ func TestMessageFactory_Deserialize(t *testing.T) {
 	_, err = factory.Deserialize(fake.NewBadContext(), nil)
 	require.EqualError(t, err, fake.Err("couldn't decode message"))
 }
+
+func TestMessageFactory_Intercepted_Deserialize(t *testing.T) {
+	factory := NewMessageFactory(fakeInterceptor{}, fake.SignatureFactory{})
+
+	testCalls.Clear()
+
+	_, err := factory.Deserialize(fake.NewContext(), []byte("#abc"))
+	require.NoError(t, err)
+	require.Equal(t, 1, testCalls.Len())
+	require.Equal(t, []byte("abc"), testCalls.Get(0, 1))
+
+	factory = NewMessageFactory(fakeInterceptor{err: fake.GetError()}, fake.SignatureFactory{})
+
+	_, err = factory.Deserialize(fake.NewContext(), nil)
+	require.EqualError(t, err, fake.Err("incoming interceptor failed"))
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+type fakeReactor struct {
+	fake.MessageFactory
+}
+
+func (fakeReactor) Invoke(mino.Address, serde.Message) ([]byte, error) {
+	return nil, nil
+}
+
+// fakeInterceptor prefixes the outgoing data with a marker that is removed from
+// the incoming data.
+type fakeInterceptor struct {
+	fakeReactor
+
+	err error
+}
+
+func (i fakeInterceptor) Outgoing(data []byte) ([]byte, error) {
+	return append([]byte("#"), data...), i.err
+}
+
+func (i fakeInterceptor) Incoming(data []byte) ([]byte, error) {
+	if i.err != nil {
+		return nil, i.err
+	}
+
+	return data[1:], nil
+}
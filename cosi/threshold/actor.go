@@ -60,13 +60,15 @@ func (a thresholdActor) Sign(ctx context.Context, msg serde.Message,
 		Value: msg,
 	}
 
-	errs := sender.Send(req, iter2slice(ca)...)
+	errs := sender.Send(cosi.Intercept(a.reactor, req), iter2slice(ca)...)
 
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	go a.waitResp(errs, ca.Len()-thres, cancel)
 
+	aggregator := a.getAggregator(ca)
+
 	count := 0
 	signature := new(types.Signature)
 	for count < thres {
@@ -77,7 +79,7 @@ func (a thresholdActor) Sign(ctx context.Context, msg serde.Message,
 
 		pubkey, index := ca.GetPublicKey(addr)
 		if index >= 0 {
-			err = a.merge(signature, resp, index, pubkey, digest)
+			err = a.merge(signature, aggregator, resp, index, pubkey, digest)
 			if err != nil {
 				a.logger.Warn().Err(err).Msg("failed to process signature response")
 			} else {
@@ -105,8 +107,8 @@ func (a thresholdActor) waitResp(errs <-chan error, maxErrs int, cancel func())
 	}
 }
 
-func (a thresholdActor) merge(signature *types.Signature, m serde.Message,
-	index int, pubkey crypto.PublicKey, digest []byte) error {
+func (a thresholdActor) merge(signature *types.Signature, aggregator types.Aggregator,
+	m serde.Message, index int, pubkey crypto.PublicKey, digest []byte) error {
 
 	resp, ok := m.(cosi.SignatureResponse)
 	if !ok {
@@ -118,7 +120,7 @@ func (a thresholdActor) merge(signature *types.Signature, m serde.Message,
 		return xerrors.Errorf("couldn't verify: %v", err)
 	}
 
-	err = signature.Merge(a.signer, index, resp.Signature)
+	err = signature.Merge(aggregator, index, resp.Signature)
 	if err != nil {
 		return xerrors.Errorf("couldn't merge signature: %v", err)
 	}
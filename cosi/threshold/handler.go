@@ -73,7 +73,7 @@ func (h thresholdHandler) processRequest(sender mino.Sender, msg serde.Message,
 		Signature: signature,
 	}
 
-	err = <-sender.Send(resp, addr)
+	err = <-sender.Send(cosi.Intercept(h.reactor, resp), addr)
 	if err != nil {
 		return xerrors.Errorf("couldn't send the response: %v", err)
 	}
@@ -8,6 +8,8 @@
 package threshold
 
 import (
+	"reflect"
+	"sync"
 	"sync/atomic"
 
 	"github.com/rs/zerolog"
@@ -16,6 +18,7 @@ import (
 	"go.dedis.ch/dela/cosi/threshold/types"
 	"go.dedis.ch/dela/crypto"
 	"go.dedis.ch/dela/mino"
+	"golang.org/x/xerrors"
 )
 
 var (
@@ -38,15 +41,10 @@ func OneThreshold(n int) int {
 }
 
 // ByzantineThreshold returns the minimum number of honest nodes required given
-// `n` total nodes in a Byzantine Fault Tolerant system.
+// `n` total nodes in a Byzantine Fault Tolerant system. It is the threshold
+// defined by cosi.ByzantineThreshold.
 func ByzantineThreshold(n int) int {
-	if n <= 0 {
-		return 0
-	}
-
-	f := (n - 1) / 3
-
-	return n - f
+	return cosi.ByzantineThreshold(n)
 }
 
 // Threshold is an implementation of the cosi.CollectiveSigning interface that
@@ -58,6 +56,17 @@ type Threshold struct {
 	// Stores the cosi.Threshold function. It will always contain a valid
 	// function by construction.
 	thresholdFn atomic.Value
+
+	aggLock sync.Mutex
+	// strategies are the aggregation strategies by type of public key.
+	strategies map[reflect.Type]strategy
+}
+
+// strategy is the pair of an aggregation of the signatures and of the factory
+// of the verifiers of the resulting aggregates.
+type strategy struct {
+	agg       types.Aggregator
+	verifiers crypto.VerifierFactory
 }
 
 // NewThreshold returns a new instance of a threshold collective signature.
@@ -95,7 +104,7 @@ func (c *Threshold) GetSignatureFactory() crypto.SignatureFactory {
 // GetVerifierFactory implements cosi.CollectiveSigning. It returns the verifier
 // factory.
 func (c *Threshold) GetVerifierFactory() crypto.VerifierFactory {
-	return types.NewThresholdVerifierFactory(c.signer.GetVerifierFactory())
+	return types.NewThresholdVerifierFactory(verifierFactory{threshold: c})
 }
 
 // SetThreshold implements cosi.CollectiveSigning. It sets a new threshold
@@ -108,6 +117,62 @@ func (c *Threshold) SetThreshold(fn cosi.Threshold) {
 	c.thresholdFn.Store(fn)
 }
 
+// SetAggregator sets the strategy that aggregates the signatures of a roster
+// whose public keys are of the same type as the one in parameter, and the
+// factory of the verifiers of the aggregates it produces. The signer of the
+// instance aggregates and verifies the signatures of the other rosters.
+func (c *Threshold) SetAggregator(key crypto.PublicKey, agg types.Aggregator,
+	verifiers crypto.VerifierFactory) {
+
+	c.aggLock.Lock()
+	defer c.aggLock.Unlock()
+
+	if c.strategies == nil {
+		c.strategies = make(map[reflect.Type]strategy)
+	}
+
+	c.strategies[reflect.TypeOf(key)] = strategy{
+		agg:       agg,
+		verifiers: verifiers,
+	}
+}
+
+// getAggregator returns the aggregation strategy for the type of the public
+// keys of the collective authority.
+func (c *Threshold) getAggregator(ca crypto.CollectiveAuthority) types.Aggregator {
+	iter := ca.PublicKeyIterator()
+	if iter.HasNext() {
+		s, found := c.getStrategy(iter.GetNext())
+		if found {
+			return s.agg
+		}
+	}
+
+	return c.signer
+}
+
+// getVerifierFactory returns the factory of the verifiers for the type of the
+// public keys in parameter.
+func (c *Threshold) getVerifierFactory(pubkeys []crypto.PublicKey) crypto.VerifierFactory {
+	if len(pubkeys) > 0 {
+		s, found := c.getStrategy(pubkeys[0])
+		if found {
+			return s.verifiers
+		}
+	}
+
+	return c.signer.GetVerifierFactory()
+}
+
+func (c *Threshold) getStrategy(key crypto.PublicKey) (strategy, bool) {
+	c.aggLock.Lock()
+	defer c.aggLock.Unlock()
+
+	s, found := c.strategies[reflect.TypeOf(key)]
+
+	return s, found
+}
+
 // Listen implements cosi.CollectiveSigning. It creates the rpc endpoint and
 // returns the actor that can trigger a collective signature.
 func (c *Threshold) Listen(r cosi.Reactor) (cosi.Actor, error) {
@@ -122,3 +187,35 @@ func (c *Threshold) Listen(r cosi.Reactor) (cosi.Actor, error) {
 
 	return actor, nil
 }
+
+// verifierFactory is the factory of the verifiers of the aggregates. It selects
+// the verifiers by the type of the public keys, in the same way as the
+// aggregation strategy.
+//
+// - implements crypto.VerifierFactory
+type verifierFactory struct {
+	threshold *Threshold
+}
+
+// FromAuthority implements crypto.VerifierFactory. It returns the verifier for
+// the type of the public keys of the authority.
+func (f verifierFactory) FromAuthority(ca crypto.CollectiveAuthority) (crypto.Verifier, error) {
+	pubkeys := make([]crypto.PublicKey, 0, ca.Len())
+	iter := ca.PublicKeyIterator()
+	for iter.HasNext() {
+		pubkeys = append(pubkeys, iter.GetNext())
+	}
+
+	return f.FromArray(pubkeys)
+}
+
+// FromArray implements crypto.VerifierFactory. It returns the verifier for the
+// type of the public keys.
+func (f verifierFactory) FromArray(pubkeys []crypto.PublicKey) (crypto.Verifier, error) {
+	verifier, err := f.threshold.getVerifierFactory(pubkeys).FromArray(pubkeys)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't make verifier: %v", err)
+	}
+
+	return verifier, nil
+}
@@ -6,12 +6,14 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"go.dedis.ch/dela/cosi"
+	"go.dedis.ch/dela/cosi/threshold/types"
 	"go.dedis.ch/dela/crypto"
 	"go.dedis.ch/dela/crypto/bls"
 	"go.dedis.ch/dela/internal/testing/fake"
 	"go.dedis.ch/dela/mino"
 	"go.dedis.ch/dela/mino/minoch"
 	"go.dedis.ch/dela/serde"
+	"golang.org/x/xerrors"
 )
 
 func TestThreshold_Scenario_Basic(t *testing.T) {
@@ -41,6 +43,85 @@ func TestThreshold_Scenario_Basic(t *testing.T) {
 	require.NoError(t, verifier.Verify([]byte{0xff}, sig))
 }
 
+func TestThreshold_Scenario_Aggregator(t *testing.T) {
+	manager := minoch.NewManager()
+
+	m1 := minoch.MustCreate(manager, "A")
+	m2 := minoch.MustCreate(manager, "B")
+	m3 := minoch.MustCreate(manager, "C")
+
+	ca := fake.NewAuthorityFromMino(bls.Generate, m1, m2, m3)
+
+	c1 := NewThreshold(m1, ca.GetSigner(0).(crypto.AggregateSigner))
+
+	actor, err := c1.Listen(fakeReactor{})
+	require.NoError(t, err)
+
+	for i, m := range []mino.Mino{m2, m3} {
+		c := NewThreshold(m, ca.GetSigner(i+1).(crypto.AggregateSigner))
+		_, err = c.Listen(fakeReactor{})
+		require.NoError(t, err)
+	}
+
+	ctx := context.Background()
+
+	// The additive strategy of the signer produces a single signature that is
+	// verified against the aggregated public key.
+	sig, err := actor.Sign(ctx, fake.Message{}, ca)
+	require.NoError(t, err)
+
+	verifier, err := c1.GetVerifierFactory().FromAuthority(ca)
+	require.NoError(t, err)
+	require.NoError(t, verifier.Verify([]byte{0xff}, sig))
+
+	// A strategy selected by the type of key keeps every signature, and the
+	// verifier factory of the instance selects the verifier of the same
+	// strategy to verify each of them individually.
+	c1.SetAggregator(bls.NewPublicKeyFromPoint(nil), listAggregator{}, listVerifierFactory{})
+
+	sig, err = actor.Sign(ctx, fake.Message{}, ca)
+	require.NoError(t, err)
+
+	list, ok := sig.(*types.Signature).GetAggregate().(listSignature)
+	require.True(t, ok)
+	require.Len(t, list, 3)
+
+	verifier, err = c1.GetVerifierFactory().FromAuthority(ca)
+	require.NoError(t, err)
+	require.NoError(t, verifier.Verify([]byte{0xff}, sig))
+	require.Error(t, verifier.Verify([]byte{0xaa}, sig))
+}
+
+func TestThreshold_SetAggregator(t *testing.T) {
+	signer := fake.NewAggregateSigner()
+	c := &Threshold{signer: signer}
+
+	ca := fake.NewAuthority(3, fake.NewSigner)
+	require.Equal(t, signer, c.getAggregator(ca))
+	require.Equal(t, signer.GetVerifierFactory(), c.getVerifierFactory(getPublicKeys(ca)))
+
+	c.SetAggregator(bls.NewPublicKeyFromPoint(nil), listAggregator{}, listVerifierFactory{})
+	require.Equal(t, signer, c.getAggregator(ca))
+	require.Equal(t, signer.GetVerifierFactory(), c.getVerifierFactory(getPublicKeys(ca)))
+
+	c.SetAggregator(fake.PublicKey{}, listAggregator{}, listVerifierFactory{})
+	require.Equal(t, listAggregator{}, c.getAggregator(ca))
+	require.Equal(t, listVerifierFactory{}, c.getVerifierFactory(getPublicKeys(ca)))
+
+	require.Equal(t, signer, c.getAggregator(fake.NewAuthority(0, fake.NewSigner)))
+	require.Equal(t, signer.GetVerifierFactory(), c.getVerifierFactory(nil))
+}
+
+func TestThreshold_GetVerifierFactory(t *testing.T) {
+	c := &Threshold{signer: fake.NewAggregateSigner()}
+	c.SetAggregator(fake.PublicKey{}, listAggregator{}, fake.NewBadVerifierFactory())
+
+	factory := verifierFactory{threshold: c}
+
+	_, err := factory.FromAuthority(fake.NewAuthority(3, fake.NewSigner))
+	require.EqualError(t, err, fake.Err("couldn't make verifier"))
+}
+
 func TestDefaultThreshold(t *testing.T) {
 	require.Equal(t, 2, defaultThreshold(2))
 	require.Equal(t, 5, defaultThreshold(5))
@@ -108,3 +189,89 @@ type fakeReactor struct {
 func (h fakeReactor) Invoke(addr mino.Address, in serde.Message) ([]byte, error) {
 	return []byte{0xff}, h.err
 }
+
+// listAggregator is an aggregation strategy that keeps the individual
+// signatures.
+type listAggregator struct{}
+
+func (listAggregator) Aggregate(signatures ...crypto.Signature) (crypto.Signature, error) {
+	var list listSignature
+
+	for _, sig := range signatures {
+		other, ok := sig.(listSignature)
+		if ok {
+			list = append(list, other...)
+		} else {
+			list = append(list, sig)
+		}
+	}
+
+	return list, nil
+}
+
+type listSignature []crypto.Signature
+
+func (listSignature) Serialize(serde.Context) ([]byte, error) {
+	return nil, nil
+}
+
+func (listSignature) MarshalBinary() ([]byte, error) {
+	return nil, nil
+}
+
+func (listSignature) Equal(crypto.Signature) bool {
+	return false
+}
+
+func getPublicKeys(ca crypto.CollectiveAuthority) []crypto.PublicKey {
+	pubkeys := make([]crypto.PublicKey, 0, ca.Len())
+	iter := ca.PublicKeyIterator()
+	for iter.HasNext() {
+		pubkeys = append(pubkeys, iter.GetNext())
+	}
+
+	return pubkeys
+}
+
+// listVerifierFactory is the factory of the verifiers of the list signatures.
+type listVerifierFactory struct{}
+
+func (listVerifierFactory) FromAuthority(ca crypto.CollectiveAuthority) (crypto.Verifier, error) {
+	return listVerifier{pubkeys: getPublicKeys(ca)}, nil
+}
+
+func (listVerifierFactory) FromArray(pubkeys []crypto.PublicKey) (crypto.Verifier, error) {
+	return listVerifier{pubkeys: pubkeys}, nil
+}
+
+// listVerifier verifies that every signature of the list is produced by one of
+// the public keys.
+type listVerifier struct {
+	pubkeys []crypto.PublicKey
+}
+
+func (v listVerifier) Verify(msg []byte, sig crypto.Signature) error {
+	list, ok := sig.(listSignature)
+	if !ok {
+		return xerrors.Errorf("invalid signature '%T'", sig)
+	}
+
+	if len(list) != len(v.pubkeys) {
+		return xerrors.Errorf("expected %d signatures but got %d", len(v.pubkeys), len(list))
+	}
+
+	for _, part := range list {
+		verified := false
+		for _, pubkey := range v.pubkeys {
+			if pubkey.Verify(msg, part) == nil {
+				verified = true
+			}
+		}
+
+		if !verified {
+			return xerrors.Errorf("signature '%v' doesn't match any key", part)
+		}
+	}
+
+	return nil
+}
@@ -93,8 +93,16 @@ func (s *Signature) GetIndices() []int {
 	return indices
 }
 
-// Merge adds the signature.
-func (s *Signature) Merge(signer crypto.AggregateSigner, index int, sig crypto.Signature) error {
+// Aggregator is the strategy that combines the signatures of the participants
+// into the aggregate of a collective signature. An aggregate signer is the
+// strategy of an additive scheme.
+type Aggregator interface {
+	// Aggregate returns the aggregate signature of the ones in parameter.
+	Aggregate(signatures ...crypto.Signature) (crypto.Signature, error)
+}
+
+// Merge adds the signature with the aggregation strategy.
+func (s *Signature) Merge(aggregator Aggregator, index int, sig crypto.Signature) error {
 	if s.HasBit(index) {
 		return xerrors.Errorf("index %d already merged", index)
 	}
@@ -106,7 +114,7 @@ func (s *Signature) Merge(signer crypto.AggregateSigner, index int, sig crypto.S
 	}
 
 	var err error
-	s.agg, err = signer.Aggregate(s.agg, sig)
+	s.agg, err = aggregator.Aggregate(s.agg, sig)
 	if err != nil {
 		return xerrors.Errorf("couldn't aggregate: %v", err)
 	}
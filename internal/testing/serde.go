@@ -0,0 +1,28 @@
+package testing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/serde"
+)
+
+// RequireRoundTrip asserts that the message is decoded by the factory to an
+// equal value after being encoded in each of the contexts. The contexts are
+// expected to cover the formats registered for the message, so that an engine
+// that drops a field of the message is detected.
+func RequireRoundTrip(t *testing.T, msg serde.Message, fac serde.Factory, ctxs ...serde.Context) {
+	require.NotEmpty(t, ctxs, "no format to check")
+
+	for _, ctx := range ctxs {
+		format := ctx.GetFormat()
+
+		data, err := msg.Serialize(ctx)
+		require.NoError(t, err, "format %s", format)
+
+		decoded, err := fac.Deserialize(ctx, data)
+		require.NoError(t, err, "format %s", format)
+
+		require.Equal(t, msg, decoded, "format %s", format)
+	}
+}
@@ -41,6 +41,7 @@ type Minoch struct {
 	rpcs       map[string]*RPC
 	context    serde.Context
 	filters    []Filter
+	envelope   *serde.Envelope
 }
 
 // NewMinoch creates a new instance of a local Mino instance.
@@ -95,6 +96,28 @@ func (m *Minoch) AddFilter(filter Filter) {
 	}
 }
 
+// SetEnvelope enables the framing of the messages in the envelope. The
+// compression is negotiated with the capabilities of the peers, and the
+// messages are sent as is to the peers that don't use an envelope. This must be
+// called before sending or receiving messages.
+func (m *Minoch) SetEnvelope(envelope serde.Envelope) {
+	m.envelope = &envelope
+
+	for _, rpc := range m.rpcs {
+		rpc.envelope = m.envelope
+	}
+}
+
+// getCapabilities returns the capabilities of the envelope of the instance, or
+// empty ones when it doesn't use an envelope.
+func (m *Minoch) getCapabilities() serde.Capabilities {
+	if m.envelope == nil {
+		return serde.Capabilities{}
+	}
+
+	return m.envelope.GetCapabilities()
+}
+
 // WithSegment returns a new mino instance that will have its URI path extended
 // with the provided segment.
 func (m *Minoch) WithSegment(path string) mino.Mino {
@@ -103,6 +126,7 @@ func (m *Minoch) WithSegment(path string) mino.Mino {
 		identifier: m.identifier,
 		path:       fmt.Sprintf("%s/%s", m.path, path),
 		rpcs:       m.rpcs,
+		envelope:   m.envelope,
 	}
 
 	return newMinoch
@@ -111,13 +135,14 @@ func (m *Minoch) WithSegment(path string) mino.Mino {
 // CreateRPC creates an RPC that can send to and receive from the unique path.
 func (m *Minoch) CreateRPC(name string, h mino.Handler, f serde.Factory) (mino.RPC, error) {
 	rpc := &RPC{
-		manager: m.manager,
-		addr:    m.GetAddress(),
-		path:    fmt.Sprintf("%s/%s", m.path, name),
-		h:       h,
-		context: m.context,
-		factory: f,
-		filters: m.filters,
+		manager:  m.manager,
+		addr:     m.GetAddress(),
+		path:     fmt.Sprintf("%s/%s", m.path, name),
+		h:        h,
+		context:  m.context,
+		factory:  f,
+		filters:  m.filters,
+		envelope: m.envelope,
 	}
 
 	m.Lock()
@@ -57,6 +57,20 @@ func TestMinoch_AddFilter(t *testing.T) {
 	require.Len(t, rpc.(*RPC).filters, 1)
 }
 
+func TestMinoch_SetEnvelope(t *testing.T) {
+	manager := NewManager()
+
+	m := MustCreate(manager, "A")
+	require.Equal(t, serde.Capabilities{}, m.getCapabilities())
+
+	rpc := mino.MustCreateRPC(m, "test", nil, nil)
+
+	m.SetEnvelope(serde.NewEnvelope(m.context))
+	require.NotNil(t, m.envelope)
+	require.Equal(t, m.envelope, rpc.(*RPC).envelope)
+	require.Equal(t, serde.EnvelopeVersion, m.getCapabilities().Version)
+}
+
 func TestMinoch_WithSegment(t *testing.T) {
 	manager := NewManager()
 
@@ -25,6 +25,7 @@ type Envelope struct {
 	to      []mino.Address
 	from    address
 	message []byte
+	sealed  bool
 }
 
 // RPC implements a remote procedure call that is calling its peers using the
@@ -32,13 +33,14 @@ type Envelope struct {
 //
 // - implements mino.RPC
 type RPC struct {
-	manager *Manager
-	addr    mino.Address
-	path    string
-	h       mino.Handler
-	context serde.Context
-	factory serde.Factory
-	filters []Filter
+	manager  *Manager
+	addr     mino.Address
+	path     string
+	h        mino.Handler
+	context  serde.Context
+	factory  serde.Factory
+	filters  []Filter
+	envelope *serde.Envelope
 }
 
 // Call implements mino.RPC. It sends the message to all participants and
@@ -48,7 +50,7 @@ type RPC struct {
 func (c RPC) Call(ctx context.Context,
 	req serde.Message, players mino.Players) (<-chan mino.Response, error) {
 
-	data, err := req.Serialize(c.context)
+	data, sealed, err := seal(c.manager, c.envelope, c.context, req, players)
 	if err != nil {
 		return nil, xerrors.Errorf("couldn't serialize: %v", err)
 	}
@@ -72,7 +74,7 @@ func (c RPC) Call(ctx context.Context,
 
 			from := peer.GetAddress()
 
-			msg, err := c.factory.Deserialize(c.context, data)
+			msg, err := open(m.envelope, c.context, c.factory, data, sealed)
 			if err != nil {
 				resp := mino.NewResponseWithError(
 					from,
@@ -162,16 +164,19 @@ func (c RPC) Stream(ctx context.Context, memship mino.Players) (mino.Sender, min
 
 		ch := make(chan Envelope, bufSize*100)
 		outs[addr.String()] = receiver{
-			out:     ch,
-			context: c.context,
-			factory: c.factory,
+			out:      ch,
+			context:  c.context,
+			factory:  c.factory,
+			envelope: peer.envelope,
 		}
 
 		go func(r receiver) {
 			s := sender{
-				addr:    peer.GetAddress(),
-				in:      in,
-				context: c.context,
+				addr:     peer.GetAddress(),
+				in:       in,
+				context:  c.context,
+				manager:  c.manager,
+				envelope: peer.envelope,
 			}
 
 			err := peer.rpcs[c.path].h.Stream(s, r)
@@ -185,16 +190,19 @@ func (c RPC) Stream(ctx context.Context, memship mino.Players) (mino.Sender, min
 	orchAddr.orchestrator = true
 
 	orchSender := sender{
-		addr:    orchAddr,
-		in:      in,
-		context: c.context,
+		addr:     orchAddr,
+		in:       in,
+		context:  c.context,
+		manager:  c.manager,
+		envelope: c.envelope,
 	}
 
 	orchRecv := receiver{
-		out:     out,
-		errs:    errs,
-		context: c.context,
-		factory: c.factory,
+		out:      out,
+		errs:     errs,
+		context:  c.context,
+		factory:  c.factory,
+		envelope: c.envelope,
 	}
 
 	go func() {
@@ -234,9 +242,11 @@ func (c RPC) Stream(ctx context.Context, memship mino.Players) (mino.Sender, min
 //
 // - implements mino.Sender
 type sender struct {
-	addr    mino.Address
-	in      chan Envelope
-	context serde.Context
+	addr     mino.Address
+	in       chan Envelope
+	context  serde.Context
+	manager  *Manager
+	envelope *serde.Envelope
 }
 
 // Send implements mino.Sender. It sends the message to all the addresses and
@@ -246,7 +256,7 @@ type sender struct {
 func (s sender) Send(msg serde.Message, addrs ...mino.Address) <-chan error {
 	errs := make(chan error, int(math.Max(1, float64(len(addrs)))))
 
-	data, err := msg.Serialize(s.context)
+	data, sealed, err := seal(s.manager, s.envelope, s.context, msg, mino.NewAddresses(addrs...))
 	if err != nil {
 		errs <- xerrors.Errorf("couldn't marshal message: %v", err)
 		close(errs)
@@ -259,6 +269,7 @@ func (s sender) Send(msg serde.Message, addrs ...mino.Address) <-chan error {
 			from:    s.addr.(address),
 			to:      addrs,
 			message: data,
+			sealed:  sealed,
 		}
 		close(errs)
 	}()
@@ -270,10 +281,11 @@ func (s sender) Send(msg serde.Message, addrs ...mino.Address) <-chan error {
 //
 // - implements mino.Receiver
 type receiver struct {
-	out     chan Envelope
-	errs    chan error
-	context serde.Context
-	factory serde.Factory
+	out      chan Envelope
+	errs     chan error
+	context  serde.Context
+	factory  serde.Factory
+	envelope *serde.Envelope
 }
 
 // Recv implements mino.Receiver. It listens for messages until the context is
@@ -286,7 +298,7 @@ func (r receiver) Recv(ctx context.Context) (mino.Address, serde.Message, error)
 			return nil, nil, io.EOF
 		}
 
-		msg, err := r.factory.Deserialize(r.context, env.message)
+		msg, err := open(r.envelope, r.context, r.factory, env.message, env.sealed)
 		if err != nil {
 			return nil, nil, xerrors.Errorf("couldn't deserialize: %v", err)
 		}
@@ -298,3 +310,56 @@ func (r receiver) Recv(ctx context.Context) (mino.Address, serde.Message, error)
 		return nil, nil, ctx.Err()
 	}
 }
+
+// seal serializes the message for the players. It is sealed in the envelope
+// when every player uses one, with a compression that all of them can open,
+// otherwise the message is sent as is.
+func seal(manager *Manager, envelope *serde.Envelope, ctx serde.Context,
+	msg serde.Message, players mino.Players) ([]byte, bool, error) {
+
+	if envelope == nil {
+		data, err := msg.Serialize(ctx)
+		return data, false, err
+	}
+
+	caps := []serde.Capabilities{}
+
+	iter := players.AddressIterator()
+	for iter.HasNext() {
+		peer, err := manager.get(iter.GetNext())
+		if err != nil {
+			return nil, false, xerrors.Errorf("couldn't find peer: %v", err)
+		}
+
+		peerCaps := peer.getCapabilities()
+		if peerCaps.Version == 0 {
+			data, err := msg.Serialize(ctx)
+			return data, false, err
+		}
+
+		caps = append(caps, peerCaps)
+	}
+
+	data, err := envelope.Negotiate(caps...).Seal(ctx, msg)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return data, true, nil
+}
+
+// open deserializes the data of a message, which is first opened with the
+// envelope when it has been sealed.
+func open(envelope *serde.Envelope, ctx serde.Context, fac serde.Factory,
+	data []byte, sealed bool) (serde.Message, error) {
+
+	if !sealed {
+		return fac.Deserialize(ctx, data)
+	}
+
+	if envelope == nil {
+		return nil, xerrors.New("no envelope to open the message")
+	}
+
+	return envelope.Open(ctx, fac, data)
+}
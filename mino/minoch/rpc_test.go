@@ -140,6 +140,98 @@ func TestRPC_BadHandler_Call(t *testing.T) {
 	require.EqualError(t, err, "couldn't process request: rpc is not supported")
 }
 
+func TestRPC_Envelope_Call(t *testing.T) {
+	manager := NewManager()
+
+	mA := MustCreate(manager, "A")
+	rpcA := mino.MustCreateRPC(mA, "test", fakeHandler{}, fake.MessageFactory{})
+
+	mB := MustCreate(manager, "B")
+	mino.MustCreateRPC(mB, "test", fakeHandler{}, fake.MessageFactory{})
+
+	mC := MustCreate(manager, "C")
+	mino.MustCreateRPC(mC, "test", fakeHandler{}, fake.MessageFactory{})
+
+	mA.SetEnvelope(serde.NewEnvelope(mA.context).WithCompression(serde.GzipCompression))
+	mB.SetEnvelope(serde.NewEnvelope(mB.context))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The message is sealed for the peers that support the envelope, and sent
+	// as is when one of them doesn't.
+	for _, addrs := range []mino.Players{
+		mino.NewAddresses(mA.GetAddress(), mB.GetAddress()),
+		mino.NewAddresses(mA.GetAddress(), mC.GetAddress()),
+	} {
+		resps, err := rpcA.Call(ctx, fake.Message{}, addrs)
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			err = testWait(t, resps, nil)
+			require.NoError(t, err)
+		}
+	}
+
+	_, err := rpcA.Call(ctx, fake.Message{}, mino.NewAddresses(address{id: "D"}))
+	require.EqualError(t, err, "couldn't serialize: couldn't find peer: address <D> not found")
+}
+
+func TestRPC_Envelope_Stream(t *testing.T) {
+	manager := NewManager()
+
+	m := MustCreate(manager, "A")
+	rpc := mino.MustCreateRPC(m, "test", fakeStreamHandler{}, fake.MessageFactory{})
+
+	m.SetEnvelope(serde.NewEnvelope(m.context).WithCompression(serde.GzipCompression))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sender, receiver, err := rpc.Stream(ctx, mino.NewAddresses(m.GetAddress()))
+	require.NoError(t, err)
+
+	sender.Send(largeMessage{}, m.GetAddress())
+	_, _, err = receiver.Recv(ctx)
+	require.NoError(t, err)
+}
+
+func TestSeal(t *testing.T) {
+	manager := NewManager()
+
+	mA := MustCreate(manager, "A")
+	mB := MustCreate(manager, "B")
+	mC := MustCreate(manager, "C")
+
+	mA.SetEnvelope(serde.NewEnvelope(mA.context).WithCompression(serde.GzipCompression))
+	mB.SetEnvelope(serde.NewEnvelope(mB.context))
+
+	data, sealed, err := seal(manager, mA.envelope, mA.context, largeMessage{}, mino.NewAddresses(mB.GetAddress()))
+	require.NoError(t, err)
+	require.True(t, sealed)
+	require.Equal(t, serde.TagJSON|0x80, data[0])
+
+	msg, err := open(mB.envelope, mB.context, fake.MessageFactory{}, data, sealed)
+	require.NoError(t, err)
+	require.Equal(t, fake.Message{}, msg)
+
+	// A peer without an envelope receives the message as is.
+	players := mino.NewAddresses(mB.GetAddress(), mC.GetAddress())
+
+	data, sealed, err = seal(manager, mA.envelope, mA.context, largeMessage{}, players)
+	require.NoError(t, err)
+	require.False(t, sealed)
+	require.Equal(t, []byte(largeData), data)
+
+	data, sealed, err = seal(manager, nil, mC.context, largeMessage{}, nil)
+	require.NoError(t, err)
+	require.False(t, sealed)
+	require.Equal(t, []byte(largeData), data)
+
+	_, err = open(nil, mC.context, fake.MessageFactory{}, data, true)
+	require.EqualError(t, err, "no envelope to open the message")
+}
+
 func TestRPC_Stream(t *testing.T) {
 	manager := NewManager()
 
@@ -261,6 +353,14 @@ func testWait(t *testing.T, resps <-chan mino.Response, errs <-chan error) error
 	}
 }
 
+var largeData = strings.Repeat(`{"Block":{"Index":0}}`, 100)
+
+type largeMessage struct{}
+
+func (largeMessage) Serialize(serde.Context) ([]byte, error) {
+	return []byte(largeData), nil
+}
+
 type fakeStreamHandler struct {
 	mino.UnsupportedHandler
 }
@@ -95,3 +95,16 @@ func ListFilter(indices []int) FilterUpdater {
 		filters.Indices = indices
 	}
 }
+
+// SortFilter is a filter to sort the indices in ascending order so that the
+// elements are taken in the canonical order of the underlying data structure,
+// whatever the order of the previous filters. It should be used as the last
+// filter.
+func SortFilter() FilterUpdater {
+	return func(filters *Filter) {
+		indices := append([]int{}, filters.Indices...)
+		sort.Ints(indices)
+
+		filters.Indices = indices
+	}
+}
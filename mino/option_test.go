@@ -75,3 +75,15 @@ func TestFilter_ListFilter(t *testing.T) {
 	ListFilter([]int{3, 4, 7})(filters)
 	require.Equal(t, []int{3, 4, 7}, filters.Indices)
 }
+
+func TestFilter_SortFilter(t *testing.T) {
+	indices := []int{4, 1, 3}
+	filters := &Filter{Indices: indices}
+
+	SortFilter()(filters)
+	require.Equal(t, []int{1, 3, 4}, filters.Indices)
+	require.Equal(t, []int{4, 1, 3}, indices)
+
+	filters = ApplyFilters([]FilterUpdater{RangeFilter(0, 4), RotateFilter(2), SortFilter()})
+	require.Equal(t, []int{0, 1, 2, 3}, filters.Indices)
+}
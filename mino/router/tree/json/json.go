@@ -22,6 +22,7 @@ type PacketJSON struct {
 // HandshakeJSON is the JSON message for the handshake.
 type HandshakeJSON struct {
 	Height    int
+	Fanout    int `json:",omitempty"`
 	Addresses [][]byte
 }
 
@@ -122,6 +123,7 @@ func (hsFormat) Encode(ctx serde.Context, msg serde.Message) ([]byte, error) {
 
 	m := HandshakeJSON{
 		Height:    hs.GetHeight(),
+		Fanout:    hs.GetFanout(),
 		Addresses: addrs,
 	}
 
@@ -154,5 +156,5 @@ func (hsFormat) Decode(ctx serde.Context, data []byte) (serde.Message, error) {
 		addrs[i] = factory.FromText(raw)
 	}
 
-	return types.NewHandshake(m.Height, addrs...), nil
+	return types.NewFanoutHandshake(m.Height, m.Fanout, addrs...), nil
 }
@@ -62,6 +62,10 @@ func TestHandshakeFormat_Encode(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, `{"Height":5,"Addresses":["AQAAAA=="]}`, string(data))
 
+	data, err = fmt.Encode(ctx, types.NewFanoutHandshake(5, 2, fake.NewAddress(1)))
+	require.NoError(t, err)
+	require.Equal(t, `{"Height":5,"Fanout":2,"Addresses":["AQAAAA=="]}`, string(data))
+
 	_, err = fmt.Encode(ctx, fake.Message{})
 	require.EqualError(t, err, "unsupported message 'fake.Message'")
 
@@ -82,6 +86,10 @@ func TestHandshakeFormat_Decode(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, types.NewHandshake(0, fake.NewAddress(1)), msg)
 
+	msg, err = fmt.Decode(ctx, []byte(`{"Height":2,"Fanout":3,"Addresses":["AQAAAA=="]}`))
+	require.NoError(t, err)
+	require.Equal(t, types.NewFanoutHandshake(2, 3, fake.NewAddress(1)), msg)
+
 	_, err = fmt.Decode(fake.NewBadContext(), []byte(`{}`))
 	require.EqualError(t, err, fake.Err("failed to unmarshal"))
 
@@ -27,6 +27,7 @@ const defaultHeight = 3
 // - implements router.Router
 type Router struct {
 	maxHeight int
+	fanout    int
 	packetFac router.PacketFactory
 	hsFac     router.HandshakeFactory
 }
@@ -42,6 +43,15 @@ func WithHeight(maxHeight int) RouterOption {
 	}
 }
 
+// WithFanout allows to specify the maximum number of peers that a node forwards
+// a message to, the others being reached through them. It takes precedence over
+// the height, which becomes the minimum to reach every participant.
+func WithFanout(fanout int) RouterOption {
+	return func(r *Router) {
+		r.fanout = fanout
+	}
+}
+
 // NewRouter returns a new router
 func NewRouter(f mino.AddressFactory, options ...RouterOption) Router {
 	fac := types.NewPacketFactory(f)
@@ -80,6 +90,10 @@ func (r Router) New(players mino.Players, _ mino.Address) (router.RoutingTable,
 		addrs = append(addrs, iter.GetNext())
 	}
 
+	if r.fanout > 0 {
+		return NewFanoutTable(r.fanout, addrs), nil
+	}
+
 	return NewTable(r.maxHeight, addrs), nil
 }
 
@@ -88,6 +102,10 @@ func (r Router) New(players mino.Players, _ mino.Address) (router.RoutingTable,
 func (r Router) GenerateTableFrom(h router.Handshake) (router.RoutingTable, error) {
 	treeH := h.(types.Handshake)
 
+	if treeH.GetFanout() > 0 {
+		return NewFanoutTable(treeH.GetFanout(), treeH.GetAddresses()), nil
+	}
+
 	return NewTable(treeH.GetHeight(), treeH.GetAddresses()), nil
 }
 
@@ -96,7 +114,8 @@ func (r Router) GenerateTableFrom(h router.Handshake) (router.RoutingTable, erro
 //
 // - implements router.RoutingTable
 type Table struct {
-	tree Tree
+	tree   Tree
+	fanout int
 }
 
 // NewTable creates a new routing table for the given addresses.
@@ -106,6 +125,15 @@ func NewTable(height int, expected []mino.Address) Table {
 	}
 }
 
+// NewFanoutTable creates a new routing table for the given addresses where each
+// node forwards to at most the given number of peers.
+func NewFanoutTable(fanout int, expected []mino.Address) Table {
+	return Table{
+		tree:   NewFanoutTree(fanout, expected),
+		fanout: fanout,
+	}
+}
+
 // Make implements router.RoutingTable. It creates a packet with the source
 // address, the destination addresses and the payload.
 func (t Table) Make(src mino.Address, to []mino.Address, msg []byte) router.Packet {
@@ -118,7 +146,7 @@ func (t Table) Make(src mino.Address, to []mino.Address, msg []byte) router.Pack
 func (t Table) PrepareHandshakeFor(to mino.Address) router.Handshake {
 	newHeight := t.tree.GetMaxHeight() - 1
 
-	return types.NewHandshake(newHeight, t.tree.GetChildren(to)...)
+	return types.NewFanoutHandshake(newHeight, t.fanout, t.tree.GetChildren(to)...)
 }
 
 // Forward implements router.RoutingTable. It takes a packet and split it into
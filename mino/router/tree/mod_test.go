@@ -76,6 +76,72 @@ func TestRouter_OptionWithHeight(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestRouter_OptionWithFanout(t *testing.T) {
+	router := NewRouter(fake.AddressFactory{}, WithFanout(3))
+
+	table, err := router.New(mino.NewAddresses(makeAddrs(20)...), nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, table.(Table).fanout)
+	require.Equal(t, 3, table.(Table).tree.(*dynTree).m)
+
+	hs := table.PrepareHandshakeFor(fake.NewAddress(0))
+	require.Equal(t, 3, hs.(types.Handshake).GetFanout())
+
+	child, err := router.GenerateTableFrom(hs)
+	require.NoError(t, err)
+	require.Equal(t, 3, child.(Table).fanout)
+}
+
+func TestRouter_Fanout_Relay(t *testing.T) {
+	const n = 50
+	const fanout = 3
+
+	router := NewRouter(fake.AddressFactory{}, WithFanout(fanout))
+
+	addrs := makeAddrs(n)
+
+	table, err := router.New(mino.NewAddresses(addrs...), nil)
+	require.NoError(t, err)
+
+	received := make(map[mino.Address]int)
+
+	// Each node that receives the message forwards it to the destinations it
+	// routes, with the routing table built from the handshake of its parent.
+	var relay func(table minoRouter.RoutingTable, dest []mino.Address)
+	relay = func(table minoRouter.RoutingTable, dest []mino.Address) {
+		routes, voids := table.Forward(types.NewPacket(fake.NewAddress(n), []byte{1}, dest...))
+		require.Empty(t, voids)
+		require.LessOrEqual(t, len(routes), fanout)
+
+		for gateway, packet := range routes {
+			received[gateway]++
+
+			rest := make([]mino.Address, 0, len(packet.GetDestination()))
+			for _, addr := range packet.GetDestination() {
+				if !addr.Equal(gateway) {
+					rest = append(rest, addr)
+				}
+			}
+
+			if len(rest) == 0 {
+				continue
+			}
+
+			next, err := router.GenerateTableFrom(table.PrepareHandshakeFor(gateway))
+			require.NoError(t, err)
+
+			relay(next, rest)
+		}
+	}
+
+	relay(table, addrs)
+
+	require.Len(t, received, n)
+	for _, count := range received {
+		require.Equal(t, 1, count)
+	}
+}
+
 func TestRouter_GenerateTableFrom(t *testing.T) {
 	router := NewRouter(fake.AddressFactory{})
 
@@ -96,6 +96,20 @@ func NewTree(height int, addrs []mino.Address) Tree {
 	// ... but we use a minimal value to avoid unnecessary deep trees.
 	m = math.Max(m, minNumChildren)
 
+	return newDynTree(height, int(m), addrs)
+}
+
+// NewFanoutTree creates a new empty tree where each node has at most the given
+// number of branches. The height is the minimum to route all the addresses.
+func NewFanoutTree(fanout int, addrs []mino.Address) Tree {
+	if fanout < 1 {
+		fanout = 1
+	}
+
+	return newDynTree(fanoutHeight(fanout, len(addrs)), fanout, addrs)
+}
+
+func newDynTree(height, m int, addrs []mino.Address) *dynTree {
 	expected := make(AddrSet)
 	for _, addr := range addrs {
 		expected[addr] = struct{}{}
@@ -103,13 +117,29 @@ func NewTree(height int, addrs []mino.Address) Tree {
 
 	return &dynTree{
 		height:   height,
-		m:        int(m),
+		m:        m,
 		branches: make(Branches),
 		expected: expected,
 		offline:  make(AddrSet),
 	}
 }
 
+// fanoutHeight returns the minimum height of a tree where each node has the
+// given number of branches so that it holds n nodes below the root.
+func fanoutHeight(fanout, n int) int {
+	height := 1
+	level := fanout
+	total := fanout
+
+	for total < n {
+		level *= fanout
+		total += level
+		height++
+	}
+
+	return height
+}
+
 // GetMaxHeight implements tree.Tree. It returns the maximum depth for this
 // tree.
 func (t *dynTree) GetMaxHeight() int {
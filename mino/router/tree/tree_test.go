@@ -32,6 +32,25 @@ func TestBranches_Search(t *testing.T) {
 	require.Nil(t, branches.Search(fake.NewAddress(5)))
 }
 
+func TestNewFanoutTree(t *testing.T) {
+	tree := NewFanoutTree(3, makeAddrs(12)).(*dynTree)
+	require.Equal(t, 3, tree.m)
+	require.Equal(t, 2, tree.GetMaxHeight())
+
+	tree = NewFanoutTree(0, makeAddrs(3)).(*dynTree)
+	require.Equal(t, 1, tree.m)
+	require.Equal(t, 3, tree.GetMaxHeight())
+}
+
+func TestFanoutHeight(t *testing.T) {
+	require.Equal(t, 1, fanoutHeight(2, 0))
+	require.Equal(t, 1, fanoutHeight(2, 2))
+	require.Equal(t, 2, fanoutHeight(2, 3))
+	require.Equal(t, 2, fanoutHeight(2, 6))
+	require.Equal(t, 3, fanoutHeight(2, 7))
+	require.Equal(t, 4, fanoutHeight(1, 4))
+}
+
 func TestDynTree_GetMaxHeight(t *testing.T) {
 	tree := NewTree(3, makeAddrs(4))
 
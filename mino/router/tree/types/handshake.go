@@ -21,6 +21,7 @@ var hsFormats = registry.NewSimpleRegistry()
 // - implements serde.Message
 type Handshake struct {
 	height   int
+	fanout   int
 	expected []mino.Address
 }
 
@@ -32,11 +33,27 @@ func NewHandshake(height int, expected ...mino.Address) Handshake {
 	}
 }
 
+// NewFanoutHandshake returns a new handshake message of a routing table where
+// each node forwards to at most the given number of peers.
+func NewFanoutHandshake(height, fanout int, expected ...mino.Address) Handshake {
+	return Handshake{
+		height:   height,
+		fanout:   fanout,
+		expected: expected,
+	}
+}
+
 // GetHeight returns the maximum height of the tree.
 func (h Handshake) GetHeight() int {
 	return h.height
 }
 
+// GetFanout returns the maximum number of peers that a node forwards to, or
+// zero when the width of the tree is derived from the height.
+func (h Handshake) GetFanout() int {
+	return h.fanout
+}
+
 // GetAddresses returns the list of addresses to route.
 func (h Handshake) GetAddresses() []mino.Address {
 	return h.expected
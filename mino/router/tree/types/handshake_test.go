@@ -20,6 +20,11 @@ func TestHandshake_GetHeight(t *testing.T) {
 	require.Equal(t, 3, hs.GetHeight())
 }
 
+func TestHandshake_GetFanout(t *testing.T) {
+	require.Equal(t, 0, NewHandshake(3).GetFanout())
+	require.Equal(t, 2, NewFanoutHandshake(3, 2).GetFanout())
+}
+
 func TestHandshake_GetAddresses(t *testing.T) {
 	hs := NewHandshake(3, makeAddrs(5)...)
 
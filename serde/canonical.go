@@ -0,0 +1,168 @@
+package serde
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// CanonicalMap is a map of bytes that is always encoded in the order of its
+// keys, so that the serialization of a message that contains one is
+// deterministic whatever the order of iteration of the map.
+type CanonicalMap map[string][]byte
+
+// Keys returns the keys of the map in ascending order.
+func (m CanonicalMap) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// MarshalJSON implements json.Marshaler. It returns the JSON object of the map
+// with the entries sorted by key.
+func (m CanonicalMap) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+
+	buffer := new(bytes.Buffer)
+	buffer.WriteByte('{')
+
+	for i, key := range m.Keys() {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := json.Marshal(m[key])
+		if err != nil {
+			return nil, err
+		}
+
+		buffer.Write(k)
+		buffer.WriteByte(':')
+		buffer.Write(v)
+	}
+
+	buffer.WriteByte('}')
+
+	return buffer.Bytes(), nil
+}
+
+var canonicalMapType = reflect.TypeOf(CanonicalMap(nil))
+
+// MapWarner is the function called with the path of a map found in a message
+// marshaled by a checked context.
+type MapWarner func(path string)
+
+// WithMapCheck returns a copy of the context that reports the maps of the
+// messages that it marshals. The iteration of a map is random, which means
+// that an engine or a message that doesn't sort the entries produces a
+// different serialization on each node. It is meant to be used as a debug mode
+// as the messages are walked before each marshaling.
+func WithMapCheck(ctx Context, warn MapWarner) Context {
+	checker := mapChecker{
+		ContextEngine: ctx.ContextEngine,
+		warn:          warn,
+	}
+
+	framed, ok := ctx.ContextEngine.(FramedEngine)
+	if ok {
+		ctx.ContextEngine = framedMapChecker{mapChecker: checker, tag: framed.GetTag()}
+	} else {
+		ctx.ContextEngine = checker
+	}
+
+	return ctx
+}
+
+// FindMaps returns the paths of the maps of the value, except the canonical
+// ones.
+func FindMaps(value interface{}) []string {
+	var paths []string
+
+	findMaps(reflect.ValueOf(value), "$", &paths)
+
+	return paths
+}
+
+func findMaps(value reflect.Value, path string, paths *[]string) {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !value.IsNil() {
+			findMaps(value.Elem(), path, paths)
+		}
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			if field.IsExported() {
+				findMaps(value.Field(i), path+"."+field.Name, paths)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			// Bytes and raw messages are opaque.
+			return
+		}
+
+		for i := 0; i < value.Len(); i++ {
+			findMaps(value.Index(i), fmt.Sprintf("%s[%d]", path, i), paths)
+		}
+	case reflect.Map:
+		if value.Type() != canonicalMapType {
+			*paths = append(*paths, path)
+		}
+
+		iter := value.MapRange()
+		for iter.Next() {
+			findMaps(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key()), paths)
+		}
+	}
+}
+
+// mapChecker is a context engine that reports the maps of the messages before
+// marshaling them with the underlying engine.
+//
+// - implements serde.ContextEngine
+type mapChecker struct {
+	ContextEngine
+
+	warn MapWarner
+}
+
+// Marshal implements serde.ContextEngine. It reports the maps of the message
+// and returns its marshaled data.
+func (c mapChecker) Marshal(message interface{}) ([]byte, error) {
+	for _, path := range FindMaps(message) {
+		c.warn(path)
+	}
+
+	return c.ContextEngine.Marshal(message)
+}
+
+// framedMapChecker is a map checker of an engine that supports the envelope
+// framing.
+//
+// - implements serde.FramedEngine
+type framedMapChecker struct {
+	mapChecker
+
+	tag byte
+}
+
+// GetTag implements serde.FramedEngine. It returns the tag of the underlying
+// engine.
+func (c framedMapChecker) GetTag() byte {
+	return c.tag
+}
@@ -0,0 +1,114 @@
+package serde
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalMap_Keys(t *testing.T) {
+	m := CanonicalMap{"c": nil, "a": nil, "b": nil}
+
+	require.Equal(t, []string{"a", "b", "c"}, m.Keys())
+	require.Empty(t, CanonicalMap(nil).Keys())
+}
+
+func TestCanonicalMap_MarshalJSON(t *testing.T) {
+	m := CanonicalMap{}
+	for _, key := range []string{"z", "b", "y", "a", "x", "c", "\"q\""} {
+		m[key] = []byte(key)
+	}
+
+	expected, err := json.Marshal(map[string][]byte(m))
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		data, err := m.MarshalJSON()
+		require.NoError(t, err)
+		require.Equal(t, string(expected), string(data))
+	}
+
+	data, err := json.Marshal(struct{ Args CanonicalMap }{})
+	require.NoError(t, err)
+	require.Equal(t, `{"Args":null}`, string(data))
+
+	data, err = json.Marshal(struct{ Args CanonicalMap }{Args: CanonicalMap{}})
+	require.NoError(t, err)
+	require.Equal(t, `{"Args":{}}`, string(data))
+}
+
+func TestFindMaps(t *testing.T) {
+	type inner struct {
+		Values map[string]int
+	}
+
+	type message struct {
+		Args    CanonicalMap
+		Raw     json.RawMessage
+		Inner   *inner
+		List    []inner
+		Any     interface{}
+		private map[string]int
+	}
+
+	msg := message{
+		Args:    CanonicalMap{"a": nil},
+		Raw:     json.RawMessage(`{}`),
+		Inner:   &inner{Values: map[string]int{}},
+		List:    []inner{{}, {Values: map[string]int{}}},
+		Any:     map[string]inner{"k": {Values: map[string]int{}}},
+		private: map[string]int{},
+	}
+
+	require.Equal(t, []string{
+		"$.Inner.Values",
+		"$.List[0].Values",
+		"$.List[1].Values",
+		"$.Any",
+		"$.Any[k].Values",
+	}, FindMaps(msg))
+
+	require.Empty(t, FindMaps(nil))
+	require.Empty(t, FindMaps(struct{ Args CanonicalMap }{}))
+}
+
+func TestContext_WithMapCheck(t *testing.T) {
+	var paths []string
+	warn := func(path string) {
+		paths = append(paths, path)
+	}
+
+	ctx := WithMapCheck(NewContext(jsonEngine{}), warn)
+	require.Equal(t, Format("plain"), ctx.GetFormat())
+
+	_, isFramed := ctx.ContextEngine.(FramedEngine)
+	require.False(t, isFramed)
+
+	data, err := ctx.Marshal(struct{ Args map[string]int }{Args: map[string]int{"b": 2, "a": 1}})
+	require.NoError(t, err)
+	require.Equal(t, `{"Args":{"a":1,"b":2}}`, string(data))
+	require.Equal(t, []string{"$.Args"}, paths)
+
+	paths = nil
+	_, err = ctx.Marshal(struct{ Args CanonicalMap }{Args: CanonicalMap{"a": nil}})
+	require.NoError(t, err)
+	require.Empty(t, paths)
+
+	ctx = WithMapCheck(NewContext(fakeEngine{tag: 3}), warn)
+
+	framed, isFramed := ctx.ContextEngine.(FramedEngine)
+	require.True(t, isFramed)
+	require.Equal(t, byte(3), framed.GetTag())
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+type jsonEngine struct {
+	plainEngine
+}
+
+func (jsonEngine) Marshal(m interface{}) ([]byte, error) {
+	return json.Marshal(m)
+}
@@ -23,6 +23,7 @@ type Context struct {
 	ContextEngine
 
 	factories map[interface{}]Factory
+	sizes     SizeSink
 }
 
 // NewContext returns a new empty context.
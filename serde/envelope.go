@@ -0,0 +1,238 @@
+package serde
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+
+	"golang.org/x/xerrors"
+)
+
+// Compression is the algorithm used to compress the sealed messages.
+type Compression int
+
+const (
+	// NoCompression leaves the sealed messages as is.
+	NoCompression Compression = iota
+
+	// GzipCompression compresses the sealed messages with gzip.
+	GzipCompression
+)
+
+const (
+	// compressedFlag is set on the tag of an envelope whose message is
+	// compressed.
+	compressedFlag byte = 0x80
+
+	// MaxDecompressedSize is the maximum size in bytes of a compressed message
+	// once decompressed, so that a small envelope can't exhaust the memory.
+	MaxDecompressedSize = 64 << 20
+
+	// EnvelopeVersion is the version of the framing of the envelopes that is
+	// announced to the peers.
+	EnvelopeVersion byte = 1
+)
+
+// Capabilities are the features of the envelopes that a peer supports. They
+// are exchanged with the peers so that a message is only sealed in a way that
+// the receiver can open.
+type Capabilities struct {
+	// Version is the version of the framing, or zero when the peer doesn't
+	// support the envelopes.
+	Version byte
+
+	// Compressions are the algorithms that the peer can decompress.
+	Compressions []Compression
+}
+
+// Supports returns true if the peer can open the envelopes compressed with the
+// algorithm.
+func (c Capabilities) Supports(compression Compression) bool {
+	if c.Version == 0 {
+		return false
+	}
+
+	if compression == NoCompression {
+		return true
+	}
+
+	for _, algo := range c.Compressions {
+		if algo == compression {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FramedEngine is the interface that a context engine implements to opt into
+// the envelope framing.
+type FramedEngine interface {
+	ContextEngine
+
+	// GetTag returns the byte that identifies the format in an envelope.
+	GetTag() byte
+}
+
+// Envelope is a self-describing framing of the messages. The serialized
+// message is prefixed with the tag of the format so that a receiver can detect
+// the format without prior knowledge. The message can optionally be compressed
+// as a whole, which is also detected by the receiver, and the compression is
+// negotiated with the capabilities of the peers.
+type Envelope struct {
+	engines     map[byte]FramedEngine
+	compression Compression
+}
+
+// NewEnvelope creates a new envelope that can open the messages of the formats
+// of the given contexts. Contexts whose engine does not support the framing
+// are ignored.
+func NewEnvelope(contexts ...Context) Envelope {
+	engines := make(map[byte]FramedEngine)
+
+	for _, ctx := range contexts {
+		engine, ok := ctx.ContextEngine.(FramedEngine)
+		if ok {
+			engines[engine.GetTag()] = engine
+		}
+	}
+
+	return Envelope{
+		engines: engines,
+	}
+}
+
+// WithCompression returns a copy of the envelope that compresses the messages
+// it seals with the given algorithm. A message is left uncompressed when the
+// compression does not reduce its size. Any envelope opens compressed messages.
+func (e Envelope) WithCompression(compression Compression) Envelope {
+	e.compression = compression
+	return e
+}
+
+// GetCapabilities returns the capabilities to announce to the peers. Any
+// envelope opens the compressed messages, whatever the compression it uses to
+// seal them.
+func (e Envelope) GetCapabilities() Capabilities {
+	return Capabilities{
+		Version:      EnvelopeVersion,
+		Compressions: []Compression{GzipCompression},
+	}
+}
+
+// Negotiate returns a copy of the envelope that seals the messages so that all
+// the peers can open them. The compression falls back to NoCompression when one
+// of the peers doesn't support it.
+func (e Envelope) Negotiate(peers ...Capabilities) Envelope {
+	for _, peer := range peers {
+		if !peer.Supports(e.compression) {
+			e.compression = NoCompression
+		}
+	}
+
+	return e
+}
+
+// Seal serializes the message according to the format of the context and
+// prefixes the data with the tag of the format.
+func (e Envelope) Seal(ctx Context, msg Message) ([]byte, error) {
+	engine, ok := ctx.ContextEngine.(FramedEngine)
+	if !ok {
+		return nil, xerrors.Errorf("format '%s' does not support envelopes", ctx.GetFormat())
+	}
+
+	data, err := msg.Serialize(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't serialize message: %v", err)
+	}
+
+	tag := engine.GetTag()
+
+	if e.compression == GzipCompression {
+		compressed, err := compress(data)
+		if err != nil {
+			return nil, xerrors.Errorf("couldn't compress message: %v", err)
+		}
+
+		if len(compressed) < len(data) {
+			data = compressed
+			tag |= compressedFlag
+		}
+	}
+
+	return append([]byte{tag}, data...), nil
+}
+
+// Open detects the format of the envelope and deserializes the message with
+// the factory. The factories of the context are kept while the format is
+// replaced by the detected one.
+func (e Envelope) Open(ctx Context, fac Factory, data []byte) (Message, error) {
+	if len(data) == 0 {
+		return nil, xerrors.New("empty envelope")
+	}
+
+	tag, payload := data[0], data[1:]
+
+	if tag&compressedFlag != 0 {
+		var err error
+
+		payload, err = decompress(payload)
+		if err != nil {
+			return nil, xerrors.Errorf("couldn't decompress message: %v", err)
+		}
+
+		tag &^= compressedFlag
+	}
+
+	engine, found := e.engines[tag]
+	if !found {
+		return nil, xerrors.Errorf("unknown format tag %#x", tag)
+	}
+
+	ctx.ContextEngine = engine
+
+	msg, err := fac.Deserialize(ctx, payload)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't deserialize message: %v", err)
+	}
+
+	return msg, nil
+}
+
+func compress(data []byte) ([]byte, error) {
+	buffer := new(bytes.Buffer)
+
+	w := gzip.NewWriter(buffer)
+
+	_, err := w.Write(data)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.Close()
+
+	out, err := io.ReadAll(io.LimitReader(r, MaxDecompressedSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(out) > MaxDecompressedSize {
+		return nil, xerrors.Errorf("message exceeds %d bytes", MaxDecompressedSize)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,204 @@
+package serde
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+func TestEnvelope_New(t *testing.T) {
+	envelope := NewEnvelope(NewContext(fakeEngine{tag: 1}), NewContext(fakeEngine{tag: 2}))
+	require.Len(t, envelope.engines, 2)
+
+	envelope = NewEnvelope(NewContext(plainEngine{}))
+	require.Len(t, envelope.engines, 0)
+}
+
+func TestEnvelope_Seal(t *testing.T) {
+	envelope := NewEnvelope()
+
+	data, err := envelope.Seal(NewContext(fakeEngine{tag: 1}), fakeMessage{})
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 'A', 'B'}, data)
+
+	_, err = envelope.Seal(NewContext(plainEngine{}), fakeMessage{})
+	require.EqualError(t, err, "format 'plain' does not support envelopes")
+
+	_, err = envelope.Seal(NewContext(fakeEngine{tag: 1}), fakeMessage{err: xerrors.New("oops")})
+	require.EqualError(t, err, "couldn't serialize message: oops")
+}
+
+func TestEnvelope_Open(t *testing.T) {
+	envelope := NewEnvelope(NewContext(fakeEngine{tag: 1}), NewContext(fakeEngine{tag: 2}))
+
+	ctx := WithFactory(NewContext(plainEngine{}), testKey{}, fakeFactory{})
+
+	msg, err := envelope.Open(ctx, formatFactory{}, []byte{2, 'A'})
+	require.NoError(t, err)
+	require.Equal(t, formatMessage{tag: 2, data: "A", factory: true}, msg)
+
+	_, err = envelope.Open(ctx, formatFactory{}, nil)
+	require.EqualError(t, err, "empty envelope")
+
+	_, err = envelope.Open(ctx, formatFactory{}, []byte{3})
+	require.EqualError(t, err, "unknown format tag 0x3")
+
+	_, err = envelope.Open(ctx, formatFactory{err: xerrors.New("oops")}, []byte{1})
+	require.EqualError(t, err, "couldn't deserialize message: oops")
+}
+
+func TestEnvelope_Compression(t *testing.T) {
+	envelope := NewEnvelope(NewContext(fakeEngine{tag: 1})).WithCompression(GzipCompression)
+
+	ctx := NewContext(fakeEngine{tag: 1})
+
+	batch := batchMessage{data: bytes.Repeat([]byte(`{"Block":{"Index":0}}`), 100)}
+
+	plain, err := NewEnvelope().Seal(ctx, batch)
+	require.NoError(t, err)
+
+	data, err := envelope.Seal(ctx, batch)
+	require.NoError(t, err)
+	require.Equal(t, byte(1)|compressedFlag, data[0])
+	require.Less(t, len(data), len(plain))
+
+	msg, err := envelope.Open(ctx, formatFactory{}, data)
+	require.NoError(t, err)
+	require.Equal(t, formatMessage{tag: 1, data: string(batch.data)}, msg)
+
+	// The receiver detects the compression whatever its configuration.
+	msg, err = NewEnvelope(ctx).Open(ctx, formatFactory{}, data)
+	require.NoError(t, err)
+	require.Equal(t, formatMessage{tag: 1, data: string(batch.data)}, msg)
+
+	// A message that doesn't shrink is left uncompressed.
+	data, err = envelope.Seal(ctx, fakeMessage{})
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 'A', 'B'}, data)
+}
+
+func TestCapabilities_Supports(t *testing.T) {
+	caps := NewEnvelope().GetCapabilities()
+	require.Equal(t, EnvelopeVersion, caps.Version)
+	require.True(t, caps.Supports(NoCompression))
+	require.True(t, caps.Supports(GzipCompression))
+
+	caps = Capabilities{Version: EnvelopeVersion}
+	require.True(t, caps.Supports(NoCompression))
+	require.False(t, caps.Supports(GzipCompression))
+
+	require.False(t, Capabilities{}.Supports(NoCompression))
+}
+
+func TestEnvelope_Negotiate(t *testing.T) {
+	envelope := NewEnvelope(NewContext(fakeEngine{tag: 1})).WithCompression(GzipCompression)
+
+	ctx := NewContext(fakeEngine{tag: 1})
+
+	batch := batchMessage{data: bytes.Repeat([]byte(`{"Block":{"Index":0}}`), 100)}
+
+	// The compression is kept when every peer supports it.
+	data, err := envelope.Negotiate(envelope.GetCapabilities()).Seal(ctx, batch)
+	require.NoError(t, err)
+	require.Equal(t, byte(1)|compressedFlag, data[0])
+
+	// The envelope falls back to uncompressed messages for a peer that
+	// doesn't announce the compression.
+	negotiated := envelope.Negotiate(envelope.GetCapabilities(), Capabilities{Version: EnvelopeVersion})
+	require.Equal(t, NoCompression, negotiated.compression)
+
+	data, err = negotiated.Seal(ctx, batch)
+	require.NoError(t, err)
+	require.Equal(t, append([]byte{1}, batch.data...), data)
+}
+
+func TestEnvelope_BadCompression_Open(t *testing.T) {
+	envelope := NewEnvelope(NewContext(fakeEngine{tag: 1}))
+
+	ctx := NewContext(plainEngine{})
+
+	_, err := envelope.Open(ctx, formatFactory{}, []byte{1 | compressedFlag, 'A'})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "couldn't decompress message: ")
+
+	data, err := compress(make([]byte, MaxDecompressedSize+1))
+	require.NoError(t, err)
+
+	_, err = envelope.Open(ctx, formatFactory{}, append([]byte{1 | compressedFlag}, data...))
+	require.EqualError(t, err,
+		fmt.Sprintf("couldn't decompress message: message exceeds %d bytes", MaxDecompressedSize))
+
+	data, err = compress([]byte("A"))
+	require.NoError(t, err)
+
+	_, err = envelope.Open(ctx, formatFactory{}, append([]byte{3 | compressedFlag}, data...))
+	require.EqualError(t, err, "unknown format tag 0x3")
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+type plainEngine struct {
+	ContextEngine
+}
+
+func (plainEngine) GetFormat() Format {
+	return Format("plain")
+}
+
+type fakeEngine struct {
+	plainEngine
+
+	tag byte
+}
+
+func (e fakeEngine) GetTag() byte {
+	return e.tag
+}
+
+type fakeMessage struct {
+	err error
+}
+
+func (m fakeMessage) Serialize(ctx Context) ([]byte, error) {
+	return []byte("AB"), m.err
+}
+
+type batchMessage struct {
+	data []byte
+}
+
+func (m batchMessage) Serialize(ctx Context) ([]byte, error) {
+	return m.data, nil
+}
+
+type formatMessage struct {
+	tag     byte
+	data    string
+	factory bool
+}
+
+func (formatMessage) Serialize(ctx Context) ([]byte, error) {
+	return nil, nil
+}
+
+type formatFactory struct {
+	err error
+}
+
+func (f formatFactory) Deserialize(ctx Context, data []byte) (Message, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	msg := formatMessage{
+		tag:     ctx.ContextEngine.(FramedEngine).GetTag(),
+		data:    string(data),
+		factory: ctx.GetFactory(testKey{}) != nil,
+	}
+
+	return msg, nil
+}
@@ -59,6 +59,36 @@ func ExampleFactory_Deserialize() {
 	// {value:12}
 }
 
+func ExampleEnvelope_Open() {
+	exampleRegistry.Register(serde.FormatJSON, exampleJSONFormat{})
+	exampleRegistry.Register(serde.FormatXML, exampleXMLFormat{})
+
+	// The envelope can open messages of both formats.
+	envelope := serde.NewEnvelope(json.NewContext(), xml.NewContext())
+
+	msg := exampleMessage{
+		value: 42,
+	}
+
+	for _, ctx := range []serde.Context{json.NewContext(), xml.NewContext()} {
+		data, err := envelope.Seal(ctx, msg)
+		if err != nil {
+			panic("seal failed: " + err.Error())
+		}
+
+		// The receiver does not know the format used by the sender.
+		res, err := envelope.Open(serde.Context{}, exampleFactory{}, data)
+		if err != nil {
+			panic("open failed: " + err.Error())
+		}
+
+		fmt.Printf("%#x %+v\n", data[0], res)
+	}
+
+	// Output: 0x1 {value:42}
+	// 0x2 {value:42}
+}
+
 var exampleRegistry = registry.NewSimpleRegistry()
 
 // exampleMessage is the data model for a message example.
@@ -25,7 +25,7 @@ import (
 
 // JSONEngine is a context engine to marshal and unmarshal in JSON format.
 //
-// - implements serde.ContextEngine
+// - implements serde.FramedEngine
 type jsonEngine struct{}
 
 // NewContext returns a JSON context.
@@ -38,6 +38,12 @@ func (ctx jsonEngine) GetFormat() serde.Format {
 	return serde.FormatJSON
 }
 
+// GetTag implements serde.FramedEngine. It returns the envelope tag of the
+// JSON format.
+func (ctx jsonEngine) GetTag() byte {
+	return serde.TagJSON
+}
+
 // Marshal implements serde.FormatEngine. It returns the bytes of the message
 // marshaled in JSON format.
 func (ctx jsonEngine) Marshal(m interface{}) ([]byte, error) {
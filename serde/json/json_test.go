@@ -13,6 +13,11 @@ func TestJSONEngine_GetFormat(t *testing.T) {
 	require.Equal(t, serde.FormatJSON, ctx.GetFormat())
 }
 
+func TestJSONEngine_GetTag(t *testing.T) {
+	ctx := NewContext()
+	require.Equal(t, serde.TagJSON, ctx.ContextEngine.(serde.FramedEngine).GetTag())
+}
+
 func TestJSONEngine_Marshal(t *testing.T) {
 	ctx := NewContext()
 
@@ -0,0 +1,67 @@
+// This file contains the implementation of a registry of message types to
+// unpack the messages whose type is only known at runtime.
+
+package registry
+
+import (
+	"go.dedis.ch/dela/serde"
+	"golang.org/x/xerrors"
+)
+
+// Any is a message packed alongside the URL of its type, so that a receiver can
+// decode it without knowing the type in advance, like a proposal of which the
+// concrete type depends on the application.
+type Any struct {
+	TypeURL string
+	Value   []byte
+}
+
+// TypeRegistry resolves the type URL of a packed message to the factory of the
+// type. The types are expected to be registered during the initialization as
+// the registry is not thread-safe.
+type TypeRegistry struct {
+	factories map[string]serde.Factory
+}
+
+// NewTypeRegistry returns a new empty registry of types.
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{
+		factories: make(map[string]serde.Factory),
+	}
+}
+
+// Register registers the factory of the type identified by the URL.
+func (r *TypeRegistry) Register(url string, fac serde.Factory) {
+	r.factories[url] = fac
+}
+
+// Pack serializes the message and packs it with the URL of its type. The type
+// must be registered so that the receivers can unpack it.
+func (r *TypeRegistry) Pack(ctx serde.Context, url string, msg serde.Message) (Any, error) {
+	_, found := r.factories[url]
+	if !found {
+		return Any{}, xerrors.Errorf("unknown type '%s'", url)
+	}
+
+	data, err := msg.Serialize(ctx)
+	if err != nil {
+		return Any{}, xerrors.Errorf("failed to serialize: %v", err)
+	}
+
+	return Any{TypeURL: url, Value: data}, nil
+}
+
+// Unpack deserializes the packed message with the factory of its type.
+func (r *TypeRegistry) Unpack(ctx serde.Context, packed Any) (serde.Message, error) {
+	fac, found := r.factories[packed.TypeURL]
+	if !found {
+		return nil, xerrors.Errorf("unknown type '%s'", packed.TypeURL)
+	}
+
+	msg, err := fac.Deserialize(ctx, packed.Value)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to deserialize '%s': %v", packed.TypeURL, err)
+	}
+
+	return msg, nil
+}
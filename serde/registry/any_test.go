@@ -0,0 +1,101 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/dela/internal/testing/fake"
+	"go.dedis.ch/dela/serde"
+	"golang.org/x/xerrors"
+)
+
+func TestTypeRegistry_Register(t *testing.T) {
+	registry := NewTypeRegistry()
+
+	registry.Register("block", blockProposalFactory{})
+	require.Len(t, registry.factories, 1)
+
+	registry.Register("block", blockProposalFactory{})
+	require.Len(t, registry.factories, 1)
+
+	registry.Register("roster", rosterProposalFactory{})
+	require.Len(t, registry.factories, 2)
+}
+
+func TestTypeRegistry_Pack(t *testing.T) {
+	registry := NewTypeRegistry()
+	registry.Register("block", blockProposalFactory{})
+
+	packed, err := registry.Pack(fake.NewContext(), "block", blockProposal{index: 2})
+	require.NoError(t, err)
+	require.Equal(t, Any{TypeURL: "block", Value: []byte{2}}, packed)
+
+	_, err = registry.Pack(fake.NewContext(), "roster", rosterProposal{})
+	require.EqualError(t, err, "unknown type 'roster'")
+
+	_, err = registry.Pack(fake.NewContext(), "block", fake.NewBadPublicKey())
+	require.EqualError(t, err, fake.Err("failed to serialize"))
+}
+
+func TestTypeRegistry_Unpack(t *testing.T) {
+	registry := NewTypeRegistry()
+	registry.Register("block", blockProposalFactory{})
+	registry.Register("roster", rosterProposalFactory{})
+
+	ctx := fake.NewContext()
+
+	packed, err := registry.Pack(ctx, "block", blockProposal{index: 5})
+	require.NoError(t, err)
+
+	msg, err := registry.Unpack(ctx, packed)
+	require.NoError(t, err)
+	require.Equal(t, blockProposal{index: 5}, msg)
+
+	packed, err = registry.Pack(ctx, "roster", rosterProposal{members: "A,B,C"})
+	require.NoError(t, err)
+
+	msg, err = registry.Unpack(ctx, packed)
+	require.NoError(t, err)
+	require.Equal(t, rosterProposal{members: "A,B,C"}, msg)
+
+	_, err = registry.Unpack(ctx, Any{TypeURL: "unknown"})
+	require.EqualError(t, err, "unknown type 'unknown'")
+
+	_, err = registry.Unpack(ctx, Any{TypeURL: "block"})
+	require.EqualError(t, err, "failed to deserialize 'block': empty block")
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+type blockProposal struct {
+	index byte
+}
+
+func (p blockProposal) Serialize(serde.Context) ([]byte, error) {
+	return []byte{p.index}, nil
+}
+
+type blockProposalFactory struct{}
+
+func (blockProposalFactory) Deserialize(ctx serde.Context, data []byte) (serde.Message, error) {
+	if len(data) != 1 {
+		return nil, xerrors.New("empty block")
+	}
+
+	return blockProposal{index: data[0]}, nil
+}
+
+type rosterProposal struct {
+	members string
+}
+
+func (p rosterProposal) Serialize(serde.Context) ([]byte, error) {
+	return []byte(p.members), nil
+}
+
+type rosterProposalFactory struct{}
+
+func (rosterProposalFactory) Deserialize(ctx serde.Context, data []byte) (serde.Message, error) {
+	return rosterProposal{members: string(data)}, nil
+}
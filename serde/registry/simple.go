@@ -33,14 +33,36 @@ func (r *SimpleRegistry) Register(name serde.Format, f serde.FormatEngine) {
 }
 
 // Get implements registry.Registry. It returns the format engine associated
-// with the format if it exists, otherwise it returns an empty format.
+// with the format if it exists, otherwise it returns an empty format. The
+// engine reports the size of the encoded messages to the sink of the context.
 func (r *SimpleRegistry) Get(name serde.Format) serde.FormatEngine {
 	fmt := r.store[name]
 	if fmt == nil {
 		return emptyFormat{name: name}
 	}
 
-	return fmt
+	return sizedFormat{FormatEngine: fmt}
+}
+
+// sizedFormat is a format engine that reports the size of the encoded messages
+// to the sink of the context.
+//
+// - implements serde.FormatEngine
+type sizedFormat struct {
+	serde.FormatEngine
+}
+
+// Encode implements serde.FormatEngine. It returns the data of the message
+// encoded by the underlying engine after reporting its size.
+func (f sizedFormat) Encode(ctx serde.Context, msg serde.Message) ([]byte, error) {
+	data, err := f.FormatEngine.Encode(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.GetSizeSink().ObserveSize(msg, len(data))
+
+	return data, nil
 }
 
 // EmptyFormat is an implementation of the FormatEngine interface. It implements
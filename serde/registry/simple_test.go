@@ -27,7 +27,7 @@ func TestSimpleRegistry_Get(t *testing.T) {
 	registry.Register(serde.FormatJSON, fake.Format{})
 
 	format := registry.Get(serde.FormatJSON)
-	require.Equal(t, fake.Format{}, format)
+	require.Equal(t, sizedFormat{FormatEngine: fake.Format{}}, format)
 
 	format = registry.Get(serde.Format("unknown"))
 	require.NotNil(t, format)
@@ -38,3 +38,30 @@ func TestSimpleRegistry_Get(t *testing.T) {
 	_, err = format.Decode(serde.NewContext(nil), nil)
 	require.EqualError(t, err, "format 'unknown' is not implemented")
 }
+
+func TestSizedFormat_Encode(t *testing.T) {
+	format := sizedFormat{FormatEngine: fake.Format{}}
+
+	sink := &fakeSizeSink{}
+	ctx := serde.WithSizeSink(fake.NewContext(), sink)
+
+	data, err := format.Encode(ctx, fake.Message{})
+	require.NoError(t, err)
+	require.Equal(t, []int{len(data)}, sink.sizes)
+
+	format.FormatEngine = fake.NewBadFormat()
+	_, err = format.Encode(ctx, fake.Message{})
+	require.EqualError(t, err, fake.GetError().Error())
+	require.Len(t, sink.sizes, 1)
+}
+
+// -----------------------------------------------------------------------------
+// Utility functions
+
+type fakeSizeSink struct {
+	sizes []int
+}
+
+func (s *fakeSizeSink) ObserveSize(msg serde.Message, size int) {
+	s.sizes = append(s.sizes, size)
+}
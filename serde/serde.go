@@ -12,7 +12,10 @@
 // Documentation Last Review: 07.10.2020
 package serde
 
-import "io"
+import (
+	"fmt"
+	"io"
+)
 
 // Format is the identifier type of a format implementation.
 type Format string
@@ -25,6 +28,14 @@ const (
 	FormatXML Format = "XML"
 )
 
+const (
+	// TagJSON is the envelope tag of the JSON format.
+	TagJSON byte = 0x01
+
+	// TagXML is the envelope tag of the XML format.
+	TagXML byte = 0x02
+)
+
 // Message is the interface that a message must implement.
 type Message interface {
 	// Serialize serializes the object by complying to the context format.
@@ -52,3 +63,15 @@ type Fingerprinter interface {
 	// into the writer.
 	Fingerprint(writer io.Writer) error
 }
+
+// ErrUnsupportedVersion is the error returned by a format engine that decodes a
+// message tagged with a version that it doesn't support, so that the sender
+// can fall back to an older version.
+type ErrUnsupportedVersion struct {
+	Version uint64
+}
+
+// Error implements error. It returns the version that is not supported.
+func (err ErrUnsupportedVersion) Error() string {
+	return fmt.Sprintf("unsupported version %d", err.Version)
+}
@@ -0,0 +1,17 @@
+package serde
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+func TestErrUnsupportedVersion_Error(t *testing.T) {
+	err := xerrors.Errorf("decoding failed: %w", ErrUnsupportedVersion{Version: 3})
+	require.EqualError(t, err, "decoding failed: unsupported version 3")
+
+	var versionErr ErrUnsupportedVersion
+	require.True(t, xerrors.As(err, &versionErr))
+	require.Equal(t, uint64(3), versionErr.Version)
+}
@@ -0,0 +1,68 @@
+// This file contains the accounting of the size of the encoded messages.
+
+package serde
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.dedis.ch/dela"
+)
+
+// defines prometheus metrics
+var promSizes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "dela_serde_message_size",
+	Help:    "size in bytes of the encoded messages by type",
+	Buckets: prometheus.ExponentialBuckets(64, 4, 8),
+}, []string{"type"})
+
+func init() {
+	dela.PromCollectors = append(dela.PromCollectors, promSizes)
+}
+
+// SizeSink is the interface to implement to collect the size of the messages
+// encoded with a context.
+type SizeSink interface {
+	// ObserveSize is called with every message encoded by a format engine and
+	// the number of bytes of its encoding.
+	ObserveSize(message Message, size int)
+}
+
+// WithSizeSink returns a copy of the context that reports the size of the
+// encoded messages to the sink. Note that a message encodes its inner messages
+// beforehand, which means that they are reported on their own as well.
+func WithSizeSink(ctx Context, sink SizeSink) Context {
+	ctx.sizes = sink
+
+	return ctx
+}
+
+// GetSizeSink returns the sink of the size of the encoded messages. It returns
+// a sink that ignores them by default.
+func (ctx Context) GetSizeSink() SizeSink {
+	if ctx.sizes == nil {
+		return noSizeSink{}
+	}
+
+	return ctx.sizes
+}
+
+// PromSizeSink is a sink that observes the size of the messages in a
+// Prometheus histogram labeled by the type of message.
+//
+// - implements serde.SizeSink
+type PromSizeSink struct{}
+
+// ObserveSize implements serde.SizeSink. It adds the size to the histogram of
+// the type of the message.
+func (PromSizeSink) ObserveSize(message Message, size int) {
+	promSizes.WithLabelValues(fmt.Sprintf("%T", message)).Observe(float64(size))
+}
+
+// noSizeSink is the default sink that ignores the sizes.
+//
+// - implements serde.SizeSink
+type noSizeSink struct{}
+
+// ObserveSize implements serde.SizeSink. It does nothing.
+func (noSizeSink) ObserveSize(Message, int) {}
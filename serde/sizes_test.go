@@ -0,0 +1,29 @@
+package serde
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContext_WithSizeSink(t *testing.T) {
+	ctx := NewContext(nil)
+	require.Equal(t, noSizeSink{}, ctx.GetSizeSink())
+
+	ctx = WithSizeSink(ctx, PromSizeSink{})
+	require.Equal(t, PromSizeSink{}, ctx.GetSizeSink())
+}
+
+func TestPromSizeSink_ObserveSize(t *testing.T) {
+	sink := PromSizeSink{}
+
+	sink.ObserveSize(fakeMessage{}, 100)
+	sink.ObserveSize(fakeMessage{}, 200)
+
+	require.Equal(t, 1, testutil.CollectAndCount(promSizes))
+}
+
+func TestNoSizeSink_ObserveSize(t *testing.T) {
+	noSizeSink{}.ObserveSize(fakeMessage{}, 100)
+}
@@ -12,7 +12,7 @@ import (
 
 // xmlEngine is a context engine that uses the XML encoding. See encoding/xml.
 //
-// - implements serde.ContextEngine
+// - implements serde.FramedEngine
 type xmlEngine struct{}
 
 // NewContext returns a new serde context that is using the XML encoding.
@@ -26,6 +26,12 @@ func (xmlEngine) GetFormat() serde.Format {
 	return serde.FormatXML
 }
 
+// GetTag implements serde.FramedEngine. It returns the envelope tag of the XML
+// format.
+func (xmlEngine) GetTag() byte {
+	return serde.TagXML
+}
+
 // Marshal implements serde.ContextEngine. It marshals the message using the XML
 // encoding.
 func (xmlEngine) Marshal(m interface{}) ([]byte, error) {
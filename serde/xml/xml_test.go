@@ -13,6 +13,12 @@ func TestXMLEngine_GetFormat(t *testing.T) {
 	require.Equal(t, serde.FormatXML, ctx.GetFormat())
 }
 
+func TestXMLEngine_GetTag(t *testing.T) {
+	ctx := NewContext()
+
+	require.Equal(t, serde.TagXML, ctx.ContextEngine.(serde.FramedEngine).GetTag())
+}
+
 func TestXMLEngine_Marshal(t *testing.T) {
 	ctx := NewContext()
 